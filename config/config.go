@@ -65,6 +65,103 @@ type ConsulConfig struct {
 type ChatConfig struct {
 	ServerName string `mapstructure:"server_name" yaml:"server_name"`
 	GRPCPort   int    `mapstructure:"grpc_port" yaml:"grpc_port"`
+	// Compressor 历史压缩方式: heuristic（默认）| textrank（抽取式，不依赖 LLM）
+	Compressor string `mapstructure:"compressor" yaml:"compressor"`
+	// ContextStrategy 进程内上下文策略: 空（默认构建器）| truncation | project_topic |
+	// attention_sink | sink_topic | bm25_top1 | keyword_top1，与 context-engine 一致
+	ContextStrategy string `mapstructure:"context_strategy" yaml:"context_strategy"`
+	// RecallTopK 长期召回每轮最多带回的旧消息条数，0 表示关闭
+	RecallTopK int `mapstructure:"recall_top_k" yaml:"recall_top_k"`
+	// Embedder 向量化方式: 空（关闭语义检索）| hashing（离线特征哈希）| grpc（远程模型）
+	Embedder string `mapstructure:"embedder" yaml:"embedder"`
+	// EmbeddingAddr embedder 为 grpc 时的 EmbeddingService 地址
+	EmbeddingAddr string `mapstructure:"embedding_addr" yaml:"embedding_addr"`
+	// EmbeddingDim hashing embedder 的向量维度
+	EmbeddingDim int `mapstructure:"embedding_dim" yaml:"embedding_dim"`
+	// AdminUserIDs 管理员用户，可以查看、修改和删除任何会话，但不能在他人会话中发言或公开分享
+	AdminUserIDs []string `mapstructure:"admin_user_ids" yaml:"admin_user_ids"`
+	// Retention 默认保留策略与定时清理
+	Retention RetentionConfig `mapstructure:"retention" yaml:"retention"`
+	// Account 账户数据导出与账户删除
+	Account AccountConfig `mapstructure:"account" yaml:"account"`
+	// Encryption 消息内容与会话标题的静态加密
+	Encryption EncryptionConfig `mapstructure:"encryption" yaml:"encryption"`
+	// PII 用户输入与会话导出中的敏感信息检测
+	PII PIIConfig `mapstructure:"pii" yaml:"pii"`
+	// Moderation 输入与回答的内容审核
+	Moderation ModerationConfig `mapstructure:"moderation" yaml:"moderation"`
+}
+
+type RetentionConfig struct {
+	// Interval 清理任务的执行间隔，0 表示不运行清理任务
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+	// BatchSize 每批删除的条数
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size"`
+	// MessageDays 没有单独设置策略的工作空间与个人空间的消息保留天数，0 表示永久保留
+	MessageDays int `mapstructure:"message_days" yaml:"message_days"`
+	// PurgeDeletedDays 软删除的记录在多少天后彻底删除，0 表示不清理
+	PurgeDeletedDays int `mapstructure:"purge_deleted_days" yaml:"purge_deleted_days"`
+}
+
+type AccountConfig struct {
+	// ErasureDrain 提交账户删除后等待多久再清扫一遍数据库与缓存，应不短于 auth.expire_access_h
+	ErasureDrain time.Duration `mapstructure:"erasure_drain" yaml:"erasure_drain"`
+	// Interval 继续未完成的账户删除任务（含等待清扫的任务）并删除过期导出的间隔，为 0 时使用 1 分钟
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+	// ExportDir 账户导出压缩包的存放目录，为空时使用系统临时目录；多个实例需要共享同一目录
+	ExportDir string `mapstructure:"export_dir" yaml:"export_dir"`
+}
+
+type EncryptionConfig struct {
+	// Keyfile 主密钥文件，每行 "<id> <base64 编码的 32 字节密钥>"，第一行为当前主密钥；为空时不加密
+	Keyfile string `mapstructure:"keyfile" yaml:"keyfile"`
+	// SearchIndex 为加密后的消息写入盲索引以保留全文检索，关闭时加密后的消息无法检索
+	SearchIndex bool `mapstructure:"search_index" yaml:"search_index"`
+	// ReencryptInterval 检查并重新加密明文与旧版本密钥密文的间隔，0 表示只在启动与轮换密钥后检查
+	ReencryptInterval time.Duration `mapstructure:"reencrypt_interval" yaml:"reencrypt_interval"`
+}
+
+type PIIConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// DefaultAction 未单独配置的类型的处理方式：allow、mask、tokenize 或 block
+	DefaultAction string `mapstructure:"default_action" yaml:"default_action"`
+	// Actions 按类型（email、phone、id_card、credit_card、api_key）设置处理方式
+	Actions map[string]string `mapstructure:"actions" yaml:"actions"`
+	// RedactExports 导出会话时遮盖敏感信息
+	RedactExports bool `mapstructure:"redact_exports" yaml:"redact_exports"`
+}
+
+type ModerationConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Window 检查流式回答时滑动窗口保留的字符数
+	Window int `mapstructure:"window" yaml:"window"`
+	// Rules 关键词与正则规则，按顺序检查
+	Rules []ModerationRule `mapstructure:"rules" yaml:"rules"`
+	// Classifier 可选的外部审核模型
+	Classifier ModerationClassifierConfig `mapstructure:"classifier" yaml:"classifier"`
+}
+
+type ModerationRule struct {
+	Name     string `mapstructure:"name" yaml:"name"`
+	Category string `mapstructure:"category" yaml:"category"`
+	// Action 为 flag（放行并进入复核队列）或 block（拒绝输入、截断回答）
+	Action string `mapstructure:"action" yaml:"action"`
+	// Stages 为 input / output，为空时两者都检查
+	Stages   []string `mapstructure:"stages" yaml:"stages"`
+	Keywords []string `mapstructure:"keywords" yaml:"keywords"`
+	Patterns []string `mapstructure:"patterns" yaml:"patterns"`
+}
+
+type ModerationClassifierConfig struct {
+	// URL 为空时不使用分类器
+	URL     string        `mapstructure:"url" yaml:"url"`
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	Action  string        `mapstructure:"action" yaml:"action"`
+	// Threshold 未单独配置的类别的阈值，Thresholds 按类别覆盖
+	Threshold  float64            `mapstructure:"threshold" yaml:"threshold"`
+	Thresholds map[string]float64 `mapstructure:"thresholds" yaml:"thresholds"`
+	// ClassifyEvery 回答每新增多少字符调用一次分类器，0 表示窗口长度的一半
+	ClassifyEvery int `mapstructure:"classify_every" yaml:"classify_every"`
 }
 
 type AuthConfig struct {
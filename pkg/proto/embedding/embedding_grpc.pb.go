@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: embedding.proto
+
+package embedding
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EmbeddingService_Embed_FullMethodName = "/embedding.EmbeddingService/Embed"
+)
+
+// EmbeddingServiceClient is the client API for EmbeddingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// EmbeddingService turns texts into dense vectors with a real embedding model.
+type EmbeddingServiceClient interface {
+	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+}
+
+type embeddingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEmbeddingServiceClient(cc grpc.ClientConnInterface) EmbeddingServiceClient {
+	return &embeddingServiceClient{cc}
+}
+
+func (c *embeddingServiceClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, EmbeddingService_Embed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EmbeddingServiceServer is the server API for EmbeddingService service.
+// All implementations must embed UnimplementedEmbeddingServiceServer
+// for forward compatibility.
+//
+// EmbeddingService turns texts into dense vectors with a real embedding model.
+type EmbeddingServiceServer interface {
+	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	mustEmbedUnimplementedEmbeddingServiceServer()
+}
+
+// UnimplementedEmbeddingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEmbeddingServiceServer struct{}
+
+func (UnimplementedEmbeddingServiceServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Embed not implemented")
+}
+func (UnimplementedEmbeddingServiceServer) mustEmbedUnimplementedEmbeddingServiceServer() {}
+func (UnimplementedEmbeddingServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeEmbeddingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EmbeddingServiceServer will
+// result in compilation errors.
+type UnsafeEmbeddingServiceServer interface {
+	mustEmbedUnimplementedEmbeddingServiceServer()
+}
+
+func RegisterEmbeddingServiceServer(s grpc.ServiceRegistrar, srv EmbeddingServiceServer) {
+	// If the following call pancis, it indicates UnimplementedEmbeddingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EmbeddingService_ServiceDesc, srv)
+}
+
+func _EmbeddingService_Embed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmbeddingServiceServer).Embed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmbeddingService_Embed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmbeddingServiceServer).Embed(ctx, req.(*EmbedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// EmbeddingService_ServiceDesc is the grpc.ServiceDesc for EmbeddingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EmbeddingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "embedding.EmbeddingService",
+	HandlerType: (*EmbeddingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Embed",
+			Handler:    _EmbeddingService_Embed_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "embedding.proto",
+}
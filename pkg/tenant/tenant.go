@@ -0,0 +1,46 @@
+// Package tenant 在网关与后端服务之间通过 gRPC metadata 传递当前工作空间。
+// 网关从已校验的 JWT 中取出工作空间写入出站 metadata，后端服务在拦截器中读出，
+// 因此每个 RPC 都带着租户信息，不需要在每个请求消息里增加字段。
+package tenant
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	workspaceIDKey   = "x-workspace-id"
+	workspaceRoleKey = "x-workspace-role"
+)
+
+// Workspace 是请求所在的工作空间，ID 为空表示用户的个人空间
+type Workspace struct {
+	ID   string
+	Role string
+}
+
+// NewOutgoingContext 把工作空间附加到出站 metadata；会覆盖 ctx 中已有的同名键
+func NewOutgoingContext(ctx context.Context, ws Workspace) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(workspaceIDKey, ws.ID)
+	md.Set(workspaceRoleKey, ws.Role)
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// FromIncomingContext 从入站 metadata 读取工作空间，没有时返回个人空间
+func FromIncomingContext(ctx context.Context) Workspace {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Workspace{}
+	}
+	return Workspace{ID: first(md, workspaceIDKey), Role: first(md, workspaceRoleKey)}
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRoundTrip(t *testing.T) {
+	ctx := NewOutgoingContext(context.Background(), Workspace{ID: "ws-1", Role: "admin"})
+	// 后设置的工作空间覆盖先前的值
+	ctx = NewOutgoingContext(ctx, Workspace{ID: "ws-2", Role: "member"})
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	got := FromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+	if got != (Workspace{ID: "ws-2", Role: "member"}) {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestFromIncomingContextWithoutMetadata(t *testing.T) {
+	if got := FromIncomingContext(context.Background()); got != (Workspace{}) {
+		t.Fatalf("expected personal workspace, got %+v", got)
+	}
+}
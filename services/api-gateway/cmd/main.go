@@ -81,6 +81,18 @@ func main() {
 			auth.POST("/refresh", authHandler.RefreshToken)
 		}
 
+		// 工作空间相关路由（需要认证）
+		workspaces := api.Group("/workspaces")
+		workspaces.Use(middleware.JwtAuth(cfg.Auth.JwtSecret))
+		{
+			workspaces.POST("", authHandler.CreateWorkspace)
+			workspaces.GET("", authHandler.ListWorkspaces)
+			workspaces.POST("/switch", authHandler.SwitchWorkspace)
+			workspaces.GET("/:workspaceId/members", authHandler.ListWorkspaceMembers)
+			workspaces.POST("/:workspaceId/members", authHandler.AddWorkspaceMember)
+			workspaces.DELETE("/:workspaceId/members/:userId", authHandler.RemoveWorkspaceMember)
+		}
+
 		// 聊天相关路由（需要认证）
 		chat := api.Group("/chat")
 		chat.Use(middleware.JwtAuth(cfg.Auth.JwtSecret))
@@ -88,11 +100,62 @@ func main() {
 			chatHandler = handler.NewChatHandler(serviceManager, cfg.Chat.ServerName, cfg.LLM.Name)
 			chat.POST("/sessions", chatHandler.CreateSession)
 			chat.GET("/sessions", chatHandler.GetSessions)
+			chat.GET("/sessions/export", chatHandler.ExportSessions)
+			chat.POST("/imports", chatHandler.ImportConversations)
 			chat.GET("/sessions/:sessionId/history", chatHandler.GetHistory)
+			chat.GET("/sessions/:sessionId/export", chatHandler.ExportSession)
+			chat.PATCH("/sessions/:sessionId", chatHandler.UpdateSession)
 			chat.DELETE("/sessions/:sessionId", chatHandler.DeleteSession)
+			chat.POST("/folders", chatHandler.CreateFolder)
+			chat.GET("/folders", chatHandler.ListFolders)
+			chat.PATCH("/folders/:folderId", chatHandler.UpdateFolder)
+			chat.DELETE("/folders/:folderId", chatHandler.DeleteFolder)
+			chat.POST("/sessions/:sessionId/messages/:messageId/pin", chatHandler.PinMessage)
+			chat.DELETE("/sessions/:sessionId/messages/:messageId/pin", chatHandler.UnpinMessage)
+			chat.POST("/sessions/:sessionId/messages/:messageId/fork", chatHandler.ForkSession)
+			chat.POST("/sessions/:sessionId/shares", chatHandler.CreateShare)
+			chat.GET("/sessions/:sessionId/shares", chatHandler.ListShares)
+			chat.DELETE("/shares/:shareId", chatHandler.RevokeShare)
+			chat.GET("/sessions/:sessionId/participants", chatHandler.ListParticipants)
+			chat.PUT("/sessions/:sessionId/participants/:userId", chatHandler.SetParticipant)
+			chat.DELETE("/sessions/:sessionId/participants/:userId", chatHandler.RemoveParticipant)
+			chat.GET("/sessions/:sessionId/events", chatHandler.SubscribeSession)
+			chat.GET("/memories", chatHandler.ListMemories)
+			chat.PUT("/memories/enabled", chatHandler.SetMemoryEnabled)
+			chat.PATCH("/memories/:memoryId", chatHandler.UpdateMemory)
+			chat.DELETE("/memories/:memoryId", chatHandler.DeleteMemory)
+			chat.POST("/documents", chatHandler.UploadDocument)
+			chat.GET("/documents", chatHandler.ListDocuments)
+			chat.DELETE("/documents/:documentId", chatHandler.DeleteDocument)
+			chat.POST("/collections", chatHandler.CreateCollection)
+			chat.GET("/collections", chatHandler.ListCollections)
+			chat.DELETE("/collections/:collectionId", chatHandler.DeleteCollection)
+			chat.GET("/search", chatHandler.SearchMessages)
+			chat.GET("/search/conversations", chatHandler.SearchConversations)
+			chat.GET("/retention", chatHandler.GetRetentionPolicy)
+			chat.PUT("/retention", chatHandler.SetRetentionPolicy)
+			chat.GET("/retention/report", chatHandler.GetRetentionReport)
+			chat.GET("/encryption/keys", chatHandler.ListDataKeys)
+			chat.POST("/encryption/rotate", chatHandler.RotateDataKey)
+			chat.GET("/moderation/flags", chatHandler.ListModerationFlags)
+			chat.POST("/moderation/flags/:flagId/review", chatHandler.ReviewModerationFlag)
 			chat.POST("/sessions/messages", chatHandler.StreamChat)
 			chat.POST("/sessions/stream", chatHandler.StreamChat)
 		}
+
+		// 账户数据导出与账户删除（需要认证）
+		account := api.Group("/account")
+		account.Use(middleware.JwtAuth(cfg.Auth.JwtSecret))
+		{
+			account.POST("/export", chatHandler.StartAccountExport)
+			account.GET("/export/:exportId", chatHandler.GetAccountExport)
+			account.GET("/export/:exportId/download", chatHandler.DownloadAccountExport)
+			account.DELETE("", chatHandler.DeleteAccount)
+			account.GET("/erasure", chatHandler.GetAccountErasure)
+		}
+
+		// 公开分享（不需要认证，凭令牌访问）
+		api.GET("/share/:token", chatHandler.GetSharedSession)
 	}
 
 	serviceManager.Start()
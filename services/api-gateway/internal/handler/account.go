@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"log"
+	"mime"
+	"net/http"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartAccountExport 在后台打包当前用户的资料与全部会话和消息，返回导出任务，完成后通过下载接口获取
+func (h *ChatHandler) StartAccountExport(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.StartAccountExport(c.Request.Context(), &chatpb.StartAccountExportRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Account not found", "Failed to start account export")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"export": accountExportJSON(resp.Export)})
+}
+
+// GetAccountExport 返回导出任务的状态
+func (h *ChatHandler) GetAccountExport(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.GetAccountExport(c.Request.Context(), &chatpb.GetAccountExportRequest{
+		UserId:   userID,
+		ExportId: c.Param("exportId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Export not found", "Failed to get account export")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"export": accountExportJSON(resp.Export)})
+}
+
+// DownloadAccountExport 下载已完成的导出压缩包，未完成时返回 409
+func (h *ChatHandler) DownloadAccountExport(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	stream, err := client.DownloadAccountExport(c.Request.Context(), &chatpb.DownloadAccountExportRequest{
+		UserId:   userID,
+		ExportId: c.Param("exportId"),
+	})
+	var first *chatpb.ExportChunk
+	if err == nil {
+		first, err = recvExport(stream)
+	}
+	if err != nil {
+		writeSessionError(c, err, "Export not found", "Failed to download account export")
+		return
+	}
+	if first == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.Header("Content-Type", first.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": first.Filename}))
+	c.Status(http.StatusOK)
+	for chunk := first; chunk != nil; {
+		if _, err := c.Writer.Write(chunk.Data); err != nil {
+			log.Printf("[WARN] write account export failed: %v", err)
+			return
+		}
+		if chunk, err = recvExport(stream); err != nil {
+			// 响应头已发出，只能中断连接让客户端看到不完整的下载
+			log.Printf("[ERROR] account export stream failed: %v", err)
+			return
+		}
+	}
+}
+
+// DeleteAccount 提交账户删除：依次删除 auth-service 中的用户、数据库中的全部数据与 Redis 缓存，
+// 等待在途事件与旧令牌失效后再清扫一遍。重复调用返回同一任务
+func (h *ChatHandler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.EraseAccount(c.Request.Context(), &chatpb.EraseAccountRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Account not found", "Failed to delete account")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"erasure": accountErasureJSON(resp.Erasure)})
+}
+
+// GetAccountErasure 返回账户删除的进度，完成后即为删除确认记录
+func (h *ChatHandler) GetAccountErasure(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.GetAccountErasure(c.Request.Context(), &chatpb.GetAccountErasureRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Account erasure not found", "Failed to get account erasure")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"erasure": accountErasureJSON(resp.Erasure)})
+}
+
+func accountExportJSON(e *chatpb.AccountExport) gin.H {
+	return gin.H{
+		"export_id":    e.GetExportId(),
+		"status":       e.GetStatus(),
+		"size":         e.GetSize(),
+		"error":        e.GetError(),
+		"created_at":   e.GetCreatedAt(),
+		"completed_at": e.GetCompletedAt(),
+		"expires_at":   e.GetExpiresAt(),
+	}
+}
+
+func accountErasureJSON(e *chatpb.AccountErasure) gin.H {
+	purged := make(gin.H, len(e.GetPurged()))
+	for _, p := range e.GetPurged() {
+		purged[p.Target] = p.Count
+	}
+	return gin.H{
+		"erasure_id":   e.GetErasureId(),
+		"status":       e.GetStatus(),
+		"done":         e.GetDone(),
+		"purged":       purged,
+		"last_error":   e.GetLastError(),
+		"requested_at": e.GetRequestedAt(),
+		"drain_until":  e.GetDrainUntil(),
+		"completed_at": e.GetCompletedAt(),
+	}
+}
@@ -94,6 +94,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	var req struct {
 		Username string `json:"username" binding:"required"`
 		Password string `json:"password" binding:"required"`
+		// 可选，登录后直接进入该工作空间
+		WorkspaceID string `json:"workspace_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -110,18 +112,16 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	client := authpb.NewAuthServiceClient(conn)
 	resp, err := client.Login(c.Request.Context(), &authpb.LoginRequest{
-		Username: req.Username,
-		Password: req.Password,
+		Username:    req.Username,
+		Password:    req.Password,
+		WorkspaceId: req.WorkspaceID,
 	})
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token":  resp.AccessToken,
-		"refresh_token": resp.RefreshToken,
-	})
+	c.JSON(http.StatusOK, tokenJSON(resp))
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
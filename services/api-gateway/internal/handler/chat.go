@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"math/rand"
@@ -103,6 +104,8 @@ func (h *ChatHandler) CreateSession(c *gin.Context) {
 
 	var req struct {
 		Title string `json:"title"`
+		// Ephemeral 无痕会话：不保存，闲置一段时间后自动消失
+		Ephemeral bool `json:"ephemeral"`
 	}
 	c.ShouldBindJSON(&req)
 
@@ -117,8 +120,9 @@ func (h *ChatHandler) CreateSession(c *gin.Context) {
 	resp, err := client.CreateSession(
 		c.Request.Context(),
 		&chatpb.CreateSessionRequest{
-			UserId: userID,
-			Title:  req.Title,
+			UserId:    userID,
+			Title:     req.Title,
+			Ephemeral: req.Ephemeral,
 		})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
@@ -129,12 +133,19 @@ func (h *ChatHandler) CreateSession(c *gin.Context) {
 		"success":    resp.Success,
 		"session_id": resp.SessionId,
 		"message":    resp.Message,
+		"ephemeral":  req.Ephemeral,
 	})
 }
 
+// GetHistory 按时间倒序分页返回会话消息，查询参数 limit（默认 20）与 cursor（上一页的 next_cursor）
 func (h *ChatHandler) GetHistory(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 	userID := c.GetString("user_id")
+	limit, err := queryLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 连接到聊天服务
 	conn, err := h.getGRPCConnection()
@@ -147,28 +158,50 @@ func (h *ChatHandler) GetHistory(c *gin.Context) {
 	resp, err := client.GetChatHistory(c.Request.Context(), &chatpb.HistoryRequest{
 		SessionId: sessionID,
 		UserId:    userID,
+		Limit:     limit,
+		Cursor:    c.Query("cursor"),
 	})
 
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get history"})
+		}
 		return
 	}
 
 	messages := make([]gin.H, len(resp.Messages))
 	for i, msg := range resp.Messages {
-		messages[i] = gin.H{
-			"role":      msg.Role,
-			"content":   msg.Content,
-			"timestamp": msg.Timestamp,
-		}
+		messages[i] = messageJSON(msg)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"messages": messages,
-		"total":    resp.Total,
+		"messages":    messages,
+		"total":       resp.Total,
+		"next_cursor": resp.NextCursor,
 	})
 }
 
+// messageJSON 中 user_id / author_name 为发言用户，多人会话中用于区分发言人
+func messageJSON(msg *chatpb.ChatMessage) gin.H {
+	return gin.H{
+		"message_id":  msg.MessageId,
+		"role":        msg.Role,
+		"content":     msg.Content,
+		"timestamp":   msg.Timestamp,
+		"pinned":      msg.Pinned,
+		"model":       msg.Model,
+		"user_id":     msg.UserId,
+		"author_name": msg.AuthorName,
+	}
+}
+
 func (h *ChatHandler) DeleteSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 	userID := c.GetString("user_id")
@@ -187,7 +220,59 @@ func (h *ChatHandler) DeleteSession(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		writeSessionError(c, err, "Session not found", "Failed to delete session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// PinMessage 置顶消息，置顶后该消息会始终保留在模型上下文中
+func (h *ChatHandler) PinMessage(c *gin.Context) {
+	h.setMessagePinned(c, true)
+}
+
+// UnpinMessage 取消置顶
+func (h *ChatHandler) UnpinMessage(c *gin.Context) {
+	h.setMessagePinned(c, false)
+}
+
+func (h *ChatHandler) setMessagePinned(c *gin.Context, pinned bool) {
+	sessionID := c.Param("sessionId")
+	messageID := c.Param("messageId")
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.PinMessage(c.Request.Context(), &chatpb.PinMessageRequest{
+		UserId:    userID,
+		SessionId: sessionID,
+		MessageId: messageID,
+		Pinned:    pinned,
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusConflict, gin.H{"error": status.Convert(err).Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message"})
+		}
 		return
 	}
 
@@ -197,19 +282,25 @@ func (h *ChatHandler) DeleteSession(c *gin.Context) {
 	})
 }
 
+// GetSessions 分页返回会话，置顶会话排在最前。查询参数：sort（activity 默认 / created / title）、
+// limit（默认 20）、cursor（同一 sort 下上一页的 next_cursor），以及过滤条件
+// folder_id、tag 与 archived（true 时只列出已归档会话）；joined=true 时改为列出作为协作者加入的他人会话
 func (h *ChatHandler) GetSessions(c *gin.Context) {
 	userID := c.GetString("user_id")
-	limit := c.Query("limit")
-	offset := c.Query("offset")
-	limit32, err := strconv.ParseInt(limit, 10, 32)
+	limit, err := queryLimit(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	offset32, err := strconv.ParseInt(offset, 10, 32)
+	archived, err := strconv.ParseBool(c.DefaultQuery("archived", "false"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid archived %q", c.Query("archived"))})
+		return
+	}
+	joined, err := strconv.ParseBool(c.DefaultQuery("joined", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid joined %q", c.Query("joined"))})
 		return
 	}
 
@@ -221,36 +312,54 @@ func (h *ChatHandler) GetSessions(c *gin.Context) {
 
 	client := chatpb.NewChatServiceClient(conn)
 	resp, err := client.GetSessions(c.Request.Context(), &chatpb.GetSessionsRequest{
-		UserId: userID,
-		Limit:  int32(limit32),
-		Offset: int32(offset32),
+		UserId:   userID,
+		Limit:    limit,
+		Cursor:   c.Query("cursor"),
+		Sort:     c.Query("sort"),
+		FolderId: c.Query("folder_id"),
+		Tag:      c.Query("tag"),
+		Archived: archived,
+		Joined:   joined,
 	})
 
 	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
 		return
 	}
 
 	sessions := make([]gin.H, len(resp.Sessions))
 	for i, s := range resp.Sessions {
-		sessions[i] = gin.H{
-			"session_id": s.SessionId,
-			"title":      s.Title,
-		}
+		sessions[i] = sessionJSON(s)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"sessions": sessions,
-		"total":    resp.Total,
+		"sessions":    sessions,
+		"total":       resp.Total,
+		"next_cursor": resp.NextCursor,
 	})
 }
 
+// queryLimit 读取分页参数 limit，缺省为 20，超出上限由聊天服务截断
+func queryLimit(c *gin.Context) (int32, error) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 32)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid limit %q", c.Query("limit"))
+	}
+	return int32(limit), nil
+}
+
 func (h *ChatHandler) StreamChat(c *gin.Context) {
 	var req struct {
 		Message   string `json:"message" binding:"required"`
 		SessionId string `json:"session_id" binding:"required"`
 		Model     string `json:"model"`
 		TopicID   int    `json:"topic_id"`
+		// CollectionIDs 本轮额外检索的知识库
+		CollectionIDs []string `json:"collection_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("req binding error: %v", err)
@@ -293,24 +402,34 @@ func (h *ChatHandler) StreamChat(c *gin.Context) {
 
 	// 创建流式聊天请求
 	stream, err := client.StreamChat(c.Request.Context(), &chatpb.ChatRequest{
-		SessionId: req.SessionId,
-		UserId:    userID,
-		Message:   messagePayload,
-		ModelName: model,
+		SessionId:     req.SessionId,
+		UserId:        userID,
+		UserName:      c.GetString("username"),
+		Message:       messagePayload,
+		ModelName:     model,
+		CollectionIds: req.CollectionIDs,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
 		return
 	}
 
+	// 流式响应
+	// 第一个响应可能是 topic_select 事件；会话不存在、无权访问或输入含有被拒绝的敏感信息的错误也在这里返回，此时还没有写出 SSE 头
+	resp, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		switch status.Code(err) {
+		case codes.NotFound, codes.PermissionDenied, codes.InvalidArgument:
+			writeSessionError(c, err, "Session not found", "Failed to send message")
+			return
+		}
+	}
+
 	// 设置SSE头
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 
-	// 流式响应
-	// 第一个响应可能是 topic_select 事件
-	resp, err := stream.Recv()
 	if err != nil {
 		if err == io.EOF {
 			c.Writer.Flush()
@@ -340,6 +459,14 @@ func (h *ChatHandler) StreamChat(c *gin.Context) {
 				c.Writer.Flush()
 				break
 			}
+			if resp.Moderation != nil {
+				c.SSEvent("moderation", moderationEventJSON(resp.Moderation, resp.SessionId))
+				c.Writer.Flush()
+				break
+			}
+			if len(resp.Citations) > 0 {
+				c.SSEvent("citations", gin.H{"citations": citationsToJSON(resp.Citations), "sessionId": resp.SessionId})
+			}
 			c.SSEvent("message", gin.H{"content": resp.Content, "finished": resp.IsFinished, "sessionId": resp.SessionId})
 			if resp.IsFinished {
 				c.Writer.Flush()
@@ -359,6 +486,15 @@ func (h *ChatHandler) StreamChat(c *gin.Context) {
 			c.Writer.Flush()
 			break
 		}
+		// 输入被拒绝或回答被截断，这是最后一帧
+		if resp.Moderation != nil {
+			c.SSEvent("moderation", moderationEventJSON(resp.Moderation, resp.SessionId))
+			c.Writer.Flush()
+			break
+		}
+		if len(resp.Citations) > 0 {
+			c.SSEvent("citations", gin.H{"citations": citationsToJSON(resp.Citations), "sessionId": resp.SessionId})
+		}
 		c.SSEvent("message", gin.H{
 			"content":   resp.Content,
 			"finished":  resp.IsFinished,
@@ -1,8 +1,16 @@
 package handler
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 // topicSelectSentinel is the special content marker that signals
@@ -96,3 +104,113 @@ func TestTopicSelectRequestHasTopicID(t *testing.T) {
 		t.Error("TopicID should default to 0 when not provided")
 	}
 }
+
+func TestFormatFromFilename(t *testing.T) {
+	cases := map[string]string{
+		"notes.md":        "markdown",
+		"README.MARKDOWN": "markdown",
+		"paper.pdf":       "pdf",
+		"log.txt":         "text",
+		"noext":           "text",
+	}
+	for name, want := range cases {
+		if got := formatFromFilename(name); got != want {
+			t.Errorf("formatFromFilename(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestImportFormatFromFilename(t *testing.T) {
+	cases := []struct{ name, contentType, want string }{
+		{"conversations.json", "", "chatgpt"},
+		{"chatgpt-export.ZIP", "", "chatgpt"},
+		{"history.jsonl", "", "jsonl"},
+		{"", "application/x-ndjson", "jsonl"},
+		{"", "application/json", "chatgpt"},
+		{"", "text/plain", ""},
+	}
+	for _, tc := range cases {
+		if got := importFormatFromFilename(tc.name, tc.contentType); got != tc.want {
+			t.Errorf("importFormatFromFilename(%q, %q) = %q, want %q", tc.name, tc.contentType, got, tc.want)
+		}
+	}
+}
+
+func TestOpenImportExtractsConversationsFromZip(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{"chat.html": "<html></html>", "export/conversations.json": "[]"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求体没有文件名时按 zip 文件头识别
+	for _, filename := range []string{"export.zip", ""} {
+		r, err := openImport(bytes.NewReader(archive.Bytes()), filename)
+		if err != nil {
+			t.Fatalf("openImport(%q): %v", filename, err)
+		}
+		if data, _ := io.ReadAll(r); string(data) != "[]" {
+			t.Errorf("openImport(%q) read %q, want conversations.json", filename, data)
+		}
+	}
+
+	r, err := openImport(bytes.NewReader([]byte(`{"role":"user"}`)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(r); string(data) != `{"role":"user"}` {
+		t.Errorf("plain body was altered: %q", data)
+	}
+}
+
+func TestParseSearchTime(t *testing.T) {
+	day := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value    string
+		endOfDay bool
+		want     int64
+	}{
+		{"", false, 0},
+		{"1717113600", false, 1717113600},
+		{"2024-05-31", false, day.Unix()},
+		{"2024-05-31", true, day.AddDate(0, 0, 1).Unix()},
+		{"2024-05-31T08:00:00Z", true, day.Add(8 * time.Hour).Unix()},
+	}
+	for _, tc := range cases {
+		got, err := parseSearchTime(tc.value, tc.endOfDay)
+		if err != nil || got != tc.want {
+			t.Errorf("parseSearchTime(%q, %v) = %d, %v; want %d", tc.value, tc.endOfDay, got, err, tc.want)
+		}
+	}
+	if _, err := parseSearchTime("yesterday", false); err == nil {
+		t.Error("expected an error for an unparseable time")
+	}
+}
+
+func TestQueryLimitDefaults(t *testing.T) {
+	cases := []struct {
+		query   string
+		want    int32
+		wantErr bool
+	}{
+		{"", 20, false},
+		{"?limit=5", 5, false},
+		{"?limit=abc", 0, true},
+		{"?limit=-1", 0, true},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/sessions"+tc.query, nil)
+		got, err := queryLimit(c)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("queryLimit(%q) = %d, %v; want %d", tc.query, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionEventsKeepAlive 没有事件时定期写出 SSE 注释，避免代理断开空闲连接
+const sessionEventsKeepAlive = 30 * time.Second
+
+// ListParticipants 列出会话的所有者与协作者，会话的任何参与者都可以查看
+func (h *ChatHandler) ListParticipants(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListParticipants(c.Request.Context(), &chatpb.ListParticipantsRequest{
+		UserId:    userID,
+		SessionId: c.Param("sessionId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to list participants")
+		return
+	}
+
+	participants := make([]gin.H, len(resp.Participants))
+	for i, p := range resp.Participants {
+		participants[i] = participantJSON(p)
+	}
+	c.JSON(http.StatusOK, gin.H{"participants": participants})
+}
+
+// SetParticipant 邀请用户加入会话或修改其角色，请求体为 {"role": "editor" | "viewer"}；只有所有者可以操作
+func (h *ChatHandler) SetParticipant(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.SetParticipant(c.Request.Context(), &chatpb.SetParticipantRequest{
+		UserId:        userID,
+		SessionId:     c.Param("sessionId"),
+		ParticipantId: c.Param("userId"),
+		Role:          req.Role,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to set participant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"participant": participantJSON(resp.Participant)})
+}
+
+// RemoveParticipant 移除协作者；userId 为自己时表示退出会话
+func (h *ChatHandler) RemoveParticipant(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.RemoveParticipant(c.Request.Context(), &chatpb.RemoveParticipantRequest{
+		UserId:        userID,
+		SessionId:     c.Param("sessionId"),
+		ParticipantId: c.Param("userId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Participant not found", "Failed to remove participant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// SubscribeSession 以 SSE 推送会话的实时事件：ready（订阅已建立）、message（完整消息）、
+// delta（助手回复的增量）与 participants（参与者变化），直到客户端断开或用户被移出会话
+func (h *ChatHandler) SubscribeSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	client := chatpb.NewChatServiceClient(conn)
+	stream, err := client.SubscribeSession(ctx, &chatpb.SubscribeSessionRequest{
+		UserId:    userID,
+		SessionId: c.Param("sessionId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to subscribe")
+		return
+	}
+	// 聊天服务订阅成功后先发送 ready，在此之前的错误（会话不存在、无权访问）以 JSON 返回
+	first, err := stream.Recv()
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to subscribe")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.SSEvent(first.Type, sessionEventJSON(first))
+	c.Writer.Flush()
+
+	events := make(chan *chatpb.SessionEvent)
+	go func() {
+		defer close(events)
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(sessionEventsKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, sessionEventJSON(event))
+		case <-ticker.C:
+			io.WriteString(c.Writer, ": keep-alive\n\n")
+		case <-ctx.Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func participantJSON(p *chatpb.Participant) gin.H {
+	return gin.H{
+		"user_id":    p.UserId,
+		"role":       p.Role,
+		"added_by":   p.AddedBy,
+		"created_at": p.CreatedAt,
+	}
+}
+
+func sessionEventJSON(e *chatpb.SessionEvent) gin.H {
+	event := gin.H{
+		"session_id": e.SessionId,
+		"user_id":    e.UserId,
+		"timestamp":  e.Timestamp,
+	}
+	if e.Message != nil {
+		event["message"] = messageJSON(e.Message)
+	}
+	if e.Delta != "" {
+		event["delta"] = e.Delta
+		event["reply_to"] = e.ReplyTo
+	}
+	return event
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxDocumentBytes 与 chat-service 的文档大小上限一致
+const maxDocumentBytes = 1 << 20
+
+// UploadDocument 上传文档到会话或知识库。
+// 支持 JSON 正文，也支持 multipart 表单的 file 字段（未指定 format 时按扩展名推断）。
+func (h *ChatHandler) UploadDocument(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		SessionID    string `json:"session_id" form:"session_id"`
+		CollectionID string `json:"collection_id" form:"collection_id"`
+		Title        string `json:"title" form:"title"`
+		Format       string `json:"format" form:"format"`
+		Content      string `json:"content"`
+	}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, maxDocumentBytes+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		if len(data) > maxDocumentBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Document is too large"})
+			return
+		}
+		req.Content = string(data)
+		if req.Title == "" {
+			req.Title = file.Filename
+		}
+		if req.Format == "" {
+			req.Format = formatFromFilename(file.Filename)
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content is required"})
+		return
+	}
+	if (req.SessionID == "") == (req.CollectionID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of session_id and collection_id is required"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.UploadDocument(c.Request.Context(), &chatpb.UploadDocumentRequest{
+		UserId:       userID,
+		SessionId:    req.SessionID,
+		CollectionId: req.CollectionID,
+		Title:        req.Title,
+		Format:       req.Format,
+		Content:      req.Content,
+	})
+	if err != nil {
+		writeDocumentError(c, err, "Failed to upload document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     resp.Success,
+		"message":     resp.Message,
+		"document_id": resp.DocumentId,
+		"chunk_count": resp.ChunkCount,
+	})
+}
+
+// ListDocuments 列出文档，可用 session_id / collection_id 查询参数过滤
+func (h *ChatHandler) ListDocuments(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListDocuments(c.Request.Context(), &chatpb.ListDocumentsRequest{
+		UserId:       userID,
+		SessionId:    c.Query("session_id"),
+		CollectionId: c.Query("collection_id"),
+	})
+	if err != nil {
+		writeDocumentError(c, err, "Failed to list documents")
+		return
+	}
+
+	documents := make([]gin.H, len(resp.Documents))
+	for i, d := range resp.Documents {
+		documents[i] = gin.H{
+			"document_id":   d.DocumentId,
+			"title":         d.Title,
+			"format":        d.Format,
+			"session_id":    d.SessionId,
+			"collection_id": d.CollectionId,
+			"chunk_count":   d.ChunkCount,
+			"created_at":    d.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": documents})
+}
+
+// DeleteDocument 删除文档
+func (h *ChatHandler) DeleteDocument(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.DeleteDocument(c.Request.Context(), &chatpb.DeleteDocumentRequest{
+		UserId:     userID,
+		DocumentId: c.Param("documentId"),
+	})
+	if err != nil {
+		writeDocumentError(c, err, "Failed to delete document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// CreateCollection 创建知识库
+func (h *ChatHandler) CreateCollection(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.CreateCollection(c.Request.Context(), &chatpb.CreateCollectionRequest{
+		UserId: userID,
+		Name:   req.Name,
+	})
+	if err != nil {
+		writeDocumentError(c, err, "Failed to create collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       resp.Success,
+		"message":       resp.Message,
+		"collection_id": resp.CollectionId,
+	})
+}
+
+// ListCollections 列出当前用户的知识库
+func (h *ChatHandler) ListCollections(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListCollections(c.Request.Context(), &chatpb.ListCollectionsRequest{UserId: userID})
+	if err != nil {
+		writeDocumentError(c, err, "Failed to list collections")
+		return
+	}
+
+	collections := make([]gin.H, len(resp.Collections))
+	for i, col := range resp.Collections {
+		collections[i] = gin.H{
+			"collection_id": col.CollectionId,
+			"name":          col.Name,
+			"created_at":    col.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// DeleteCollection 删除知识库及其中的文档
+func (h *ChatHandler) DeleteCollection(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.DeleteCollection(c.Request.Context(), &chatpb.DeleteCollectionRequest{
+		UserId:       userID,
+		CollectionId: c.Param("collectionId"),
+	})
+	if err != nil {
+		writeDocumentError(c, err, "Failed to delete collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// formatFromFilename 按扩展名推断文档格式；PDF 需由客户端先抽取为文本
+func formatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return "markdown"
+	case ".pdf":
+		return "pdf"
+	default:
+		return "text"
+	}
+}
+
+// citationsToJSON 转换 citations 流事件的负载，start/end 为回答中的 rune 偏移
+func citationsToJSON(citations []*chatpb.Citation) []gin.H {
+	out := make([]gin.H, len(citations))
+	for i, c := range citations {
+		out[i] = gin.H{
+			"start":       c.Start,
+			"end":         c.End,
+			"source":      c.Source,
+			"document_id": c.DocumentId,
+			"chunk_id":    c.ChunkId,
+			"title":       c.Title,
+			"snippet":     c.Snippet,
+		}
+	}
+	return out
+}
+
+func writeDocumentError(c *gin.Context, err error, fallback string) {
+	switch status.Code(err) {
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": status.Convert(err).Message()})
+	case codes.PermissionDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+	case codes.FailedPrecondition:
+		c.JSON(http.StatusConflict, gin.H{"error": status.Convert(err).Message()})
+	case codes.Unavailable:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Documents are unavailable"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDataKeys 返回当前空间加密消息与会话标题的数据密钥版本，工作空间中只有 owner / admin 可以查看
+func (h *ChatHandler) ListDataKeys(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListDataKeys(c.Request.Context(), &chatpb.ListDataKeysRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Data keys not found", "Failed to list data keys")
+		return
+	}
+
+	keys := make([]gin.H, len(resp.Keys))
+	for i, k := range resp.Keys {
+		keys[i] = dataKeyJSON(k)
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// RotateDataKey 为当前空间创建新的数据密钥版本，旧数据在后台逐步重新加密
+func (h *ChatHandler) RotateDataKey(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.RotateDataKey(c.Request.Context(), &chatpb.RotateDataKeyRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Data key not found", "Failed to rotate data key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"key": dataKeyJSON(resp.Key)})
+}
+
+func dataKeyJSON(k *chatpb.DataKey) gin.H {
+	return gin.H{
+		"scope":      k.GetScope(),
+		"version":    k.GetVersion(),
+		"current":    k.GetCurrent(),
+		"retired_at": k.GetRetiredAt(),
+		"destroyed":  k.GetDestroyed(),
+		"created_at": k.GetCreatedAt(),
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSession 下载一个会话的完整历史。查询参数 format：md（默认）、json 或 html
+func (h *ChatHandler) ExportSession(c *gin.Context) {
+	stream, first, ok := h.openExport(c, c.Param("sessionId"))
+	if !ok {
+		return
+	}
+	if first == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.Header("Content-Type", first.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": first.Filename}))
+	c.Status(http.StatusOK)
+	for chunk := first; chunk != nil; {
+		if _, err := c.Writer.Write(chunk.Data); err != nil {
+			log.Printf("[WARN] write export failed: %v", err)
+			return
+		}
+		var err error
+		if chunk, err = recvExport(stream); err != nil {
+			// 响应头已发出，只能中断连接让客户端看到不完整的下载
+			log.Printf("[ERROR] export stream failed: %v", err)
+			return
+		}
+	}
+}
+
+// ExportSessions 把当前用户的全部会话（含已归档）打包为 zip 下载，每个会话一个文件
+func (h *ChatHandler) ExportSessions(c *gin.Context) {
+	stream, chunk, ok := h.openExport(c, "")
+	if !ok {
+		return
+	}
+
+	name := fmt.Sprintf("sessions-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	var (
+		entry    io.Writer
+		filename string
+		err      error
+	)
+	for chunk != nil {
+		if entry == nil || chunk.Filename != filename {
+			filename = chunk.Filename
+			entry, err = zw.CreateHeader(&zip.FileHeader{
+				Name:     filename,
+				Method:   zip.Deflate,
+				Modified: time.Now(),
+			})
+			if err != nil {
+				log.Printf("[WARN] write export failed: %v", err)
+				return
+			}
+		}
+		if _, err := entry.Write(chunk.Data); err != nil {
+			log.Printf("[WARN] write export failed: %v", err)
+			return
+		}
+		if chunk, err = recvExport(stream); err != nil {
+			// 不写入 zip 目录，客户端会得到一个无法打开的文件而不是缺少会话的“完整”压缩包
+			log.Printf("[ERROR] export stream failed: %v", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("[WARN] write export failed: %v", err)
+	}
+}
+
+// openExport 发起导出并读取第一块数据，以便在写响应头之前处理错误；没有任何数据时 first 为 nil
+func (h *ChatHandler) openExport(c *gin.Context, sessionID string) (chatpb.ChatService_ExportSessionClient, *chatpb.ExportChunk, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, nil, false
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return nil, nil, false
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	stream, err := client.ExportSession(c.Request.Context(), &chatpb.ExportSessionRequest{
+		UserId:    userID,
+		SessionId: sessionID,
+		Format:    c.Query("format"),
+	})
+	if err == nil {
+		var first *chatpb.ExportChunk
+		if first, err = recvExport(stream); err == nil {
+			return stream, first, true
+		}
+	}
+	writeSessionError(c, err, "Session not found", "Failed to export sessions")
+	return nil, nil, false
+}
+
+// recvExport 读取下一块数据，流正常结束时返回 nil, nil
+func recvExport(stream chatpb.ChatService_ExportSessionClient) (*chatpb.ExportChunk, error) {
+	chunk, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	return chunk, err
+}
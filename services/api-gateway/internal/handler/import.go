@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImportBytes 单次导入（解压前）的大小上限
+	maxImportBytes = 128 << 20
+	// importChunkSize 与导出分块大小一致
+	importChunkSize = 32 << 10
+)
+
+// errImportTooLarge 上传内容超过 maxImportBytes
+var errImportTooLarge = errors.New("import is too large")
+
+// ImportConversations 导入第三方聊天导出。
+// 支持 multipart 表单的 file 字段，也支持直接把文件作为请求体上传；
+// 查询参数 format 为 chatgpt（conversations.json，或包含它的 ChatGPT 导出 zip）或 jsonl，
+// 未指定时按文件扩展名或 Content-Type 推断；dry_run=true 时只返回导入报告而不写入。
+func (h *ChatHandler) ImportConversations(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	dryRun := false
+	if v := c.Query("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+			return
+		}
+		dryRun = b
+	}
+
+	var (
+		body     io.Reader
+		filename string
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		if file.Size > maxImportBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import is too large"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer f.Close()
+		body, filename = f, file.Filename
+	} else {
+		body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = importFormatFromFilename(filename, c.ContentType())
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format is required (chatgpt or jsonl)"})
+		return
+	}
+
+	r, err := openImport(body, filename)
+	if err != nil {
+		writeImportReadError(c, err)
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	stream, err := client.ImportConversations(c.Request.Context())
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to import conversations")
+		return
+	}
+	// 第一块携带导入参数，即使文件为空也要发送；gRPC 发送后可能仍引用消息，每块使用新的缓冲区
+	chunk := &chatpb.ImportChunk{UserId: userID, Format: format, DryRun: dryRun}
+	for first := true; ; first = false {
+		buf := make([]byte, importChunkSize)
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			stream.CloseSend()
+			writeImportReadError(c, readErr)
+			return
+		}
+		if n == 0 && !first {
+			break
+		}
+		chunk.Data = buf[:n]
+		if err := stream.Send(chunk); err != nil {
+			// 服务端已提前结束（例如格式错误），真正的错误由 CloseAndRecv 返回
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			writeSessionError(c, err, "Session not found", "Failed to import conversations")
+			return
+		}
+		if readErr != nil {
+			break
+		}
+		chunk = &chatpb.ImportChunk{}
+	}
+	report, err := stream.CloseAndRecv()
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to import conversations")
+		return
+	}
+
+	results := make([]gin.H, len(report.Results))
+	for i, r := range report.Results {
+		results[i] = gin.H{
+			"source_id":     r.SourceId,
+			"session_id":    r.SessionId,
+			"title":         r.Title,
+			"message_count": r.MessageCount,
+			"token_count":   r.TokenCount,
+			"status":        r.Status,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":          report.DryRun,
+		"sessions":         report.Sessions,
+		"messages":         report.Messages,
+		"tokens":           report.Tokens,
+		"skipped_messages": report.SkippedMessages,
+		"duplicates":       report.Duplicates,
+		"results":          results,
+	})
+}
+
+// openImport 返回要上传的数据；ChatGPT 导出的 zip 包会被读入内存并取出其中的 conversations.json
+func openImport(body io.Reader, filename string) (io.Reader, error) {
+	if !strings.EqualFold(filepath.Ext(filename), ".zip") {
+		// 直接上传的请求体没有文件名，按 zip 文件头识别
+		head := make([]byte, 4)
+		n, err := io.ReadFull(body, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		body = io.MultiReader(bytes.NewReader(head[:n]), body)
+		if filename != "" || string(head[:n]) != "PK\x03\x04" {
+			return body, nil
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxImportBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportBytes {
+		return nil, errImportTooLarge
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("invalid zip archive")
+	}
+	for _, f := range zr.File {
+		if path.Base(f.Name) != "conversations.json" {
+			continue
+		}
+		if f.UncompressedSize64 > maxImportBytes {
+			return nil, errImportTooLarge
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, errors.New("invalid zip archive")
+		}
+		// zip 中的条目在请求结束前读完，不需要单独关闭
+		return io.LimitReader(rc, maxImportBytes), nil
+	}
+	return nil, errors.New("conversations.json not found in archive")
+}
+
+// importFormatFromFilename 按扩展名或 Content-Type 推断导入格式，无法推断时返回空
+func importFormatFromFilename(name, contentType string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".json", ".zip":
+		return "chatgpt"
+	}
+	switch contentType {
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return "jsonl"
+	case "application/json", "application/zip":
+		return "chatgpt"
+	}
+	return ""
+}
+
+func writeImportReadError(c *gin.Context, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) || errors.Is(err, errImportTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import is too large"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"net/http"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListMemories 列出当前用户的长期记忆及记忆开关
+func (h *ChatHandler) ListMemories(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListMemories(c.Request.Context(), &chatpb.ListMemoriesRequest{UserId: userID})
+	if err != nil {
+		writeMemoryError(c, err, "Failed to list memories")
+		return
+	}
+
+	memories := make([]gin.H, len(resp.Memories))
+	for i, m := range resp.Memories {
+		memories[i] = gin.H{
+			"memory_id":          m.MemoryId,
+			"content":            m.Content,
+			"category":           m.Category,
+			"source_session_id":  m.SourceSessionId,
+			"source_message_ids": m.SourceMessageIds,
+			"created_at":         m.CreatedAt,
+			"updated_at":         m.UpdatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memories": memories,
+		"enabled":  resp.Enabled,
+	})
+}
+
+// UpdateMemory 修改一条记忆的内容
+func (h *ChatHandler) UpdateMemory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.UpdateMemory(c.Request.Context(), &chatpb.UpdateMemoryRequest{
+		UserId:   userID,
+		MemoryId: c.Param("memoryId"),
+		Content:  req.Content,
+	})
+	if err != nil {
+		writeMemoryError(c, err, "Failed to update memory")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// DeleteMemory 删除一条记忆
+func (h *ChatHandler) DeleteMemory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.DeleteMemory(c.Request.Context(), &chatpb.DeleteMemoryRequest{
+		UserId:   userID,
+		MemoryId: c.Param("memoryId"),
+	})
+	if err != nil {
+		writeMemoryError(c, err, "Failed to delete memory")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// SetMemoryEnabled 开启或关闭长期记忆
+func (h *ChatHandler) SetMemoryEnabled(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.SetMemoryEnabled(c.Request.Context(), &chatpb.SetMemoryEnabledRequest{
+		UserId:  userID,
+		Enabled: *req.Enabled,
+	})
+	if err != nil {
+		writeMemoryError(c, err, "Failed to update memory setting")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+func writeMemoryError(c *gin.Context, err error, fallback string) {
+	switch status.Code(err) {
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Memory not found"})
+	case codes.PermissionDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+	case codes.Unavailable:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Memory is unavailable"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
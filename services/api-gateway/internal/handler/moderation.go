@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListModerationFlags 返回当前空间的审核复核队列，工作空间中只有 owner / admin 可以查看，个人空间只对运维管理员开放
+func (h *ChatHandler) ListModerationFlags(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	limit, err := queryLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListModerationFlags(c.Request.Context(), &chatpb.ListModerationFlagsRequest{
+		UserId: userID,
+		Status: c.Query("status"),
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Moderation flags not found", "Failed to list moderation flags")
+		return
+	}
+
+	flags := make([]gin.H, len(resp.Flags))
+	for i, f := range resp.Flags {
+		flags[i] = moderationFlagJSON(f)
+	}
+	c.JSON(http.StatusOK, gin.H{"flags": flags, "next_cursor": resp.NextCursor})
+}
+
+// ReviewModerationFlag 记录复核结论：dismissed、confirmed，或 pending 重新打开
+func (h *ChatHandler) ReviewModerationFlag(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ReviewModerationFlag(c.Request.Context(), &chatpb.ReviewModerationFlagRequest{
+		UserId: userID,
+		FlagId: c.Param("flagId"),
+		Status: req.Status,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Moderation flag not found", "Failed to review moderation flag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"flag": moderationFlagJSON(resp.Flag)})
+}
+
+func moderationFlagJSON(f *chatpb.ModerationFlag) gin.H {
+	return gin.H{
+		"flag_id":     f.GetFlagId(),
+		"user_id":     f.GetUserId(),
+		"session_id":  f.GetSessionId(),
+		"message_id":  f.GetMessageId(),
+		"stage":       f.GetStage(),
+		"rule":        f.GetRule(),
+		"category":    f.GetCategory(),
+		"action":      f.GetAction(),
+		"score":       f.GetScore(),
+		"excerpt":     f.GetExcerpt(),
+		"status":      f.GetStatus(),
+		"reviewed_by": f.GetReviewedBy(),
+		"reviewed_at": f.GetReviewedAt(),
+		"created_at":  f.GetCreatedAt(),
+	}
+}
+
+// moderationEventJSON 是 StreamChat 中 moderation 事件的内容
+func moderationEventJSON(m *chatpb.ModerationEvent, sessionID string) gin.H {
+	return gin.H{
+		"stage":     m.GetStage(),
+		"category":  m.GetCategory(),
+		"rule":      m.GetRule(),
+		"flag_id":   m.GetFlagId(),
+		"sessionId": sessionID,
+	}
+}
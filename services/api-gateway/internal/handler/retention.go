@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRetentionPolicy 返回当前空间（工作空间或个人空间）生效的消息保留策略
+func (h *ChatHandler) GetRetentionPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.GetRetentionPolicy(c.Request.Context(), &chatpb.GetRetentionPolicyRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Retention policy not found", "Failed to get retention policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": retentionPolicyJSON(resp.Policy)})
+}
+
+// SetRetentionPolicy 设置当前空间的消息保留天数（0 表示永久保留），工作空间中只有 owner / admin 可以设置
+func (h *ChatHandler) SetRetentionPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		MessageDays *int32 `json:"message_days" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.SetRetentionPolicy(c.Request.Context(), &chatpb.SetRetentionPolicyRequest{
+		UserId:      userID,
+		MessageDays: *req.MessageDays,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Retention policy not found", "Failed to set retention policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": retentionPolicyJSON(resp.Policy)})
+}
+
+// GetRetentionReport 试运行清理，返回下一次执行时当前空间中会被彻底删除的数据条数
+func (h *ChatHandler) GetRetentionReport(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.GetRetentionReport(c.Request.Context(), &chatpb.GetRetentionReportRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Retention policy not found", "Failed to get retention report")
+		return
+	}
+
+	deleted := make(gin.H, len(resp.Deleted))
+	for _, d := range resp.Deleted {
+		deleted[d.Target] = d.Count
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"policy":           retentionPolicyJSON(resp.Policy),
+		"message_cutoff":   resp.MessageCutoff,
+		"expired_messages": resp.ExpiredMessages,
+		"deleted_cutoff":   resp.DeletedCutoff,
+		"deleted":          deleted,
+	})
+}
+
+func retentionPolicyJSON(p *chatpb.RetentionPolicy) gin.H {
+	return gin.H{
+		"workspace_id": p.GetWorkspaceId(),
+		"message_days": p.GetMessageDays(),
+		"is_default":   p.GetIsDefault(),
+		"updated_by":   p.GetUpdatedBy(),
+		"updated_at":   p.GetUpdatedAt(),
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SearchConversations 按语义检索当前用户的历史会话，查询参数 q 为检索内容，k 为返回的会话数
+func (h *ChatHandler) SearchConversations(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	k, _ := strconv.Atoi(c.DefaultQuery("k", "5"))
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.SearchConversations(c.Request.Context(), &chatpb.SearchConversationsRequest{
+		UserId: userID,
+		Query:  query,
+		K:      int32(k),
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unavailable {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Semantic search is unavailable"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search conversations"})
+		return
+	}
+
+	results := make([]gin.H, len(resp.Results))
+	for i, r := range resp.Results {
+		messages := make([]gin.H, len(r.Messages))
+		for j, m := range r.Messages {
+			messages[j] = gin.H{
+				"message_id": m.MessageId,
+				"role":       m.Role,
+				"content":    m.Content,
+				"score":      m.Score,
+				"timestamp":  m.Timestamp,
+			}
+		}
+		results[i] = gin.H{
+			"session_id": r.SessionId,
+			"title":      r.Title,
+			"score":      r.Score,
+			"messages":   messages,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// SearchMessages 全文检索当前用户的消息。
+// 查询参数：q（必填）、session_id、role、from / to（RFC3339、2006-01-02 或 Unix 秒，
+// 只写日期时 to 包含当天）、cursor（上一页的 next_cursor）、limit
+func (h *ChatHandler) SearchMessages(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	from, err := parseSearchTime(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+		return
+	}
+	to, err := parseSearchTime(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.SearchMessages(c.Request.Context(), &chatpb.SearchMessagesRequest{
+		UserId:    userID,
+		Query:     query,
+		SessionId: c.Query("session_id"),
+		Role:      c.Query("role"),
+		From:      from,
+		To:        to,
+		Cursor:    c.Query("cursor"),
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+		case codes.Unavailable:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Search is unavailable"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search messages"})
+		}
+		return
+	}
+
+	results := make([]gin.H, len(resp.Results))
+	for i, r := range resp.Results {
+		results[i] = gin.H{
+			"message_id": r.MessageId,
+			"session_id": r.SessionId,
+			"role":       r.Role,
+			"snippet":    r.Snippet,
+			"timestamp":  r.Timestamp,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":     results,
+		"next_cursor": resp.NextCursor,
+	})
+}
+
+// parseSearchTime 把时间参数转换为 Unix 秒，空值返回 0。
+// endOfDay 为 true 时只有日期的值取次日零点，使区间包含当天。
+func parseSearchTime(value string, endOfDay bool) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return secs, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return 0, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Unix(), nil
+}
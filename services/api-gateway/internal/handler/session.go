@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"net/http"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpdateSession 修改会话的标题、置顶、归档、文件夹与标签，未提供的字段保持不变
+func (h *ChatHandler) UpdateSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Title    *string `json:"title"`
+		Pinned   *bool   `json:"pinned"`
+		Archived *bool   `json:"archived"`
+		// FolderID 为空字符串时移出文件夹
+		FolderID *string `json:"folder_id"`
+		// Tags 整体替换，空数组表示清空
+		Tags *[]string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	update := &chatpb.UpdateSessionRequest{
+		UserId:    userID,
+		SessionId: c.Param("sessionId"),
+		Title:     req.Title,
+		Pinned:    req.Pinned,
+		Archived:  req.Archived,
+		FolderId:  req.FolderID,
+	}
+	if req.Tags != nil {
+		update.Tags = &chatpb.SessionTags{Tags: *req.Tags}
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.UpdateSession(c.Request.Context(), update)
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to update session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": sessionJSON(resp.Session)})
+}
+
+// ForkSession 从指定消息处分叉出新会话，新会话包含到该消息为止的历史
+func (h *ChatHandler) ForkSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ForkSession(c.Request.Context(), &chatpb.ForkSessionRequest{
+		UserId:    userID,
+		SessionId: c.Param("sessionId"),
+		MessageId: c.Param("messageId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Session or message not found", "Failed to fork session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": sessionJSON(resp.Session)})
+}
+
+// CreateFolder 新建会话文件夹
+func (h *ChatHandler) CreateFolder(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.CreateFolder(c.Request.Context(), &chatpb.CreateFolderRequest{
+		UserId: userID,
+		Name:   req.Name,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Folder not found", "Failed to create folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"folder": folderJSON(resp.Folder)})
+}
+
+// ListFolders 列出当前用户的文件夹
+func (h *ChatHandler) ListFolders(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListFolders(c.Request.Context(), &chatpb.ListFoldersRequest{UserId: userID})
+	if err != nil {
+		writeSessionError(c, err, "Folder not found", "Failed to list folders")
+		return
+	}
+
+	folders := make([]gin.H, len(resp.Folders))
+	for i, f := range resp.Folders {
+		folders[i] = folderJSON(f)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"folders": folders})
+}
+
+// UpdateFolder 重命名文件夹
+func (h *ChatHandler) UpdateFolder(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.UpdateFolder(c.Request.Context(), &chatpb.UpdateFolderRequest{
+		UserId:   userID,
+		FolderId: c.Param("folderId"),
+		Name:     req.Name,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Folder not found", "Failed to update folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// DeleteFolder 删除文件夹，其中的会话保留并移出文件夹
+func (h *ChatHandler) DeleteFolder(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.DeleteFolder(c.Request.Context(), &chatpb.DeleteFolderRequest{
+		UserId:   userID,
+		FolderId: c.Param("folderId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Folder not found", "Failed to delete folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+func sessionJSON(s *chatpb.Session) gin.H {
+	if s == nil {
+		return gin.H{}
+	}
+	tags := s.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return gin.H{
+		"session_id":      s.SessionId,
+		"title":           s.Title,
+		"created_at":      s.CreatedAt,
+		"updated_at":      s.UpdatedAt,
+		"last_message_at": s.LastMessageAt,
+		"message_count":   s.MessageCount,
+		"preview":         s.Preview,
+		"pinned":          s.Pinned,
+		"archived":        s.Archived,
+		"folder_id":       s.FolderId,
+		"tags":            tags,
+		"forked_from":     s.ForkedFrom,
+		"fork_message_id": s.ForkMessageId,
+	}
+}
+
+func folderJSON(f *chatpb.Folder) gin.H {
+	if f == nil {
+		return gin.H{}
+	}
+	return gin.H{
+		"folder_id":  f.FolderId,
+		"name":       f.Name,
+		"created_at": f.CreatedAt,
+	}
+}
+
+func writeSessionError(c *gin.Context, err error, notFound, fallback string) {
+	switch status.Code(err) {
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case codes.PermissionDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+	case codes.FailedPrecondition:
+		// 无痕会话不支持的操作
+		c.JSON(http.StatusConflict, gin.H{"error": status.Convert(err).Message()})
+	case codes.Unavailable:
+		// 包括功能关闭与分叉点消息尚未落库，后者稍后重试即可
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": status.Convert(err).Message()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sharePathPrefix 公开分享链接的路径，令牌拼接在其后
+const sharePathPrefix = "/api/v1/share/"
+
+// CreateShare 为会话创建只读公开分享。
+// expires_at 可以是 Unix 秒、RFC 3339 或日期，expires_in 为从现在起的秒数，两者都不填则永不过期；
+// include_future 为 true 时，查看分享还会看到之后新增的消息。令牌只在这里返回一次。
+func (h *ChatHandler) CreateShare(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		ExpiresAt     string `json:"expires_at"`
+		ExpiresIn     int64  `json:"expires_in"`
+		IncludeFuture bool   `json:"include_future"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.ExpiresAt != "" && req.ExpiresIn != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at and expires_in are mutually exclusive"})
+		return
+	}
+	expiresAt, err := parseSearchTime(req.ExpiresAt, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_at"})
+		return
+	}
+	if req.ExpiresIn < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must be positive"})
+		return
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt = time.Now().Unix() + req.ExpiresIn
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.CreateShare(c.Request.Context(), &chatpb.CreateShareRequest{
+		UserId:        userID,
+		SessionId:     c.Param("sessionId"),
+		ExpiresAt:     expiresAt,
+		IncludeFuture: req.IncludeFuture,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to create share")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"share": shareJSON(resp.Share),
+		"token": resp.Token,
+		"url":   sharePathPrefix + resp.Token,
+	})
+}
+
+// ListShares 列出会话的全部分享（含已过期和已撤销的），不包含令牌
+func (h *ChatHandler) ListShares(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.ListShares(c.Request.Context(), &chatpb.ListSharesRequest{
+		UserId:    userID,
+		SessionId: c.Param("sessionId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Session not found", "Failed to list shares")
+		return
+	}
+
+	shares := make([]gin.H, len(resp.Shares))
+	for i, s := range resp.Shares {
+		shares[i] = shareJSON(s)
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+// RevokeShare 撤销分享，链接立即失效
+func (h *ChatHandler) RevokeShare(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.RevokeShare(c.Request.Context(), &chatpb.RevokeShareRequest{
+		UserId:  userID,
+		ShareId: c.Param("shareId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Share not found", "Failed to revoke share")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": resp.Success,
+		"message": resp.Message,
+	})
+}
+
+// GetSharedSession 通过令牌查看分享的会话，不需要登录
+func (h *ChatHandler) GetSharedSession(c *gin.Context) {
+	// 令牌就是访问凭证，避免被缓存或被搜索引擎收录
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chat service unavailable"})
+		return
+	}
+
+	client := chatpb.NewChatServiceClient(conn)
+	resp, err := client.GetSharedSession(c.Request.Context(), &chatpb.GetSharedSessionRequest{
+		Token: c.Param("token"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Share not found", "Failed to get shared session")
+		return
+	}
+
+	messages := make([]gin.H, len(resp.Messages))
+	for i, m := range resp.Messages {
+		messages[i] = gin.H{
+			"role":      m.Role,
+			"content":   m.Content,
+			"model":     m.Model,
+			"timestamp": m.Timestamp,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"title":     resp.Title,
+		"shared_at": resp.SharedAt,
+		"messages":  messages,
+		"truncated": resp.Truncated,
+	})
+}
+
+func shareJSON(s *chatpb.Share) gin.H {
+	if s == nil {
+		return gin.H{}
+	}
+	return gin.H{
+		"share_id":       s.ShareId,
+		"session_id":     s.SessionId,
+		"title":          s.Title,
+		"include_future": s.IncludeFuture,
+		"message_count":  s.MessageCount,
+		"expires_at":     s.ExpiresAt,
+		"revoked_at":     s.RevokedAt,
+		"created_at":     s.CreatedAt,
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+
+	authpb "free-chat/pkg/proto/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWorkspace 创建工作空间，请求者成为 owner
+func (h *AuthHandler) CreateWorkspace(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	client, ok := h.workspaceClient(c)
+	if !ok {
+		return
+	}
+	resp, err := client.CreateWorkspace(c.Request.Context(), &authpb.CreateWorkspaceRequest{
+		UserId: c.GetString("user_id"),
+		Name:   req.Name,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Workspace not found", "Failed to create workspace")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"workspace": workspaceJSON(resp.Workspace)})
+}
+
+// ListWorkspaces 返回请求者加入的全部工作空间及当前所在的空间
+func (h *AuthHandler) ListWorkspaces(c *gin.Context) {
+	client, ok := h.workspaceClient(c)
+	if !ok {
+		return
+	}
+	resp, err := client.ListWorkspaces(c.Request.Context(), &authpb.ListWorkspacesRequest{
+		UserId: c.GetString("user_id"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Workspace not found", "Failed to list workspaces")
+		return
+	}
+	workspaces := make([]gin.H, len(resp.Workspaces))
+	for i, w := range resp.Workspaces {
+		workspaces[i] = workspaceJSON(w)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"workspaces":        workspaces,
+		"current_workspace": c.GetString("workspace_id"),
+	})
+}
+
+func (h *AuthHandler) ListWorkspaceMembers(c *gin.Context) {
+	client, ok := h.workspaceClient(c)
+	if !ok {
+		return
+	}
+	resp, err := client.ListWorkspaceMembers(c.Request.Context(), &authpb.ListWorkspaceMembersRequest{
+		UserId:      c.GetString("user_id"),
+		WorkspaceId: c.Param("workspaceId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Workspace not found", "Failed to list members")
+		return
+	}
+	members := make([]gin.H, len(resp.Members))
+	for i, m := range resp.Members {
+		members[i] = workspaceMemberJSON(m)
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// AddWorkspaceMember 按用户名邀请成员，已是成员时修改其角色
+func (h *AuthHandler) AddWorkspaceMember(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = "member"
+	}
+	client, ok := h.workspaceClient(c)
+	if !ok {
+		return
+	}
+	resp, err := client.AddWorkspaceMember(c.Request.Context(), &authpb.AddWorkspaceMemberRequest{
+		UserId:      c.GetString("user_id"),
+		WorkspaceId: c.Param("workspaceId"),
+		Username:    req.Username,
+		Role:        req.Role,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Workspace or user not found", "Failed to add member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"member": workspaceMemberJSON(resp.Member)})
+}
+
+// RemoveWorkspaceMember 移除成员；成员移除自己即退出空间
+func (h *AuthHandler) RemoveWorkspaceMember(c *gin.Context) {
+	client, ok := h.workspaceClient(c)
+	if !ok {
+		return
+	}
+	_, err := client.RemoveWorkspaceMember(c.Request.Context(), &authpb.RemoveWorkspaceMemberRequest{
+		UserId:      c.GetString("user_id"),
+		WorkspaceId: c.Param("workspaceId"),
+		MemberId:    c.Param("userId"),
+	})
+	if err != nil {
+		writeSessionError(c, err, "Member not found", "Failed to remove member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// SwitchWorkspace 返回以指定工作空间为当前空间的新令牌，workspace_id 为空时切回个人空间
+func (h *AuthHandler) SwitchWorkspace(c *gin.Context) {
+	var req struct {
+		WorkspaceID string `json:"workspace_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	client, ok := h.workspaceClient(c)
+	if !ok {
+		return
+	}
+	resp, err := client.SwitchWorkspace(c.Request.Context(), &authpb.SwitchWorkspaceRequest{
+		UserId:      c.GetString("user_id"),
+		WorkspaceId: req.WorkspaceID,
+	})
+	if err != nil {
+		writeSessionError(c, err, "Workspace not found", "Failed to switch workspace")
+		return
+	}
+	c.JSON(http.StatusOK, tokenJSON(resp))
+}
+
+func (h *AuthHandler) workspaceClient(c *gin.Context) (authpb.AuthServiceClient, bool) {
+	if c.GetString("user_id") == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+	conn, err := h.getGRPCConnection()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Auth service unavailable"})
+		return nil, false
+	}
+	return authpb.NewAuthServiceClient(conn), true
+}
+
+func tokenJSON(resp *authpb.LoginResponse) gin.H {
+	return gin.H{
+		"access_token":   resp.AccessToken,
+		"refresh_token":  resp.RefreshToken,
+		"expires_at":     resp.ExpiresAt,
+		"workspace_id":   resp.WorkspaceId,
+		"workspace_role": resp.WorkspaceRole,
+	}
+}
+
+func workspaceJSON(w *authpb.Workspace) gin.H {
+	return gin.H{
+		"workspace_id": w.WorkspaceId,
+		"name":         w.Name,
+		"created_by":   w.CreatedBy,
+		"role":         w.Role,
+		"created_at":   w.CreatedAt,
+	}
+}
+
+func workspaceMemberJSON(m *authpb.WorkspaceMember) gin.H {
+	return gin.H{
+		"user_id":   m.UserId,
+		"username":  m.Username,
+		"role":      m.Role,
+		"joined_at": m.JoinedAt,
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"free-chat/pkg/tenant"
 	"net/http"
 	"strings"
 
@@ -39,6 +40,13 @@ func JwtAuth(jwtSecret string) gin.HandlerFunc {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
 			c.Set("user_id", claims["user_id"])
 			c.Set("username", claims["username"])
+			// 当前工作空间随请求上下文传给后端服务，个人空间时为空
+			workspaceID, _ := claims["workspace_id"].(string)
+			workspaceRole, _ := claims["workspace_role"].(string)
+			c.Set("workspace_id", workspaceID)
+			c.Set("workspace_role", workspaceRole)
+			c.Request = c.Request.WithContext(tenant.NewOutgoingContext(c.Request.Context(),
+				tenant.Workspace{ID: workspaceID, Role: workspaceRole}))
 		}
 
 		c.Next()
@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"free-chat/pkg/tenant"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/metadata"
 )
 
 func testHandler(c *gin.Context) {
@@ -96,6 +99,43 @@ func TestJwtAuth_ValidToken_SetsUserContext(t *testing.T) {
 	}
 }
 
+func TestJwtAuth_ValidToken_SetsWorkspace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":        "user-123",
+		"username":       "testuser",
+		"workspace_id":   "ws-1",
+		"workspace_role": "admin",
+	})
+	tokenStr, err := token.SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(JwtAuth("test-secret"))
+	r.GET("/protected", func(c *gin.Context) {
+		md, _ := metadata.FromOutgoingContext(c.Request.Context())
+		got := tenant.FromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+		c.JSON(200, gin.H{
+			"workspace_id": c.GetString("workspace_id"),
+			"metadata_id":  got.ID,
+			"role":         got.Role,
+		})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenStr)
+	r.ServeHTTP(w, req)
+
+	want := `{"metadata_id":"ws-1","role":"admin","workspace_id":"ws-1"}`
+	if w.Code != 200 || w.Body.String() != want {
+		t.Errorf("expected 200 %s, got %d %s", want, w.Code, w.Body.String())
+	}
+}
+
 func TestJwtAuth_WrongSecret(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -69,6 +69,7 @@ func main() {
 	}
 	// 初始化依赖 (Infrastructure Layer)
 	userRepo := persistence.NewUserRepository(db)
+	workspaceRepo := persistence.NewWorkspaceRepository(db)
 	passwordService := security.NewBcryptService()
 	tokenService := security.NewJWTService(cfg.Auth.JwtSecret, cfg.Auth.Expire_Access_H, cfg.Auth.Expire_Refresh_H)
 
@@ -76,10 +77,11 @@ func main() {
 	userService := domain.NewUserService()
 
 	// 初始化应用服务 (Application Layer)
-	authService := application.NewAuthService(*userService, userRepo, tokenService, passwordService)
+	authService := application.NewAuthService(*userService, userRepo, workspaceRepo, tokenService, passwordService)
+	workspaceService := application.NewWorkspaceService(userRepo, workspaceRepo, tokenService)
 
 	// 初始化接口层 (Interface/Handler Layer)
-	authHandler := grpc.NewAuthHandler(authService)
+	authHandler := grpc.NewAuthHandler(authService, workspaceService)
 	authServer := grpc.NewAuthServer(serverEndpoint, serviceName, authHandler)
 
 	if err := sm.Start(); err != nil {
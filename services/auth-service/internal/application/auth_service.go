@@ -10,6 +10,7 @@ import (
 type AuthService struct {
 	userService     domain.UserService
 	userRepo        domain.UserRepository
+	workspaceRepo   domain.WorkspaceRepository
 	tokenService    domain.TokenService
 	passwordService domain.PasswordEncoder
 }
@@ -17,12 +18,14 @@ type AuthService struct {
 func NewAuthService(
 	userService domain.UserService,
 	userRepo domain.UserRepository,
+	workspaceRepo domain.WorkspaceRepository,
 	tokenService domain.TokenService,
 	passwordService domain.PasswordEncoder,
 ) *AuthService {
 	return &AuthService{
 		userService:     userService,
 		userRepo:        userRepo,
+		workspaceRepo:   workspaceRepo,
 		tokenService:    tokenService,
 		passwordService: passwordService,
 	}
@@ -39,20 +42,11 @@ func (s *AuthService) Login(req *dto.LoginReq) (*dto.LoginResp, error) {
 	if u.Status == domain.UserStatusDisabled {
 		return nil, domain.ErrUserDisabled
 	}
-	accessToken, err := s.tokenService.GenerateAccessToken(u.ID, u.Username)
+	subject, err := workspaceSubject(s.workspaceRepo, u, req.WorkspaceID)
 	if err != nil {
-		return nil, domain.ErrTokenGenerateFailed
-	}
-	refreshToken, err := s.tokenService.GenerateRefreshToken(u.ID, u.Username)
-	if err != nil {
-		return nil, domain.ErrTokenGenerateFailed
+		return nil, err
 	}
-	return &dto.LoginResp{
-		AccessToken:  accessToken.Token,
-		RefreshToken: refreshToken.Token,
-		ExpiresAt:    accessToken.ExpiresAt.Unix(),
-		UserID:       u.ID,
-	}, nil
+	return issueTokens(s.tokenService, subject)
 }
 
 func (s *AuthService) Register(req *dto.RegisterReq) (*dto.RegisterResp, error) {
@@ -81,14 +75,74 @@ func (s *AuthService) ValidateToken(token string) (bool, error) {
 	return s.tokenService.ValidateToken(token)
 }
 
+// RefreshToken 重新签发令牌前复核工作空间成员资格，被移出空间或角色变化后不会沿用旧的身份
 func (s *AuthService) RefreshToken(refreshToken string) (*dto.LoginResp, error) {
-	accessToken, newRefreshToken, err := s.tokenService.RefreshToken(refreshToken)
+	subject, err := s.tokenService.ParseRefreshToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
+	if subject.WorkspaceID != "" {
+		member, err := s.workspaceRepo.FindMember(subject.WorkspaceID, subject.UserID)
+		if err != nil {
+			return nil, err
+		}
+		subject.WorkspaceRole = member.Role
+	}
+	return issueTokens(s.tokenService, subject)
+}
+
+// GetProfile 返回用户资料与所在的全部工作空间
+func (s *AuthService) GetProfile(userID string) (*domain.Profile, error) {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.workspaceRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Profile{User: u, Memberships: memberships}, nil
+}
+
+// DeleteUser 先移除工作空间成员资格再删除用户，可以重复调用：
+// 中途失败后再次调用会从剩余的部分继续，返回本次是否删除了用户
+func (s *AuthService) DeleteUser(userID string) (bool, error) {
+	if err := s.workspaceRepo.RemoveUser(userID); err != nil {
+		return false, err
+	}
+	return s.userRepo.Delete(userID)
+}
+
+// workspaceSubject 返回用户在 workspaceID 中的令牌身份，workspaceID 为空表示个人空间
+func workspaceSubject(repo domain.WorkspaceRepository, u *domain.User, workspaceID string) (*domain.TokenSubject, error) {
+	subject := &domain.TokenSubject{UserID: u.ID, Username: u.Username}
+	if workspaceID == "" {
+		return subject, nil
+	}
+	member, err := repo.FindMember(workspaceID, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	subject.WorkspaceID, subject.WorkspaceRole = workspaceID, member.Role
+	return subject, nil
+}
+
+// issueTokens 为 subject 签发一对新的访问令牌与刷新令牌
+func issueTokens(tokens domain.TokenService, subject *domain.TokenSubject) (*dto.LoginResp, error) {
+	accessToken, err := tokens.GenerateAccessToken(subject)
+	if err != nil {
+		return nil, domain.ErrTokenGenerateFailed
+	}
+	refreshToken, err := tokens.GenerateRefreshToken(subject)
+	if err != nil {
+		return nil, domain.ErrTokenGenerateFailed
+	}
 	return &dto.LoginResp{
-		AccessToken:  accessToken.Token,
-		RefreshToken: newRefreshToken.Token,
-		ExpiresAt:    accessToken.ExpiresAt.Unix(),
+		AccessToken:   accessToken.Token,
+		RefreshToken:  refreshToken.Token,
+		ExpiresAt:     accessToken.ExpiresAt.Unix(),
+		UserID:        subject.UserID,
+		WorkspaceID:   subject.WorkspaceID,
+		WorkspaceRole: string(subject.WorkspaceRole),
 	}, nil
 }
@@ -18,6 +18,8 @@ type RegisterResp struct {
 type LoginReq struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// WorkspaceID 登录后所在的工作空间，为空表示个人空间
+	WorkspaceID string `json:"workspace_id"`
 }
 
 // LoginResp 登录响应DTO（含JWT Token）
@@ -26,4 +28,7 @@ type LoginResp struct {
 	RefreshToken string `json:"refresh_token"`
 	ExpiresAt    int64  `json:"expires_at"` // Token过期时间（秒）
 	UserID       string `json:"user_id"`
+	// WorkspaceID / WorkspaceRole 为令牌中的当前工作空间，个人空间时为空
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceRole string `json:"workspace_role"`
 }
@@ -0,0 +1,141 @@
+package application
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"free-chat/services/auth-service/internal/application/dto"
+	"free-chat/services/auth-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxWorkspaceNameLen 工作空间名称的最大字符数
+const maxWorkspaceNameLen = 64
+
+type WorkspaceService struct {
+	userRepo      domain.UserRepository
+	workspaceRepo domain.WorkspaceRepository
+	tokenService  domain.TokenService
+}
+
+func NewWorkspaceService(
+	userRepo domain.UserRepository,
+	workspaceRepo domain.WorkspaceRepository,
+	tokenService domain.TokenService,
+) *WorkspaceService {
+	return &WorkspaceService{
+		userRepo:      userRepo,
+		workspaceRepo: workspaceRepo,
+		tokenService:  tokenService,
+	}
+}
+
+// CreateWorkspace 创建工作空间，创建者成为 owner
+func (s *WorkspaceService) CreateWorkspace(userID, name string) (*domain.Membership, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWorkspaceNameLen {
+		return nil, domain.ErrInvalidWorkspace
+	}
+	workspace := &domain.Workspace{
+		ID:        uuid.NewString(),
+		Name:      name,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.workspaceRepo.Save(workspace); err != nil {
+		return nil, err
+	}
+	return &domain.Membership{Workspace: workspace, Role: domain.WorkspaceOwner}, nil
+}
+
+func (s *WorkspaceService) ListWorkspaces(userID string) ([]*domain.Membership, error) {
+	return s.workspaceRepo.ListByUser(userID)
+}
+
+// ListMembers 只有空间成员可以查看成员列表
+func (s *WorkspaceService) ListMembers(userID, workspaceID string) ([]*domain.Member, error) {
+	if _, err := s.workspaceRepo.FindMember(workspaceID, userID); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListMembers(workspaceID)
+}
+
+// AddMember 由 owner 或 admin 按用户名邀请成员，已是成员时修改其角色
+func (s *WorkspaceService) AddMember(userID, workspaceID, username, role string) (*domain.Member, error) {
+	r, ok := domain.ParseWorkspaceRole(role)
+	if !ok {
+		return nil, domain.ErrInvalidMemberRole
+	}
+	if err := s.requireManager(workspaceID, userID); err != nil {
+		return nil, err
+	}
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	existing, err := s.workspaceRepo.FindMember(workspaceID, u.ID)
+	switch {
+	case err == nil && existing.Role == domain.WorkspaceOwner:
+		return nil, domain.ErrWorkspaceOwner
+	case err != nil && err != domain.ErrNotWorkspaceMember:
+		return nil, err
+	}
+	member := &domain.Member{
+		WorkspaceID: workspaceID,
+		UserID:      u.ID,
+		Username:    u.Username,
+		Role:        r,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.workspaceRepo.SaveMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember 由 owner 或 admin 移除成员，成员也可以移除自己（退出空间）；owner 不能被移除
+func (s *WorkspaceService) RemoveMember(userID, workspaceID, memberID string) error {
+	if memberID != userID {
+		if err := s.requireManager(workspaceID, userID); err != nil {
+			return err
+		}
+	}
+	member, err := s.workspaceRepo.FindMember(workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if member.Role == domain.WorkspaceOwner {
+		return domain.ErrWorkspaceOwner
+	}
+	return s.workspaceRepo.DeleteMember(workspaceID, memberID)
+}
+
+// SwitchWorkspace 签发以 workspaceID 为当前空间的新令牌，workspaceID 为空时切回个人空间
+func (s *WorkspaceService) SwitchWorkspace(userID, workspaceID string) (*dto.LoginResp, error) {
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	if u.Status == domain.UserStatusDisabled {
+		return nil, domain.ErrUserDisabled
+	}
+	subject, err := workspaceSubject(s.workspaceRepo, u, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return issueTokens(s.tokenService, subject)
+}
+
+// requireManager 要求 userID 是空间的 owner 或 admin
+func (s *WorkspaceService) requireManager(workspaceID, userID string) error {
+	member, err := s.workspaceRepo.FindMember(workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !member.Role.CanManageMembers() {
+		return domain.ErrWorkspacePermission
+	}
+	return nil
+}
@@ -11,11 +11,12 @@ const (
 )
 
 type User struct {
-	ID       string
-	Username string
-	Email    string
-	Password Password
-	Status   UserStatus
+	ID        string
+	Username  string
+	Email     string
+	Password  Password
+	Status    UserStatus
+	CreatedAt time.Time
 }
 
 func NewUser(id, username, email string, password Password) *User {
@@ -48,3 +49,66 @@ type Token struct {
 	Token     string
 	ExpiresAt time.Time
 }
+
+// WorkspaceRole 是成员在工作空间中的角色
+type WorkspaceRole string
+
+const (
+	// WorkspaceOwner 创建者，不能被移除或降级
+	WorkspaceOwner WorkspaceRole = "owner"
+	// WorkspaceAdmin 可以管理成员，并能查看和管理空间内所有人的会话
+	WorkspaceAdmin  WorkspaceRole = "admin"
+	WorkspaceMember WorkspaceRole = "member"
+)
+
+// ParseWorkspaceRole 解析可以授予成员的角色（admin / member），owner 只在创建时产生
+func ParseWorkspaceRole(s string) (WorkspaceRole, bool) {
+	switch r := WorkspaceRole(s); r {
+	case WorkspaceAdmin, WorkspaceMember:
+		return r, true
+	}
+	return "", false
+}
+
+// CanManageMembers 返回该角色能否邀请和移除成员
+func (r WorkspaceRole) CanManageMembers() bool {
+	return r == WorkspaceOwner || r == WorkspaceAdmin
+}
+
+// Workspace 是一个团队的租户空间，会话等数据按空间隔离。
+// 用户总是拥有一个隐含的个人空间，其 ID 为空字符串，不需要保存
+type Workspace struct {
+	ID        string
+	Name      string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Member 是工作空间中的一个成员
+type Member struct {
+	WorkspaceID string
+	UserID      string
+	Username    string
+	Role        WorkspaceRole
+	CreatedAt   time.Time
+}
+
+// Membership 是用户加入的一个工作空间及其在其中的角色
+type Membership struct {
+	Workspace *Workspace
+	Role      WorkspaceRole
+}
+
+// Profile 是导出给用户本人的账户资料
+type Profile struct {
+	User        *User
+	Memberships []*Membership
+}
+
+// TokenSubject 是令牌代表的身份：用户以及当前所在的工作空间（为空表示个人空间）
+type TokenSubject struct {
+	UserID        string
+	Username      string
+	WorkspaceID   string
+	WorkspaceRole WorkspaceRole
+}
@@ -17,6 +17,17 @@ var (
 	ErrUserNotFound   = errors.New("user not found")
 )
 
+// workspace
+var (
+	ErrWorkspaceNotFound   = errors.New("workspace not found")
+	ErrInvalidWorkspace    = errors.New("invalid workspace name")
+	ErrNotWorkspaceMember  = errors.New("not a member of the workspace")
+	ErrWorkspacePermission = errors.New("permission denied")
+	ErrInvalidMemberRole   = errors.New("role must be admin or member")
+	// ErrWorkspaceOwner 所有者不能被移除、降级或退出自己的空间
+	ErrWorkspaceOwner = errors.New("the workspace owner cannot be changed")
+)
+
 // token
 var (
 	ErrTokenGenerateFailed = errors.New("generate token failed")
@@ -5,4 +5,24 @@ type UserRepository interface {
 	FindByUsername(username string) (*User, error)
 	FindByEmail(email string) (*User, error)
 	FindByID(id string) (*User, error)
+	// Delete 彻底删除用户，用户不存在时返回 false
+	Delete(id string) (bool, error)
+}
+
+type WorkspaceRepository interface {
+	// Save 在一个事务中保存工作空间并把创建者加为 owner
+	Save(workspace *Workspace) error
+	FindByID(id string) (*Workspace, error)
+	// FindMember 用户不是成员时返回 ErrNotWorkspaceMember
+	FindMember(workspaceID, userID string) (*Member, error)
+	// ListByUser 按加入时间返回用户所在的全部工作空间
+	ListByUser(userID string) ([]*Membership, error)
+	// ListMembers 按加入时间返回工作空间的全部成员
+	ListMembers(workspaceID string) ([]*Member, error)
+	// SaveMember 添加成员，已存在时更新角色
+	SaveMember(member *Member) error
+	DeleteMember(workspaceID, userID string) error
+	// RemoveUser 移除用户的全部成员资格：空间只剩该用户时删除空间，
+	// 用户是 owner 时把空间（及 CreatedBy）转给最早加入的 admin，没有 admin 时转给最早加入的成员
+	RemoveUser(userID string) error
 }
@@ -39,8 +39,9 @@ type PasswordEncoder interface {
 }
 
 type TokenService interface {
-	GenerateAccessToken(userID, username string) (*Token, error)
-	GenerateRefreshToken(userID, username string) (*Token, error)
+	GenerateAccessToken(subject *TokenSubject) (*Token, error)
+	GenerateRefreshToken(subject *TokenSubject) (*Token, error)
 	ValidateToken(token string) (bool, error)
-	RefreshToken(refreshToken string) (*Token, *Token, error)
+	// ParseRefreshToken 校验刷新令牌并返回其中的身份，供重新签发前复核工作空间成员资格
+	ParseRefreshToken(refreshToken string) (*TokenSubject, error)
 }
@@ -15,7 +15,7 @@ func InitGorm(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = db.AutoMigrate(&UserModel{})
+	err = db.AutoMigrate(&UserModel{}, &WorkspaceModel{}, &WorkspaceMemberModel{})
 	if err != nil {
 		return nil, err
 	}
@@ -36,11 +36,12 @@ type UserModel struct {
 // 转换：UserModel → 领域实体User
 func (m *UserModel) ToDomainEntity() *domain.User {
 	return &domain.User{
-		ID:       m.ID,
-		Username: m.Username,
-		Password: *domain.NewPassword(m.Password), // 直接赋值哈希（避免二次加密）
-		Email:    m.Email,
-		Status:   domain.UserStatus(m.Status),
+		ID:        m.ID,
+		Username:  m.Username,
+		Password:  *domain.NewPassword(m.Password), // 直接赋值哈希（避免二次加密）
+		Email:     m.Email,
+		Status:    domain.UserStatus(m.Status),
+		CreatedAt: m.CreatedAt,
 	}
 }
 
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"free-chat/services/auth-service/internal/domain"
+	"time"
+)
+
+// WorkspaceModel 工作空间
+type WorkspaceModel struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)"`
+	Name      string    `gorm:"type:varchar(100);not null"`
+	CreatedBy string    `gorm:"type:varchar(36);not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// WorkspaceMemberModel 工作空间成员，(workspace_id, user_id) 唯一
+type WorkspaceMemberModel struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	WorkspaceID string    `gorm:"uniqueIndex:idx_workspace_member,priority:1;type:varchar(36);not null"`
+	UserID      string    `gorm:"uniqueIndex:idx_workspace_member,priority:2;index:idx_workspace_member_user;type:varchar(36);not null"`
+	Role        string    `gorm:"type:varchar(20);not null"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// 转换：WorkspaceModel → 领域实体Workspace
+func (m *WorkspaceModel) ToDomainEntity() *domain.Workspace {
+	return &domain.Workspace{
+		ID:        m.ID,
+		Name:      m.Name,
+		CreatedBy: m.CreatedBy,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// 转换：领域实体Workspace → WorkspaceModel
+func ToWorkspaceModel(d *domain.Workspace) *WorkspaceModel {
+	return &WorkspaceModel{
+		ID:        d.ID,
+		Name:      d.Name,
+		CreatedBy: d.CreatedBy,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+// 转换：领域实体Member → WorkspaceMemberModel（Username 来自用户表，不冗余保存）
+func ToWorkspaceMemberModel(d *domain.Member) *WorkspaceMemberModel {
+	return &WorkspaceMemberModel{
+		WorkspaceID: d.WorkspaceID,
+		UserID:      d.UserID,
+		Role:        string(d.Role),
+		CreatedAt:   d.CreatedAt,
+	}
+}
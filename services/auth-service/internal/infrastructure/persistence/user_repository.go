@@ -59,9 +59,17 @@ func (r *UserRepository) FindByEmail(email string) (*domain.User, error) {
 func (r *UserRepository) FindByID(id string) (*domain.User, error) {
 	var model db.UserModel
 	if err := r.db.
-		Where("user_id = ?", id).
+		Where("id = ?", id).
 		First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return model.ToDomainEntity(), nil
 }
+
+func (r *UserRepository) Delete(id string) (bool, error) {
+	res := r.db.Where("id = ?", id).Delete(&db.UserModel{})
+	return res.RowsAffected > 0, res.Error
+}
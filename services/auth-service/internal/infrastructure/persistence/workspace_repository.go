@@ -0,0 +1,175 @@
+package persistence
+
+import (
+	"time"
+
+	"free-chat/services/auth-service/internal/domain"
+	"free-chat/services/auth-service/internal/infrastructure/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkspaceRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceRepository(db *gorm.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+// memberRow 是成员与用户表联查的结果
+type memberRow struct {
+	db.WorkspaceMemberModel
+	Username string
+}
+
+func (r memberRow) toDomain() *domain.Member {
+	return &domain.Member{
+		WorkspaceID: r.WorkspaceID,
+		UserID:      r.UserID,
+		Username:    r.Username,
+		Role:        domain.WorkspaceRole(r.Role),
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func (r *WorkspaceRepository) Save(workspace *domain.Workspace) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(db.ToWorkspaceModel(workspace)).Error; err != nil {
+			return err
+		}
+		return tx.Create(db.ToWorkspaceMemberModel(&domain.Member{
+			WorkspaceID: workspace.ID,
+			UserID:      workspace.CreatedBy,
+			Role:        domain.WorkspaceOwner,
+			CreatedAt:   workspace.CreatedAt,
+		})).Error
+	})
+}
+
+func (r *WorkspaceRepository) FindByID(id string) (*domain.Workspace, error) {
+	var model db.WorkspaceModel
+	if err := r.db.
+		Where("id = ?", id).
+		First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+	return model.ToDomainEntity(), nil
+}
+
+func (r *WorkspaceRepository) FindMember(workspaceID, userID string) (*domain.Member, error) {
+	var row memberRow
+	if err := r.members().
+		Where("workspace_member_models.workspace_id = ? AND workspace_member_models.user_id = ?", workspaceID, userID).
+		Take(&row).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrNotWorkspaceMember
+		}
+		return nil, err
+	}
+	return row.toDomain(), nil
+}
+
+func (r *WorkspaceRepository) ListByUser(userID string) ([]*domain.Membership, error) {
+	var rows []struct {
+		db.WorkspaceModel
+		Role string
+	}
+	if err := r.db.Model(&db.WorkspaceModel{}).
+		Select("workspace_models.*, workspace_member_models.role").
+		Joins("JOIN workspace_member_models ON workspace_member_models.workspace_id = workspace_models.id").
+		Where("workspace_member_models.user_id = ?", userID).
+		Order("workspace_member_models.created_at asc").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	memberships := make([]*domain.Membership, len(rows))
+	for i, row := range rows {
+		memberships[i] = &domain.Membership{
+			Workspace: row.ToDomainEntity(),
+			Role:      domain.WorkspaceRole(row.Role),
+		}
+	}
+	return memberships, nil
+}
+
+func (r *WorkspaceRepository) ListMembers(workspaceID string) ([]*domain.Member, error) {
+	var rows []memberRow
+	if err := r.members().
+		Where("workspace_member_models.workspace_id = ?", workspaceID).
+		Order("workspace_member_models.created_at asc").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	members := make([]*domain.Member, len(rows))
+	for i, row := range rows {
+		members[i] = row.toDomain()
+	}
+	return members, nil
+}
+
+func (r *WorkspaceRepository) SaveMember(member *domain.Member) error {
+	model := db.ToWorkspaceMemberModel(member)
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = time.Now()
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(model).Error
+}
+
+func (r *WorkspaceRepository) DeleteMember(workspaceID, userID string) error {
+	return r.db.
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Delete(&db.WorkspaceMemberModel{}).Error
+}
+
+func (r *WorkspaceRepository) RemoveUser(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var owned []db.WorkspaceMemberModel
+		if err := tx.Where("user_id = ? AND role = ?", userID, string(domain.WorkspaceOwner)).
+			Find(&owned).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&db.WorkspaceMemberModel{}).Error; err != nil {
+			return err
+		}
+		for _, m := range owned {
+			var heir db.WorkspaceMemberModel
+			err := tx.Where("workspace_id = ?", m.WorkspaceID).
+				Order(clause.Expr{SQL: "CASE WHEN role = ? THEN 0 ELSE 1 END, created_at asc", Vars: []interface{}{string(domain.WorkspaceAdmin)}}).
+				Take(&heir).Error
+			if err == gorm.ErrRecordNotFound {
+				if err := tx.Where("id = ?", m.WorkspaceID).Delete(&db.WorkspaceModel{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&heir).Update("role", string(domain.WorkspaceOwner)).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&db.WorkspaceModel{}).Where("id = ?", m.WorkspaceID).
+				Update("created_by", heir.UserID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// members 返回成员与用户名的联查
+func (r *WorkspaceRepository) members() *gorm.DB {
+	return r.db.Model(&db.WorkspaceMemberModel{}).
+		Select("workspace_member_models.*, user_models.username").
+		Joins("JOIN user_models ON user_models.id = workspace_member_models.user_id")
+}
+
+var _ domain.WorkspaceRepository = (*WorkspaceRepository)(nil)
@@ -23,6 +23,9 @@ type JWTService struct {
 type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
+	// WorkspaceID 为当前工作空间，个人空间时省略
+	WorkspaceID   string `json:"workspace_id,omitempty"`
+	WorkspaceRole string `json:"workspace_role,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -34,8 +37,8 @@ func NewJWTService(secretKey string, expirationAccess, expirationRefresh int) *J
 	}
 }
 
-func (j *JWTService) GenerateAccessToken(userID string, userName string) (*domain.Token, error) {
-	token, expiresAt, err := j.generate(userID, userName, TypeAccess)
+func (j *JWTService) GenerateAccessToken(subject *domain.TokenSubject) (*domain.Token, error) {
+	token, expiresAt, err := j.generate(subject, TypeAccess)
 	if err != nil {
 		return nil, err
 	}
@@ -45,8 +48,8 @@ func (j *JWTService) GenerateAccessToken(userID string, userName string) (*domai
 	}, nil
 }
 
-func (j *JWTService) GenerateRefreshToken(userID string, userName string) (*domain.Token, error) {
-	token, expiresAt, err := j.generate(userID, userName, TypeRefresh)
+func (j *JWTService) GenerateRefreshToken(subject *domain.TokenSubject) (*domain.Token, error) {
+	token, expiresAt, err := j.generate(subject, TypeRefresh)
 	if err != nil {
 		return nil, err
 	}
@@ -75,8 +78,24 @@ func (j *JWTService) ValidateToken(tokenStr string) (bool, error) {
 	return false, fmt.Errorf("invalid token")
 }
 
+// RefreshToken 用刷新令牌签发新的令牌对，保留其中的工作空间
 func (j *JWTService) RefreshToken(tokenStr string) (*domain.Token, *domain.Token, error) {
-	// Validate existing token
+	subject, err := j.ParseRefreshToken(tokenStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	accessToken, err := j.GenerateAccessToken(subject)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := j.GenerateRefreshToken(subject)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accessToken, refreshToken, nil
+}
+
+func (j *JWTService) ParseRefreshToken(tokenStr string) (*domain.TokenSubject, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims,
 		func(token *jwt.Token) (any, error) {
@@ -84,31 +103,30 @@ func (j *JWTService) RefreshToken(tokenStr string) (*domain.Token, *domain.Token
 		})
 
 	if err != nil || !token.Valid {
-		return nil, nil, errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
 	if claims.Subject != TypeRefresh {
-		return nil, nil, errors.New("invalid token type")
-	}
-	accessToken, err := j.GenerateAccessToken(claims.UserID, claims.Username)
-	if err != nil {
-		return nil, nil, err
+		return nil, errors.New("invalid token type")
 	}
-	refreshToken, err := j.GenerateRefreshToken(claims.UserID, claims.Username)
-	if err != nil {
-		return nil, nil, err
-	}
-	return accessToken, refreshToken, nil
+	return &domain.TokenSubject{
+		UserID:        claims.UserID,
+		Username:      claims.Username,
+		WorkspaceID:   claims.WorkspaceID,
+		WorkspaceRole: domain.WorkspaceRole(claims.WorkspaceRole),
+	}, nil
 }
 
-func (j *JWTService) generate(userID, username, tokenType string) (*string, *time.Time, error) {
+func (j *JWTService) generate(subject *domain.TokenSubject, tokenType string) (*string, *time.Time, error) {
 	expiration := j.accessExpiration
 	if tokenType == TypeRefresh {
 		expiration = j.refreshExpiration
 	}
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:        subject.UserID,
+		Username:      subject.Username,
+		WorkspaceID:   subject.WorkspaceID,
+		WorkspaceRole: string(subject.WorkspaceRole),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
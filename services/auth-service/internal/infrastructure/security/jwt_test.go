@@ -4,19 +4,23 @@ import (
 	"testing"
 	"time"
 
+	"free-chat/services/auth-service/internal/domain"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var testSubject = &domain.TokenSubject{UserID: "user-1", Username: "testuser"}
+
 func TestJWTAccessTokenHasShorterExpiryThanRefresh(t *testing.T) {
 	// Setup: access 1h, refresh 72h
 	svc := NewJWTService("test-secret", 1, 72)
 
-	accessToken, err := svc.GenerateAccessToken("user-1", "testuser")
+	accessToken, err := svc.GenerateAccessToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateAccessToken failed: %v", err)
 	}
 
-	refreshToken, err := svc.GenerateRefreshToken("user-1", "testuser")
+	refreshToken, err := svc.GenerateRefreshToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateRefreshToken failed: %v", err)
 	}
@@ -46,7 +50,7 @@ func TestJWTAccessTokenHasShorterExpiryThanRefresh(t *testing.T) {
 func TestJWTGenerateAccessTokenClaims(t *testing.T) {
 	svc := NewJWTService("test-secret", 1, 72)
 
-	token, err := svc.GenerateAccessToken("user-1", "testuser")
+	token, err := svc.GenerateAccessToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateAccessToken failed: %v", err)
 	}
@@ -78,7 +82,7 @@ func TestJWTGenerateAccessTokenClaims(t *testing.T) {
 func TestJWTGenerateRefreshTokenClaims(t *testing.T) {
 	svc := NewJWTService("test-secret", 1, 72)
 
-	token, err := svc.GenerateRefreshToken("user-1", "testuser")
+	token, err := svc.GenerateRefreshToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateRefreshToken failed: %v", err)
 	}
@@ -110,7 +114,7 @@ func TestJWTGenerateRefreshTokenClaims(t *testing.T) {
 func TestJWTValidateTokenValid(t *testing.T) {
 	svc := NewJWTService("test-secret", 1, 72)
 
-	token, err := svc.GenerateAccessToken("user-1", "testuser")
+	token, err := svc.GenerateAccessToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateAccessToken failed: %v", err)
 	}
@@ -139,7 +143,7 @@ func TestJWTValidateTokenInvalid(t *testing.T) {
 func TestJWTRefreshTokenSuccess(t *testing.T) {
 	svc := NewJWTService("test-secret", 1, 72)
 
-	refreshToken, err := svc.GenerateRefreshToken("user-1", "testuser")
+	refreshToken, err := svc.GenerateRefreshToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateRefreshToken failed: %v", err)
 	}
@@ -174,7 +178,7 @@ func TestJWTRefreshTokenInvalid(t *testing.T) {
 func TestJWTRefreshTokenWithAccessTokenFails(t *testing.T) {
 	svc := NewJWTService("test-secret", 1, 72)
 
-	accessToken, err := svc.GenerateAccessToken("user-1", "testuser")
+	accessToken, err := svc.GenerateAccessToken(testSubject)
 	if err != nil {
 		t.Fatalf("GenerateAccessToken failed: %v", err)
 	}
@@ -185,3 +189,31 @@ func TestJWTRefreshTokenWithAccessTokenFails(t *testing.T) {
 		t.Error("should return error when using access token for refresh")
 	}
 }
+
+func TestJWTRefreshTokenKeepsWorkspace(t *testing.T) {
+	svc := NewJWTService("test-secret", 1, 72)
+
+	refreshToken, err := svc.GenerateRefreshToken(&domain.TokenSubject{
+		UserID:        "user-1",
+		Username:      "testuser",
+		WorkspaceID:   "ws-1",
+		WorkspaceRole: domain.WorkspaceAdmin,
+	})
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken failed: %v", err)
+	}
+
+	accessToken, _, err := svc.RefreshToken(refreshToken.Token)
+	if err != nil {
+		t.Fatalf("RefreshToken failed: %v", err)
+	}
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(accessToken.Token, claims, func(token *jwt.Token) (any, error) {
+		return []byte("test-secret"), nil
+	}); err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if claims.WorkspaceID != "ws-1" || claims.WorkspaceRole != string(domain.WorkspaceAdmin) {
+		t.Errorf("expected workspace ws-1/admin, got %q/%q", claims.WorkspaceID, claims.WorkspaceRole)
+	}
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	authpb "free-chat/pkg/proto/auth"
+	"free-chat/services/auth-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *AuthHandler) GetUserProfile(ctx context.Context, req *authpb.GetUserProfileRequest) (*authpb.GetUserProfileResponse, error) {
+	profile, err := s.authSvc.GetProfile(req.UserId)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &authpb.GetUserProfileResponse{Profile: ToUserProfileRPC(profile)}, nil
+}
+
+func (s *AuthHandler) DeleteUser(ctx context.Context, req *authpb.DeleteUserRequest) (*authpb.DeleteUserResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	deleted, err := s.authSvc.DeleteUser(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &authpb.DeleteUserResponse{Deleted: deleted}, nil
+}
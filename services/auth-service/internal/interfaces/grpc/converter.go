@@ -3,6 +3,7 @@ package grpc
 import (
 	authpb "free-chat/pkg/proto/auth"
 	"free-chat/services/auth-service/internal/application/dto"
+	"free-chat/services/auth-service/internal/domain"
 )
 
 // ToLoginDTO converts gRPC LoginRequest to application layer LoginReq DTO
@@ -11,8 +12,9 @@ func ToLoginDTO(req *authpb.LoginRequest) *dto.LoginReq {
 		return nil
 	}
 	return &dto.LoginReq{
-		Username: req.Username,
-		Password: req.Password,
+		Username:    req.Username,
+		Password:    req.Password,
+		WorkspaceID: req.WorkspaceId,
 	}
 }
 
@@ -23,8 +25,9 @@ func ToLoginRPC(req *dto.LoginReq) *authpb.LoginRequest {
 		return nil
 	}
 	return &authpb.LoginRequest{
-		Username: req.Username,
-		Password: req.Password,
+		Username:    req.Username,
+		Password:    req.Password,
+		WorkspaceId: req.WorkspaceID,
 	}
 }
 
@@ -37,11 +40,14 @@ func ToLoginResponseRPC(resp *dto.LoginResp) *authpb.LoginResponse {
 		}
 	}
 	return &authpb.LoginResponse{
-		Success:      true,
-		Message:      "Login successful",
-		AccessToken:  resp.AccessToken,
-		RefreshToken: resp.RefreshToken,
-		ExpiresAt:    resp.ExpiresAt,
+		Success:       true,
+		Message:       "Login successful",
+		AccessToken:   resp.AccessToken,
+		RefreshToken:  resp.RefreshToken,
+		ExpiresAt:     resp.ExpiresAt,
+		UserId:        resp.UserID,
+		WorkspaceId:   resp.WorkspaceID,
+		WorkspaceRole: resp.WorkspaceRole,
 	}
 }
 
@@ -71,3 +77,40 @@ func ToRegisterResponseRPC(resp *dto.RegisterResp) *authpb.RegisterResponse {
 		UserId:  resp.UserID,
 	}
 }
+
+// ToWorkspaceRPC converts a domain Membership to gRPC Workspace
+func ToWorkspaceRPC(m *domain.Membership) *authpb.Workspace {
+	return &authpb.Workspace{
+		WorkspaceId: m.Workspace.ID,
+		Name:        m.Workspace.Name,
+		CreatedBy:   m.Workspace.CreatedBy,
+		Role:        string(m.Role),
+		CreatedAt:   m.Workspace.CreatedAt.Unix(),
+	}
+}
+
+// ToWorkspaceMemberRPC converts a domain Member to gRPC WorkspaceMember
+func ToWorkspaceMemberRPC(m *domain.Member) *authpb.WorkspaceMember {
+	return &authpb.WorkspaceMember{
+		WorkspaceId: m.WorkspaceID,
+		UserId:      m.UserID,
+		Username:    m.Username,
+		Role:        string(m.Role),
+		JoinedAt:    m.CreatedAt.Unix(),
+	}
+}
+
+// ToUserProfileRPC converts a domain Profile to gRPC UserProfile
+func ToUserProfileRPC(p *domain.Profile) *authpb.UserProfile {
+	profile := &authpb.UserProfile{
+		UserId:     p.User.ID,
+		Username:   p.User.Username,
+		Email:      p.User.Email,
+		CreatedAt:  p.User.CreatedAt.Unix(),
+		Workspaces: make([]*authpb.Workspace, len(p.Memberships)),
+	}
+	for i, m := range p.Memberships {
+		profile.Workspaces[i] = ToWorkspaceRPC(m)
+	}
+	return profile
+}
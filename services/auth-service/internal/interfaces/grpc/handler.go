@@ -11,11 +11,12 @@ import (
 
 type AuthHandler struct {
 	authpb.UnimplementedAuthServiceServer
-	authSvc *application.AuthService
+	authSvc      *application.AuthService
+	workspaceSvc *application.WorkspaceService
 }
 
-func NewAuthHandler(svc *application.AuthService) *AuthHandler {
-	return &AuthHandler{authSvc: svc}
+func NewAuthHandler(svc *application.AuthService, workspaceSvc *application.WorkspaceService) *AuthHandler {
+	return &AuthHandler{authSvc: svc, workspaceSvc: workspaceSvc}
 }
 
 func (s *AuthHandler) Register(ctx context.Context, req *authpb.RegisterRequest) (*authpb.RegisterResponse, error) {
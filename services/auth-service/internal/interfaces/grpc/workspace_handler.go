@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	authpb "free-chat/pkg/proto/auth"
+	"free-chat/services/auth-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *AuthHandler) CreateWorkspace(ctx context.Context, req *authpb.CreateWorkspaceRequest) (*authpb.CreateWorkspaceResponse, error) {
+	membership, err := s.workspaceSvc.CreateWorkspace(req.UserId, req.Name)
+	if err != nil {
+		return nil, workspaceStatus(err)
+	}
+	return &authpb.CreateWorkspaceResponse{Workspace: ToWorkspaceRPC(membership)}, nil
+}
+
+func (s *AuthHandler) ListWorkspaces(ctx context.Context, req *authpb.ListWorkspacesRequest) (*authpb.ListWorkspacesResponse, error) {
+	memberships, err := s.workspaceSvc.ListWorkspaces(req.UserId)
+	if err != nil {
+		return nil, workspaceStatus(err)
+	}
+	resp := &authpb.ListWorkspacesResponse{Workspaces: make([]*authpb.Workspace, len(memberships))}
+	for i, m := range memberships {
+		resp.Workspaces[i] = ToWorkspaceRPC(m)
+	}
+	return resp, nil
+}
+
+func (s *AuthHandler) ListWorkspaceMembers(ctx context.Context, req *authpb.ListWorkspaceMembersRequest) (*authpb.ListWorkspaceMembersResponse, error) {
+	members, err := s.workspaceSvc.ListMembers(req.UserId, req.WorkspaceId)
+	if err != nil {
+		return nil, workspaceStatus(err)
+	}
+	resp := &authpb.ListWorkspaceMembersResponse{Members: make([]*authpb.WorkspaceMember, len(members))}
+	for i, m := range members {
+		resp.Members[i] = ToWorkspaceMemberRPC(m)
+	}
+	return resp, nil
+}
+
+func (s *AuthHandler) AddWorkspaceMember(ctx context.Context, req *authpb.AddWorkspaceMemberRequest) (*authpb.AddWorkspaceMemberResponse, error) {
+	member, err := s.workspaceSvc.AddMember(req.UserId, req.WorkspaceId, req.Username, req.Role)
+	if err != nil {
+		return nil, workspaceStatus(err)
+	}
+	return &authpb.AddWorkspaceMemberResponse{Member: ToWorkspaceMemberRPC(member)}, nil
+}
+
+func (s *AuthHandler) RemoveWorkspaceMember(ctx context.Context, req *authpb.RemoveWorkspaceMemberRequest) (*authpb.RemoveWorkspaceMemberResponse, error) {
+	if err := s.workspaceSvc.RemoveMember(req.UserId, req.WorkspaceId, req.MemberId); err != nil {
+		return nil, workspaceStatus(err)
+	}
+	return &authpb.RemoveWorkspaceMemberResponse{Success: true}, nil
+}
+
+func (s *AuthHandler) SwitchWorkspace(ctx context.Context, req *authpb.SwitchWorkspaceRequest) (*authpb.LoginResponse, error) {
+	resp, err := s.workspaceSvc.SwitchWorkspace(req.UserId, req.WorkspaceId)
+	if err != nil {
+		return nil, workspaceStatus(err)
+	}
+	return ToLoginResponseRPC(resp), nil
+}
+
+// workspaceStatus 把工作空间相关的领域错误转换为 gRPC 状态码；
+// 非成员与空间不存在一样返回 NotFound，不泄露空间是否存在
+func workspaceStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrWorkspaceNotFound), errors.Is(err, domain.ErrNotWorkspaceMember),
+		errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrWorkspacePermission), errors.Is(err, domain.ErrWorkspaceOwner):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrInvalidWorkspace), errors.Is(err, domain.ErrInvalidMemberRole):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrUserDisabled):
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
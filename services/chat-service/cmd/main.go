@@ -1,23 +1,32 @@
 package main
 
 import (
+	stdcontext "context"
 	"fmt"
 	"free-chat/config"
 	chatpb "free-chat/pkg/proto/chat"
 	"free-chat/pkg/registry"
 	"free-chat/services/chat-service/internal/application"
+	"free-chat/services/chat-service/internal/domain"
 	"free-chat/services/chat-service/internal/infrastructure/adapter"
 	"free-chat/services/chat-service/internal/infrastructure/context"
+	"free-chat/services/chat-service/internal/infrastructure/encryption"
+	"free-chat/services/chat-service/internal/infrastructure/export"
+	"free-chat/services/chat-service/internal/infrastructure/importer"
+	"free-chat/services/chat-service/internal/infrastructure/moderation"
 	"free-chat/services/chat-service/internal/infrastructure/mq"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/cache"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/db"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/repository"
+	"free-chat/services/chat-service/internal/infrastructure/pii"
 	"free-chat/services/chat-service/internal/infrastructure/tokenizer"
 	handler "free-chat/services/chat-service/internal/interfaces"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -100,6 +109,17 @@ func main() {
 
 	var msgRepo *repository.MessageRepository
 	var sessionRepo *repository.SessionRepository
+	var memoryRepo *repository.MemoryRepository
+	var documentRepo *repository.DocumentRepository
+	var embeddingRepo *repository.EmbeddingRepository
+	var folderRepo *repository.FolderRepository
+	var shareRepo *repository.ShareRepository
+	var participantRepo *repository.ParticipantRepository
+	var retentionRepo *repository.RetentionRepository
+	var accountRepo *repository.AccountRepository
+	var encryptionRepo *repository.EncryptionRepository
+	var piiRepo *repository.PIIRepository
+	var moderationRepo *repository.ModerationRepository
 
 	gormDB, err := db.InitGorm(dsn)
 	if err != nil {
@@ -107,10 +127,37 @@ func main() {
 	} else {
 		msgRepo = repository.NewMessageRepository(gormDB)
 		sessionRepo = repository.NewSessionRepository(gormDB)
+		memoryRepo = repository.NewMemoryRepository(gormDB)
+		documentRepo = repository.NewDocumentRepository(gormDB)
+		embeddingRepo = repository.NewEmbeddingRepository(gormDB)
+		folderRepo = repository.NewFolderRepository(gormDB)
+		shareRepo = repository.NewShareRepository(gormDB)
+		participantRepo = repository.NewParticipantRepository(gormDB)
+		retentionRepo = repository.NewRetentionRepository(gormDB)
+		accountRepo = repository.NewAccountRepository(gormDB)
+		encryptionRepo = repository.NewEncryptionRepository(gormDB)
+		piiRepo = repository.NewPIIRepository(gormDB)
+		moderationRepo = repository.NewModerationRepository(gormDB)
+	}
+
+	// 静态加密：数据密钥保存在 PostgreSQL，由主密钥包装。需在任何读写消息与会话之前启用；
+	// 配置了主密钥而数据库不可用时拒绝启动，避免缓存中写入明文
+	var keyRing *encryption.KeyRing
+	if cfg.Chat.Encryption.Keyfile != "" {
+		if gormDB == nil {
+			log.Fatalf("Encryption is configured but PostgreSQL is unavailable: %v", err)
+		}
+		master, err := encryption.LoadKeyfile(cfg.Chat.Encryption.Keyfile)
+		if err != nil {
+			log.Fatalf("Failed to load encryption keyfile: %v", err)
+		}
+		keyRing = encryption.NewKeyRing(repository.NewKeyRepository(gormDB), master, cfg.Chat.Encryption.SearchIndex)
+		model.UseCipher(keyRing)
+		log.Printf("Encryption at rest enabled with master key %s", master.KeyID())
 	}
 
 	// Initialize RocketMQ Consumer
-	mqConsumer, err := mq.InitConsumer(cfg, msgRepo, sessionRepo)
+	mqConsumer, err := mq.InitConsumer(cfg, msgRepo, sessionRepo, accountRepo)
 	if err != nil {
 		log.Printf("Failed to initialize RocketMQ consumer: %v", err)
 	}
@@ -128,7 +175,133 @@ func main() {
 	llmClient := handler.NewLLMClient()
 
 	// Initialize Application
-	chatApp := application.NewChatService(chatRepoAdapter, modelRepoAdapter)
+	var recaller domain.MessageRecaller
+	if cfg.Chat.RecallTopK > 0 {
+		recaller = context.NewSessionRecaller(chatRepoAdapter, cfg.Chat.RecallTopK)
+	}
+	var embedder domain.Embedder
+	switch cfg.Chat.Embedder {
+	case "":
+	case "hashing":
+		embedder = context.NewHashingEmbedder(cfg.Chat.EmbeddingDim)
+	case "grpc":
+		if cfg.Chat.EmbeddingAddr == "" {
+			log.Printf("[WARN] embedder grpc needs embedding_addr, semantic search disabled")
+		} else {
+			embedder = handler.NewEmbeddingClient(cfg.Chat.EmbeddingAddr, "")
+		}
+	default:
+		log.Printf("[WARN] unknown embedder %q, semantic search disabled", cfg.Chat.Embedder)
+	}
+	// 语义检索的向量存放在 PostgreSQL，不可用时关闭
+	var searcher domain.ConversationSearcher
+	if embedder != nil && embeddingRepo != nil {
+		searcher = context.NewConversationSearcher(embeddingRepo, chatRepoAdapter, embedder)
+	}
+	// 所有会话级操作共用同一授权策略；协作者存放在 PostgreSQL，不可用时只有所有者和管理员
+	var participants domain.ParticipantRepository
+	if participantRepo != nil {
+		participants = participantRepo
+	}
+	policy := application.NewSessionPolicy(cfg.Chat.AdminUserIDs, participants)
+	chatApp := application.NewChatService(chatRepoAdapter, modelRepoAdapter, recaller, searcher, policy)
+	// 长期记忆依赖 PostgreSQL，不可用时关闭
+	var memoryApp *application.MemoryService
+	if memoryRepo != nil {
+		memoryApp = application.NewMemoryService(memoryRepo, modelRepoAdapter, llmClient, cfg.LLM.Name)
+	}
+	// 文档检索同样依赖 PostgreSQL；embedder 为空时仅使用 BM25
+	var documentApp *application.DocumentService
+	if documentRepo != nil {
+		documentApp = application.NewDocumentService(documentRepo, chatRepoAdapter,
+			context.NewDocumentChunker(0, 0), context.NewDocumentRetriever(embedder), policy)
+	}
+	// 会话整理（重命名、置顶、归档、标签、文件夹）直接写库
+	var sessionApp *application.SessionService
+	if folderRepo != nil {
+		sessionApp = application.NewSessionService(chatRepoAdapter, folderRepo, policy)
+	}
+	// 分享的消息快照存放在 PostgreSQL
+	var shareApp *application.ShareService
+	if shareRepo != nil {
+		shareApp = application.NewShareService(chatRepoAdapter, shareRepo, policy)
+	}
+	// 多人会话的实时事件经 Redis 在实例间转发
+	var collabApp *application.CollaborationService
+	if participantRepo != nil {
+		var events domain.SessionEventBus
+		if redisCache != nil {
+			events = cache.NewSessionEventBus(redisClient)
+		}
+		collabApp = application.NewCollaborationService(chatRepoAdapter, participantRepo, policy, events)
+	}
+	// 敏感信息检测：审计事件写入 PostgreSQL，不可用时只写日志；占位符表存放在 Redis，不可用时 tokenize 按 mask 处理
+	var piiApp *application.PIIService
+	if cfg.Chat.PII.Enabled {
+		opts := application.PIIOptions{Actions: make(map[domain.PIIKind]domain.PIIAction)}
+		if cfg.Chat.PII.DefaultAction != "" {
+			if opts.Default, err = domain.ParsePIIAction(cfg.Chat.PII.DefaultAction); err != nil {
+				log.Fatalf("Invalid pii default_action: %v", err)
+			}
+		}
+		for kind, action := range cfg.Chat.PII.Actions {
+			if !slices.Contains(domain.PIIKinds, domain.PIIKind(kind)) {
+				log.Fatalf("Invalid pii actions: unknown kind %q", kind)
+			}
+			if opts.Actions[domain.PIIKind(kind)], err = domain.ParsePIIAction(action); err != nil {
+				log.Fatalf("Invalid pii actions: %v", err)
+			}
+		}
+		var vault domain.PIIVault
+		if redisCache != nil {
+			vault = redisCache
+		}
+		var audits domain.PIIRepository
+		if piiRepo != nil {
+			audits = piiRepo
+		}
+		piiApp = application.NewPIIService(pii.NewScanner(), audits, vault, opts)
+	}
+	// 内容审核：规则配置错误时拒绝启动；复核队列存放在 PostgreSQL，不可用时命中只写日志
+	var moderationApp *application.ModerationService
+	if mc := cfg.Chat.Moderation; mc.Enabled {
+		specs := make([]moderation.RuleSpec, len(mc.Rules))
+		for i, r := range mc.Rules {
+			specs[i] = moderation.RuleSpec{Name: r.Name, Category: r.Category, Action: r.Action,
+				Stages: r.Stages, Keywords: r.Keywords, Patterns: r.Patterns}
+		}
+		rules, err := moderation.NewRuleSet(specs)
+		if err != nil {
+			log.Fatalf("Invalid moderation rules: %v", err)
+		}
+		var classifier domain.ModerationClassifier
+		if mc.Classifier.URL != "" {
+			action, err := moderation.ParseAction(mc.Classifier.Action)
+			if err != nil {
+				log.Fatalf("Invalid moderation classifier: %v", err)
+			}
+			classifier = moderation.NewHTTPClassifier(mc.Classifier.URL, mc.Classifier.Timeout, action,
+				mc.Classifier.Threshold, mc.Classifier.Thresholds)
+		}
+		var flags domain.ModerationRepository
+		if moderationRepo != nil {
+			flags = moderationRepo
+		}
+		moderationApp = application.NewModerationService(rules, classifier, flags, application.ModerationOptions{
+			Window:        mc.Window,
+			ClassifyEvery: mc.Classifier.ClassifyEvery,
+			Reviewers:     cfg.Chat.AdminUserIDs,
+		})
+	}
+	// 导出需要逐批读库，开启 redact_exports 时导出前遮盖敏感信息
+	var exportApp *application.ExportService
+	if msgRepo != nil {
+		var exporter domain.SessionExporter = export.NewExporter()
+		if piiApp != nil && cfg.Chat.PII.RedactExports {
+			exporter = piiApp.RedactExporter(exporter)
+		}
+		exportApp = application.NewExportService(chatRepoAdapter, exporter, policy)
+	}
 
 	// Initialize Tokenizer and ContextBuilder
 	modelName := cfg.LLM.Name
@@ -140,13 +313,96 @@ func main() {
 		log.Printf("[WARN] tokenizer init failed, fallback to approximate: %v", err)
 		tk = nil
 	}
-	compressor := context.NewDefaultCompressor()
+	var compressor context.Compressor
+	switch cfg.Chat.Compressor {
+	case "textrank":
+		compressor = context.NewTextRankCompressor(tk)
+	default:
+		compressor = context.NewDefaultCompressor(tk)
+	}
 	ctxBuilder := context.NewDefaultBuilder(compressor, tk)
+	if strategy := cfg.Chat.ContextStrategy; strategy != "" {
+		if tk == nil {
+			log.Printf("[WARN] context strategy %s needs a tokenizer, fallback to default builder", strategy)
+		} else if sb, err := context.NewStrategyBuilder(strategy, tk); err != nil {
+			log.Printf("[WARN] %v, fallback to default builder", err)
+		} else {
+			ctxBuilder = sb
+		}
+	}
+
+	// 导入同步批量写库；tokenizer 不可用时不预先计数
+	var importApp *application.ImportService
+	if msgRepo != nil {
+		var counter domain.TokenCounter
+		if tk != nil {
+			counter = tk
+		}
+		importApp = application.NewImportService(chatRepoAdapter, importer.NewDecoder(), counter)
+	}
+
+	// 保留策略与定时清理作用于 PostgreSQL 中的数据，interval 为 0 时只提供策略管理与试运行报告
+	var retentionApp *application.RetentionService
+	stopRetention := func() {}
+	if retentionRepo != nil {
+		retentionApp = application.NewRetentionService(retentionRepo, chatRepoAdapter, recaller, application.RetentionOptions{
+			MessageDays:      cfg.Chat.Retention.MessageDays,
+			PurgeDeletedDays: cfg.Chat.Retention.PurgeDeletedDays,
+			BatchSize:        cfg.Chat.Retention.BatchSize,
+		})
+		if cfg.Chat.Retention.Interval > 0 {
+			var retentionCtx stdcontext.Context
+			retentionCtx, stopRetention = stdcontext.WithCancel(stdcontext.Background())
+			go retentionApp.Start(retentionCtx, cfg.Chat.Retention.Interval)
+		}
+	}
+
+	// 账户导出与删除的任务状态存放在 PostgreSQL，用户资料与账户本身经服务发现调用 auth-service
+	var accountApp *application.AccountService
+	stopAccount := func() {}
+	if accountRepo != nil && svcMgr != nil {
+		authClient := handler.NewAuthClient(svcMgr, cfg.Auth.ServerName)
+		defer authClient.Close()
+		fileStore, err := export.NewFileStore(cfg.Chat.Account.ExportDir)
+		if err != nil {
+			log.Fatalf("Failed to open account export dir: %v", err)
+		}
+		var keys domain.KeyManager
+		var exportStore domain.ExportStore = fileStore
+		if keyRing != nil {
+			// 导出压缩包用用户个人范围的密钥加密，账户删除粉碎密钥后同样无法解密
+			keys = keyRing
+			exportStore = encryption.NewSealedExportStore(fileStore, keyRing)
+		}
+		accountApp = application.NewAccountService(accountRepo, chatRepoAdapter, authClient, export.NewExporter(), exportStore, recaller, keys,
+			application.AccountOptions{DrainPeriod: cfg.Chat.Account.ErasureDrain})
+		interval := cfg.Chat.Account.Interval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		var accountCtx stdcontext.Context
+		accountCtx, stopAccount = stdcontext.WithCancel(stdcontext.Background())
+		go accountApp.Start(accountCtx, interval)
+	}
+
+	// 后台重新加密明文与旧版本密钥的密文，轮换数据密钥或主密钥后旧数据逐步迁移
+	var encryptionApp *application.EncryptionService
+	stopEncryption := func() {}
+	if keyRing != nil {
+		encryptionApp = application.NewEncryptionService(keyRing, encryptionRepo)
+		var encryptionCtx stdcontext.Context
+		encryptionCtx, stopEncryption = stdcontext.WithCancel(stdcontext.Background())
+		go encryptionApp.Start(encryptionCtx, cfg.Chat.Encryption.ReencryptInterval)
+	}
 
 	// Initialize Handler
-	chatHandler := handler.NewChatHandler(chatApp, llmClient, ctxBuilder)
+	chatHandler := handler.NewChatHandler(chatApp, memoryApp, documentApp, sessionApp, exportApp, importApp, shareApp, collabApp, retentionApp, accountApp, encryptionApp, piiApp, moderationApp, llmClient, ctxBuilder)
 
-	grpcServer := grpc.NewServer()
+	// 工作空间随请求 metadata 传入，每个 RPC 都按租户隔离
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(handler.TenantUnaryInterceptor),
+		grpc.StreamInterceptor(handler.TenantStreamInterceptor),
+	)
 	chatpb.RegisterChatServiceServer(grpcServer, chatHandler)
 	reflection.Register(grpcServer)
 
@@ -181,6 +437,9 @@ func main() {
 		svcMgr.Stop()
 	}
 
+	stopRetention()
+	stopAccount()
+	stopEncryption()
 	grpcServer.GracefulStop()
 	log.Printf("`%s` Server exited", cfg.Chat.ServerName)
 }
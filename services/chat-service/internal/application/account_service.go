@@ -0,0 +1,421 @@
+package application
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// exportTTL 导出的压缩包保留的时间
+	exportTTL = 7 * 24 * time.Hour
+	// exportTimeout 生成一次导出的最长时间，超时仍未完成的导出视为失败
+	exportTimeout = 30 * time.Minute
+	// defaultErasureBatchSize 账户删除每批删除的条数
+	defaultErasureBatchSize = 500
+)
+
+// errExportTimeout 生成导出的实例在完成前退出
+var errExportTimeout = errors.New("export timed out")
+
+// AccountOptions 是账户删除的参数
+type AccountOptions struct {
+	// DrainPeriod 提交删除后等待多久再清扫一遍数据库与缓存，
+	// 应不短于访问令牌的有效期与 MQ 消息的最长重试时间
+	DrainPeriod time.Duration
+	// BatchSize 每批删除的条数，非正时使用默认值
+	BatchSize int
+}
+
+// AccountService 导出用户的全部数据，以及在各个存储中彻底删除账户
+type AccountService struct {
+	repo      domain.AccountRepository
+	chatRepo  domain.ChatRepository
+	directory domain.AccountDirectory
+	exporter  domain.SessionExporter
+	store     domain.ExportStore
+	recaller  domain.MessageRecaller
+	keys      domain.KeyManager
+	opts      AccountOptions
+	// running 本实例正在执行的删除任务，避免同一任务被并发执行
+	running sync.Map
+}
+
+// NewAccountService creates the account service. Export archives are
+// streamed into store. recaller may be nil; otherwise its per-session index
+// is dropped for erased sessions. keys may be nil when encryption at rest is
+// disabled; otherwise the user's data keys are destroyed during erasure.
+func NewAccountService(repo domain.AccountRepository, chatRepo domain.ChatRepository, directory domain.AccountDirectory,
+	exporter domain.SessionExporter, store domain.ExportStore, recaller domain.MessageRecaller, keys domain.KeyManager, opts AccountOptions) *AccountService {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultErasureBatchSize
+	}
+	return &AccountService{
+		repo:      repo,
+		chatRepo:  chatRepo,
+		directory: directory,
+		exporter:  exporter,
+		store:     store,
+		recaller:  recaller,
+		keys:      keys,
+		opts:      opts,
+	}
+}
+
+// StartExport 创建一次账户数据导出并在后台生成压缩包，用户之前的导出随之删除
+func (s *AccountService) StartExport(ctx context.Context, userID string) (*domain.AccountExport, error) {
+	erased, err := s.repo.IsErased(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if erased {
+		return nil, domain.ErrAccountErased
+	}
+	if err := s.repo.DeleteExports(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	export := &domain.AccountExport{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    domain.ExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SaveExport(ctx, export); err != nil {
+		return nil, err
+	}
+	go s.buildExport(*export)
+	return export, nil
+}
+
+// GetExport 返回用户自己的导出，已过期的导出视为不存在
+func (s *AccountService) GetExport(ctx context.Context, userID, exportID string) (*domain.AccountExport, error) {
+	export, err := s.repo.GetExport(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.UserID != userID {
+		return nil, domain.ErrExportNotFound
+	}
+	now := time.Now()
+	switch export.Status {
+	case domain.ExportPending:
+		if now.Sub(export.CreatedAt) > exportTimeout {
+			export.Status, export.Error = domain.ExportFailed, errExportTimeout.Error()
+		}
+	case domain.ExportReady:
+		if now.After(export.ExpiresAt) {
+			return nil, domain.ErrExportNotFound
+		}
+	}
+	return export, nil
+}
+
+// DownloadExport 返回已生成的压缩包，调用方负责关闭
+func (s *AccountService) DownloadExport(ctx context.Context, userID, exportID string) (*domain.AccountExport, io.ReadCloser, error) {
+	export, err := s.GetExport(ctx, userID, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != domain.ExportReady {
+		return nil, nil, domain.ErrExportNotReady
+	}
+	archive, err := s.store.Open(ctx, userID, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, archive, nil
+}
+
+// buildExport 把压缩包边生成边写入 ExportStore 并保存结果，不依赖发起请求的上下文
+func (s *AccountService) buildExport(export domain.AccountExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	size, err := s.saveArchive(ctx, &export)
+	export.CompletedAt = time.Now()
+	if err != nil {
+		log.Printf("[ERROR] build account export %s failed: %v", export.ID, err)
+		if err := s.store.Delete(context.Background(), export.UserID, export.ID); err != nil {
+			log.Printf("[WARN] delete account export %s failed: %v", export.ID, err)
+		}
+		export.Status, export.Error = domain.ExportFailed, err.Error()
+	} else {
+		export.Status, export.Size = domain.ExportReady, size
+		export.ExpiresAt = export.CompletedAt.Add(exportTTL)
+	}
+	if err := s.repo.CompleteExport(context.Background(), &export); err != nil {
+		log.Printf("[ERROR] save account export %s failed: %v", export.ID, err)
+	}
+}
+
+// saveArchive 返回写入的字节数
+func (s *AccountService) saveArchive(ctx context.Context, export *domain.AccountExport) (int64, error) {
+	f, err := s.store.Create(ctx, export.UserID, export.ID)
+	if err != nil {
+		return 0, err
+	}
+	w := &countingWriter{w: f}
+	if err := s.writeArchive(ctx, export.UserID, w); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return w.n, nil
+}
+
+// writeArchive 把用户资料与用户在所有工作空间中的会话（含已归档）写入 zip：
+// profile.json 以及 sessions/ 下每个会话一个 JSON 文件
+func (s *AccountService) writeArchive(ctx context.Context, userID string, out io.Writer) error {
+	profile, err := s.directory.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	w, err := zw.Create("profile.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(profile); err != nil {
+		return err
+	}
+	for after := ""; ; {
+		sessions, err := s.repo.ListUserSessions(ctx, userID, after, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			w, err := zw.Create(path.Join("sessions", domain.ExportFilename(session, domain.ExportJSON)))
+			if err != nil {
+				return err
+			}
+			if err := writeSession(ctx, s.chatRepo, s.exporter, w, session, domain.ExportJSON); err != nil {
+				return err
+			}
+		}
+		if len(sessions) < exportBatchSize {
+			break
+		}
+		after = sessions[len(sessions)-1].ID
+	}
+	return zw.Close()
+}
+
+// purgeExports 删除过期导出的记录与压缩包，以及生成中途实例退出留下的导出
+func (s *AccountService) purgeExports(ctx context.Context) {
+	now := time.Now()
+	exports, err := s.repo.DeleteExpiredExports(ctx, now, now.Add(-exportTTL))
+	if err != nil {
+		log.Printf("[ERROR] purge account exports failed: %v", err)
+		return
+	}
+	for _, export := range exports {
+		if err := s.store.Delete(ctx, export.UserID, export.ID); err != nil {
+			log.Printf("[WARN] delete account export %s failed: %v", export.ID, err)
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// EraseAccount 提交账户删除并在后台执行。重复提交返回已有的任务，任务未完成时继续执行
+func (s *AccountService) EraseAccount(ctx context.Context, userID string) (*domain.AccountErasure, error) {
+	now := time.Now()
+	erasure, err := s.repo.CreateErasure(ctx, &domain.AccountErasure{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Purged:      make(map[domain.ErasureTarget]int64),
+		RequestedAt: now,
+		DrainUntil:  now.Add(s.opts.DrainPeriod),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !erasure.Completed() {
+		task := *erasure
+		go func() {
+			if err := s.run(context.Background(), &task); err != nil {
+				log.Printf("[ERROR] account erasure %s failed, will resume: %v", task.ID, err)
+			}
+		}()
+	}
+	return erasure, nil
+}
+
+// GetErasure 返回用户的账户删除任务或完成后的确认记录
+func (s *AccountService) GetErasure(ctx context.Context, userID string) (*domain.AccountErasure, error) {
+	erasure, err := s.repo.GetErasure(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if erasure == nil {
+		return nil, domain.ErrErasureNotFound
+	}
+	return erasure, nil
+}
+
+// Start 每隔 interval 继续未完成的账户删除：中途失败或实例退出的任务，以及等待清扫的任务；
+// 同时删除过期的导出
+func (s *AccountService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.purgeExports(ctx)
+		erasures, err := s.repo.ListPendingErasures(ctx)
+		if err != nil {
+			log.Printf("[ERROR] list account erasures failed: %v", err)
+		}
+		for _, erasure := range erasures {
+			if err := s.run(ctx, erasure); err != nil {
+				log.Printf("[ERROR] account erasure %s failed, will resume: %v", erasure.ID, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run 从第一个未完成的步骤开始依次执行，每完成一步保存一次进度；
+// 清扫步骤要等到 DrainUntil 之后由 Start 执行
+func (s *AccountService) run(ctx context.Context, erasure *domain.AccountErasure) error {
+	if _, busy := s.running.LoadOrStore(erasure.ID, true); busy {
+		return nil
+	}
+	defer s.running.Delete(erasure.ID)
+
+	for {
+		step, ok := erasure.NextStep()
+		if !ok || step == domain.ErasureDrain && time.Now().Before(erasure.DrainUntil) {
+			return nil
+		}
+		if err := s.runStep(ctx, erasure, step); err != nil {
+			erasure.LastError = err.Error()
+			if saveErr := s.repo.SaveErasure(ctx, erasure); saveErr != nil {
+				log.Printf("[ERROR] save account erasure %s failed: %v", erasure.ID, saveErr)
+			}
+			return err
+		}
+		erasure.Done = append(erasure.Done, step)
+		erasure.LastError = ""
+		if _, more := erasure.NextStep(); !more {
+			erasure.CompletedAt = time.Now()
+		}
+		if err := s.repo.SaveErasure(ctx, erasure); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *AccountService) runStep(ctx context.Context, erasure *domain.AccountErasure, step domain.ErasureStep) error {
+	switch step {
+	case domain.ErasureAuth:
+		return s.directory.DeleteUser(ctx, erasure.UserID)
+	case domain.ErasureKeys:
+		return s.shredKeys(ctx, erasure.UserID)
+	case domain.ErasureDatabase:
+		return s.eraseData(ctx, erasure)
+	case domain.ErasureCache:
+		return s.chatRepo.EvictUserSessions(ctx, erasure.UserID)
+	case domain.ErasureDrain:
+		// MQ 消费者丢弃已删除用户的事件；这里清理提交删除前已落库的在途事件与仍在有效期内的令牌写入的数据
+		if err := s.eraseData(ctx, erasure); err != nil {
+			return err
+		}
+		if err := s.chatRepo.EvictUserSessions(ctx, erasure.UserID); err != nil {
+			return err
+		}
+		// 在途写入可能又为用户创建了数据密钥
+		return s.shredKeys(ctx, erasure.UserID)
+	}
+	return nil
+}
+
+// shredKeys 销毁用户个人范围的数据密钥；工作空间中的数据使用空间的密钥，由上面的删除步骤处理
+func (s *AccountService) shredKeys(ctx context.Context, userID string) error {
+	if s.keys == nil {
+		return nil
+	}
+	_, err := s.keys.Shred(ctx, domain.KeyScope("", userID))
+	return err
+}
+
+// eraseData 按 ErasureTargets 的顺序分批删除用户数据，同步清理缓存与召回索引，并累计删除条数
+func (s *AccountService) eraseData(ctx context.Context, erasure *domain.AccountErasure) error {
+	for _, target := range domain.ErasureTargets {
+		for {
+			var n int
+			switch target {
+			case domain.EraseMessages:
+				messages, err := s.repo.EraseMessages(ctx, erasure.UserID, s.opts.BatchSize)
+				if err != nil {
+					return err
+				}
+				if err := s.chatRepo.EvictMessages(ctx, messages); err != nil {
+					log.Printf("[WARN] evict erased messages failed: %v", err)
+				}
+				n = len(messages)
+			case domain.EraseSessions:
+				sessions, err := s.repo.EraseSessions(ctx, erasure.UserID, s.opts.BatchSize)
+				if err != nil {
+					return err
+				}
+				if err := s.chatRepo.EvictSessions(ctx, sessions); err != nil {
+					log.Printf("[WARN] evict erased sessions failed: %v", err)
+				}
+				if s.recaller != nil {
+					for _, session := range sessions {
+						s.recaller.Forget(session.ID)
+					}
+				}
+				n = len(sessions)
+			default:
+				count, err := s.repo.EraseRows(ctx, erasure.UserID, target, s.opts.BatchSize)
+				if err != nil {
+					return err
+				}
+				n = int(count)
+			}
+			// 压缩包不在数据库中，随导出记录一起删除
+			if target == domain.EraseExports {
+				if err := s.store.DeleteUser(ctx, erasure.UserID); err != nil {
+					return err
+				}
+			}
+			erasure.Purged[target] += int64(n)
+			if n < s.opts.BatchSize {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package application
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// fakeAccounts 按批次删除预设的消息、会话与其他数据，并记录保存的删除进度
+type fakeAccounts struct {
+	domain.AccountRepository
+	messages []*domain.Message
+	sessions []*domain.Session
+	rows     map[domain.ErasureTarget]int64
+	saved    []domain.AccountErasure
+	exports  map[string]*domain.AccountExport
+}
+
+func (f *fakeAccounts) CompleteExport(ctx context.Context, export *domain.AccountExport) error {
+	f.exports[export.ID] = export
+	return nil
+}
+
+func (f *fakeAccounts) GetExport(ctx context.Context, exportID string) (*domain.AccountExport, error) {
+	if export, ok := f.exports[exportID]; ok {
+		return export, nil
+	}
+	return nil, domain.ErrExportNotFound
+}
+
+func (f *fakeAccounts) ListUserSessions(ctx context.Context, userID, afterID string, limit int) ([]*domain.Session, error) {
+	return nil, nil
+}
+
+func (f *fakeAccounts) DeleteExpiredExports(ctx context.Context, now, createdBefore time.Time) ([]*domain.AccountExport, error) {
+	var expired []*domain.AccountExport
+	for id, export := range f.exports {
+		if export.ExpiresAt.Before(now) {
+			expired = append(expired, export)
+			delete(f.exports, id)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeAccounts) SaveErasure(ctx context.Context, erasure *domain.AccountErasure) error {
+	f.saved = append(f.saved, *erasure)
+	return nil
+}
+
+func (f *fakeAccounts) EraseMessages(ctx context.Context, userID string, limit int) ([]*domain.Message, error) {
+	batch := f.messages[:min(limit, len(f.messages))]
+	f.messages = f.messages[len(batch):]
+	return batch, nil
+}
+
+func (f *fakeAccounts) EraseSessions(ctx context.Context, userID string, limit int) ([]*domain.Session, error) {
+	batch := f.sessions[:min(limit, len(f.sessions))]
+	f.sessions = f.sessions[len(batch):]
+	return batch, nil
+}
+
+func (f *fakeAccounts) EraseRows(ctx context.Context, userID string, target domain.ErasureTarget, limit int) (int64, error) {
+	n := min(f.rows[target], int64(limit))
+	f.rows[target] -= n
+	return n, nil
+}
+
+// fakeDirectory 的 DeleteUser 在 fail 次之后才成功
+type fakeDirectory struct {
+	fail    int
+	deleted int
+}
+
+func (f *fakeDirectory) GetProfile(ctx context.Context, userID string) (*domain.AccountProfile, error) {
+	return &domain.AccountProfile{UserID: userID}, nil
+}
+
+func (f *fakeDirectory) DeleteUser(ctx context.Context, userID string) error {
+	if f.fail > 0 {
+		f.fail--
+		return errors.New("auth service unavailable")
+	}
+	f.deleted++
+	return nil
+}
+
+type fakeAccountCache struct {
+	fakeEvicter
+	sessions  int
+	userLists int
+}
+
+func (f *fakeAccountCache) EvictSessions(ctx context.Context, sessions []*domain.Session) error {
+	f.sessions += len(sessions)
+	return nil
+}
+
+func (f *fakeAccountCache) EvictUserSessions(ctx context.Context, userID string) error {
+	f.userLists++
+	return nil
+}
+
+// fakeExportStore 在内存中保存压缩包，键为 "<userID>/<exportID>"
+type fakeExportStore struct {
+	files       map[string][]byte
+	deletedUser []string
+}
+
+type fakeExportFile struct {
+	bytes.Buffer
+	store *fakeExportStore
+	key   string
+}
+
+func (f *fakeExportFile) Close() error {
+	f.store.files[f.key] = f.Bytes()
+	return nil
+}
+
+func (f *fakeExportStore) Create(ctx context.Context, userID, exportID string) (io.WriteCloser, error) {
+	return &fakeExportFile{store: f, key: userID + "/" + exportID}, nil
+}
+
+func (f *fakeExportStore) Open(ctx context.Context, userID, exportID string) (io.ReadCloser, error) {
+	data, ok := f.files[userID+"/"+exportID]
+	if !ok {
+		return nil, domain.ErrExportNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *fakeExportStore) Delete(ctx context.Context, userID, exportID string) error {
+	delete(f.files, userID+"/"+exportID)
+	return nil
+}
+
+func (f *fakeExportStore) DeleteUser(ctx context.Context, userID string) error {
+	f.deletedUser = append(f.deletedUser, userID)
+	return nil
+}
+
+// fakeKeys 记录被销毁数据密钥的范围
+type fakeKeys struct {
+	domain.KeyManager
+	shredded []string
+}
+
+func (f *fakeKeys) Shred(ctx context.Context, scope string) (int64, error) {
+	f.shredded = append(f.shredded, scope)
+	return 1, nil
+}
+
+func TestAccountErasureResume(t *testing.T) {
+	repo := &fakeAccounts{
+		messages: []*domain.Message{{ID: "m1"}, {ID: "m2"}, {ID: "m3"}},
+		sessions: []*domain.Session{{ID: "s1"}},
+		rows:     map[domain.ErasureTarget]int64{domain.EraseFolders: 2, domain.EraseSettings: 1, domain.ErasePIIAudits: 3},
+	}
+	directory := &fakeDirectory{fail: 1}
+	chatRepo := &fakeAccountCache{}
+	keys := &fakeKeys{}
+	store := &fakeExportStore{files: map[string][]byte{}}
+	svc := NewAccountService(repo, chatRepo, directory, nil, store, nil, keys, AccountOptions{DrainPeriod: time.Hour, BatchSize: 2})
+	erasure := &domain.AccountErasure{
+		ID:          "e1",
+		UserID:      "u1",
+		Purged:      make(map[domain.ErasureTarget]int64),
+		RequestedAt: time.Now(),
+		DrainUntil:  time.Now().Add(time.Hour),
+	}
+
+	// auth-service 不可用：不执行后续步骤，记录错误等待重试
+	if err := svc.run(context.Background(), erasure); err == nil {
+		t.Fatal("expected auth step to fail")
+	}
+	if len(erasure.Done) != 0 || erasure.LastError == "" || len(repo.saved) != 1 {
+		t.Fatalf("failed step not recorded: %+v", erasure)
+	}
+
+	// 重试后执行到清扫步骤为止
+	if err := svc.run(context.Background(), erasure); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	want := []domain.ErasureStep{domain.ErasureAuth, domain.ErasureKeys, domain.ErasureDatabase, domain.ErasureCache}
+	if len(erasure.Done) != len(want) {
+		t.Fatalf("done = %v, want %v", erasure.Done, want)
+	}
+	for i, step := range want {
+		if erasure.Done[i] != step {
+			t.Fatalf("done = %v, want %v", erasure.Done, want)
+		}
+	}
+	if erasure.Status() != domain.ErasureDraining || erasure.LastError != "" {
+		t.Fatalf("status = %s, last error %q", erasure.Status(), erasure.LastError)
+	}
+	if erasure.Purged[domain.EraseMessages] != 3 || erasure.Purged[domain.EraseSessions] != 1 ||
+		erasure.Purged[domain.EraseFolders] != 2 || erasure.Purged[domain.EraseSettings] != 1 {
+		t.Fatalf("purged = %v", erasure.Purged)
+	}
+	// 敏感信息审计记录同样按用户分批删除
+	if erasure.Purged[domain.ErasePIIAudits] != 3 || repo.rows[domain.ErasePIIAudits] != 0 {
+		t.Fatalf("pii audits purged = %d, left %d", erasure.Purged[domain.ErasePIIAudits], repo.rows[domain.ErasePIIAudits])
+	}
+	if chatRepo.evicted != 3 || chatRepo.sessions != 1 || chatRepo.userLists != 1 {
+		t.Fatalf("cache evictions = %d messages, %d sessions, %d lists", chatRepo.evicted, chatRepo.sessions, chatRepo.userLists)
+	}
+
+	// 删除后才落库的在途消息由清扫步骤删除
+	repo.messages = []*domain.Message{{ID: "m4"}}
+	erasure.DrainUntil = time.Now().Add(-time.Second)
+	if err := svc.run(context.Background(), erasure); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if !erasure.Completed() || erasure.Status() != domain.ErasureCompleted {
+		t.Fatalf("erasure not completed: %+v", erasure)
+	}
+	if erasure.Purged[domain.EraseMessages] != 4 || len(repo.messages) != 0 {
+		t.Fatalf("drain did not sweep messages: %v", erasure.Purged)
+	}
+	if directory.deleted != 1 {
+		t.Fatalf("auth step ran %d times", directory.deleted)
+	}
+	// 导出的压缩包在数据库之外，删除步骤与清扫步骤各删除一次
+	if len(store.deletedUser) != 2 || store.deletedUser[0] != "u1" {
+		t.Fatalf("export files deleted for %v", store.deletedUser)
+	}
+	// 加密粉碎在删除数据前执行一次，清扫后再执行一次
+	if len(keys.shredded) != 2 || keys.shredded[0] != "user:u1" {
+		t.Fatalf("shredded = %v", keys.shredded)
+	}
+
+	// 新增步骤之前创建的任务按步骤名补做缺少的步骤
+	legacy := &domain.AccountErasure{Done: []domain.ErasureStep{domain.ErasureAuth, domain.ErasureDatabase}}
+	if step, _ := legacy.NextStep(); step != domain.ErasureKeys {
+		t.Fatalf("next step = %s, want keys", step)
+	}
+
+	// 已完成的任务不再执行
+	saved := len(repo.saved)
+	if err := svc.run(context.Background(), erasure); err != nil || len(repo.saved) != saved {
+		t.Fatalf("completed erasure ran again: %v", err)
+	}
+}
+
+func TestAccountExportStreamsToStore(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeAccounts{exports: map[string]*domain.AccountExport{}}
+	store := &fakeExportStore{files: map[string][]byte{}}
+	svc := NewAccountService(repo, &fakeAccountCache{}, &fakeDirectory{}, nil, store, nil, nil, AccountOptions{})
+
+	svc.buildExport(domain.AccountExport{ID: "x1", UserID: "u1", Status: domain.ExportPending, CreatedAt: time.Now()})
+	export, archive, err := svc.DownloadExport(ctx, "u1", "x1")
+	if err != nil {
+		t.Fatalf("DownloadExport: %v", err)
+	}
+	defer archive.Close()
+	data, err := io.ReadAll(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Status != domain.ExportReady || export.Size != int64(len(data)) {
+		t.Fatalf("export = %+v, archive has %d bytes", export, len(data))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(zr.File) != 1 || zr.File[0].Name != "profile.json" {
+		t.Fatalf("archive: %v", err)
+	}
+	if _, _, err := svc.DownloadExport(ctx, "u2", "x1"); !errors.Is(err, domain.ErrExportNotFound) {
+		t.Fatalf("other user's download err = %v", err)
+	}
+
+	// 过期后记录与压缩包一起删除
+	export.ExpiresAt = time.Now().Add(-time.Second)
+	svc.purgeExports(ctx)
+	if len(repo.exports) != 0 || len(store.files) != 0 {
+		t.Fatalf("expired export left: %d rows, %d files", len(repo.exports), len(store.files))
+	}
+}
@@ -0,0 +1,170 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// SessionRole 是请求者相对某个会话的身份
+type SessionRole string
+
+const (
+	// SessionRoleNone 与会话无关，连会话是否存在都不应知道
+	SessionRoleNone SessionRole = ""
+	// SessionRoleSharedViewer 持有该会话有效分享链接的访客，只能查看分享的内容
+	SessionRoleSharedViewer SessionRole = "shared_viewer"
+	SessionRoleOwner        SessionRole = "owner"
+	// SessionRoleEditor / SessionRoleViewer 是所有者邀请的协作者：编辑者可以查看和发言，查看者只能查看
+	SessionRoleEditor SessionRole = "editor"
+	SessionRoleViewer SessionRole = "viewer"
+	// SessionRoleAdmin 运维管理员或工作空间的 owner / admin，可以查看和删除（空间内的）任何会话，但不能以用户身份发言
+	SessionRoleAdmin SessionRole = "admin"
+)
+
+// SessionAction 是对会话的一类操作
+type SessionAction string
+
+const (
+	// ActionView 读取历史、导出
+	ActionView SessionAction = "view"
+	// ActionPost 在会话中发消息、置顶消息、上传附件、分叉
+	ActionPost SessionAction = "post"
+	// ActionManage 修改会话属性、删除、查看与撤销分享链接
+	ActionManage SessionAction = "manage"
+	// ActionShare 创建公开分享链接、邀请或移除协作者，只有所有者可以把会话开放给他人
+	ActionShare SessionAction = "share"
+)
+
+// Principal 是发起请求的一方：登录用户，或只持有分享令牌的匿名访客
+type Principal struct {
+	UserID string
+	// Tenant 是登录用户当前所在的工作空间
+	Tenant domain.Tenant
+	// Share 通过分享链接访问时为对应的分享
+	Share *domain.Share
+}
+
+// UserPrincipal 返回登录用户在 ctx 所在工作空间中的 Principal
+func UserPrincipal(ctx context.Context, userID string) Principal {
+	return Principal{UserID: userID, Tenant: domain.TenantFromContext(ctx)}
+}
+
+// SessionPolicy 决定请求者能否对会话执行某个操作。
+// 所有会话级操作都经由它判断：与会话无关的请求者得到 ErrSessionNotFound，
+// 有身份但权限不足时得到 ErrPermissionDenied，两者不会混用。
+type SessionPolicy interface {
+	// RoleOf 返回 principal 在会话中的身份
+	RoleOf(ctx context.Context, p Principal, session *domain.Session) (SessionRole, error)
+	// Authorize session 为空（不存在）时返回 ErrSessionNotFound
+	Authorize(ctx context.Context, p Principal, session *domain.Session, action SessionAction) error
+}
+
+// rolePolicy 是默认策略：会话所有者拥有全部权限，编辑者可以查看和发言，
+// 管理员可以查看和管理，查看者与分享访客只能查看。
+// 登录用户只在会话所属的工作空间中拥有所有者、协作者与空间管理员身份，
+// 配置中的运维管理员不受工作空间限制。
+type rolePolicy struct {
+	admins       map[string]struct{}
+	participants domain.ParticipantRepository
+}
+
+// NewSessionPolicy 创建默认策略，adminUserIDs 中的用户拥有管理员身份；
+// participants 为空时不支持协作者
+func NewSessionPolicy(adminUserIDs []string, participants domain.ParticipantRepository) SessionPolicy {
+	admins := make(map[string]struct{}, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		if id != "" {
+			admins[id] = struct{}{}
+		}
+	}
+	return &rolePolicy{admins: admins, participants: participants}
+}
+
+// RoleOf 同时具备多种身份时取权限最大的一种：所有者、编辑者、管理员、查看者、分享访客
+func (p *rolePolicy) RoleOf(ctx context.Context, principal Principal, session *domain.Session) (SessionRole, error) {
+	if session == nil {
+		return SessionRoleNone, nil
+	}
+	if principal.UserID != "" {
+		inWorkspace := principal.Tenant.WorkspaceID == session.WorkspaceID
+		if inWorkspace && principal.UserID == session.UserID {
+			return SessionRoleOwner, nil
+		}
+		var participant *domain.Participant
+		if inWorkspace && p.participants != nil {
+			var err error
+			if participant, err = p.participants.GetParticipant(ctx, session.ID, principal.UserID); err != nil {
+				return SessionRoleNone, err
+			}
+		}
+		switch {
+		case participant != nil && participant.Role == domain.ParticipantEditor:
+			return SessionRoleEditor, nil
+		case p.isAdmin(principal.UserID), inWorkspace && principal.Tenant.IsWorkspaceAdmin():
+			return SessionRoleAdmin, nil
+		case participant != nil && participant.Role == domain.ParticipantViewer:
+			return SessionRoleViewer, nil
+		}
+	}
+	if principal.Share != nil && principal.Share.SessionID == session.ID &&
+		principal.Share.UserID == session.UserID && principal.Share.Active(time.Now()) {
+		return SessionRoleSharedViewer, nil
+	}
+	return SessionRoleNone, nil
+}
+
+func (p *rolePolicy) Authorize(ctx context.Context, principal Principal, session *domain.Session, action SessionAction) error {
+	role, err := p.RoleOf(ctx, principal, session)
+	if err != nil {
+		return err
+	}
+	if role == SessionRoleNone {
+		return domain.ErrSessionNotFound
+	}
+	if !roleAllows(role, action) {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
+
+func (p *rolePolicy) isAdmin(userID string) bool {
+	_, ok := p.admins[userID]
+	return ok
+}
+
+// roleAllows 是身份到操作的授权表
+func roleAllows(role SessionRole, action SessionAction) bool {
+	switch role {
+	case SessionRoleOwner:
+		return true
+	case SessionRoleEditor:
+		return action == ActionView || action == ActionPost
+	case SessionRoleAdmin:
+		return action == ActionView || action == ActionManage
+	case SessionRoleViewer, SessionRoleSharedViewer:
+		return action == ActionView
+	}
+	return false
+}
+
+// authorizeSession 读取会话并校验权限，供各应用服务共用
+func authorizeSession(ctx context.Context, repo domain.ChatRepository, policy SessionPolicy, p Principal, sessionID string, action SessionAction) (*domain.Session, error) {
+	session, err := repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Authorize(ctx, p, session, action); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// requirePersistent 拒绝对无痕会话执行需要把内容保存下来或开放给他人的操作
+func requirePersistent(session *domain.Session) error {
+	if session.Ephemeral {
+		return domain.ErrEphemeralSession
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestSessionPolicy(t *testing.T) {
+	ctx := context.Background()
+	participants := fakeParticipants{
+		"s1/erin":  {SessionID: "s1", UserID: "erin", Role: domain.ParticipantEditor},
+		"s1/vic":   {SessionID: "s1", UserID: "vic", Role: domain.ParticipantViewer},
+		"s1/admin": {SessionID: "s1", UserID: "admin", Role: domain.ParticipantViewer},
+	}
+	policy := NewSessionPolicy([]string{"admin"}, participants)
+	session := &domain.Session{ID: "s1", UserID: "alice"}
+	share := &domain.Share{ID: "sh1", SessionID: "s1", UserID: "alice"}
+	expired := &domain.Share{ID: "sh2", SessionID: "s1", UserID: "alice", ExpiresAt: time.Now().Add(-time.Minute)}
+	otherSession := &domain.Share{ID: "sh3", SessionID: "s2", UserID: "alice"}
+	wsSession := &domain.Session{ID: "s3", UserID: "alice", WorkspaceID: "ws1"}
+	wsCtx := domain.WithTenant(ctx, domain.Tenant{WorkspaceID: "ws1", Role: domain.WorkspaceAdmin})
+	memberCtx := domain.WithTenant(ctx, domain.Tenant{WorkspaceID: "ws1", Role: domain.WorkspaceMember})
+
+	cases := []struct {
+		name      string
+		principal Principal
+		session   *domain.Session
+		action    SessionAction
+		want      error
+	}{
+		{"owner posts", UserPrincipal(ctx, "alice"), session, ActionPost, nil},
+		{"owner shares", UserPrincipal(ctx, "alice"), session, ActionShare, nil},
+		{"stranger is told nothing", UserPrincipal(ctx, "bob"), session, ActionView, domain.ErrSessionNotFound},
+		{"missing session", UserPrincipal(ctx, "alice"), nil, ActionView, domain.ErrSessionNotFound},
+		{"admin views", UserPrincipal(ctx, "admin"), session, ActionView, nil},
+		{"admin deletes", UserPrincipal(ctx, "admin"), session, ActionManage, nil},
+		{"admin cannot post", UserPrincipal(ctx, "admin"), session, ActionPost, domain.ErrPermissionDenied},
+		{"admin cannot share", UserPrincipal(ctx, "admin"), session, ActionShare, domain.ErrPermissionDenied},
+		{"editor posts", UserPrincipal(ctx, "erin"), session, ActionPost, nil},
+		{"editor cannot delete", UserPrincipal(ctx, "erin"), session, ActionManage, domain.ErrPermissionDenied},
+		{"editor cannot invite", UserPrincipal(ctx, "erin"), session, ActionShare, domain.ErrPermissionDenied},
+		{"participant viewer views", UserPrincipal(ctx, "vic"), session, ActionView, nil},
+		{"participant viewer cannot post", UserPrincipal(ctx, "vic"), session, ActionPost, domain.ErrPermissionDenied},
+		{"participant of another session", UserPrincipal(ctx, "erin"), &domain.Session{ID: "s2", UserID: "alice"}, ActionView, domain.ErrSessionNotFound},
+		{"admin keeps admin rights as viewer", UserPrincipal(ctx, "admin"), session, ActionManage, nil},
+		{"owner in another workspace", UserPrincipal(wsCtx, "alice"), session, ActionView, domain.ErrSessionNotFound},
+		{"collaborator in another workspace", UserPrincipal(wsCtx, "erin"), session, ActionView, domain.ErrSessionNotFound},
+		{"global admin crosses workspaces", UserPrincipal(wsCtx, "admin"), session, ActionManage, nil},
+		{"workspace admin views member sessions", UserPrincipal(wsCtx, "olga"), wsSession, ActionView, nil},
+		{"workspace admin deletes member sessions", UserPrincipal(wsCtx, "olga"), wsSession, ActionManage, nil},
+		{"workspace admin cannot post", UserPrincipal(wsCtx, "olga"), wsSession, ActionPost, domain.ErrPermissionDenied},
+		{"workspace admin outside the workspace", UserPrincipal(wsCtx, "olga"), session, ActionView, domain.ErrSessionNotFound},
+		{"workspace member is told nothing", UserPrincipal(memberCtx, "bob"), wsSession, ActionView, domain.ErrSessionNotFound},
+		{"viewer views", Principal{Share: share}, session, ActionView, nil},
+		{"viewer cannot post", Principal{Share: share}, session, ActionPost, domain.ErrPermissionDenied},
+		{"expired share", Principal{Share: expired}, session, ActionView, domain.ErrSessionNotFound},
+		{"share of another session", Principal{Share: otherSession}, session, ActionView, domain.ErrSessionNotFound},
+	}
+	for _, tc := range cases {
+		if err := policy.Authorize(ctx, tc.principal, tc.session, tc.action); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Authorize() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if role, err := policy.RoleOf(ctx, UserPrincipal(ctx, ""), session); err != nil || role != SessionRoleNone {
+		t.Errorf("empty user got role %q, %v", role, err)
+	}
+}
+
+// fakeParticipants 以 "会话/用户" 为键的内存协作者表
+type fakeParticipants map[string]*domain.Participant
+
+func (f fakeParticipants) SaveParticipant(ctx context.Context, p *domain.Participant) error {
+	f[p.SessionID+"/"+p.UserID] = p
+	return nil
+}
+
+func (f fakeParticipants) GetParticipant(ctx context.Context, sessionID, userID string) (*domain.Participant, error) {
+	return f[sessionID+"/"+userID], nil
+}
+
+func (f fakeParticipants) ListParticipants(ctx context.Context, sessionID string) ([]*domain.Participant, error) {
+	var out []*domain.Participant
+	for _, p := range f {
+		if p.SessionID == sessionID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f fakeParticipants) DeleteParticipant(ctx context.Context, sessionID, userID string) error {
+	delete(f, sessionID+"/"+userID)
+	return nil
+}
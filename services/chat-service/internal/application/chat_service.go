@@ -4,22 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"free-chat/services/chat-service/internal/domain"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// defaultPageLimit / maxPageLimit 历史消息与会话列表每页的默认与最大条数
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	// defaultSearchK 语义检索默认返回的会话数
+	defaultSearchK = 5
+	// maxSearchK 语义检索最多返回的会话数
+	maxSearchK = 20
+	// defaultSearchLimit / maxSearchLimit 全文检索每页的默认与最大条数
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// maxSearchQueryRunes 全文检索关键词的最大长度
+	maxSearchQueryRunes = 200
+)
+
 type ChatService struct {
 	chatRepo     domain.ChatRepository
 	modelBalance domain.ModelBalanceService
+	recaller     domain.MessageRecaller
+	searcher     domain.ConversationSearcher
+	policy       SessionPolicy
 }
 
-func NewChatService(chatRepo domain.ChatRepository, modelBalance domain.ModelBalanceService) *ChatService {
+// NewChatService creates the application service. recaller may be nil to
+// disable long-term recall, searcher may be nil to disable semantic search.
+// policy authorizes every access to an existing session.
+func NewChatService(chatRepo domain.ChatRepository, modelBalance domain.ModelBalanceService, recaller domain.MessageRecaller, searcher domain.ConversationSearcher, policy SessionPolicy) *ChatService {
 	return &ChatService{
 		chatRepo:     chatRepo,
 		modelBalance: modelBalance,
+		recaller:     recaller,
+		searcher:     searcher,
+		policy:       policy,
 	}
 }
 
@@ -33,43 +59,59 @@ func (s *ChatService) DecrementModelLoad(ctx context.Context, modelName, addr st
 	return s.modelBalance.DecrementTaskCount(ctx, modelName, addr)
 }
 
-// EnsureSession 确保会话存在
-func (s *ChatService) EnsureSession(ctx context.Context, userID, sessionID, content string) (string, error) {
-	if sessionID == "" {
-		sessionID = uuid.New().String()
-		// 创建新 Session
-		session := &domain.Session{
-			ID:     sessionID,
-			UserID: userID,
-		}
-		session.SetTitle(content, 20)
-		if err := s.chatRepo.SaveSession(ctx, session); err != nil {
-			return "", err
-		}
+// EnsureSession 确保会话存在：sessionID 为空时在当前工作空间中新建（ephemeral 为真时新建无痕会话），
+// 否则校验用户可以在该会话中发言
+func (s *ChatService) EnsureSession(ctx context.Context, userID, sessionID, content string, ephemeral bool) (*domain.Session, error) {
+	if sessionID != "" {
+		return authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionPost)
+	}
+
+	// 创建新 Session
+	now := time.Now()
+	session := &domain.Session{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		WorkspaceID:   domain.TenantFromContext(ctx).WorkspaceID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		LastMessageAt: now,
+		Ephemeral:     ephemeral,
+	}
+	session.SetTitle(content, 20)
+	if err := s.chatRepo.SaveSession(ctx, session); err != nil {
+		return nil, err
 	}
-	return sessionID, nil
+	return session, nil
 }
 
-// SaveMessage 保存消息
-func (s *ChatService) SaveMessage(ctx context.Context, sessionID, userID string, role domain.Role, content string) error {
+// SaveMessage 保存消息，返回已保存的消息（含生成的 ID）。model 为生成回复的模型，用户消息传空。
+// 消息随会话标记无痕，存储层据此决定是否落库
+func (s *ChatService) SaveMessage(ctx context.Context, session *domain.Session, userID, authorName string, role domain.Role, content, model string) (*domain.Message, error) {
 	msg := &domain.Message{
-		ID:        uuid.New().String(),
-		SessionID: sessionID,
-		UserID:    userID,
-		Role:      role,
-		Content:   content,
-		CreatedAt: time.Now(),
+		ID:         uuid.New().String(),
+		SessionID:  session.ID,
+		Ephemeral:  session.Ephemeral,
+		UserID:     userID,
+		Role:       role,
+		Content:    content,
+		Model:      model,
+		AuthorName: authorName,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.chatRepo.SaveMessage(ctx, msg); err != nil {
+		return nil, err
 	}
-	return s.chatRepo.SaveMessage(ctx, msg)
+	return msg, nil
 }
 
 // GetContext 获取上下文
 func (s *ChatService) GetContext(ctx context.Context, sessionID string) (string, error) {
 	// 获取最近的 10 条消息
-	messages, err := s.chatRepo.GetSessionMessages(ctx, sessionID, 10, 0)
+	page, err := s.chatRepo.GetSessionMessages(ctx, sessionID, 10, "")
 	if err != nil {
 		return "", fmt.Errorf("get session messages: %w", err)
 	}
+	messages := page.Messages
 	if len(messages) == 0 {
 		return "", nil
 	}
@@ -92,12 +134,17 @@ func (s *ChatService) GetContext(ctx context.Context, sessionID string) (string,
 	return string(jsonBytes), nil
 }
 
-// CreateSession 创建会话
-func (s *ChatService) CreateSession(ctx context.Context, userID, title string) (*domain.Session, error) {
+// CreateSession 在当前工作空间中创建会话，ephemeral 为真时创建无痕会话
+func (s *ChatService) CreateSession(ctx context.Context, userID, title string, ephemeral bool) (*domain.Session, error) {
+	now := time.Now()
 	session := &domain.Session{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		CreatedAt: time.Now(),
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		WorkspaceID:   domain.TenantFromContext(ctx).WorkspaceID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		LastMessageAt: now,
+		Ephemeral:     ephemeral,
 	}
 	// Set title with length limit
 	session.SetTitle(title, 50)
@@ -108,36 +155,166 @@ func (s *ChatService) CreateSession(ctx context.Context, userID, title string) (
 	return session, nil
 }
 
-// GetHistory 获取会话历史
-func (s *ChatService) GetHistory(ctx context.Context, sessionID string, limit, offset int) ([]*domain.Message, error) {
-	session, err := s.chatRepo.GetSession(ctx, sessionID)
-	if err != nil {
+// GetHistory 按时间倒序获取会话历史的一页，cursor 为上一页的 NextCursor
+func (s *ChatService) GetHistory(ctx context.Context, userID, sessionID string, limit int, cursor string) (*domain.MessagePage, error) {
+	if _, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionView); err != nil {
 		return nil, err
 	}
-	if session == nil {
-		return nil, fmt.Errorf("session not found")
+	return s.chatRepo.GetSessionMessages(ctx, sessionID, pageLimit(limit), cursor)
+}
+
+// GetSessions 按 query 过滤、排序并获取用户在当前工作空间中会话列表的一页
+func (s *ChatService) GetSessions(ctx context.Context, query domain.SessionQuery) (*domain.SessionPage, error) {
+	query.WorkspaceID = domain.TenantFromContext(ctx).WorkspaceID
+	if query.Tag != "" {
+		tags, err := domain.NormalizeTags([]string{query.Tag})
+		if err != nil || len(tags) == 0 {
+			return nil, domain.ErrInvalidSession
+		}
+		query.Tag = tags[0]
 	}
+	query.Limit = pageLimit(query.Limit)
+	return s.chatRepo.GetSessions(ctx, query)
+}
 
-	return s.chatRepo.GetSessionMessages(ctx, sessionID, limit, offset)
+// pageLimit 把每页条数限制在 (0, maxPageLimit]，非正时使用默认值
+func pageLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultPageLimit
+	case limit > maxPageLimit:
+		return maxPageLimit
+	}
+	return limit
 }
 
-// GetSessions 获取用户会话列表
-func (s *ChatService) GetSessions(ctx context.Context, userID string, limit, offset int) ([]*domain.Session, error) {
-	return s.chatRepo.GetSessions(ctx, userID, limit, offset)
+// DeleteSession 删除会话，返回被删除的会话以便清理其附件（管理员删除时所有者不是 userID）
+func (s *ChatService) DeleteSession(ctx context.Context, sessionID, userID string) (*domain.Session, error) {
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.chatRepo.DeleteSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	if s.recaller != nil {
+		s.recaller.Forget(sessionID)
+	}
+	if s.searcher != nil {
+		if err := s.searcher.Forget(ctx, sessionID); err != nil {
+			return nil, fmt.Errorf("forget session embeddings: %w", err)
+		}
+	}
+	return session, nil
 }
 
-// DeleteSession 删除会话
-func (s *ChatService) DeleteSession(ctx context.Context, sessionID, userID string) error {
-	session, err := s.chatRepo.GetSession(ctx, sessionID)
+// PinMessage 置顶或取消置顶会话中的一条消息，置顶消息始终保留在上下文中
+func (s *ChatService) PinMessage(ctx context.Context, userID, sessionID, messageID string, pinned bool) error {
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionPost)
 	if err != nil {
 		return err
 	}
-	if session == nil {
-		return nil // Already deleted
+	if err := requirePersistent(session); err != nil {
+		return err
 	}
-	if session.UserID != userID {
-		return fmt.Errorf("permission denied")
+
+	msg, err := s.chatRepo.GetMessage(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if msg == nil || msg.SessionID != sessionID {
+		return domain.ErrMessageNotFound
+	}
+	if msg.Pinned == pinned {
+		return nil
+	}
+
+	return s.chatRepo.SetMessagePinned(ctx, messageID, pinned)
+}
+
+// GetPinnedMessages 获取会话中的置顶消息（从旧到新）
+func (s *ChatService) GetPinnedMessages(ctx context.Context, sessionID string) ([]*domain.Message, error) {
+	return s.chatRepo.GetPinnedMessages(ctx, sessionID)
+}
+
+// RecallMessages 召回最近窗口之前与 query 相关的旧消息（按相关度排序），返回的消息已标记 Recalled。
+// window 为已经进入上下文的消息（最近历史 + 置顶），其中的消息不会被重复召回。
+func (s *ChatService) RecallMessages(ctx context.Context, sessionID, query string, window []*domain.Message) ([]*domain.Message, error) {
+	if s.recaller == nil {
+		return nil, nil
+	}
+
+	var before time.Time
+	inWindow := make(map[string]bool, len(window))
+	for _, m := range window {
+		inWindow[m.ID] = true
+		if !m.Pinned && !m.CreatedAt.IsZero() && (before.IsZero() || m.CreatedAt.Before(before)) {
+			before = m.CreatedAt
+		}
+	}
+	// 窗口为空说明会话很短，全部历史都已在上下文中
+	if before.IsZero() {
+		return nil, nil
 	}
 
-	return s.chatRepo.DeleteSession(ctx, sessionID)
+	messages, err := s.recaller.Recall(ctx, sessionID, query, before)
+	if err != nil {
+		return nil, err
+	}
+	recalled := make([]*domain.Message, 0, len(messages))
+	for _, m := range messages {
+		if inWindow[m.ID] || m.Pinned {
+			continue
+		}
+		m.Recalled = true
+		recalled = append(recalled, m)
+	}
+	return recalled, nil
+}
+
+// IndexMessages 为消息建立向量索引，供跨会话语义检索；未开启检索时忽略
+func (s *ChatService) IndexMessages(ctx context.Context, messages []*domain.Message) error {
+	if s.searcher == nil {
+		return nil
+	}
+	return s.searcher.Index(ctx, messages)
+}
+
+// SearchConversations 按语义检索用户在当前工作空间中的历史会话，k 为返回的会话数
+func (s *ChatService) SearchConversations(ctx context.Context, userID, query string, k int) ([]*domain.ConversationMatch, error) {
+	if s.searcher == nil {
+		return nil, domain.ErrSearchUnavailable
+	}
+	switch {
+	case k <= 0:
+		k = defaultSearchK
+	case k > maxSearchK:
+		k = maxSearchK
+	}
+	return s.searcher.Search(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID, query, k)
+}
+
+// SearchMessages 全文检索用户在当前工作空间所有会话中的消息，结果按时间倒序分页
+func (s *ChatService) SearchMessages(ctx context.Context, query domain.MessageSearchQuery) (*domain.MessageSearchPage, error) {
+	query.WorkspaceID = domain.TenantFromContext(ctx).WorkspaceID
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" || utf8.RuneCountInString(query.Query) > maxSearchQueryRunes {
+		return nil, domain.ErrInvalidSearch
+	}
+	switch query.Role {
+	case "", domain.RoleUser, domain.RoleAssistant, domain.RoleSystem:
+	default:
+		return nil, domain.ErrInvalidSearch
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, domain.ErrInvalidSearch
+	}
+	switch {
+	case query.Limit <= 0:
+		query.Limit = defaultSearchLimit
+	case query.Limit > maxSearchLimit:
+		query.Limit = maxSearchLimit
+	}
+	return s.chatRepo.SearchMessages(ctx, query)
 }
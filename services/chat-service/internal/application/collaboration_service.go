@@ -0,0 +1,191 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// maxParticipants 每个会话最多邀请的协作者数（不含所有者）
+const maxParticipants = 50
+
+// CollaborationService 负责多人会话：邀请与移除协作者，以及向所有参与者的打开的流推送实时事件
+type CollaborationService struct {
+	chatRepo        domain.ChatRepository
+	participantRepo domain.ParticipantRepository
+	policy          SessionPolicy
+	// events 为空时不推送实时事件
+	events domain.SessionEventBus
+}
+
+func NewCollaborationService(chatRepo domain.ChatRepository, participantRepo domain.ParticipantRepository, policy SessionPolicy, events domain.SessionEventBus) *CollaborationService {
+	return &CollaborationService{
+		chatRepo:        chatRepo,
+		participantRepo: participantRepo,
+		policy:          policy,
+		events:          events,
+	}
+}
+
+// ListParticipants 返回会话的所有者（排在第一位）与全部协作者，参与者都可以查看
+func (s *CollaborationService) ListParticipants(ctx context.Context, userID, sessionID string) ([]*domain.Participant, error) {
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionView)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := s.participantRepo.ListParticipants(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	owner := &domain.Participant{
+		SessionID: sessionID,
+		UserID:    session.UserID,
+		Role:      domain.ParticipantOwner,
+		CreatedAt: session.CreatedAt,
+	}
+	return append([]*domain.Participant{owner}, participants...), nil
+}
+
+// SetParticipant 邀请协作者或修改其角色（editor / viewer），只有所有者可以操作
+func (s *CollaborationService) SetParticipant(ctx context.Context, userID, sessionID, participantID, role string) (*domain.Participant, error) {
+	r, ok := domain.ParseParticipantRole(role)
+	if !ok || participantID == "" {
+		return nil, domain.ErrInvalidParticipant
+	}
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionShare)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePersistent(session); err != nil {
+		return nil, err
+	}
+	if participantID == session.UserID {
+		return nil, domain.ErrInvalidParticipant
+	}
+	existing, err := s.participantRepo.GetParticipant(ctx, sessionID, participantID)
+	if err != nil {
+		return nil, err
+	}
+	p := &domain.Participant{
+		SessionID: sessionID,
+		UserID:    participantID,
+		Role:      r,
+		AddedBy:   userID,
+		CreatedAt: time.Now(),
+	}
+	if existing != nil {
+		p.AddedBy, p.CreatedAt = existing.AddedBy, existing.CreatedAt
+	} else {
+		participants, err := s.participantRepo.ListParticipants(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if len(participants) >= maxParticipants {
+			return nil, domain.ErrInvalidParticipant
+		}
+	}
+	if err := s.participantRepo.SaveParticipant(ctx, p); err != nil {
+		return nil, err
+	}
+	// 事件只用于刷新界面，推送失败不影响修改本身
+	_ = s.publish(ctx, &domain.SessionEvent{Type: domain.SessionEventParticipants, SessionID: sessionID, UserID: userID})
+	return p, nil
+}
+
+// RemoveParticipant 移除协作者：所有者可以移除任何人，协作者可以移除自己（退出会话）
+func (s *CollaborationService) RemoveParticipant(ctx context.Context, userID, sessionID, participantID string) error {
+	action := ActionShare
+	if participantID == userID {
+		action = ActionView
+	}
+	if _, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, action); err != nil {
+		return err
+	}
+	existing, err := s.participantRepo.GetParticipant(ctx, sessionID, participantID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return domain.ErrParticipantNotFound
+	}
+	if err := s.participantRepo.DeleteParticipant(ctx, sessionID, participantID); err != nil {
+		return err
+	}
+	_ = s.publish(ctx, &domain.SessionEvent{Type: domain.SessionEventParticipants, SessionID: sessionID, UserID: userID})
+	return nil
+}
+
+// IsCollaborative 返回会话是否有协作者；只有多人会话会标注发言人并推送实时事件
+func (s *CollaborationService) IsCollaborative(ctx context.Context, sessionID string) (bool, error) {
+	participants, err := s.participantRepo.ListParticipants(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	return len(participants) > 0, nil
+}
+
+// Subscribe 订阅会话的实时事件，直到 ctx 结束。
+// 参与者变化时重新校验权限，被移出会话的用户的订阅随之结束。
+func (s *CollaborationService) Subscribe(ctx context.Context, userID, sessionID string) (<-chan *domain.SessionEvent, error) {
+	if s.events == nil {
+		return nil, domain.ErrEventsUnavailable
+	}
+	if _, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionView); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	events, err := s.events.Subscribe(ctx, sessionID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	out := make(chan *domain.SessionEvent)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for event := range events {
+			if event.Type == domain.SessionEventParticipants {
+				if _, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionView); err != nil {
+					return
+				}
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// PublishMessage 向参与者推送一条完整的消息
+func (s *CollaborationService) PublishMessage(ctx context.Context, msg *domain.Message) error {
+	return s.publish(ctx, &domain.SessionEvent{
+		Type:      domain.SessionEventMessage,
+		SessionID: msg.SessionID,
+		UserID:    msg.UserID,
+		Message:   msg,
+	})
+}
+
+// PublishDelta 向参与者推送助手回复 replyTo 的增量文本
+func (s *CollaborationService) PublishDelta(ctx context.Context, sessionID, userID, replyTo, delta string) error {
+	return s.publish(ctx, &domain.SessionEvent{
+		Type:      domain.SessionEventDelta,
+		SessionID: sessionID,
+		UserID:    userID,
+		Delta:     delta,
+		ReplyTo:   replyTo,
+	})
+}
+
+// publish 在没有配置事件总线时什么也不做
+func (s *CollaborationService) publish(ctx context.Context, event *domain.SessionEvent) error {
+	if s.events == nil {
+		return nil
+	}
+	event.CreatedAt = time.Now()
+	return s.events.Publish(ctx, event)
+}
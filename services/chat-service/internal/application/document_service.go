@@ -0,0 +1,197 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxDocumentBytes 单个文档正文的最大字节数
+	maxDocumentBytes = 1 << 20
+	// maxTitleRunes 文档标题与知识库名称的最大长度
+	maxTitleRunes = 255
+	// maxRetrievalChunks 每轮对话参与检索的切片上限
+	maxRetrievalChunks = 2000
+	// documentTopK 每轮注入上下文的切片数
+	documentTopK = 4
+)
+
+// DocumentService 管理会话附件与知识库文档，并在对话时检索相关切片
+type DocumentService struct {
+	docRepo   domain.DocumentRepository
+	chatRepo  domain.ChatRepository
+	chunker   domain.DocumentChunker
+	retriever domain.DocumentRetriever
+	policy    SessionPolicy
+}
+
+func NewDocumentService(docRepo domain.DocumentRepository, chatRepo domain.ChatRepository, chunker domain.DocumentChunker, retriever domain.DocumentRetriever, policy SessionPolicy) *DocumentService {
+	return &DocumentService{
+		docRepo:   docRepo,
+		chatRepo:  chatRepo,
+		chunker:   chunker,
+		retriever: retriever,
+		policy:    policy,
+	}
+}
+
+// UploadDocument 切分并保存文档，sessionID 与 collectionID 必须且只能指定一个
+func (s *DocumentService) UploadDocument(ctx context.Context, userID, sessionID, collectionID, title, format, content string) (*domain.Document, error) {
+	if (sessionID == "") == (collectionID == "") {
+		return nil, domain.ErrInvalidDocument
+	}
+	docFormat, ok := domain.ParseDocumentFormat(format)
+	if !ok || strings.TrimSpace(content) == "" || len(content) > maxDocumentBytes || !utf8.ValidString(content) {
+		return nil, domain.ErrInvalidDocument
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = "Untitled"
+	}
+	if utf8.RuneCountInString(title) > maxTitleRunes {
+		title = string([]rune(title)[:maxTitleRunes])
+	}
+
+	if sessionID != "" {
+		session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionPost)
+		if err != nil {
+			return nil, err
+		}
+		if err := requirePersistent(session); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.ownedCollection(ctx, userID, collectionID); err != nil {
+		return nil, err
+	}
+
+	texts := s.chunker.Chunk(content, docFormat)
+	if len(texts) == 0 {
+		return nil, domain.ErrInvalidDocument
+	}
+	doc := &domain.Document{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		WorkspaceID:  domain.TenantFromContext(ctx).WorkspaceID,
+		SessionID:    sessionID,
+		CollectionID: collectionID,
+		Title:        title,
+		Format:       docFormat,
+		ChunkCount:   len(texts),
+		CreatedAt:    time.Now(),
+	}
+	chunks := make([]*domain.DocumentChunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = &domain.DocumentChunk{
+			ID:         uuid.New().String(),
+			DocumentID: doc.ID,
+			Index:      i,
+			Content:    text,
+			Title:      title,
+		}
+	}
+	if err := s.docRepo.SaveDocument(ctx, doc, chunks); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ListDocuments 列出用户在当前工作空间中的文档，可按会话或知识库过滤
+func (s *DocumentService) ListDocuments(ctx context.Context, userID, sessionID, collectionID string) ([]*domain.Document, error) {
+	return s.docRepo.ListDocuments(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID, sessionID, collectionID)
+}
+
+// DeleteDocument 删除文档及其切片
+func (s *DocumentService) DeleteDocument(ctx context.Context, userID, documentID string) error {
+	doc, err := s.docRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if doc == nil || doc.WorkspaceID != domain.TenantFromContext(ctx).WorkspaceID {
+		return domain.ErrDocumentNotFound
+	}
+	if doc.UserID != userID {
+		return domain.ErrPermissionDenied
+	}
+	return s.docRepo.DeleteDocument(ctx, documentID)
+}
+
+// DeleteSessionDocuments 删除会话所有者上传到会话的全部附件，会话删除后调用
+func (s *DocumentService) DeleteSessionDocuments(ctx context.Context, session *domain.Session) error {
+	docs, err := s.docRepo.ListDocuments(ctx, session.UserID, session.WorkspaceID, session.ID, "")
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := s.docRepo.DeleteDocument(ctx, doc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateCollection 创建知识库
+func (s *DocumentService) CreateCollection(ctx context.Context, userID, name string) (*domain.Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTitleRunes {
+		return nil, domain.ErrInvalidDocument
+	}
+	collection := &domain.Collection{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		WorkspaceID: domain.TenantFromContext(ctx).WorkspaceID,
+		Name:        name,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.docRepo.SaveCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// ListCollections 列出用户在当前工作空间中的知识库
+func (s *DocumentService) ListCollections(ctx context.Context, userID string) ([]*domain.Collection, error) {
+	return s.docRepo.ListCollections(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID)
+}
+
+// DeleteCollection 删除知识库及其中的文档
+func (s *DocumentService) DeleteCollection(ctx context.Context, userID, collectionID string) error {
+	if _, err := s.ownedCollection(ctx, userID, collectionID); err != nil {
+		return err
+	}
+	return s.docRepo.DeleteCollection(ctx, collectionID)
+}
+
+// RetrieveForChat 从会话附件和指定知识库中检索与本轮输入最相关的切片
+func (s *DocumentService) RetrieveForChat(ctx context.Context, userID, sessionID string, collectionIDs []string, query string) ([]*domain.DocumentChunk, error) {
+	for _, id := range collectionIDs {
+		if _, err := s.ownedCollection(ctx, userID, id); err != nil {
+			return nil, err
+		}
+	}
+	chunks, err := s.docRepo.GetChunks(ctx, sessionID, collectionIDs, maxRetrievalChunks)
+	if err != nil || len(chunks) == 0 {
+		return nil, err
+	}
+	return s.retriever.Retrieve(ctx, query, chunks, documentTopK)
+}
+
+func (s *DocumentService) ownedCollection(ctx context.Context, userID, collectionID string) (*domain.Collection, error) {
+	collection, err := s.docRepo.GetCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	// 其他工作空间的知识库视为不存在
+	if collection == nil || collection.WorkspaceID != domain.TenantFromContext(ctx).WorkspaceID {
+		return nil, domain.ErrCollectionNotFound
+	}
+	if collection.UserID != userID {
+		return nil, domain.ErrPermissionDenied
+	}
+	return collection, nil
+}
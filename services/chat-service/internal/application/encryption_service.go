@@ -0,0 +1,119 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// defaultReencryptBatchSize 重新加密时每批检查的行数
+const defaultReencryptBatchSize = 500
+
+// EncryptionService 管理静态加密的数据密钥，并在后台把明文与旧版本密钥的密文逐步重新加密
+type EncryptionService struct {
+	keys      domain.KeyManager
+	repo      domain.EncryptionRepository
+	batchSize int
+	// wake 轮换密钥后提前开始一轮重新加密
+	wake chan struct{}
+}
+
+func NewEncryptionService(keys domain.KeyManager, repo domain.EncryptionRepository) *EncryptionService {
+	return &EncryptionService{
+		keys:      keys,
+		repo:      repo,
+		batchSize: defaultReencryptBatchSize,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Keys 返回请求者当前空间的数据密钥版本，工作空间的密钥只对空间的 owner / admin 开放
+func (s *EncryptionService) Keys(ctx context.Context, userID string) ([]*domain.DataKey, error) {
+	if err := requireKeyManager(ctx); err != nil {
+		return nil, err
+	}
+	return s.keys.Keys(ctx, keyScope(ctx, userID))
+}
+
+// Rotate 为当前空间创建新的数据密钥版本。旧版本仍可解密，已有数据由后台任务重新加密后不再使用旧版本
+func (s *EncryptionService) Rotate(ctx context.Context, userID string) (*domain.DataKey, error) {
+	if err := requireKeyManager(ctx); err != nil {
+		return nil, err
+	}
+	key, err := s.keys.Rotate(ctx, keyScope(ctx, userID))
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return key, nil
+}
+
+// Reencrypt 用当前主密钥重新包装数据密钥，再逐批检查消息与会话，返回重新加密的行数
+func (s *EncryptionService) Reencrypt(ctx context.Context) (int, error) {
+	if n, err := s.keys.RewrapKeys(ctx); err != nil {
+		return 0, err
+	} else if n > 0 {
+		log.Printf("[INFO] rewrapped %d data keys with the current master key", n)
+	}
+	total := 0
+	for _, target := range domain.EncryptTargets {
+		var afterID uint
+		for {
+			lastID, n, err := s.repo.Reencrypt(ctx, target, afterID, s.batchSize)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if lastID == afterID || ctx.Err() != nil {
+				break
+			}
+			afterID = lastID
+		}
+	}
+	return total, nil
+}
+
+// Start 启动时执行一轮重新加密，之后每隔 interval 以及每次轮换密钥后再执行，直到 ctx 取消。
+// interval 为 0 时只在启动与轮换后执行
+func (s *EncryptionService) Start(ctx context.Context, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		n, err := s.Reencrypt(ctx)
+		if err != nil {
+			log.Printf("[ERROR] reencrypt failed: %v", err)
+		}
+		if n > 0 {
+			log.Printf("[INFO] reencrypted %d rows with current data keys", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-s.wake:
+		}
+	}
+}
+
+// keyScope 返回请求者当前空间的密钥范围
+func keyScope(ctx context.Context, userID string) string {
+	return domain.KeyScope(domain.TenantFromContext(ctx).WorkspaceID, userID)
+}
+
+// requireKeyManager 工作空间的数据密钥只有空间的 owner / admin 可以查看与轮换
+func requireKeyManager(ctx context.Context) error {
+	tenant := domain.TenantFromContext(ctx)
+	if tenant.WorkspaceID != "" && !tenant.IsWorkspaceAdmin() {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"io"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// exportBatchSize 导出时每批读取的消息数
+const exportBatchSize = 200
+
+// ExportTarget 为每个要导出的会话返回写入目标，导出多个会话时按顺序调用
+type ExportTarget func(session *domain.Session) (io.Writer, error)
+
+// ExportService 把会话完整历史导出为 Markdown、JSON 或 HTML
+type ExportService struct {
+	chatRepo domain.ChatRepository
+	exporter domain.SessionExporter
+	policy   SessionPolicy
+}
+
+func NewExportService(chatRepo domain.ChatRepository, exporter domain.SessionExporter, policy SessionPolicy) *ExportService {
+	return &ExportService{
+		chatRepo: chatRepo,
+		exporter: exporter,
+		policy:   policy,
+	}
+}
+
+// ExportSession 导出用户可以查看的一个会话
+func (s *ExportService) ExportSession(ctx context.Context, userID, sessionID string, format domain.ExportFormat, target ExportTarget) error {
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionView)
+	if err != nil {
+		return err
+	}
+	if err := requirePersistent(session); err != nil {
+		return err
+	}
+	return s.export(ctx, session, format, target)
+}
+
+// ExportSessions 依次导出用户在当前工作空间中的全部会话（含已归档），按创建时间倒序
+func (s *ExportService) ExportSessions(ctx context.Context, userID string, format domain.ExportFormat, target ExportTarget) error {
+	for _, archived := range []bool{false, true} {
+		query := domain.SessionQuery{
+			UserID:      userID,
+			WorkspaceID: domain.TenantFromContext(ctx).WorkspaceID,
+			Sort:        domain.SessionSortCreated,
+			Archived:    archived,
+			Limit:       maxPageLimit,
+		}
+		for {
+			page, err := s.chatRepo.GetSessions(ctx, query)
+			if err != nil {
+				return err
+			}
+			for _, session := range page.Sessions {
+				if err := s.export(ctx, session, format, target); err != nil {
+					return err
+				}
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+	return nil
+}
+
+func (s *ExportService) export(ctx context.Context, session *domain.Session, format domain.ExportFormat, target ExportTarget) error {
+	w, err := target(session)
+	if err != nil {
+		return err
+	}
+	return writeSession(ctx, s.chatRepo, s.exporter, w, session, format)
+}
+
+// writeSession 逐批读取消息并写出，内存占用与会话长度无关
+func writeSession(ctx context.Context, chatRepo domain.ChatRepository, exporter domain.SessionExporter, w io.Writer, session *domain.Session, format domain.ExportFormat) error {
+	ew, err := exporter.NewWriter(w, format, session)
+	if err != nil {
+		return err
+	}
+	var after *domain.Message
+	for {
+		batch, err := chatRepo.GetSessionMessagesAfter(ctx, session.ID, after, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		if err := ew.WriteMessages(batch); err != nil {
+			return err
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+		after = batch[len(batch)-1]
+	}
+	return ew.Close()
+}
@@ -0,0 +1,171 @@
+package application
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// importedTitle 是既没有标题也没有用户消息时的会话标题
+const importedTitle = "Imported chat"
+
+// ImportService 把第三方聊天导出导入为用户的会话
+type ImportService struct {
+	chatRepo domain.ChatRepository
+	decoder  domain.ConversationDecoder
+	counter  domain.TokenCounter
+}
+
+// NewImportService 创建导入服务，counter 为 nil 时不预先计算 token 数（上下文构建时再实时计数）
+func NewImportService(chatRepo domain.ChatRepository, decoder domain.ConversationDecoder, counter domain.TokenCounter) *ImportService {
+	return &ImportService{
+		chatRepo: chatRepo,
+		decoder:  decoder,
+		counter:  counter,
+	}
+}
+
+// Import 逐个解析并保存会话。dryRun 时只解析与统计，不写入任何数据；
+// 已导入过的会话（同一格式下原会话 ID 相同）会被跳过并标记为 duplicate。
+func (s *ImportService) Import(ctx context.Context, userID string, format domain.ImportFormat, r io.Reader, dryRun bool) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{DryRun: dryRun}
+	err := s.decoder.Decode(r, format, func(conv *domain.ImportedConversation) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := s.importConversation(ctx, userID, format, conv, dryRun)
+		if err != nil {
+			return err
+		}
+		report.Results = append(report.Results, result)
+		switch result.Status {
+		case domain.ImportStatusDuplicate:
+			report.Duplicates++
+		case domain.ImportStatusImported, domain.ImportStatusReady:
+			report.Sessions++
+			report.Messages += result.MessageCount
+			report.Tokens += result.TokenCount
+			report.SkippedMessages += conv.Skipped
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *ImportService) importConversation(ctx context.Context, userID string, format domain.ImportFormat, conv *domain.ImportedConversation, dryRun bool) (*domain.ImportResult, error) {
+	result := &domain.ImportResult{SourceID: conv.SourceID, Title: importTitle(conv)}
+	if len(conv.Messages) == 0 {
+		result.Status = domain.ImportStatusEmpty
+		return result, nil
+	}
+
+	// 导入到当前工作空间，同一来源在不同工作空间中可以各导入一次
+	workspaceID := domain.TenantFromContext(ctx).WorkspaceID
+	var importedFrom string
+	if conv.SourceID != "" {
+		importedFrom = string(format) + ":" + conv.SourceID
+		exists, err := s.chatRepo.HasImportedSession(ctx, userID, workspaceID, importedFrom)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			result.Status = domain.ImportStatusDuplicate
+			return result, nil
+		}
+	}
+
+	sessionID := uuid.New().String()
+	messages := normalizeImported(conv, sessionID, userID, s.counter)
+	for _, m := range messages {
+		result.TokenCount += m.TokenCount
+	}
+	result.MessageCount = len(messages)
+
+	if dryRun {
+		result.Status = domain.ImportStatusReady
+		return result, nil
+	}
+
+	last := messages[len(messages)-1]
+	session := &domain.Session{
+		ID:            sessionID,
+		UserID:        userID,
+		WorkspaceID:   workspaceID,
+		Title:         result.Title,
+		CreatedAt:     messages[0].CreatedAt,
+		UpdatedAt:     last.CreatedAt,
+		LastMessageAt: last.CreatedAt,
+		MessageCount:  len(messages),
+		Preview:       domain.MessagePreview(last.Content),
+		ImportedFrom:  importedFrom,
+	}
+	if !conv.CreatedAt.IsZero() && conv.CreatedAt.Before(session.CreatedAt) {
+		session.CreatedAt = conv.CreatedAt
+	}
+	if conv.UpdatedAt.After(session.UpdatedAt) {
+		session.UpdatedAt = conv.UpdatedAt
+	}
+	if err := s.chatRepo.ImportSession(ctx, session, messages); err != nil {
+		return nil, err
+	}
+	result.SessionID = sessionID
+	result.Status = domain.ImportStatusImported
+	return result, nil
+}
+
+// normalizeImported 为消息分配 ID 与所属会话、计算 token 数，并让时间严格递增：
+// 缺失的时间取前一条（或会话创建时间），相同或倒退的时间顺延 1 微秒，保证按 (created_at, id) 分页时顺序不变。
+func normalizeImported(conv *domain.ImportedConversation, sessionID, userID string, counter domain.TokenCounter) []*domain.Message {
+	prev := conv.CreatedAt
+	if prev.IsZero() {
+		prev = time.Now().UTC()
+	}
+	prev = prev.Add(-time.Microsecond)
+
+	messages := make([]*domain.Message, len(conv.Messages))
+	for i, m := range conv.Messages {
+		at := m.CreatedAt.Truncate(time.Microsecond)
+		if !at.After(prev) {
+			at = prev.Add(time.Microsecond)
+		}
+		prev = at
+		msg := &domain.Message{
+			ID:        uuid.New().String(),
+			SessionID: sessionID,
+			UserID:    userID,
+			Role:      m.Role,
+			Content:   m.Content,
+			Model:     m.Model,
+			CreatedAt: at,
+		}
+		if counter != nil {
+			msg.TokenCount = counter.Count(m.Content)
+		}
+		messages[i] = msg
+	}
+	return messages
+}
+
+// importTitle 优先使用原标题，否则取第一条用户消息
+func importTitle(conv *domain.ImportedConversation) string {
+	session := &domain.Session{Title: importedTitle}
+	if conv.Title != "" {
+		session.SetTitle(conv.Title, maxSessionTitleRunes)
+		return session.Title
+	}
+	for _, m := range conv.Messages {
+		if m.Role == domain.RoleUser {
+			session.SetTitle(strings.Join(strings.Fields(m.Content), " "), maxSessionTitleRunes)
+			break
+		}
+	}
+	return session.Title
+}
@@ -0,0 +1,282 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxMemoriesPerUser 每个用户最多保留的记忆条数，超出时淘汰最久未更新的
+	maxMemoriesPerUser = 200
+	// maxFactsPerExtraction 单次抽取最多保存的事实数
+	maxFactsPerExtraction = 5
+	// maxMemoryRunes 单条记忆的最大长度
+	maxMemoryRunes = 200
+)
+
+// memoryExtractionPrompt 要求模型只输出 JSON 数组，便于解析
+const memoryExtractionPrompt = `You maintain long-term memory about a user across conversations.
+Read the exchange below and extract durable facts about the USER that will still be useful in future, unrelated conversations:
+preferences (language, tone, tools, formats), projects they work on (names, stack, setup), and how they want to be addressed.
+Ignore one-off questions, facts about the world, and anything already listed under "Known memories".
+Reply with ONLY a JSON array, e.g. [{"category":"preference","content":"Prefers answers in Chinese"}].
+Allowed categories: preference, project, profile, other. Reply [] if there is nothing worth remembering.`
+
+// MemoryService 管理跨会话的用户长期记忆：对话后由模型抽取，新对话时注入上下文，用户可查看和控制
+type MemoryService struct {
+	memRepo      domain.MemoryRepository
+	modelBalance domain.ModelBalanceService
+	inference    domain.InferenceService
+	modelName    string
+}
+
+// NewMemoryService creates the memory service. modelName is the model used
+// for fact extraction; inference may be nil to disable extraction.
+func NewMemoryService(memRepo domain.MemoryRepository, modelBalance domain.ModelBalanceService, inference domain.InferenceService, modelName string) *MemoryService {
+	return &MemoryService{
+		memRepo:      memRepo,
+		modelBalance: modelBalance,
+		inference:    inference,
+		modelName:    modelName,
+	}
+}
+
+// ListMemories 返回用户在当前工作空间中的全部记忆以及记忆开关状态
+func (s *MemoryService) ListMemories(ctx context.Context, userID string) ([]*domain.Memory, bool, error) {
+	enabled, err := s.memRepo.IsMemoryEnabled(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	memories, err := s.memRepo.ListMemories(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID)
+	if err != nil {
+		return nil, false, err
+	}
+	return memories, enabled, nil
+}
+
+// ActiveMemories 返回当前工作空间中可注入上下文的记忆；用户关闭记忆时返回空
+func (s *MemoryService) ActiveMemories(ctx context.Context, userID string) ([]*domain.Memory, error) {
+	enabled, err := s.memRepo.IsMemoryEnabled(ctx, userID)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	return s.memRepo.ListMemories(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID)
+}
+
+// UpdateMemory 修改一条记忆的内容
+func (s *MemoryService) UpdateMemory(ctx context.Context, userID, memoryID, content string) error {
+	content = strings.TrimSpace(content)
+	if content == "" || len([]rune(content)) > maxMemoryRunes {
+		return domain.ErrInvalidMemory
+	}
+	if _, err := s.ownedMemory(ctx, userID, memoryID); err != nil {
+		return err
+	}
+	return s.memRepo.UpdateMemoryContent(ctx, memoryID, content)
+}
+
+// DeleteMemory 删除一条记忆
+func (s *MemoryService) DeleteMemory(ctx context.Context, userID, memoryID string) error {
+	if _, err := s.ownedMemory(ctx, userID, memoryID); err != nil {
+		return err
+	}
+	return s.memRepo.DeleteMemory(ctx, memoryID)
+}
+
+// SetMemoryEnabled 开启或关闭用户记忆；关闭后既不抽取新记忆也不注入已有记忆，已有记忆保留
+func (s *MemoryService) SetMemoryEnabled(ctx context.Context, userID string, enabled bool) error {
+	return s.memRepo.SetMemoryEnabled(ctx, userID, enabled)
+}
+
+func (s *MemoryService) ownedMemory(ctx context.Context, userID, memoryID string) (*domain.Memory, error) {
+	memory, err := s.memRepo.GetMemory(ctx, memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil {
+		return nil, domain.ErrMemoryNotFound
+	}
+	// 其他工作空间的记忆视为不存在
+	if memory.WorkspaceID != domain.TenantFromContext(ctx).WorkspaceID {
+		return nil, domain.ErrMemoryNotFound
+	}
+	if memory.UserID != userID {
+		return nil, domain.ErrPermissionDenied
+	}
+	return memory, nil
+}
+
+// ExtractMemories 调用模型从一轮对话中抽取关于用户的持久事实并保存到当前工作空间，记录来源会话与消息。
+// 返回新保存的记忆。
+func (s *MemoryService) ExtractMemories(ctx context.Context, userID, sessionID string, exchange []*domain.Message) ([]*domain.Memory, error) {
+	if s.inference == nil || len(exchange) == 0 {
+		return nil, nil
+	}
+	enabled, err := s.memRepo.IsMemoryEnabled(ctx, userID)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	workspaceID := domain.TenantFromContext(ctx).WorkspaceID
+	existing, err := s.memRepo.ListMemories(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.complete(ctx, userID, sessionID, buildExtractionRequest(exchange, existing))
+	if err != nil {
+		return nil, err
+	}
+	facts := parseExtractedFacts(output)
+
+	known := make(map[string]bool, len(existing))
+	for _, m := range existing {
+		known[normalizeFact(m.Content)] = true
+	}
+	sourceIDs := make([]string, 0, len(exchange))
+	for _, m := range exchange {
+		if m.ID != "" {
+			sourceIDs = append(sourceIDs, m.ID)
+		}
+	}
+
+	var saved []*domain.Memory
+	for _, f := range facts {
+		key := normalizeFact(f.Content)
+		if key == "" || known[key] {
+			continue
+		}
+		known[key] = true
+		now := time.Now()
+		memory := &domain.Memory{
+			ID:               uuid.New().String(),
+			UserID:           userID,
+			WorkspaceID:      workspaceID,
+			Content:          f.Content,
+			Category:         domain.ParseMemoryCategory(f.Category),
+			SourceSessionID:  sessionID,
+			SourceMessageIDs: sourceIDs,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if err := s.memRepo.SaveMemory(ctx, memory); err != nil {
+			return saved, err
+		}
+		saved = append(saved, memory)
+	}
+
+	// 超出上限时淘汰最久未更新的记忆（existing 按更新时间倒序）
+	if overflow := len(existing) + len(saved) - maxMemoriesPerUser; overflow > 0 {
+		for i := len(existing) - 1; i >= 0 && overflow > 0; i-- {
+			if err := s.memRepo.DeleteMemory(ctx, existing[i].ID); err != nil {
+				return saved, err
+			}
+			overflow--
+		}
+	}
+	return saved, nil
+}
+
+// complete 选择模型实例并收集完整输出
+func (s *MemoryService) complete(ctx context.Context, userID, sessionID, request string) (string, error) {
+	addr, err := s.modelBalance.SelectAndIncreaseModelLoads(ctx, s.modelName)
+	if err != nil {
+		return "", fmt.Errorf("select model instance: %w", err)
+	}
+	defer func() {
+		_ = s.modelBalance.DecrementTaskCount(context.Background(), s.modelName, addr)
+	}()
+
+	tokens, err := s.inference.StreamInference(ctx, &domain.InferenceRequest{
+		SessionID: sessionID,
+		UserID:    userID,
+		Request:   request,
+		Model:     addr,
+	})
+	if err != nil {
+		return "", fmt.Errorf("call llm: %w", err)
+	}
+	var sb strings.Builder
+	for token := range tokens {
+		if token.Error != "" {
+			return "", fmt.Errorf("llm stream error: %s", token.Error)
+		}
+		sb.WriteString(token.Content)
+	}
+	return sb.String(), nil
+}
+
+// buildExtractionRequest 以与对话请求相同的消息 JSON 格式构造抽取请求
+func buildExtractionRequest(exchange []*domain.Message, existing []*domain.Memory) string {
+	var transcript strings.Builder
+	if len(existing) > 0 {
+		transcript.WriteString("Known memories:\n")
+		for _, m := range existing {
+			fmt.Fprintf(&transcript, "- %s\n", m.Content)
+		}
+		transcript.WriteString("\n")
+	}
+	transcript.WriteString("Exchange:\n")
+	for _, m := range exchange {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+	}
+
+	messages := []*domain.Message{
+		{Role: domain.RoleSystem, Content: memoryExtractionPrompt},
+		{Role: domain.RoleUser, Content: transcript.String()},
+	}
+	data, _ := json.Marshal(messages)
+	return string(data)
+}
+
+type extractedFact struct {
+	Category string `json:"category"`
+	Content  string `json:"content"`
+}
+
+// parseExtractedFacts 从模型输出中解析 JSON 数组，容忍 <think> 块和 markdown 代码块，
+// 无法解析时视为没有可记忆的内容
+func parseExtractedFacts(output string) []extractedFact {
+	if i := strings.LastIndex(output, "</think>"); i >= 0 {
+		output = output[i+len("</think>"):]
+	}
+	start, end := strings.Index(output, "["), strings.LastIndex(output, "]")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var facts []extractedFact
+	if err := json.Unmarshal([]byte(output[start:end+1]), &facts); err != nil {
+		return nil
+	}
+
+	valid := make([]extractedFact, 0, len(facts))
+	for _, f := range facts {
+		f.Content = strings.TrimSpace(f.Content)
+		if f.Content == "" || len([]rune(f.Content)) > maxMemoryRunes {
+			continue
+		}
+		valid = append(valid, f)
+		if len(valid) == maxFactsPerExtraction {
+			break
+		}
+	}
+	return valid
+}
+
+// normalizeFact 用于去重：忽略大小写、空白和标点
+func normalizeFact(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,283 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultModerationWindow 检查回答时滑动窗口保留的字符数
+	defaultModerationWindow = 200
+	// maxFlagExcerpt 复核记录中保存的文本上限（字符）
+	maxFlagExcerpt = 2000
+)
+
+// ModerationOptions 是服务配置中的审核参数
+type ModerationOptions struct {
+	// Window 检查回答时窗口保留的字符数，非正时使用默认值。跨越多个分片的关键词只要落在窗口内就能命中
+	Window int
+	// ClassifyEvery 回答每新增多少字符调用一次分类器，非正时为 Window 的一半
+	ClassifyEvery int
+	// Reviewers 可以复核个人空间内容的运维管理员；工作空间的复核队列对空间的 owner / admin 开放
+	Reviewers []string
+}
+
+// ModerationResult 是一次检查的全部命中
+type ModerationResult struct {
+	Stage domain.ModerationStage
+	Hits  []domain.ModerationHit
+	// Excerpt 命中时检查的文本
+	Excerpt string
+}
+
+// Blocked 任一命中的处理方式为 block
+func (r *ModerationResult) Blocked() bool {
+	return r.decisive().Action == domain.ModerationActionBlock
+}
+
+// decisive 返回决定处理方式的命中：第一个 block 命中，没有时为第一个命中
+func (r *ModerationResult) decisive() domain.ModerationHit {
+	for _, h := range r.Hits {
+		if h.Action == domain.ModerationActionBlock {
+			return h
+		}
+	}
+	return r.Hits[0]
+}
+
+// Event 返回发给客户端的审核事件
+func (r *ModerationResult) Event() (rule, category string) {
+	h := r.decisive()
+	return h.Rule, h.Category
+}
+
+// ModerationService 在选择模型之前检查用户输入，在流式返回时检查回答，命中的内容进入复核队列
+type ModerationService struct {
+	rules      domain.ModerationRules
+	classifier domain.ModerationClassifier
+	repo       domain.ModerationRepository
+	opts       ModerationOptions
+	reviewers  map[string]struct{}
+}
+
+// NewModerationService creates the moderation service. classifier may be nil.
+// repo may be nil as well: flags are then only written to the log and the
+// review queue is unavailable.
+func NewModerationService(rules domain.ModerationRules, classifier domain.ModerationClassifier, repo domain.ModerationRepository, opts ModerationOptions) *ModerationService {
+	if opts.Window <= 0 {
+		opts.Window = defaultModerationWindow
+	}
+	if opts.ClassifyEvery <= 0 {
+		opts.ClassifyEvery = opts.Window / 2
+	}
+	reviewers := make(map[string]struct{}, len(opts.Reviewers))
+	for _, id := range opts.Reviewers {
+		if id != "" {
+			reviewers[id] = struct{}{}
+		}
+	}
+	return &ModerationService{
+		rules:      rules,
+		classifier: classifier,
+		repo:       repo,
+		opts:       opts,
+		reviewers:  reviewers,
+	}
+}
+
+// CheckInput 检查一条用户输入，没有命中时返回 nil
+func (s *ModerationService) CheckInput(ctx context.Context, text string) *ModerationResult {
+	hits := s.rules.Match(domain.ModerationInput, text)
+	hits = append(hits, s.classify(ctx, domain.ModerationInput, text)...)
+	if len(hits) == 0 {
+		return nil
+	}
+	return &ModerationResult{Stage: domain.ModerationInput, Hits: hits, Excerpt: text}
+}
+
+// classify 分类器不可用时放行，只依靠关键词与正则规则
+func (s *ModerationService) classify(ctx context.Context, stage domain.ModerationStage, text string) []domain.ModerationHit {
+	if s.classifier == nil || text == "" {
+		return nil
+	}
+	hits, err := s.classifier.Classify(ctx, stage, text)
+	if err != nil {
+		log.Printf("[WARN] moderation classifier failed: %v", err)
+		return nil
+	}
+	return hits
+}
+
+// Flag 把一次检查的结果写入复核队列并返回记录 ID。session 为 nil 表示输入在创建会话之前被拒绝，
+// messageID 为空表示内容没有保存。无痕会话的内容不落库，只记录命中的规则与类别。写入失败不影响对话本身
+func (s *ModerationService) Flag(ctx context.Context, userID string, session *domain.Session, messageID string, res *ModerationResult) string {
+	hit := res.decisive()
+	flag := &domain.ModerationFlag{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		WorkspaceID: domain.TenantFromContext(ctx).WorkspaceID,
+		MessageID:   messageID,
+		Stage:       res.Stage,
+		Rule:        hit.Rule,
+		Category:    hit.Category,
+		Action:      hit.Action,
+		Score:       hit.Score,
+		Excerpt:     truncateRunes(res.Excerpt, maxFlagExcerpt),
+		Status:      domain.FlagPending,
+		CreatedAt:   time.Now(),
+	}
+	if session != nil {
+		flag.WorkspaceID = session.WorkspaceID
+		flag.SessionID = session.ID
+		if session.Ephemeral {
+			flag.Excerpt = ""
+		}
+	}
+	if s.repo == nil {
+		log.Printf("[WARN] moderation %s %s: user=%s session=%s rule=%s category=%s",
+			flag.Stage, flag.Action, flag.UserID, flag.SessionID, flag.Rule, flag.Category)
+		return flag.ID
+	}
+	if err := s.repo.SaveFlag(ctx, flag); err != nil {
+		log.Printf("[ERROR] save moderation flag failed: %v", err)
+	}
+	return flag.ID
+}
+
+// ListFlags 返回请求者当前空间的复核队列，按创建时间倒序；status 为空时返回全部状态
+func (s *ModerationService) ListFlags(ctx context.Context, userID string, status domain.FlagStatus, limit int, cursor string) (*domain.ModerationFlagPage, error) {
+	if err := s.requireReviewer(ctx, userID); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", domain.FlagPending, domain.FlagDismissed, domain.FlagConfirmed:
+	default:
+		return nil, domain.ErrInvalidReview
+	}
+	return s.repo.ListFlags(ctx, domain.ModerationFlagQuery{
+		WorkspaceID: domain.TenantFromContext(ctx).WorkspaceID,
+		Status:      status,
+		Limit:       pageLimit(limit),
+		Cursor:      cursor,
+	})
+}
+
+// ReviewFlag 记录复核结论：dismissed 为误判，confirmed 为确认违规，pending 重新打开
+func (s *ModerationService) ReviewFlag(ctx context.Context, userID, flagID string, status domain.FlagStatus) (*domain.ModerationFlag, error) {
+	if err := s.requireReviewer(ctx, userID); err != nil {
+		return nil, err
+	}
+	switch status {
+	case domain.FlagPending, domain.FlagDismissed, domain.FlagConfirmed:
+	default:
+		return nil, domain.ErrInvalidReview
+	}
+	return s.repo.ReviewFlag(ctx, domain.TenantFromContext(ctx).WorkspaceID, flagID, status, userID)
+}
+
+// requireReviewer 工作空间的复核队列对空间的 owner / admin 与运维管理员开放，个人空间的只对运维管理员开放
+func (s *ModerationService) requireReviewer(ctx context.Context, userID string) error {
+	if s.repo == nil {
+		return domain.ErrReviewQueueUnavailable
+	}
+	if _, ok := s.reviewers[userID]; ok {
+		return nil
+	}
+	tenant := domain.TenantFromContext(ctx)
+	if tenant.WorkspaceID != "" && tenant.IsWorkspaceAdmin() {
+		return nil
+	}
+	return domain.ErrPermissionDenied
+}
+
+// NewOutputMonitor 为一次回答创建流式检查器
+func (s *ModerationService) NewOutputMonitor() *OutputMonitor {
+	return &OutputMonitor{s: s}
+}
+
+// OutputMonitor 在滑动窗口上检查流式回答：每个分片加入窗口后检查关键词与正则，
+// 每新增 ClassifyEvery 个字符把窗口交给分类器
+type OutputMonitor struct {
+	s       *ModerationService
+	window  string
+	pending int
+	result  *ModerationResult
+}
+
+// Write 检查加入 chunk 后的窗口，命中 block 时返回累计的结果，调用方应在发送该分片之前截断回答
+func (m *OutputMonitor) Write(ctx context.Context, chunk string) *ModerationResult {
+	if m == nil || chunk == "" {
+		return nil
+	}
+	m.window += chunk
+	m.pending += utf8.RuneCountInString(chunk)
+	hits := m.s.rules.Match(domain.ModerationOutput, m.window)
+	if m.pending >= m.s.opts.ClassifyEvery {
+		hits = append(hits, m.s.classify(ctx, domain.ModerationOutput, m.window)...)
+		m.pending = 0
+	}
+	blocked := m.add(hits)
+	if n := utf8.RuneCountInString(m.window); n > m.s.opts.Window {
+		m.window = string([]rune(m.window)[n-m.s.opts.Window:])
+	}
+	if blocked {
+		return m.result
+	}
+	return nil
+}
+
+// Finish 回答结束时把最后一段尚未送检的窗口交给分类器，返回累计的全部命中，没有命中时为 nil。
+// 此时回答已经发出，block 命中同样只进入复核队列
+func (m *OutputMonitor) Finish(ctx context.Context) *ModerationResult {
+	if m == nil {
+		return nil
+	}
+	if m.pending > 0 {
+		m.add(m.s.classify(ctx, domain.ModerationOutput, m.window))
+		m.pending = 0
+	}
+	return m.result
+}
+
+// add 合并同一规则与类别的重复命中，返回是否首次出现 block 命中
+func (m *OutputMonitor) add(hits []domain.ModerationHit) bool {
+	if len(hits) == 0 {
+		return false
+	}
+	wasBlocked := m.result != nil && m.result.Blocked()
+	if m.result == nil {
+		m.result = &ModerationResult{Stage: domain.ModerationOutput, Excerpt: m.window}
+	}
+	for _, h := range hits {
+		if !m.seen(h) {
+			m.result.Hits = append(m.result.Hits, h)
+		}
+	}
+	if !wasBlocked && m.result.Blocked() {
+		m.result.Excerpt = m.window
+		return true
+	}
+	return false
+}
+
+func (m *OutputMonitor) seen(h domain.ModerationHit) bool {
+	for _, prev := range m.result.Hits {
+		if prev.Rule == h.Rule && prev.Category == h.Category {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,186 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// fakeRules 按预设的关键词命中，不区分大小写
+type fakeRules map[string]domain.ModerationAction
+
+func (f fakeRules) Match(stage domain.ModerationStage, text string) []domain.ModerationHit {
+	var hits []domain.ModerationHit
+	for kw, action := range f {
+		if strings.Contains(strings.ToLower(text), kw) {
+			hits = append(hits, domain.ModerationHit{Rule: kw, Category: kw, Action: action, Score: 1})
+		}
+	}
+	return hits
+}
+
+type fakeClassifier struct {
+	calls []string
+	hits  []domain.ModerationHit
+	err   error
+}
+
+func (f *fakeClassifier) Classify(ctx context.Context, stage domain.ModerationStage, text string) ([]domain.ModerationHit, error) {
+	f.calls = append(f.calls, text)
+	return f.hits, f.err
+}
+
+type fakeModeration struct {
+	flags []*domain.ModerationFlag
+}
+
+func (f *fakeModeration) SaveFlag(ctx context.Context, flag *domain.ModerationFlag) error {
+	f.flags = append(f.flags, flag)
+	return nil
+}
+
+func (f *fakeModeration) ListFlags(ctx context.Context, q domain.ModerationFlagQuery) (*domain.ModerationFlagPage, error) {
+	return &domain.ModerationFlagPage{Flags: f.flags}, nil
+}
+
+func (f *fakeModeration) ReviewFlag(ctx context.Context, workspaceID, flagID string, status domain.FlagStatus, reviewerID string) (*domain.ModerationFlag, error) {
+	for _, flag := range f.flags {
+		if flag.ID == flagID && flag.WorkspaceID == workspaceID {
+			flag.Status = status
+			flag.ReviewedBy = reviewerID
+			return flag, nil
+		}
+	}
+	return nil, domain.ErrFlagNotFound
+}
+
+func TestModerationCheckInput(t *testing.T) {
+	ctx := context.Background()
+	rules := fakeRules{"bomb": domain.ModerationActionBlock, "scam": domain.ModerationActionFlag}
+	classifier := &fakeClassifier{err: errors.New("timeout")}
+	repo := &fakeModeration{}
+	s := NewModerationService(rules, classifier, repo, ModerationOptions{})
+
+	if res := s.CheckInput(ctx, "hello"); res != nil {
+		t.Fatalf("CheckInput = %+v, want nil", res)
+	}
+	// 分类器失败时放行，规则照常生效
+	res := s.CheckInput(ctx, "a scam about a BOMB")
+	if res == nil || !res.Blocked() {
+		t.Fatalf("CheckInput = %+v, want blocked", res)
+	}
+	if rule, _ := res.Event(); rule != "bomb" {
+		t.Fatalf("decisive rule = %s, want bomb", rule)
+	}
+	if res := s.CheckInput(ctx, "a scam"); res == nil || res.Blocked() {
+		t.Fatalf("CheckInput = %+v, want flag only", res)
+	}
+
+	classifier.err = nil
+	classifier.hits = []domain.ModerationHit{{Rule: "classifier", Category: "hate", Action: domain.ModerationActionBlock, Score: 0.9}}
+	res = s.CheckInput(ctx, "hello")
+	if res == nil || !res.Blocked() || res.Hits[0].Category != "hate" {
+		t.Fatalf("CheckInput = %+v, want classifier block", res)
+	}
+
+	id := s.Flag(ctx, "u1", nil, "", res)
+	if len(repo.flags) != 1 || repo.flags[0].ID != id || repo.flags[0].SessionID != "" ||
+		repo.flags[0].Status != domain.FlagPending || repo.flags[0].Score != 0.9 {
+		t.Fatalf("flags = %+v", repo.flags)
+	}
+}
+
+func TestOutputMonitorWindow(t *testing.T) {
+	ctx := context.Background()
+	classifier := &fakeClassifier{}
+	s := NewModerationService(fakeRules{"pipe bomb": domain.ModerationActionBlock, "scam": domain.ModerationActionFlag},
+		classifier, &fakeModeration{}, ModerationOptions{Window: 20, ClassifyEvery: 10})
+
+	m := s.NewOutputMonitor()
+	for _, chunk := range []string{"this is a scam ", "and a scam, "} {
+		if res := m.Write(ctx, chunk); res != nil {
+			t.Fatalf("Write(%q) = %+v, want nil", chunk, res)
+		}
+	}
+	// 关键词跨越两个分片
+	if res := m.Write(ctx, "build a pi"); res != nil {
+		t.Fatalf("partial keyword blocked: %+v", res)
+	}
+	res := m.Write(ctx, "pe bomb")
+	if res == nil || !res.Blocked() {
+		t.Fatalf("Write = %+v, want blocked", res)
+	}
+	if len(res.Hits) != 2 || !strings.Contains(res.Excerpt, "pipe bomb") {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(classifier.calls) == 0 {
+		t.Fatal("classifier was never called")
+	}
+	// 送检的文本不超过窗口加上最新的分片
+	for _, text := range classifier.calls {
+		if n := len([]rune(text)); n > 20+len("and a scam, ") {
+			t.Fatalf("classified %d runes, window is 20", n)
+		}
+	}
+
+	var nilMonitor *OutputMonitor
+	if nilMonitor.Write(ctx, "pipe bomb") != nil || nilMonitor.Finish(ctx) != nil {
+		t.Fatal("nil monitor returned a result")
+	}
+}
+
+func TestModerationReviewPermissions(t *testing.T) {
+	repo := &fakeModeration{flags: []*domain.ModerationFlag{{ID: "f1", WorkspaceID: "w1", Status: domain.FlagPending}}}
+	s := NewModerationService(fakeRules{}, nil, repo, ModerationOptions{Reviewers: []string{"ops"}})
+
+	member := domain.WithTenant(context.Background(), domain.Tenant{WorkspaceID: "w1", Role: domain.WorkspaceMember})
+	if _, err := s.ListFlags(member, "u1", "", 0, ""); !errors.Is(err, domain.ErrPermissionDenied) {
+		t.Fatalf("member ListFlags err = %v", err)
+	}
+	if _, err := s.ListFlags(context.Background(), "u1", "", 0, ""); !errors.Is(err, domain.ErrPermissionDenied) {
+		t.Fatalf("personal ListFlags err = %v", err)
+	}
+	admin := domain.WithTenant(context.Background(), domain.Tenant{WorkspaceID: "w1", Role: domain.WorkspaceAdmin})
+	if _, err := s.ReviewFlag(admin, "u2", "f1", "deleted"); !errors.Is(err, domain.ErrInvalidReview) {
+		t.Fatalf("invalid status err = %v", err)
+	}
+	flag, err := s.ReviewFlag(admin, "u2", "f1", domain.FlagDismissed)
+	if err != nil || flag.Status != domain.FlagDismissed || flag.ReviewedBy != "u2" {
+		t.Fatalf("ReviewFlag = %+v, %v", flag, err)
+	}
+	if _, err := s.ListFlags(context.Background(), "ops", domain.FlagPending, 0, ""); err != nil {
+		t.Fatalf("reviewer ListFlags err = %v", err)
+	}
+
+	s = NewModerationService(fakeRules{}, nil, nil, ModerationOptions{Reviewers: []string{"ops"}})
+	if _, err := s.ListFlags(context.Background(), "ops", "", 0, ""); !errors.Is(err, domain.ErrReviewQueueUnavailable) {
+		t.Fatalf("no repo err = %v", err)
+	}
+}
+
+// 无痕会话的内容不写入复核队列，只保留命中的规则与类别
+func TestModerationFlagEphemeral(t *testing.T) {
+	repo := &fakeModeration{}
+	s := NewModerationService(fakeRules{"forbidden": domain.ModerationActionBlock}, nil, repo, ModerationOptions{})
+	ctx := context.Background()
+
+	res := s.CheckInput(ctx, "a forbidden request")
+	s.Flag(ctx, "u1", &domain.Session{ID: "s1", Ephemeral: true}, "", res)
+	s.Flag(ctx, "u1", &domain.Session{ID: "s2"}, "m1", res)
+	if len(repo.flags) != 2 {
+		t.Fatalf("flags = %d", len(repo.flags))
+	}
+	if f := repo.flags[0]; f.Excerpt != "" || f.SessionID != "s1" || f.Rule != "forbidden" || f.Action != domain.ModerationActionBlock {
+		t.Fatalf("ephemeral flag = %+v", f)
+	}
+	if f := repo.flags[1]; f.Excerpt != "a forbidden request" {
+		t.Fatalf("excerpt = %q", f.Excerpt)
+	}
+	// 结果本身不被修改，之后的保存仍能使用
+	if res.Excerpt != "a forbidden request" {
+		t.Fatalf("result excerpt = %q", res.Excerpt)
+	}
+}
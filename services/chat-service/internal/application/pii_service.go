@@ -0,0 +1,368 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxPIITokenLen 占位符的最大长度，流式还原时超过该长度仍未闭合的 '<' 按普通文本输出
+const maxPIITokenLen = 32
+
+// PIIOptions 是服务配置中各类敏感信息的处理方式
+type PIIOptions struct {
+	// Actions 按类型设置处理方式，未设置的类型使用 Default
+	Actions map[domain.PIIKind]domain.PIIAction
+	// Default 为空时按 PIIMask 处理
+	Default domain.PIIAction
+}
+
+func (o PIIOptions) action(kind domain.PIIKind) domain.PIIAction {
+	if a, ok := o.Actions[kind]; ok {
+		return a
+	}
+	if o.Default != "" {
+		return o.Default
+	}
+	return domain.PIIMask
+}
+
+// ScreenedInput 是筛查后的用户输入
+type ScreenedInput struct {
+	// Text 保存、检索与发送给模型的文本
+	Text string
+	// Matches 检测到的敏感信息，与 actions 一一对应
+	Matches []domain.PIIMatch
+	actions []domain.PIIAction
+	// added 本轮新增的占位符，known 为会话已有与本轮新增的全部占位符
+	added map[string]string
+	known map[string]string
+}
+
+// Restorer 返回把模型回答中的占位符还原为原值的流式还原器
+func (in *ScreenedInput) Restorer() *PIIRestorer {
+	return &PIIRestorer{tokens: in.known}
+}
+
+// PIIService 在用户输入保存与推理之前检测敏感信息，并按配置放行、遮盖、替换为占位符或拒绝，
+// 每次检测都记录审计事件。导出会话时复用同一个检测器
+type PIIService struct {
+	scanner domain.PIIScanner
+	repo    domain.PIIRepository
+	vault   domain.PIIVault
+	opts    PIIOptions
+}
+
+// NewPIIService creates the PII service. repo may be nil, in which case
+// audit events are only written to the log. vault may be nil as well; without
+// somewhere to keep the originals, tokenize falls back to mask.
+func NewPIIService(scanner domain.PIIScanner, repo domain.PIIRepository, vault domain.PIIVault, opts PIIOptions) *PIIService {
+	if vault == nil {
+		opts.Actions = maps.Clone(opts.Actions)
+		for kind, action := range opts.Actions {
+			if action == domain.PIITokenize {
+				opts.Actions[kind] = domain.PIIMask
+			}
+		}
+		if opts.Default == domain.PIITokenize {
+			opts.Default = domain.PIIMask
+		}
+	}
+	return &PIIService{
+		scanner: scanner,
+		repo:    repo,
+		vault:   vault,
+		opts:    opts,
+	}
+}
+
+// Screen 检测一条用户输入。session 为 nil 表示本轮会新建会话。
+// 任一处敏感信息的处理方式为 block 时记录审计事件并返回 ErrPIIBlocked，其余按各自的处理方式改写文本；
+// 占位符在会话内稳定：同一个值在后续轮次中替换为同一个占位符
+func (s *PIIService) Screen(ctx context.Context, userID string, session *domain.Session, text string) (*ScreenedInput, error) {
+	in := &ScreenedInput{Text: text, Matches: s.scanner.Scan(text)}
+	if len(in.Matches) == 0 {
+		return in, nil
+	}
+	in.actions = make([]domain.PIIAction, len(in.Matches))
+	var blocked []string
+	for i, m := range in.Matches {
+		in.actions[i] = s.opts.action(m.Kind)
+		if in.actions[i] == domain.PIIBlock && !slices.Contains(blocked, string(m.Kind)) {
+			blocked = append(blocked, string(m.Kind))
+		}
+	}
+	if len(blocked) > 0 {
+		s.saveAudits(ctx, s.inputAudits(ctx, userID, session, in))
+		return nil, fmt.Errorf("%w: %s", domain.ErrPIIBlocked, strings.Join(blocked, ", "))
+	}
+
+	known := map[string]string{}
+	if session != nil && s.vault != nil {
+		tokens, err := s.vault.GetPIITokens(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		known = tokens
+	}
+	// 反查已有占位符，并按类型统计已用的编号
+	byValue := make(map[string]string, len(known))
+	next := make(map[string]int)
+	for token, value := range known {
+		byValue[value] = token
+		next[tokenPrefix(token)]++
+	}
+
+	var sb strings.Builder
+	last := 0
+	for i, m := range in.Matches {
+		sb.WriteString(text[last:m.Start])
+		last = m.End
+		switch in.actions[i] {
+		case domain.PIIMask:
+			sb.WriteString(domain.MaskPII(m.Kind, m.Value))
+		case domain.PIITokenize:
+			token, ok := byValue[m.Value]
+			if !ok {
+				prefix := strings.ToUpper(string(m.Kind))
+				next[prefix]++
+				token = fmt.Sprintf("<%s_%d>", prefix, next[prefix])
+				byValue[m.Value] = token
+				known[token] = m.Value
+				if in.added == nil {
+					in.added = make(map[string]string)
+				}
+				in.added[token] = m.Value
+			}
+			sb.WriteString(token)
+		default:
+			sb.WriteString(m.Value)
+		}
+	}
+	sb.WriteString(text[last:])
+	in.Text = sb.String()
+	in.known = known
+	return in, nil
+}
+
+// Record 保存本轮新增的占位符并记录审计事件，在会话确定之后调用
+func (s *PIIService) Record(ctx context.Context, userID string, session *domain.Session, in *ScreenedInput) error {
+	if len(in.Matches) == 0 {
+		return nil
+	}
+	s.Audit(ctx, userID, session, in)
+	if len(in.added) == 0 {
+		return nil
+	}
+	return s.vault.SavePIITokens(ctx, session, in.added)
+}
+
+// Audit 只记录审计事件，用于输入在保存之前被其他环节拒绝的情况。session 可以为 nil
+func (s *PIIService) Audit(ctx context.Context, userID string, session *domain.Session, in *ScreenedInput) {
+	s.saveAudits(ctx, s.inputAudits(ctx, userID, session, in))
+}
+
+func (s *PIIService) inputAudits(ctx context.Context, userID string, session *domain.Session, in *ScreenedInput) []*domain.PIIAudit {
+	t := piiTally{stage: domain.PIIStageInput, userID: userID, workspaceID: domain.TenantFromContext(ctx).WorkspaceID}
+	if session != nil {
+		t.workspaceID = session.WorkspaceID
+		t.sessionID = session.ID
+	}
+	for i, m := range in.Matches {
+		t.add(m.Kind, in.actions[i])
+	}
+	return t.audits
+}
+
+// saveAudits 审计失败不影响对话与导出本身
+func (s *PIIService) saveAudits(ctx context.Context, audits []*domain.PIIAudit) {
+	if len(audits) == 0 {
+		return
+	}
+	if s.repo == nil {
+		for _, a := range audits {
+			log.Printf("[INFO] pii %s: user=%s session=%s kind=%s action=%s count=%d",
+				a.Stage, a.UserID, a.SessionID, a.Kind, a.Action, a.Count)
+		}
+		return
+	}
+	if err := s.repo.SaveAudits(ctx, audits); err != nil {
+		log.Printf("[WARN] save pii audits failed: %v", err)
+	}
+}
+
+// RedactExporter 包装会话导出器：导出的标题与消息中的敏感信息除配置为 allow 的类型外一律遮盖，
+// 占位符与拒绝在导出时没有意义。每个会话导出结束时记录审计事件
+func (s *PIIService) RedactExporter(exporter domain.SessionExporter) domain.SessionExporter {
+	return &redactingExporter{pii: s, exporter: exporter}
+}
+
+type redactingExporter struct {
+	pii      *PIIService
+	exporter domain.SessionExporter
+}
+
+func (e *redactingExporter) NewWriter(w io.Writer, format domain.ExportFormat, session *domain.Session) (domain.ExportWriter, error) {
+	rw := &redactingWriter{
+		pii: e.pii,
+		tally: piiTally{
+			stage:       domain.PIIStageExport,
+			userID:      session.UserID,
+			workspaceID: session.WorkspaceID,
+			sessionID:   session.ID,
+		},
+	}
+	redacted := *session
+	redacted.Title = rw.redact(session.Title)
+	redacted.Preview = rw.redact(session.Preview)
+	ew, err := e.exporter.NewWriter(w, format, &redacted)
+	if err != nil {
+		return nil, err
+	}
+	rw.ExportWriter = ew
+	return rw, nil
+}
+
+type redactingWriter struct {
+	domain.ExportWriter
+	pii   *PIIService
+	tally piiTally
+}
+
+func (w *redactingWriter) WriteMessages(messages []*domain.Message) error {
+	redacted := make([]*domain.Message, len(messages))
+	for i, m := range messages {
+		c := *m
+		c.Content = w.redact(m.Content)
+		redacted[i] = &c
+	}
+	return w.ExportWriter.WriteMessages(redacted)
+}
+
+func (w *redactingWriter) Close() error {
+	err := w.ExportWriter.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w.pii.saveAudits(ctx, w.tally.audits)
+	return err
+}
+
+func (w *redactingWriter) redact(text string) string {
+	matches := w.pii.scanner.Scan(text)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(text[last:m.Start])
+		last = m.End
+		if w.pii.opts.action(m.Kind) == domain.PIIAllow {
+			w.tally.add(m.Kind, domain.PIIAllow)
+			sb.WriteString(m.Value)
+			continue
+		}
+		w.tally.add(m.Kind, domain.PIIMask)
+		sb.WriteString(domain.MaskPII(m.Kind, m.Value))
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// piiTally 把同一环节的检测按 (类型, 处理方式) 合并为审计事件，保持首次出现的顺序
+type piiTally struct {
+	stage       domain.PIIStage
+	userID      string
+	workspaceID string
+	sessionID   string
+	audits      []*domain.PIIAudit
+}
+
+func (t *piiTally) add(kind domain.PIIKind, action domain.PIIAction) {
+	for _, a := range t.audits {
+		if a.Kind == kind && a.Action == action {
+			a.Count++
+			return
+		}
+	}
+	t.audits = append(t.audits, &domain.PIIAudit{
+		ID:          uuid.New().String(),
+		UserID:      t.userID,
+		WorkspaceID: t.workspaceID,
+		SessionID:   t.sessionID,
+		Stage:       t.stage,
+		Kind:        kind,
+		Action:      action,
+		Count:       1,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// PIIRestorer 把流式回答中的占位符还原为原值。占位符可能被切分在相邻的两段中，
+// 以 '<' 开头且尚未闭合的尾部会留到下一段再处理
+type PIIRestorer struct {
+	tokens  map[string]string
+	pending string
+}
+
+// Write 返回可以立即发送的文本。nil 还原器原样返回
+func (r *PIIRestorer) Write(chunk string) string {
+	if r == nil || len(r.tokens) == 0 {
+		return chunk
+	}
+	text := r.pending + chunk
+	r.pending = ""
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(text, '<')
+		if i < 0 {
+			sb.WriteString(text)
+			break
+		}
+		sb.WriteString(text[:i])
+		text = text[i:]
+		j := strings.IndexByte(text, '>')
+		if j < 0 && len(text) < maxPIITokenLen {
+			r.pending = text
+			break
+		}
+		if j >= 0 {
+			if value, ok := r.tokens[text[:j+1]]; ok {
+				sb.WriteString(value)
+				text = text[j+1:]
+				continue
+			}
+		}
+		sb.WriteByte('<')
+		text = text[1:]
+	}
+	return sb.String()
+}
+
+// Flush 返回回答结束时仍未闭合的尾部
+func (r *PIIRestorer) Flush() string {
+	if r == nil {
+		return ""
+	}
+	rest := r.pending
+	r.pending = ""
+	return rest
+}
+
+// tokenPrefix 返回占位符 <EMAIL_1> 中的 EMAIL
+func tokenPrefix(token string) string {
+	token = strings.TrimSuffix(strings.TrimPrefix(token, "<"), ">")
+	if i := strings.LastIndexByte(token, '_'); i >= 0 {
+		return token[:i]
+	}
+	return token
+}
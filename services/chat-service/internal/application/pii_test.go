@@ -0,0 +1,177 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// fakeScanner 按预设的字面值检测敏感信息
+type fakeScanner map[string]domain.PIIKind
+
+func (f fakeScanner) Scan(text string) []domain.PIIMatch {
+	var matches []domain.PIIMatch
+	for value, kind := range f {
+		for from := 0; ; {
+			i := strings.Index(text[from:], value)
+			if i < 0 {
+				break
+			}
+			start := from + i
+			matches = append(matches, domain.PIIMatch{Kind: kind, Start: start, End: start + len(value), Value: value})
+			from = start + len(value)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+type fakePII struct {
+	tokens map[string]map[string]string
+	audits []*domain.PIIAudit
+}
+
+func (f *fakePII) GetPIITokens(ctx context.Context, session *domain.Session) (map[string]string, error) {
+	tokens := map[string]string{}
+	for k, v := range f.tokens[session.ID] {
+		tokens[k] = v
+	}
+	return tokens, nil
+}
+
+func (f *fakePII) SavePIITokens(ctx context.Context, session *domain.Session, tokens map[string]string) error {
+	if f.tokens[session.ID] == nil {
+		f.tokens[session.ID] = map[string]string{}
+	}
+	for k, v := range tokens {
+		f.tokens[session.ID][k] = v
+	}
+	return nil
+}
+
+func (f *fakePII) SaveAudits(ctx context.Context, audits []*domain.PIIAudit) error {
+	f.audits = append(f.audits, audits...)
+	return nil
+}
+
+func newTestPII(f *fakePII) *PIIService {
+	scanner := fakeScanner{
+		"a@x.com":     domain.PIIEmail,
+		"b@x.com":     domain.PIIEmail,
+		"13812345678": domain.PIIPhone,
+		"sk-secret":   domain.PIIAPIKey,
+	}
+	return NewPIIService(scanner, f, f, PIIOptions{
+		Actions: map[domain.PIIKind]domain.PIIAction{
+			domain.PIIEmail:  domain.PIITokenize,
+			domain.PIIAPIKey: domain.PIIBlock,
+		},
+	})
+}
+
+func TestPIIScreenTokenizeAcrossTurns(t *testing.T) {
+	ctx := context.Background()
+	f := &fakePII{tokens: map[string]map[string]string{}}
+	s := newTestPII(f)
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	in, err := s.Screen(ctx, "u1", nil, "mail a@x.com or a@x.com, call 13812345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "mail <EMAIL_1> or <EMAIL_1>, call 138****5678"; in.Text != want {
+		t.Fatalf("Text = %q, want %q", in.Text, want)
+	}
+	if err := s.Record(ctx, "u1", session, in); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.audits) != 2 || f.audits[0].Kind != domain.PIIEmail || f.audits[0].Count != 2 ||
+		f.audits[1].Action != domain.PIIMask || f.audits[1].SessionID != "s1" {
+		t.Fatalf("audits = %+v", f.audits)
+	}
+
+	// 后续轮次复用已有占位符，新值继续编号
+	in, err = s.Screen(ctx, "u1", session, "b@x.com and a@x.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<EMAIL_2> and <EMAIL_1>"; in.Text != want {
+		t.Fatalf("Text = %q, want %q", in.Text, want)
+	}
+
+	r := in.Restorer()
+	var out strings.Builder
+	for _, chunk := range []string{"Sent to <EMA", "IL_2> and <EMAIL_1", ">; <b>ok</b> <EMAIL_9> <"} {
+		out.WriteString(r.Write(chunk))
+	}
+	out.WriteString(r.Flush())
+	if want := "Sent to b@x.com and a@x.com; <b>ok</b> <EMAIL_9> <"; out.String() != want {
+		t.Fatalf("restored = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPIIScreenBlock(t *testing.T) {
+	f := &fakePII{tokens: map[string]map[string]string{}}
+	s := newTestPII(f)
+	_, err := s.Screen(context.Background(), "u1", nil, "key sk-secret for a@x.com")
+	if !errors.Is(err, domain.ErrPIIBlocked) {
+		t.Fatalf("err = %v, want ErrPIIBlocked", err)
+	}
+	if len(f.audits) != 2 || f.audits[0].Action != domain.PIIBlock || f.audits[1].Action != domain.PIITokenize {
+		t.Fatalf("audits = %+v", f.audits)
+	}
+	if len(f.tokens) != 0 {
+		t.Fatalf("blocked input saved tokens: %v", f.tokens)
+	}
+}
+
+type recordingExporter struct {
+	title    string
+	messages []string
+}
+
+func (e *recordingExporter) NewWriter(w io.Writer, format domain.ExportFormat, session *domain.Session) (domain.ExportWriter, error) {
+	e.title = session.Title
+	return e, nil
+}
+
+func (e *recordingExporter) WriteMessages(messages []*domain.Message) error {
+	for _, m := range messages {
+		e.messages = append(e.messages, m.Content)
+	}
+	return nil
+}
+
+func (e *recordingExporter) Close() error { return nil }
+
+func TestPIIRedactExporter(t *testing.T) {
+	f := &fakePII{tokens: map[string]map[string]string{}}
+	s := newTestPII(f)
+	inner := &recordingExporter{}
+	session := &domain.Session{ID: "s1", UserID: "u1", Title: "call 13812345678"}
+	ew, err := s.RedactExporter(inner).NewWriter(io.Discard, domain.ExportMarkdown, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &domain.Message{Content: "mail a@x.com"}
+	if err := ew.WriteMessages([]*domain.Message{msg}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if inner.title != "call 138****5678" || inner.messages[0] != "mail a***@x.com" {
+		t.Fatalf("exported title=%q messages=%q", inner.title, inner.messages)
+	}
+	if msg.Content != "mail a@x.com" || session.Title != "call 13812345678" {
+		t.Fatal("redaction modified the source data")
+	}
+	if len(f.audits) != 2 || f.audits[0].Stage != domain.PIIStageExport {
+		t.Fatalf("audits = %+v", f.audits)
+	}
+}
@@ -0,0 +1,270 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// defaultPurgeBatchSize 清理任务每批删除的条数
+const defaultPurgeBatchSize = 500
+
+// RetentionOptions 是服务配置中的默认保留策略与清理参数
+type RetentionOptions struct {
+	// MessageDays 没有单独设置策略的工作空间与个人空间的消息保留天数，0 表示永久保留
+	MessageDays int
+	// PurgeDeletedDays 软删除的记录在多少天后彻底删除，0 表示不清理
+	PurgeDeletedDays int
+	// BatchSize 每批删除的条数，非正时使用默认值
+	BatchSize int
+}
+
+// RetentionRun 是一轮清理的结果
+type RetentionRun struct {
+	ID      string
+	Audits  []*domain.RetentionAudit
+	Elapsed time.Duration
+}
+
+type RetentionService struct {
+	repo     domain.RetentionRepository
+	chatRepo domain.ChatRepository
+	recaller domain.MessageRecaller
+	opts     RetentionOptions
+}
+
+// NewRetentionService creates the retention service. recaller may be nil;
+// otherwise its per-session index is dropped for sessions that lost messages.
+func NewRetentionService(repo domain.RetentionRepository, chatRepo domain.ChatRepository, recaller domain.MessageRecaller, opts RetentionOptions) *RetentionService {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultPurgeBatchSize
+	}
+	return &RetentionService{
+		repo:     repo,
+		chatRepo: chatRepo,
+		recaller: recaller,
+		opts:     opts,
+	}
+}
+
+// GetPolicy 返回请求者当前空间生效的保留策略：工作空间的策略，或用户的个人策略；没有单独设置时为配置中的默认策略
+func (s *RetentionService) GetPolicy(ctx context.Context, userID string) (*domain.RetentionPolicy, error) {
+	workspaceID, ownerID := retentionKey(ctx, userID)
+	policy, err := s.repo.GetRetentionPolicy(ctx, workspaceID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &domain.RetentionPolicy{
+			WorkspaceID: workspaceID,
+			UserID:      ownerID,
+			MessageDays: s.opts.MessageDays,
+			Default:     true,
+		}
+	}
+	return policy, nil
+}
+
+// SetPolicy 设置当前空间的消息保留天数：工作空间的策略只有空间的 owner / admin 可以修改，个人策略由用户自己设置
+func (s *RetentionService) SetPolicy(ctx context.Context, userID string, messageDays int) (*domain.RetentionPolicy, error) {
+	if messageDays < 0 || messageDays > domain.MaxRetentionDays {
+		return nil, domain.ErrInvalidRetention
+	}
+	if err := requireRetentionManager(ctx); err != nil {
+		return nil, err
+	}
+	workspaceID, ownerID := retentionKey(ctx, userID)
+	policy := &domain.RetentionPolicy{
+		WorkspaceID: workspaceID,
+		UserID:      ownerID,
+		MessageDays: messageDays,
+		UpdatedBy:   userID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.repo.SaveRetentionPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Report 试运行清理：统计下一次执行时请求者当前空间中会被彻底删除的数据，不做任何修改
+func (s *RetentionService) Report(ctx context.Context, userID string) (*domain.RetentionReport, error) {
+	if err := requireRetentionManager(ctx); err != nil {
+		return nil, err
+	}
+	policy, err := s.GetPolicy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	report := &domain.RetentionReport{Policy: policy}
+	scope := policy.Scope()
+	if cutoff, ok := policy.MessageCutoff(now); ok {
+		report.MessageCutoff = cutoff
+		if report.ExpiredMessages, err = s.repo.CountExpiredMessages(ctx, scope, cutoff); err != nil {
+			return nil, err
+		}
+	}
+	if cutoff, ok := s.deletedCutoff(now); ok {
+		report.DeletedCutoff = cutoff
+		if report.Deleted, err = s.repo.CountDeleted(ctx, &scope, cutoff); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// Purge 执行一轮清理：先按各空间的策略删除过期消息，再按配置彻底删除超过期限的软删除记录。
+// 每批删除独立提交，中途失败时已删除的部分照常记录审计，下一轮从剩余的数据继续
+func (s *RetentionService) Purge(ctx context.Context) (*RetentionRun, error) {
+	start := time.Now()
+	run := &RetentionRun{ID: uuid.New().String()}
+	policies, err := s.repo.ListRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies = append(policies, &domain.RetentionPolicy{MessageDays: s.opts.MessageDays, Default: true})
+	for _, policy := range policies {
+		cutoff, ok := policy.MessageCutoff(start)
+		if !ok {
+			continue
+		}
+		scope := policy.Scope()
+		scope.Default = policy.Default
+		count, err := s.purgeExpired(ctx, scope, cutoff)
+		s.audit(ctx, run, &domain.RetentionAudit{
+			Kind:         domain.PurgeExpired,
+			Target:       domain.PurgeMessages,
+			WorkspaceID:  scope.WorkspaceID,
+			UserID:       scope.UserID,
+			DefaultScope: scope.Default,
+			Cutoff:       cutoff,
+			Count:        count,
+		})
+		if err != nil {
+			return run, err
+		}
+	}
+	if cutoff, ok := s.deletedCutoff(start); ok {
+		for _, target := range domain.SoftDeletedTargets {
+			count, err := s.purgeDeleted(ctx, target, cutoff)
+			s.audit(ctx, run, &domain.RetentionAudit{
+				Kind:   domain.PurgeDeleted,
+				Target: target,
+				Cutoff: cutoff,
+				Count:  count,
+			})
+			if err != nil {
+				return run, err
+			}
+		}
+	}
+	run.Elapsed = time.Since(start)
+	return run, nil
+}
+
+// Start 每隔 interval 执行一轮清理，直到 ctx 结束
+func (s *RetentionService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run, err := s.Purge(ctx)
+		if err != nil {
+			log.Printf("[ERROR] retention purge failed: %v", err)
+		}
+		if run != nil && len(run.Audits) > 0 {
+			log.Printf("[INFO] retention run %s purged %d groups in %s", run.ID, len(run.Audits), run.Elapsed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpired 分批删除范围内的过期消息，同步清理缓存与召回索引
+func (s *RetentionService) purgeExpired(ctx context.Context, scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		messages, err := s.repo.PurgeExpiredMessages(ctx, scope, cutoff, s.opts.BatchSize)
+		total += int64(len(messages))
+		if len(messages) > 0 {
+			if err := s.chatRepo.EvictMessages(ctx, messages); err != nil {
+				log.Printf("[WARN] evict purged messages failed: %v", err)
+			}
+			if s.recaller != nil {
+				forgotten := make(map[string]bool)
+				for _, m := range messages {
+					if !forgotten[m.SessionID] {
+						forgotten[m.SessionID] = true
+						s.recaller.Forget(m.SessionID)
+					}
+				}
+			}
+		}
+		if err != nil || len(messages) < s.opts.BatchSize {
+			return total, err
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// purgeDeleted 分批彻底删除一类软删除记录；这些记录删除时已清理过缓存
+func (s *RetentionService) purgeDeleted(ctx context.Context, target domain.PurgeTarget, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		n, err := s.repo.PurgeDeleted(ctx, target, cutoff, s.opts.BatchSize)
+		total += n
+		if err != nil || n < int64(s.opts.BatchSize) {
+			return total, err
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// audit 记录实际删除了数据的清理，审计写入失败不影响清理本身
+func (s *RetentionService) audit(ctx context.Context, run *RetentionRun, a *domain.RetentionAudit) {
+	if a.Count == 0 {
+		return
+	}
+	a.ID = uuid.New().String()
+	a.RunID = run.ID
+	a.CreatedAt = time.Now()
+	run.Audits = append(run.Audits, a)
+	if err := s.repo.SaveRetentionAudit(ctx, a); err != nil {
+		log.Printf("[ERROR] save retention audit failed: %v", err)
+	}
+}
+
+func (s *RetentionService) deletedCutoff(now time.Time) (time.Time, bool) {
+	if s.opts.PurgeDeletedDays <= 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -s.opts.PurgeDeletedDays), true
+}
+
+// retentionKey 返回请求者当前空间的策略键：工作空间中为 (空间, "")，个人空间中为 ("", 用户)
+func retentionKey(ctx context.Context, userID string) (workspaceID, ownerID string) {
+	if ws := domain.TenantFromContext(ctx).WorkspaceID; ws != "" {
+		return ws, ""
+	}
+	return "", userID
+}
+
+// requireRetentionManager 工作空间的保留策略与清理报告只对空间的 owner / admin 开放
+func requireRetentionManager(ctx context.Context) error {
+	tenant := domain.TenantFromContext(ctx)
+	if tenant.WorkspaceID != "" && !tenant.IsWorkspaceAdmin() {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// fakeRetention 按批次返回预设的过期消息与软删除条数
+type fakeRetention struct {
+	policies []*domain.RetentionPolicy
+	expired  map[domain.RetentionScope][]*domain.Message
+	deleted  map[domain.PurgeTarget]int64
+	audits   []*domain.RetentionAudit
+	saved    *domain.RetentionPolicy
+}
+
+func (f *fakeRetention) GetRetentionPolicy(ctx context.Context, workspaceID, userID string) (*domain.RetentionPolicy, error) {
+	for _, p := range f.policies {
+		if p.WorkspaceID == workspaceID && p.UserID == userID {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRetention) SaveRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) error {
+	f.saved = policy
+	return nil
+}
+
+func (f *fakeRetention) ListRetentionPolicies(ctx context.Context) ([]*domain.RetentionPolicy, error) {
+	return f.policies, nil
+}
+
+func (f *fakeRetention) CountExpiredMessages(ctx context.Context, scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	return int64(len(f.expired[scope])), nil
+}
+
+func (f *fakeRetention) PurgeExpiredMessages(ctx context.Context, scope domain.RetentionScope, cutoff time.Time, limit int) ([]*domain.Message, error) {
+	batch := f.expired[scope]
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	f.expired[scope] = f.expired[scope][len(batch):]
+	return batch, nil
+}
+
+func (f *fakeRetention) CountDeleted(ctx context.Context, scope *domain.RetentionScope, cutoff time.Time) ([]domain.PurgeCount, error) {
+	return nil, nil
+}
+
+func (f *fakeRetention) PurgeDeleted(ctx context.Context, target domain.PurgeTarget, cutoff time.Time, limit int) (int64, error) {
+	n := f.deleted[target]
+	if n > int64(limit) {
+		n = int64(limit)
+	}
+	f.deleted[target] -= n
+	return n, nil
+}
+
+func (f *fakeRetention) SaveRetentionAudit(ctx context.Context, audit *domain.RetentionAudit) error {
+	f.audits = append(f.audits, audit)
+	return nil
+}
+
+type fakeEvicter struct {
+	domain.ChatRepository
+	evicted int
+}
+
+func (f *fakeEvicter) EvictMessages(ctx context.Context, messages []*domain.Message) error {
+	f.evicted += len(messages)
+	return nil
+}
+
+func TestRetentionSetPolicy(t *testing.T) {
+	repo := &fakeRetention{}
+	svc := NewRetentionService(repo, &fakeEvicter{}, nil, RetentionOptions{MessageDays: 30})
+	ctx := context.Background()
+	memberCtx := domain.WithTenant(ctx, domain.Tenant{WorkspaceID: "ws1", Role: domain.WorkspaceMember})
+	adminCtx := domain.WithTenant(ctx, domain.Tenant{WorkspaceID: "ws1", Role: domain.WorkspaceAdmin})
+
+	policy, err := svc.GetPolicy(ctx, "alice")
+	if err != nil || !policy.Default || policy.MessageDays != 30 || policy.UserID != "alice" {
+		t.Fatalf("default policy = %+v, %v", policy, err)
+	}
+	if _, err := svc.SetPolicy(ctx, "alice", -1); !errors.Is(err, domain.ErrInvalidRetention) {
+		t.Errorf("negative days: got %v", err)
+	}
+	if _, err := svc.SetPolicy(ctx, "alice", domain.MaxRetentionDays+1); !errors.Is(err, domain.ErrInvalidRetention) {
+		t.Errorf("too many days: got %v", err)
+	}
+	if _, err := svc.SetPolicy(memberCtx, "bob", 7); !errors.Is(err, domain.ErrPermissionDenied) {
+		t.Errorf("workspace member: got %v", err)
+	}
+	if _, err := svc.Report(memberCtx, "bob"); !errors.Is(err, domain.ErrPermissionDenied) {
+		t.Errorf("workspace member report: got %v", err)
+	}
+	if _, err := svc.SetPolicy(adminCtx, "olga", 7); err != nil {
+		t.Fatalf("workspace admin: %v", err)
+	}
+	if repo.saved.WorkspaceID != "ws1" || repo.saved.UserID != "" || repo.saved.UpdatedBy != "olga" {
+		t.Errorf("workspace policy saved as %+v", repo.saved)
+	}
+	if _, err := svc.SetPolicy(ctx, "alice", 0); err != nil {
+		t.Fatalf("personal policy: %v", err)
+	}
+	if repo.saved.WorkspaceID != "" || repo.saved.UserID != "alice" {
+		t.Errorf("personal policy saved as %+v", repo.saved)
+	}
+}
+
+func TestRetentionPurge(t *testing.T) {
+	ws := domain.RetentionScope{WorkspaceID: "ws1"}
+	def := domain.RetentionScope{Default: true}
+	forever := domain.RetentionScope{UserID: "carol"}
+	messages := func(n int) []*domain.Message {
+		out := make([]*domain.Message, n)
+		for i := range out {
+			out[i] = &domain.Message{ID: "m", SessionID: "s"}
+		}
+		return out
+	}
+	repo := &fakeRetention{
+		policies: []*domain.RetentionPolicy{
+			{WorkspaceID: "ws1", MessageDays: 7},
+			{UserID: "carol", MessageDays: 0},
+		},
+		expired: map[domain.RetentionScope][]*domain.Message{
+			ws:      messages(5),
+			def:     messages(2),
+			forever: messages(3),
+		},
+		deleted: map[domain.PurgeTarget]int64{domain.PurgeSessions: 4},
+	}
+	evicter := &fakeEvicter{}
+	svc := NewRetentionService(repo, evicter, nil, RetentionOptions{MessageDays: 90, PurgeDeletedDays: 30, BatchSize: 2})
+
+	run, err := svc.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if len(repo.expired[forever]) != 3 {
+		t.Errorf("keep-forever policy purged messages")
+	}
+	if evicter.evicted != 7 {
+		t.Errorf("evicted %d messages, want 7", evicter.evicted)
+	}
+	want := map[domain.PurgeTarget]int64{domain.PurgeMessages: 7, domain.PurgeSessions: 4}
+	got := make(map[domain.PurgeTarget]int64)
+	for _, a := range run.Audits {
+		if a.RunID != run.ID {
+			t.Errorf("audit %s has run %s, want %s", a.ID, a.RunID, run.ID)
+		}
+		got[a.Target] += a.Count
+	}
+	for target, n := range want {
+		if got[target] != n {
+			t.Errorf("audited %d %s, want %d", got[target], target, n)
+		}
+	}
+	if len(run.Audits) != 3 || len(repo.audits) != 3 {
+		t.Errorf("got %d audits (%d saved), want 3", len(run.Audits), len(repo.audits))
+	}
+}
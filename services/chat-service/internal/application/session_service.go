@@ -0,0 +1,215 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxSessionTitleRunes 会话标题的最大长度，与 CreateSession 一致
+	maxSessionTitleRunes = 50
+	// maxFolderNameRunes 文件夹名称的最大长度
+	maxFolderNameRunes = 64
+)
+
+// SessionService 负责会话的整理：重命名、置顶、归档、标签、文件夹管理以及分叉
+type SessionService struct {
+	chatRepo   domain.ChatRepository
+	folderRepo domain.FolderRepository
+	policy     SessionPolicy
+}
+
+func NewSessionService(chatRepo domain.ChatRepository, folderRepo domain.FolderRepository, policy SessionPolicy) *SessionService {
+	return &SessionService{
+		chatRepo:   chatRepo,
+		folderRepo: folderRepo,
+		policy:     policy,
+	}
+}
+
+// UpdateSession 按 update 修改会话，返回修改后的会话；目标文件夹必须属于会话所有者且与会话在同一工作空间
+func (s *SessionService) UpdateSession(ctx context.Context, userID, sessionID string, update domain.SessionUpdate) (*domain.Session, error) {
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionManage)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePersistent(session); err != nil {
+		return nil, err
+	}
+
+	if update.Title != nil {
+		title := strings.TrimSpace(*update.Title)
+		if title == "" {
+			return nil, domain.ErrInvalidSession
+		}
+		session.SetTitle(title, maxSessionTitleRunes)
+	}
+	if update.Pinned != nil {
+		session.Pinned = *update.Pinned
+	}
+	if update.Archived != nil {
+		session.Archived = *update.Archived
+	}
+	if update.FolderID != nil {
+		if *update.FolderID != "" {
+			if _, err := s.ownedFolder(ctx, session.UserID, session.WorkspaceID, *update.FolderID); err != nil {
+				return nil, err
+			}
+		}
+		session.FolderID = *update.FolderID
+	}
+	if update.Tags != nil {
+		tags, err := domain.NormalizeTags(*update.Tags)
+		if err != nil {
+			return nil, err
+		}
+		session.Tags = tags
+	}
+
+	if err := s.chatRepo.UpdateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	session.UpdatedAt = time.Now()
+	return session, nil
+}
+
+// ForkSession 从 messageID 处分叉出一个属于 userID 的新会话。新会话复制到该消息为止（含）的历史，
+// 沿用原消息的作者、token 数、置顶标记与创建时间，以及原会话的工作空间、标题、文件夹和标签；
+// 会话的最后活动时间取分叉时间，让新分支出现在列表顶部。
+func (s *SessionService) ForkSession(ctx context.Context, userID, sessionID, messageID string) (*domain.Session, error) {
+	origin, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionPost)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePersistent(origin); err != nil {
+		return nil, err
+	}
+	at, err := s.chatRepo.GetMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if at == nil || at.SessionID != sessionID {
+		return nil, domain.ErrMessageNotFound
+	}
+
+	history, err := s.chatRepo.GetSessionMessagesUntil(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 || history[len(history)-1].ID != messageID {
+		// 消息经 MQ 异步落库，分叉点还没写入数据库
+		return nil, domain.ErrForkNotReady
+	}
+
+	now := time.Now()
+	fork := &domain.Session{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		WorkspaceID:   origin.WorkspaceID,
+		Title:         origin.Title,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		LastMessageAt: now,
+		MessageCount:  len(history),
+		Preview:       domain.MessagePreview(at.Content),
+		FolderID:      origin.FolderID,
+		Tags:          append([]string(nil), origin.Tags...),
+		ForkedFrom:    origin.ID,
+		ForkMessageID: messageID,
+	}
+	// 副本 ID 使用单调递增的 UUIDv7：创建时间相同的消息按 (created_at, message_id) 排序时仍保持原顺序。
+	// 多人会话中的发言保留原作者与发言人名称
+	copies := make([]*domain.Message, len(history))
+	for i, m := range history {
+		copies[i] = &domain.Message{
+			ID:         uuid.Must(uuid.NewV7()).String(),
+			SessionID:  fork.ID,
+			UserID:     m.UserID,
+			Role:       m.Role,
+			Content:    m.Content,
+			TokenCount: m.TokenCount,
+			Pinned:     m.Pinned,
+			Model:      m.Model,
+			AuthorName: m.AuthorName,
+			CreatedAt:  m.CreatedAt,
+		}
+	}
+
+	if err := s.chatRepo.ForkSession(ctx, fork, copies); err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// CreateFolder 为用户创建文件夹
+func (s *SessionService) CreateFolder(ctx context.Context, userID, name string) (*domain.Folder, error) {
+	name, err := folderName(name)
+	if err != nil {
+		return nil, err
+	}
+	folder := &domain.Folder{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		WorkspaceID: domain.TenantFromContext(ctx).WorkspaceID,
+		Name:        name,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.folderRepo.SaveFolder(ctx, folder); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// ListFolders 返回用户在当前工作空间中的全部文件夹
+func (s *SessionService) ListFolders(ctx context.Context, userID string) ([]*domain.Folder, error) {
+	return s.folderRepo.ListFolders(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID)
+}
+
+// RenameFolder 重命名用户自己的文件夹
+func (s *SessionService) RenameFolder(ctx context.Context, userID, folderID, name string) error {
+	name, err := folderName(name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ownedFolder(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID, folderID); err != nil {
+		return err
+	}
+	return s.folderRepo.RenameFolder(ctx, folderID, name)
+}
+
+// DeleteFolder 删除文件夹，其中的会话移出文件夹而不会被删除
+func (s *SessionService) DeleteFolder(ctx context.Context, userID, folderID string) error {
+	if _, err := s.ownedFolder(ctx, userID, domain.TenantFromContext(ctx).WorkspaceID, folderID); err != nil {
+		return err
+	}
+	if err := s.chatRepo.ClearSessionFolder(ctx, userID, folderID); err != nil {
+		return err
+	}
+	return s.folderRepo.DeleteFolder(ctx, folderID)
+}
+
+// ownedFolder 返回用户在工作空间中的文件夹；不存在、属于他人或其他工作空间时都视为不存在
+func (s *SessionService) ownedFolder(ctx context.Context, userID, workspaceID, folderID string) (*domain.Folder, error) {
+	folder, err := s.folderRepo.GetFolder(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if folder == nil || folder.UserID != userID || folder.WorkspaceID != workspaceID {
+		return nil, domain.ErrFolderNotFound
+	}
+	return folder, nil
+}
+
+func folderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxFolderNameRunes {
+		return "", domain.ErrInvalidFolder
+	}
+	return name, nil
+}
@@ -0,0 +1,152 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// fakeChatRepo 中 messages 相当于缓存（刚保存的消息立即可读），persisted 是已经经 MQ 写入数据库的消息，按时间正序
+type fakeChatRepo struct {
+	domain.ChatRepository
+	sessions  map[string]*domain.Session
+	messages  map[string]*domain.Message
+	persisted []*domain.Message
+	forked    *domain.Session
+	copies    []*domain.Message
+}
+
+func (f *fakeChatRepo) GetSession(ctx context.Context, sessionID string) (*domain.Session, error) {
+	return f.sessions[sessionID], nil
+}
+
+func (f *fakeChatRepo) GetMessage(ctx context.Context, messageID string) (*domain.Message, error) {
+	return f.messages[messageID], nil
+}
+
+func (f *fakeChatRepo) GetSessionMessagesUntil(ctx context.Context, until *domain.Message) ([]*domain.Message, error) {
+	var history []*domain.Message
+	for _, m := range f.persisted {
+		if m.SessionID != until.SessionID {
+			continue
+		}
+		history = append(history, m)
+		if m.ID == until.ID {
+			break
+		}
+	}
+	return history, nil
+}
+
+func (f *fakeChatRepo) ForkSession(ctx context.Context, session *domain.Session, messages []*domain.Message) error {
+	f.forked, f.copies = session, messages
+	return nil
+}
+
+func newForkFixture() *fakeChatRepo {
+	at := time.Now().Add(-time.Hour).Truncate(time.Second)
+	// 同一秒内保存的问答，按 ID 排序时与原顺序相反
+	history := []*domain.Message{
+		{ID: "m9", SessionID: "s1", UserID: "alice", AuthorName: "Alice", Role: domain.RoleUser, Content: "question", CreatedAt: at},
+		{ID: "m5", SessionID: "s1", UserID: "alice", Role: domain.RoleAssistant, Content: "answer", Model: "m", CreatedAt: at},
+		{ID: "m1", SessionID: "s1", UserID: "erin", AuthorName: "Erin", Role: domain.RoleUser, Content: "follow-up", CreatedAt: at},
+	}
+	repo := &fakeChatRepo{
+		sessions: map[string]*domain.Session{
+			"s1": {ID: "s1", UserID: "alice", Title: "plan", Tags: []string{"work"}},
+			"s2": {ID: "s2", UserID: "alice", Ephemeral: true},
+		},
+		messages:  map[string]*domain.Message{"other": {ID: "other", SessionID: "s3"}},
+		persisted: history,
+	}
+	for _, m := range history {
+		repo.messages[m.ID] = m
+	}
+	return repo
+}
+
+func TestForkSession(t *testing.T) {
+	ctx := context.Background()
+	repo := newForkFixture()
+	participants := fakeParticipants{
+		"s1/erin": {SessionID: "s1", UserID: "erin", Role: domain.ParticipantEditor},
+		"s1/vic":  {SessionID: "s1", UserID: "vic", Role: domain.ParticipantViewer},
+	}
+	s := NewSessionService(repo, nil, NewSessionPolicy(nil, participants))
+
+	// 协作者分叉：新会话属于分叉者，每条发言保留原作者
+	fork, err := s.ForkSession(ctx, "erin", "s1", "m1")
+	if err != nil {
+		t.Fatalf("ForkSession: %v", err)
+	}
+	if fork.UserID != "erin" || fork.ForkedFrom != "s1" || fork.ForkMessageID != "m1" || fork.MessageCount != 3 ||
+		fork.Title != "plan" || fork.Preview != "follow-up" || !slices.Equal(fork.Tags, []string{"work"}) {
+		t.Fatalf("fork = %+v", fork)
+	}
+	if repo.forked != fork || len(repo.copies) != 3 {
+		t.Fatalf("saved %+v with %d messages", repo.forked, len(repo.copies))
+	}
+	for i, c := range repo.copies {
+		m := repo.persisted[i]
+		if c.SessionID != fork.ID || c.ID == m.ID || c.UserID != m.UserID || c.AuthorName != m.AuthorName ||
+			c.Content != m.Content || c.Role != m.Role || !c.CreatedAt.Equal(m.CreatedAt) {
+			t.Fatalf("copy %d = %+v, original %+v", i, c, m)
+		}
+	}
+
+	// 创建时间相同的副本按 (created_at, message_id) 排序后仍是原顺序
+	sorted := slices.Clone(repo.copies)
+	slices.SortFunc(sorted, func(a, b *domain.Message) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	for i := range sorted {
+		if sorted[i] != repo.copies[i] {
+			t.Fatalf("copy order changed at %d: %s", i, sorted[i].Content)
+		}
+	}
+}
+
+func TestForkSessionRejected(t *testing.T) {
+	ctx := context.Background()
+	participants := fakeParticipants{"s1/vic": {SessionID: "s1", UserID: "vic", Role: domain.ParticipantViewer}}
+
+	cases := []struct {
+		name      string
+		userID    string
+		sessionID string
+		messageID string
+		prepare   func(*fakeChatRepo)
+		want      error
+	}{
+		{"stranger is told nothing", "bob", "s1", "m1", nil, domain.ErrSessionNotFound},
+		{"viewer cannot fork", "vic", "s1", "m1", nil, domain.ErrPermissionDenied},
+		{"ephemeral session", "alice", "s2", "m1", nil, domain.ErrEphemeralSession},
+		{"message of another session", "alice", "s1", "other", nil, domain.ErrMessageNotFound},
+		{"missing message", "alice", "s1", "missing", nil, domain.ErrMessageNotFound},
+		// 分叉点已在缓存中，但还没经 MQ 写入数据库
+		{"fork point not persisted", "alice", "s1", "m1", func(f *fakeChatRepo) {
+			f.persisted = f.persisted[:2]
+		}, domain.ErrForkNotReady},
+	}
+	for _, tc := range cases {
+		repo := newForkFixture()
+		if tc.prepare != nil {
+			tc.prepare(repo)
+		}
+		s := NewSessionService(repo, nil, NewSessionPolicy(nil, participants))
+		if _, err := s.ForkSession(ctx, tc.userID, tc.sessionID, tc.messageID); !errors.Is(err, tc.want) {
+			t.Errorf("%s: ForkSession() = %v, want %v", tc.name, err, tc.want)
+		}
+		if repo.forked != nil {
+			t.Errorf("%s: fork saved", tc.name)
+		}
+	}
+}
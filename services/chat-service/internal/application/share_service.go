@@ -0,0 +1,184 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// shareTokenBytes 分享令牌的随机字节数（256 位），编码后为 43 个字符
+	shareTokenBytes = 32
+	// maxShareFutureMessages 查看分享时最多追加的新消息数
+	maxShareFutureMessages = 500
+)
+
+// ShareService 负责会话的只读公开分享：创建快照、列出、撤销以及通过令牌查看
+type ShareService struct {
+	chatRepo  domain.ChatRepository
+	shareRepo domain.ShareRepository
+	policy    SessionPolicy
+}
+
+func NewShareService(chatRepo domain.ChatRepository, shareRepo domain.ShareRepository, policy SessionPolicy) *ShareService {
+	return &ShareService{
+		chatRepo:  chatRepo,
+		shareRepo: shareRepo,
+		policy:    policy,
+	}
+}
+
+// CreateShare 为用户自己的会话创建分享，复制当前已落库的全部消息作为快照。
+// expiresAt 为零值表示永不过期；返回的令牌只在此时可见，数据库中只保存其哈希。
+func (s *ShareService) CreateShare(ctx context.Context, userID, sessionID string, expiresAt time.Time, includeFuture bool) (*domain.Share, string, error) {
+	session, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionShare)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := requirePersistent(session); err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return nil, "", domain.ErrInvalidShare
+	}
+
+	var (
+		messages []*domain.Message
+		after    *domain.Message
+	)
+	for {
+		batch, err := s.chatRepo.GetSessionMessagesAfter(ctx, sessionID, after, exportBatchSize)
+		if err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, batch...)
+		if len(batch) < exportBatchSize {
+			break
+		}
+		after = batch[len(batch)-1]
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, "", err
+	}
+	share := &domain.Share{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		SessionID:     sessionID,
+		TokenHash:     domain.HashShareToken(token),
+		Title:         session.Title,
+		IncludeFuture: includeFuture,
+		MessageCount:  len(messages),
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+	}
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		share.LastMessageID = last.ID
+		share.LastMessageAt = last.CreatedAt
+	}
+	if err := s.shareRepo.SaveShare(ctx, share, messages); err != nil {
+		return nil, "", err
+	}
+	return share, token, nil
+}
+
+// ListShares 返回会话的全部分享
+func (s *ShareService) ListShares(ctx context.Context, userID, sessionID string) ([]*domain.Share, error) {
+	if _, err := authorizeSession(ctx, s.chatRepo, s.policy, UserPrincipal(ctx, userID), sessionID, ActionManage); err != nil {
+		return nil, err
+	}
+	return s.shareRepo.ListShares(ctx, sessionID)
+}
+
+// RevokeShare 撤销分享，之后通过令牌访问将返回不存在；重复撤销不报错
+func (s *ShareService) RevokeShare(ctx context.Context, userID, shareID string) error {
+	share, err := s.shareRepo.GetShare(ctx, shareID)
+	if err != nil {
+		return err
+	}
+	if share == nil {
+		return domain.ErrShareNotFound
+	}
+	session, err := s.chatRepo.GetSession(ctx, share.SessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		// 原会话已删除，按分享创建时的所有者判断，分享者仍然可以撤销遗留的链接；
+		// 会话所属的工作空间已无从得知，视为请求所在的空间
+		session = &domain.Session{ID: share.SessionID, UserID: share.UserID, WorkspaceID: domain.TenantFromContext(ctx).WorkspaceID}
+	}
+	if err := s.policy.Authorize(ctx, UserPrincipal(ctx, userID), session, ActionManage); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return domain.ErrShareNotFound
+		}
+		return err
+	}
+	if !share.RevokedAt.IsZero() {
+		return nil
+	}
+	return s.shareRepo.RevokeShare(ctx, shareID, time.Now())
+}
+
+// ViewShare 通过令牌读取分享，不需要登录。不存在、已过期或已撤销的分享一律返回 ErrShareNotFound。
+func (s *ShareService) ViewShare(ctx context.Context, token string) (*domain.SharedSession, error) {
+	if token == "" {
+		return nil, domain.ErrShareNotFound
+	}
+	share, err := s.shareRepo.GetShareByToken(ctx, domain.HashShareToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if share == nil || !share.Active(time.Now()) {
+		return nil, domain.ErrShareNotFound
+	}
+	messages, err := s.shareRepo.GetShareMessages(ctx, share.ID)
+	if err != nil {
+		return nil, err
+	}
+	shared := &domain.SharedSession{Share: share, Messages: messages}
+	if !share.IncludeFuture {
+		return shared, nil
+	}
+
+	// 追加新消息相当于以分享访客身份查看原会话；原会话已删除（或不再属于分享者）时只展示快照
+	session, err := s.chatRepo.GetSession(ctx, share.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if s.policy.Authorize(ctx, Principal{Share: share}, session, ActionView) != nil {
+		return shared, nil
+	}
+	var after *domain.Message
+	if share.LastMessageID != "" {
+		after = &domain.Message{ID: share.LastMessageID, CreatedAt: share.LastMessageAt}
+	}
+	future, err := s.chatRepo.GetSessionMessagesAfter(ctx, share.SessionID, after, maxShareFutureMessages+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(future) > maxShareFutureMessages {
+		future = future[:maxShareFutureMessages]
+		shared.Truncated = true
+	}
+	shared.Messages = append(shared.Messages, future...)
+	return shared, nil
+}
+
+// newShareToken 生成 URL 安全的随机令牌
+func newShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+)
+
+// AccountProfile 是 auth-service 中的用户资料，原样写入数据导出包
+type AccountProfile struct {
+	UserID     string             `json:"user_id"`
+	Username   string             `json:"username"`
+	Email      string             `json:"email"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Workspaces []AccountWorkspace `json:"workspaces"`
+}
+
+// AccountWorkspace 是用户所在的一个工作空间及其角色
+type AccountWorkspace struct {
+	WorkspaceID string    `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ExportStatus 是账户数据导出任务的状态
+type ExportStatus string
+
+const (
+	ExportPending ExportStatus = "pending"
+	ExportReady   ExportStatus = "ready"
+	ExportFailed  ExportStatus = "failed"
+)
+
+// AccountExport 是一次异步的账户数据导出，完成后压缩包保存到 ExpiresAt。
+// 压缩包在 ExportStore 中以导出 ID 为键，数据库只记录任务状态
+type AccountExport struct {
+	ID          string
+	UserID      string
+	Status      ExportStatus
+	Size        int64
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// ErasureStep 是账户删除的一个步骤，每一步都可以重复执行
+type ErasureStep string
+
+const (
+	// ErasureAuth 删除 auth-service 中的用户与工作空间成员资格，之后无法再登录或刷新令牌
+	ErasureAuth ErasureStep = "auth"
+	// ErasureKeys 销毁用户个人范围的数据密钥（加密粉碎），之后库中、缓存中与备份中的密文都无法解密
+	ErasureKeys ErasureStep = "keys"
+	// ErasureDatabase 分批删除 PostgreSQL 中的用户数据，并清理对应的消息与会话缓存
+	ErasureDatabase ErasureStep = "database"
+	// ErasureCache 删除用户的会话列表缓存
+	ErasureCache ErasureStep = "cache"
+	// ErasureDrain 等到 DrainUntil（MQ 在途事件已消费、旧的访问令牌已过期）后再清扫一遍数据库与缓存，并再次销毁数据密钥
+	ErasureDrain ErasureStep = "drain"
+)
+
+// ErasureSteps 是账户删除的步骤，按顺序执行
+var ErasureSteps = []ErasureStep{ErasureAuth, ErasureKeys, ErasureDatabase, ErasureCache, ErasureDrain}
+
+// ErasureTarget 是账户删除时清理的一类数据
+type ErasureTarget string
+
+const (
+	EraseMessages     ErasureTarget = "messages"
+	EraseSessions     ErasureTarget = "sessions"
+	EraseParticipants ErasureTarget = "participants"
+	EraseShares       ErasureTarget = "shares"
+	EraseFolders      ErasureTarget = "folders"
+	EraseMemories     ErasureTarget = "memories"
+	EraseDocuments    ErasureTarget = "documents"
+	EraseCollections  ErasureTarget = "collections"
+	EraseEmbeddings   ErasureTarget = "embeddings"
+	EraseSettings     ErasureTarget = "settings"
+	EraseExports      ErasureTarget = "exports"
+	EraseFlags        ErasureTarget = "moderation_flags"
+	ErasePIIAudits    ErasureTarget = "pii_audits"
+)
+
+// ErasureTargets 是按顺序清理的数据：消息要在会话之前删除，才能找到用户会话中他人的消息
+var ErasureTargets = []ErasureTarget{
+	EraseMessages, EraseSessions, EraseParticipants, EraseShares, EraseFolders, EraseMemories,
+	EraseDocuments, EraseCollections, EraseEmbeddings, EraseSettings, EraseExports, EraseFlags, ErasePIIAudits,
+}
+
+// AccountErasure 是账户删除任务，同时也是删除完成后保留的确认记录，只包含用户 ID 与各类数据的删除条数
+type AccountErasure struct {
+	ID          string
+	UserID      string
+	Done        []ErasureStep
+	Purged      map[ErasureTarget]int64
+	LastError   string
+	RequestedAt time.Time
+	DrainUntil  time.Time
+	CompletedAt time.Time
+}
+
+// ErasureStatus 是账户删除任务对外展示的状态
+type ErasureStatus string
+
+const (
+	ErasureRunning   ErasureStatus = "running"
+	ErasureDraining  ErasureStatus = "draining"
+	ErasureCompleted ErasureStatus = "completed"
+)
+
+// Status 返回任务状态：只剩清扫步骤时为 draining
+func (e *AccountErasure) Status() ErasureStatus {
+	switch step, _ := e.NextStep(); {
+	case e.Completed():
+		return ErasureCompleted
+	case step == ErasureDrain:
+		return ErasureDraining
+	}
+	return ErasureRunning
+}
+
+// Completed 返回全部步骤是否已完成
+func (e *AccountErasure) Completed() bool {
+	return !e.CompletedAt.IsZero()
+}
+
+// NextStep 返回第一个未完成的步骤。按步骤名而不是个数判断，新增步骤后旧任务也会补做
+func (e *AccountErasure) NextStep() (ErasureStep, bool) {
+	for _, step := range ErasureSteps {
+		if !slices.Contains(e.Done, step) {
+			return step, true
+		}
+	}
+	return "", false
+}
+
+// AccountDirectory 是 auth-service 中的账户资料
+type AccountDirectory interface {
+	GetProfile(ctx context.Context, userID string) (*AccountProfile, error)
+	// DeleteUser 删除用户及其工作空间成员资格，用户不存在时同样成功
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// ExportStore 保存账户导出的压缩包。压缩包可能有数百 MB，生成与下载都以流的方式读写
+type ExportStore interface {
+	// Create 打开一个新的压缩包用于写入，Close 成功之后才能读取
+	Create(ctx context.Context, userID, exportID string) (io.WriteCloser, error)
+	// Open 压缩包不存在时返回 ErrExportNotFound
+	Open(ctx context.Context, userID, exportID string) (io.ReadCloser, error)
+	// Delete 压缩包不存在时同样成功
+	Delete(ctx context.Context, userID, exportID string) error
+	// DeleteUser 删除用户的全部压缩包，包括未写完的
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// AccountRepository 定义账户数据导出与账户删除的存取
+type AccountRepository interface {
+	SaveExport(ctx context.Context, export *AccountExport) error
+	// CompleteExport 保存导出结果
+	CompleteExport(ctx context.Context, export *AccountExport) error
+	// GetExport 不存在时返回 ErrExportNotFound
+	GetExport(ctx context.Context, exportID string) (*AccountExport, error)
+	// DeleteExports 删除用户之前的全部导出
+	DeleteExports(ctx context.Context, userID string) error
+	// DeleteExpiredExports 删除 now 时已过期的导出，以及 createdBefore 之前创建但没有完成的导出，返回被删除的导出
+	DeleteExpiredExports(ctx context.Context, now, createdBefore time.Time) ([]*AccountExport, error)
+	// ListUserSessions 按 id 正序返回用户在所有工作空间中 afterID 之后的至多 limit 个未删除会话
+	ListUserSessions(ctx context.Context, userID, afterID string, limit int) ([]*Session, error)
+
+	// GetErasure 没有删除任务时返回 nil
+	GetErasure(ctx context.Context, userID string) (*AccountErasure, error)
+	// CreateErasure 已存在时不覆盖，返回已有的任务
+	CreateErasure(ctx context.Context, erasure *AccountErasure) (*AccountErasure, error)
+	SaveErasure(ctx context.Context, erasure *AccountErasure) error
+	// ListPendingErasures 返回未完成的删除任务
+	ListPendingErasures(ctx context.Context) ([]*AccountErasure, error)
+	// IsErased 返回用户是否已提交账户删除
+	IsErased(ctx context.Context, userID string) (bool, error)
+	// EraseMessages 彻底删除一批用户发送的消息以及用户会话中的全部消息，返回被删除的消息
+	EraseMessages(ctx context.Context, userID string, limit int) ([]*Message, error)
+	// EraseSessions 彻底删除一批用户的会话（含已软删除的），返回被删除的会话
+	EraseSessions(ctx context.Context, userID string, limit int) ([]*Session, error)
+	// EraseRows 彻底删除一批其他类别的用户数据，返回删除的条数
+	EraseRows(ctx context.Context, userID string, target ErasureTarget, limit int) (int64, error)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// KeyScope 返回数据密钥的范围：工作空间中的会话与消息使用工作空间的密钥，个人空间使用会话所有者的密钥
+func KeyScope(workspaceID, userID string) string {
+	if workspaceID != "" {
+		return "workspace:" + workspaceID
+	}
+	return "user:" + userID
+}
+
+// DataKey 是某个范围的一个数据密钥版本，密钥本身只以被主密钥包装的形式保存
+type DataKey struct {
+	Scope   string
+	Version int
+	// RetiredAt 轮换后为新版本生效的时间，仍用于解密旧数据，直到重新加密完成
+	RetiredAt time.Time
+	// Destroyed 的密钥已被加密粉碎，用它加密的数据无法再解密
+	Destroyed bool
+	CreatedAt time.Time
+}
+
+// Current 返回是否为加密新数据使用的版本
+func (k *DataKey) Current() bool {
+	return k.RetiredAt.IsZero() && !k.Destroyed
+}
+
+// KeyManager manages the envelope-encrypted data keys that protect message
+// content and session titles at rest.
+type KeyManager interface {
+	// Keys 按版本正序返回范围内的全部数据密钥
+	Keys(ctx context.Context, scope string) ([]*DataKey, error)
+	// Rotate 创建新版本作为当前密钥，旧版本仍可解密，由后台任务逐步重新加密
+	Rotate(ctx context.Context, scope string) (*DataKey, error)
+	// Shred 销毁范围内的全部数据密钥（加密粉碎），返回销毁的版本数
+	Shred(ctx context.Context, scope string) (int64, error)
+	// RewrapKeys 用当前主密钥重新包装由旧主密钥包装的数据密钥，返回重新包装的个数
+	RewrapKeys(ctx context.Context) (int, error)
+}
+
+// EncryptTarget 是静态加密的一类数据
+type EncryptTarget string
+
+const (
+	// EncryptMessages 消息内容及其检索文本
+	EncryptMessages EncryptTarget = "messages"
+	// EncryptSessions 会话标题与摘要
+	EncryptSessions EncryptTarget = "sessions"
+	// EncryptShares 分享的标题与消息快照
+	EncryptShares EncryptTarget = "shares"
+	// EncryptMemories 长期记忆内容
+	EncryptMemories EncryptTarget = "memories"
+	// EncryptDocuments 文档分块内容
+	EncryptDocuments EncryptTarget = "documents"
+)
+
+// EncryptTargets 是重新加密时依次检查的数据
+var EncryptTargets = []EncryptTarget{EncryptMessages, EncryptSessions, EncryptShares, EncryptMemories, EncryptDocuments}
+
+// EncryptionRepository 定义重新加密的存取
+type EncryptionRepository interface {
+	// Reencrypt 检查 id 大于 afterID 的至多 limit 行，用当前数据密钥重新加密其中仍为明文或使用旧版本密钥的行，
+	// 返回最后检查的 id（没有更多行时等于 afterID）与重写的行数
+	Reencrypt(ctx context.Context, target EncryptTarget, afterID uint, limit int) (uint, int, error)
+}
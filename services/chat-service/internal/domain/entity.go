@@ -1,7 +1,11 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Role string
@@ -23,6 +27,15 @@ type Message struct {
 	Role      Role
 	Content   string
 	TokenCount int
+	Pinned     bool
+	// Model 生成该回复的模型，用户消息为空
+	Model string
+	// AuthorName 用户消息发言人的显示名，多人会话中用于区分发言人；助手消息为空
+	AuthorName string
+	// Recalled 标记由长期召回带回的旧消息，仅用于上下文构建，不持久化
+	Recalled  bool
+	// Ephemeral 所属会话为无痕会话，由保存方根据会话设置，消息只写入缓存
+	Ephemeral bool
 	CreatedAt time.Time
 }
 
@@ -31,12 +44,209 @@ func (m *Message) IsUser() bool {
 }
 
 type Session struct {
-	ID        string
+	ID     string
+	UserID string
+	// WorkspaceID 为会话所属的工作空间，空表示所有者的个人空间
+	WorkspaceID string
+	Title       string
+	CreatedAt   time.Time
+	// UpdatedAt 会话最后一次变更（含新消息）的时间
+	UpdatedAt time.Time
+	// LastMessageAt 最后一条消息的时间，没有消息时等于 CreatedAt
+	LastMessageAt time.Time
+	MessageCount  int
+	// Preview 最后一条消息的单行摘要，见 MessagePreview
+	Preview string
+	// Pinned 的会话在列表中排在最前，Archived 的会话默认不出现在列表中
+	Pinned   bool
+	Archived bool
+	// FolderID 为空表示不在任何文件夹中
+	FolderID string
+	Tags     []string
+	// ForkedFrom 为分叉来源会话，ForkMessageID 为分叉点消息（已复制到本会话之前的原消息）；
+	// 非分叉会话两者均为空
+	ForkedFrom    string
+	ForkMessageID string
+	// ImportedFrom 为导入来源（格式:原会话 ID），用于避免重复导入；非导入会话为空
+	ImportedFrom string
+	// Ephemeral 无痕会话：会话与消息只保存在有过期时间的缓存中，一段时间没有新消息后消失，
+	// 不写入数据库，不出现在会话列表、导出与检索中，也不提取长期记忆
+	Ephemeral bool
+}
+
+// 会话标签的限制
+const (
+	MaxSessionTags   = 20
+	MaxSessionTagLen = 32
+)
+
+// NormalizeTags 去掉空白与重复的标签（不区分大小写，统一为小写），
+// 标签过长或过多时返回 ErrInvalidSession
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t == "" || seen[t] {
+			continue
+		}
+		if len([]rune(t)) > MaxSessionTagLen {
+			return nil, ErrInvalidSession
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > MaxSessionTags {
+		return nil, ErrInvalidSession
+	}
+	return out, nil
+}
+
+// SessionUpdate 描述对会话的局部修改，nil 字段保持不变；
+// FolderID 指向空字符串表示移出文件夹，Tags 指向空切片表示清空标签
+type SessionUpdate struct {
+	Title    *string
+	Pinned   *bool
+	Archived *bool
+	FolderID *string
+	Tags     *[]string
+}
+
+// Folder 是用户整理会话的文件夹，不支持嵌套
+type Folder struct {
+	ID          string
+	UserID      string
+	WorkspaceID string
+	Name        string
+	CreatedAt   time.Time
+}
+
+// Share 是会话的只读公开分享。消息在创建时复制为快照，原会话之后的修改或删除不会影响分享内容；
+// IncludeFuture 为 true 时，查看时还会追加原会话在快照之后产生的新消息（原会话删除后不再追加）
+type Share struct {
+	ID     string
+	UserID string
+	// SessionID 为原会话，原会话删除后仍保留
+	SessionID string
+	// TokenHash 为分享令牌的 SHA-256，令牌本身只在创建时返回一次
+	TokenHash     string
+	Title         string
+	IncludeFuture bool
+	MessageCount  int
+	// LastMessageID / LastMessageAt 为快照中最后一条消息，追加新消息时从它之后开始
+	LastMessageID string
+	LastMessageAt time.Time
+	// ExpiresAt / RevokedAt 为零值表示永不过期 / 未撤销
+	ExpiresAt time.Time
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+// Active 返回分享在 now 时是否可以访问
+func (s *Share) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && (s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt))
+}
+
+// HashShareToken 返回分享令牌的存储形式，数据库泄露时无法据此还原分享链接
+func HashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParticipantRole 是协作者在会话中的角色；所有者即会话的 UserID，不作为参与者保存
+type ParticipantRole string
+
+const (
+	ParticipantOwner  ParticipantRole = "owner"
+	ParticipantEditor ParticipantRole = "editor"
+	ParticipantViewer ParticipantRole = "viewer"
+)
+
+// ParseParticipantRole 解析可以授予协作者的角色（editor / viewer）
+func ParseParticipantRole(s string) (ParticipantRole, bool) {
+	switch r := ParticipantRole(strings.ToLower(s)); r {
+	case ParticipantEditor, ParticipantViewer:
+		return r, true
+	}
+	return "", false
+}
+
+// Participant 是被所有者邀请加入会话的协作者
+type Participant struct {
+	SessionID string
 	UserID    string
-	Title     string
+	Role      ParticipantRole
+	// AddedBy 为邀请者
+	AddedBy   string
+	CreatedAt time.Time
+}
+
+// SessionEventType 是推送给会话参与者的事件类型
+type SessionEventType string
+
+const (
+	// SessionEventMessage 一条完整的消息：用户发言，或助手回复完成
+	SessionEventMessage SessionEventType = "message"
+	// SessionEventDelta 助手回复的增量文本
+	SessionEventDelta SessionEventType = "delta"
+	// SessionEventParticipants 参与者被添加、修改或移除
+	SessionEventParticipants SessionEventType = "participants"
+)
+
+// SessionEvent 是多人会话中推送给所有打开的流的实时事件
+type SessionEvent struct {
+	Type      SessionEventType
+	SessionID string
+	// UserID 为触发事件的用户
+	UserID string
+	// Message 在 SessionEventMessage 时为完整消息
+	Message *Message
+	// Delta 在 SessionEventDelta 时为增量文本，ReplyTo 为它回复的用户消息
+	Delta     string
+	ReplyTo   string
 	CreatedAt time.Time
 }
 
+// SharedSession 是通过分享链接看到的会话：快照中的消息，以及 IncludeFuture 时追加的新消息
+type SharedSession struct {
+	Share    *Share
+	Messages []*Message
+	// Truncated 表示追加的新消息超过上限，只返回了一部分
+	Truncated bool
+}
+
+// SessionPreviewRunes 会话摘要的最大长度
+const SessionPreviewRunes = 100
+
+// MessagePreview 把消息压缩为一行并截断，用作会话列表中的摘要
+func MessagePreview(content string) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	if len(runes) > SessionPreviewRunes {
+		return string(runes[:SessionPreviewRunes])
+	}
+	return string(runes)
+}
+
+// SessionSort 是会话列表的排序方式，均为倒序，标题按字典序正序
+type SessionSort string
+
+const (
+	SessionSortActivity SessionSort = "activity"
+	SessionSortCreated  SessionSort = "created"
+	SessionSortTitle    SessionSort = "title"
+)
+
+// ParseSessionSort 解析排序参数，空值默认按最近活动排序
+func ParseSessionSort(s string) (SessionSort, error) {
+	switch sort := SessionSort(strings.ToLower(strings.TrimSpace(s))); sort {
+	case "":
+		return SessionSortActivity, nil
+	case SessionSortActivity, SessionSortCreated, SessionSortTitle:
+		return sort, nil
+	}
+	return "", ErrInvalidSessionSort
+}
+
 func (s *Session) SetTitle(content string, maxLen int) {
 	runes := []rune(content)
 	if len(runes) > maxLen {
@@ -46,6 +256,291 @@ func (s *Session) SetTitle(content string, maxLen int) {
 	}
 }
 
+type MemoryCategory string
+
+const (
+	MemoryPreference MemoryCategory = "preference"
+	MemoryProject    MemoryCategory = "project"
+	MemoryProfile    MemoryCategory = "profile"
+	MemoryOther      MemoryCategory = "other"
+)
+
+// ParseMemoryCategory 将模型输出的类别归一化，未知类别归为 other
+func ParseMemoryCategory(s string) MemoryCategory {
+	switch c := MemoryCategory(strings.ToLower(strings.TrimSpace(s))); c {
+	case MemoryPreference, MemoryProject, MemoryProfile:
+		return c
+	}
+	return MemoryOther
+}
+
+// Memory 是跨会话保留的用户长期记忆（偏好、项目、称呼等），记录来源消息便于用户追溯
+type Memory struct {
+	ID               string
+	UserID           string
+	WorkspaceID      string
+	Content          string
+	Category         MemoryCategory
+	SourceSessionID  string
+	SourceMessageIDs []string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "md"
+	ExportJSON     ExportFormat = "json"
+	ExportHTML     ExportFormat = "html"
+)
+
+// ParseExportFormat 校验导出格式，空值视为 Markdown
+func ParseExportFormat(s string) (ExportFormat, bool) {
+	switch f := ExportFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "", "markdown":
+		return ExportMarkdown, true
+	case ExportMarkdown, ExportJSON, ExportHTML:
+		return f, true
+	}
+	return "", false
+}
+
+// ContentType 返回导出文件的 MIME 类型
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportJSON:
+		return "application/json; charset=utf-8"
+	case ExportHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "text/markdown; charset=utf-8"
+}
+
+// exportNameRunes 导出文件名中标题部分的最大长度
+const exportNameRunes = 40
+
+// ExportFilename 由会话标题与 ID 前缀生成导出文件名，标题中的非字母数字字符替换为连字符
+func ExportFilename(s *Session, f ExportFormat) string {
+	var sb strings.Builder
+	n, dash := 0, false
+	for _, r := range strings.ToLower(s.Title) {
+		if n >= exportNameRunes {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+				n++
+			}
+			sb.WriteRune(r)
+			n++
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	name := sb.String()
+	if name == "" {
+		name = "session"
+	}
+	id := s.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return name + "-" + id + "." + string(f)
+}
+
+type ImportFormat string
+
+const (
+	// ImportChatGPT 是 ChatGPT 数据导出中的 conversations.json
+	ImportChatGPT ImportFormat = "chatgpt"
+	// ImportJSONL 每行一条消息：{"conversation_id","title","role","content","created_at","model"}
+	ImportJSONL ImportFormat = "jsonl"
+)
+
+// ParseImportFormat 校验导入格式
+func ParseImportFormat(s string) (ImportFormat, bool) {
+	switch f := ImportFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case ImportChatGPT, ImportJSONL:
+		return f, true
+	}
+	return "", false
+}
+
+// ImportedConversation 是从第三方导出中解析出的一个会话，Messages 已按时间排序且只含支持的角色
+type ImportedConversation struct {
+	// SourceID 为原系统中的会话 ID，可能为空
+	SourceID  string
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Messages  []*Message
+	// Skipped 为解析时跳过的消息数（工具调用、隐藏消息、空消息等）
+	Skipped int
+}
+
+// 导入结果中单个会话的状态
+const (
+	ImportStatusImported  = "imported"
+	ImportStatusReady     = "ready"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusEmpty     = "empty"
+)
+
+// ImportResult 是单个会话的导入结果；试运行时 Status 为 ready 且 SessionID 为空
+type ImportResult struct {
+	SourceID     string
+	SessionID    string
+	Title        string
+	MessageCount int
+	TokenCount   int
+	Status       string
+}
+
+// ImportReport 汇总一次导入（或试运行）的结果，计数只包含已导入（或将导入）的会话
+type ImportReport struct {
+	DryRun          bool
+	Sessions        int
+	Messages        int
+	Tokens          int
+	SkippedMessages int
+	Duplicates      int
+	Results         []*ImportResult
+}
+
+type DocumentFormat string
+
+const (
+	DocumentText     DocumentFormat = "text"
+	DocumentMarkdown DocumentFormat = "markdown"
+	// DocumentPDF 指客户端从 PDF 中抽取出的纯文本
+	DocumentPDF DocumentFormat = "pdf"
+)
+
+// ParseDocumentFormat 校验文档格式，空值视为纯文本
+func ParseDocumentFormat(s string) (DocumentFormat, bool) {
+	switch f := DocumentFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return DocumentText, true
+	case DocumentText, DocumentMarkdown, DocumentPDF:
+		return f, true
+	}
+	return "", false
+}
+
+// Collection 是可在多个会话中复用的知识库
+type Collection struct {
+	ID          string
+	UserID      string
+	WorkspaceID string
+	Name        string
+	CreatedAt   time.Time
+}
+
+// Document 是上传到会话或知识库的文档，SessionID 与 CollectionID 二选一
+type Document struct {
+	ID           string
+	UserID       string
+	WorkspaceID  string
+	SessionID    string
+	CollectionID string
+	Title        string
+	Format       DocumentFormat
+	ChunkCount   int
+	CreatedAt    time.Time
+}
+
+// DocumentChunk 是文档切分后的检索单元
+type DocumentChunk struct {
+	ID         string
+	DocumentID string
+	Index      int
+	Content   string
+	// Title 冗余存储文档标题，便于引用展示
+	Title string
+}
+
+// MessageSearchQuery 是跨会话全文检索的条件，零值字段表示不过滤
+type MessageSearchQuery struct {
+	UserID string
+	// WorkspaceID 只检索该工作空间中会话的消息，空表示个人空间
+	WorkspaceID string
+	Query       string
+	SessionID   string
+	Role        Role
+	From        time.Time
+	To          time.Time
+	// Cursor 为上一页返回的 NextCursor，空表示第一页
+	Cursor string
+	Limit  int
+}
+
+// MessageSearchPage 是按时间倒序的一页检索结果
+type MessageSearchPage struct {
+	Messages   []*Message
+	NextCursor string
+}
+
+// MessagePage 是会话历史中按时间倒序的一页消息
+type MessagePage struct {
+	Messages []*Message
+	// Total 为会话中的消息总数
+	Total int
+	// NextCursor 指向更早的一页，空表示没有更多
+	NextCursor string
+}
+
+// SessionQuery 是会话列表的过滤、排序与分页条件，置顶会话总是排在最前
+type SessionQuery struct {
+	UserID string
+	// WorkspaceID 只列出该工作空间中的会话，空表示个人空间
+	WorkspaceID string
+	Sort        SessionSort
+	// FolderID / Tag 为空表示不过滤
+	FolderID string
+	Tag      string
+	// Archived 为 true 时只列出已归档的会话，否则只列出未归档的
+	Archived bool
+	// Joined 为 true 时列出用户作为协作者加入的他人会话，忽略文件夹、标签与归档条件
+	Joined bool
+	// Cursor 为同一条件下上一页的 NextCursor
+	Cursor string
+	Limit  int
+}
+
+// SessionPage 是用户会话列表中按 SessionSort 排序的一页
+type SessionPage struct {
+	Sessions []*Session
+	// Total 为用户的会话总数
+	Total int
+	// NextCursor 指向更早的一页，空表示没有更多
+	NextCursor string
+}
+
+// MessageEmbedding 是一条消息的向量，用于跨会话语义检索
+type MessageEmbedding struct {
+	MessageID string
+	SessionID string
+	UserID    string
+	Vector    []float32
+	CreatedAt time.Time
+}
+
+// MessageMatch 是语义检索命中的一条消息
+type MessageMatch struct {
+	Message *Message
+	Score   float64
+}
+
+// ConversationMatch 是按最佳命中消息排序的会话
+type ConversationMatch struct {
+	Session  *Session
+	Score    float64
+	Messages []*MessageMatch
+}
+
 type InferenceRequest struct {
 	SessionID string
 	UserID    string
@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestMessageTokenCountField(t *testing.T) {
@@ -33,3 +35,87 @@ func TestMessageHasTokenCountDefault(t *testing.T) {
 		t.Errorf("expected default TokenCount=0, got %d", msg.TokenCount)
 	}
 }
+
+func TestMessagePreviewFlattensAndTruncates(t *testing.T) {
+	if got := MessagePreview("  第一行\n\n  second\tline  "); got != "第一行 second line" {
+		t.Errorf("unexpected preview %q", got)
+	}
+	long := MessagePreview(strings.Repeat("字", SessionPreviewRunes+10))
+	if n := utf8.RuneCountInString(long); n != SessionPreviewRunes {
+		t.Errorf("expected %d runes, got %d", SessionPreviewRunes, n)
+	}
+}
+
+func TestParseSessionSort(t *testing.T) {
+	cases := map[string]SessionSort{"": SessionSortActivity, "Title": SessionSortTitle, "created": SessionSortCreated}
+	for in, want := range cases {
+		if got, err := ParseSessionSort(in); err != nil || got != want {
+			t.Errorf("ParseSessionSort(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseSessionSort("size"); err != ErrInvalidSessionSort {
+		t.Errorf("expected ErrInvalidSessionSort, got %v", err)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags([]string{" Work ", "work", "", "side  project"})
+	if err != nil || len(got) != 2 || got[0] != "work" || got[1] != "side project" {
+		t.Fatalf("unexpected tags %q, %v", got, err)
+	}
+	if _, err := NormalizeTags([]string{strings.Repeat("x", MaxSessionTagLen+1)}); err != ErrInvalidSession {
+		t.Errorf("expected ErrInvalidSession for a long tag, got %v", err)
+	}
+	many := make([]string, MaxSessionTags+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	if _, err := NormalizeTags(many); err != ErrInvalidSession {
+		t.Errorf("expected ErrInvalidSession for too many tags, got %v", err)
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	cases := map[string]ExportFormat{"": ExportMarkdown, "markdown": ExportMarkdown, "MD": ExportMarkdown, "json": ExportJSON, " html ": ExportHTML}
+	for in, want := range cases {
+		if got, ok := ParseExportFormat(in); !ok || got != want {
+			t.Errorf("ParseExportFormat(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseExportFormat("pdf"); ok {
+		t.Error("expected pdf to be rejected")
+	}
+}
+
+func TestExportFilename(t *testing.T) {
+	s := &Session{ID: "0123456789abcdef", Title: "Trip: Tokyo / 京都 (draft)"}
+	if got := ExportFilename(s, ExportJSON); got != "trip-tokyo-京都-draft-01234567.json" {
+		t.Errorf("unexpected filename %q", got)
+	}
+	s.Title = "!!!"
+	if got := ExportFilename(s, ExportMarkdown); got != "session-01234567.md" {
+		t.Errorf("unexpected filename %q", got)
+	}
+}
+
+func TestShareActive(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name  string
+		share Share
+		want  bool
+	}{
+		{"no expiry", Share{}, true},
+		{"not yet expired", Share{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", Share{ExpiresAt: now}, false},
+		{"revoked", Share{RevokedAt: now.Add(-time.Minute)}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.share.Active(now); got != tc.want {
+			t.Errorf("%s: Active() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if h := HashShareToken("abc"); len(h) != 64 || h == HashShareToken("abd") {
+		t.Errorf("unexpected token hash %q", h)
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import "errors"
+
+// session
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrInvalidSessionSort = errors.New("invalid session sort")
+	// ErrTitleSortUnavailable 表示会话标题已加密，不能按标题排序
+	ErrTitleSortUnavailable = errors.New("sorting by title is unavailable while session titles are encrypted")
+	ErrInvalidSession       = errors.New("invalid session update")
+	ErrFolderNotFound       = errors.New("folder not found")
+	ErrInvalidFolder        = errors.New("invalid folder name")
+	// ErrEphemeralSession 表示该操作需要持久化会话，无痕会话不支持
+	ErrEphemeralSession = errors.New("not available for ephemeral sessions")
+)
+
+// participant
+var (
+	ErrParticipantNotFound = errors.New("participant not found")
+	ErrInvalidParticipant  = errors.New("invalid participant")
+	ErrEventsUnavailable   = errors.New("live updates are unavailable")
+)
+
+// message
+var (
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrForkNotReady 表示分叉点之前的消息仍在异步落库，稍后重试即可
+	ErrForkNotReady = errors.New("message history is still being saved")
+)
+
+// share
+var (
+	// ErrShareNotFound 也用于已过期或已撤销的分享，避免泄露分享是否存在
+	ErrShareNotFound = errors.New("share not found")
+	ErrInvalidShare  = errors.New("invalid share")
+)
+
+// export
+var (
+	ErrInvalidExportFormat = errors.New("invalid export format")
+)
+
+// import
+var (
+	ErrInvalidImport = errors.New("invalid import file")
+)
+
+// memory
+var (
+	ErrMemoryNotFound = errors.New("memory not found")
+	ErrInvalidMemory  = errors.New("invalid memory content")
+)
+
+// document
+var (
+	ErrDocumentNotFound   = errors.New("document not found")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrInvalidDocument    = errors.New("invalid document")
+)
+
+// retention
+var (
+	ErrInvalidRetention = errors.New("invalid retention policy")
+)
+
+// account
+var (
+	ErrExportNotFound = errors.New("export not found")
+	// ErrExportNotReady 表示导出仍在生成，或生成失败
+	ErrExportNotReady = errors.New("export is not ready")
+	// ErrAccountErased 表示账户已提交删除，不再接受新的数据
+	ErrAccountErased   = errors.New("account has been deleted")
+	ErrErasureNotFound = errors.New("account deletion not found")
+)
+
+// encryption
+var (
+	// ErrKeyDestroyed 表示数据已被加密粉碎，密文所用的数据密钥已销毁
+	ErrKeyDestroyed = errors.New("data key has been destroyed")
+)
+
+// pii
+var (
+	// ErrPIIBlocked 表示输入中含有配置为拒绝的敏感信息
+	ErrPIIBlocked = errors.New("message contains sensitive data that is not allowed")
+)
+
+// moderation
+var (
+	ErrFlagNotFound  = errors.New("moderation flag not found")
+	ErrInvalidReview = errors.New("invalid moderation review")
+	// ErrReviewQueueUnavailable 表示没有保存复核记录的数据库，命中只写入日志
+	ErrReviewQueueUnavailable = errors.New("moderation review queue is unavailable")
+)
+
+// search
+var (
+	ErrSearchUnavailable = errors.New("search is unavailable")
+	ErrInvalidSearch     = errors.New("invalid search query")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+)
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ModerationStage 是审核发生的环节
+type ModerationStage string
+
+const (
+	// ModerationInput 用户输入在选择模型之前
+	ModerationInput ModerationStage = "input"
+	// ModerationOutput 模型回答在流式返回的过程中
+	ModerationOutput ModerationStage = "output"
+)
+
+// ModerationAction 是命中规则后的处理方式
+type ModerationAction string
+
+const (
+	// ModerationActionFlag 放行，同时进入人工复核队列
+	ModerationActionFlag ModerationAction = "flag"
+	// ModerationActionBlock 拒绝输入或截断回答，同时进入人工复核队列
+	ModerationActionBlock ModerationAction = "block"
+)
+
+// ModerationHit 是一条规则或分类器的命中结果
+type ModerationHit struct {
+	Rule     string
+	Category string
+	Action   ModerationAction
+	// Score 分类器给出的分数，关键词与正则规则为 1
+	Score float64
+}
+
+// ModerationRules matches text against the configured keyword lists and
+// regular expressions. It is cheap enough to run on every streamed chunk.
+type ModerationRules interface {
+	Match(stage ModerationStage, text string) []ModerationHit
+}
+
+// ModerationClassifier scores text with an external model and returns the
+// categories above their configured thresholds.
+type ModerationClassifier interface {
+	Classify(ctx context.Context, stage ModerationStage, text string) ([]ModerationHit, error)
+}
+
+// FlagStatus 是复核状态
+type FlagStatus string
+
+const (
+	FlagPending FlagStatus = "pending"
+	// FlagDismissed 复核认为是误判
+	FlagDismissed FlagStatus = "dismissed"
+	// FlagConfirmed 复核确认违规
+	FlagConfirmed FlagStatus = "confirmed"
+)
+
+// ModerationFlag 是复核队列中的一条记录。同一次检查的多个命中合并为一条，Rule/Category 取决定处理方式的命中
+type ModerationFlag struct {
+	ID          string
+	UserID      string
+	WorkspaceID string
+	// SessionID 输入在创建会话之前被拒绝时为空
+	SessionID string
+	// MessageID 被拒绝的输入与被截断的回答没有保存，为空
+	MessageID string
+	Stage     ModerationStage
+	Rule      string
+	Category  string
+	Action    ModerationAction
+	Score     float64
+	// Excerpt 命中的文本：输入为全文，回答为滑动窗口中的片段
+	Excerpt    string
+	Status     FlagStatus
+	ReviewedBy string
+	ReviewedAt time.Time
+	CreatedAt  time.Time
+}
+
+// ModerationFlagQuery 按空间查询复核队列，WorkspaceID 为空表示个人空间
+type ModerationFlagQuery struct {
+	WorkspaceID string
+	// Status 为空时返回全部状态
+	Status FlagStatus
+	Limit  int
+	Cursor string
+}
+
+type ModerationFlagPage struct {
+	Flags      []*ModerationFlag
+	NextCursor string
+}
+
+type ModerationRepository interface {
+	SaveFlag(ctx context.Context, flag *ModerationFlag) error
+	// ListFlags 按创建时间倒序
+	ListFlags(ctx context.Context, q ModerationFlagQuery) (*ModerationFlagPage, error)
+	// ReviewFlag 更新空间中一条记录的复核状态，不存在时返回 ErrFlagNotFound
+	ReviewFlag(ctx context.Context, workspaceID, flagID string, status FlagStatus, reviewerID string) (*ModerationFlag, error)
+}
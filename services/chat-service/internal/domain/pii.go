@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PIIKind 是一类个人敏感信息
+type PIIKind string
+
+const (
+	PIIEmail PIIKind = "email"
+	// PIIPhone 手机号，包括中国大陆手机号与带国家码的国际号码
+	PIIPhone PIIKind = "phone"
+	// PIIIDCard 中国居民身份证号，校验位有效
+	PIIIDCard PIIKind = "id_card"
+	// PIICreditCard 银行卡号，通过 Luhn 校验
+	PIICreditCard PIIKind = "credit_card"
+	// PIIAPIKey 常见云服务与代码托管平台的 API 密钥
+	PIIAPIKey PIIKind = "api_key"
+)
+
+// PIIKinds 是内置检测器识别的全部类型
+var PIIKinds = []PIIKind{PIIEmail, PIIPhone, PIIIDCard, PIICreditCard, PIIAPIKey}
+
+// PIIAction 是检测到敏感信息后的处理方式
+type PIIAction string
+
+const (
+	// PIIAllow 原样保留，只记录审计事件
+	PIIAllow PIIAction = "allow"
+	// PIIMask 部分遮盖后保存与发送给模型，原值不再保留
+	PIIMask PIIAction = "mask"
+	// PIITokenize 替换为会话内稳定的占位符，原值只保存在占位符表中，模型回答中的占位符在返回给用户时还原
+	PIITokenize PIIAction = "tokenize"
+	// PIIBlock 拒绝整条输入
+	PIIBlock PIIAction = "block"
+)
+
+// ParsePIIAction 解析配置中的处理方式
+func ParsePIIAction(s string) (PIIAction, error) {
+	switch a := PIIAction(strings.ToLower(strings.TrimSpace(s))); a {
+	case PIIAllow, PIIMask, PIITokenize, PIIBlock:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown pii action %q", s)
+}
+
+// PIIMatch 是文本中检测到的一处敏感信息，Start/End 为字节偏移
+type PIIMatch struct {
+	Kind  PIIKind
+	Start int
+	End   int
+	Value string
+}
+
+// PIIScanner finds sensitive data in text. Matches are sorted by position and
+// never overlap.
+type PIIScanner interface {
+	Scan(text string) []PIIMatch
+}
+
+// PIIStage 是检测发生的环节
+type PIIStage string
+
+const (
+	// PIIStageInput 用户输入保存与推理之前
+	PIIStageInput PIIStage = "input"
+	// PIIStageExport 会话导出
+	PIIStageExport PIIStage = "export"
+)
+
+// PIIAudit 是一次检测的审计事件：同一环节中同类信息按同一方式处理的次数，不包含原值
+type PIIAudit struct {
+	ID          string
+	UserID      string
+	WorkspaceID string
+	SessionID   string
+	Stage       PIIStage
+	Kind        PIIKind
+	Action      PIIAction
+	Count       int
+	CreatedAt   time.Time
+}
+
+// PIIRepository 保存审计事件
+type PIIRepository interface {
+	SaveAudits(ctx context.Context, audits []*PIIAudit) error
+}
+
+// PIIVault 保存会话中占位符与原值的对应关系，用于还原模型回答并在后续轮次复用同一占位符
+type PIIVault interface {
+	GetPIITokens(ctx context.Context, session *Session) (map[string]string, error)
+	SavePIITokens(ctx context.Context, session *Session, tokens map[string]string) error
+}
+
+// MaskPII 部分遮盖敏感信息，保留足以让用户辨认的少量字符
+func MaskPII(kind PIIKind, value string) string {
+	switch kind {
+	case PIIEmail:
+		if at := strings.LastIndexByte(value, '@'); at > 0 {
+			first, _ := utf8.DecodeRuneInString(value)
+			return string(first) + "***" + value[at:]
+		}
+	case PIIPhone:
+		return maskDigits(value, 3, 4)
+	case PIIIDCard, PIICreditCard:
+		return maskDigits(value, 0, 4)
+	case PIIAPIKey:
+		if len(value) > 8 {
+			return value[:4] + "****"
+		}
+	}
+	return strings.Repeat("*", utf8.RuneCountInString(value))
+}
+
+// maskDigits 遮盖除前 head 位与后 tail 位之外的数字，保留分隔符与身份证末位的 X
+func maskDigits(value string, head, tail int) string {
+	total := 0
+	for _, r := range value {
+		if isDigitOrX(r) {
+			total++
+		}
+	}
+	var sb strings.Builder
+	i := 0
+	for _, r := range value {
+		if !isDigitOrX(r) {
+			sb.WriteRune(r)
+			continue
+		}
+		if i < head || i >= total-tail {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('*')
+		}
+		i++
+	}
+	return sb.String()
+}
+
+func isDigitOrX(r rune) bool {
+	return r >= '0' && r <= '9' || r == 'X' || r == 'x'
+}
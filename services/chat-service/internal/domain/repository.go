@@ -1,17 +1,125 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ChatRepository 定义数据访问接口
 // 不关心具体实现是redis，mq，还是db
 type ChatRepository interface {
+	// SaveMessage 保存消息并更新所属会话的最后活动时间、消息数与摘要
 	SaveMessage(ctx context.Context, msg *Message) error
 	SaveSession(ctx context.Context, session *Session) error
 	GetSession(ctx context.Context, sessionID string) (*Session, error)
-	GetSessionMessages(ctx context.Context, sessionID string, limit, offset int) ([]*Message, error)
-	GetSessions(ctx context.Context, userID string, limit, offset int) ([]*Session, error)
+	// GetSessionMessages 按 (created_at, id) 倒序分页，cursor 为上一页的 NextCursor
+	GetSessionMessages(ctx context.Context, sessionID string, limit int, cursor string) (*MessagePage, error)
+	// GetSessionMessagesSince 按时间正序返回 created_at >= since 的消息（直接读库）
+	GetSessionMessagesSince(ctx context.Context, sessionID string, since time.Time) ([]*Message, error)
+	// GetSessionMessagesUntil 按时间正序返回会话中不晚于 until 的消息（含 until，直接读库）
+	GetSessionMessagesUntil(ctx context.Context, until *Message) ([]*Message, error)
+	// GetSessionMessagesAfter 按 (created_at, id) 正序返回 after 之后的至多 limit 条消息，after 为空时从头开始（直接读库）
+	GetSessionMessagesAfter(ctx context.Context, sessionID string, after *Message, limit int) ([]*Message, error)
+	// ForkSession 在一个事务中创建分叉会话并写入复制的消息
+	ForkSession(ctx context.Context, session *Session, messages []*Message) error
+	// ImportSession 保存导入的会话及其全部消息，消息分批写入
+	ImportSession(ctx context.Context, session *Session, messages []*Message) error
+	// HasImportedSession 返回用户是否已在工作空间中导入过来源为 importedFrom 的会话
+	HasImportedSession(ctx context.Context, userID, workspaceID, importedFrom string) (bool, error)
+	// GetSessions 按 query 过滤、排序并分页
+	GetSessions(ctx context.Context, query SessionQuery) (*SessionPage, error)
+	// UpdateSession 保存会话的标题、置顶、归档、文件夹与标签，不影响消息统计字段
+	UpdateSession(ctx context.Context, session *Session) error
+	// ClearSessionFolder 把文件夹中的会话全部移出该文件夹
+	ClearSessionFolder(ctx context.Context, userID, folderID string) error
+	GetMessage(ctx context.Context, messageID string) (*Message, error)
+	// SearchMessages 全文检索用户的消息（直接读库）
+	SearchMessages(ctx context.Context, query MessageSearchQuery) (*MessageSearchPage, error)
+	GetPinnedMessages(ctx context.Context, sessionID string) ([]*Message, error)
+	SetMessagePinned(ctx context.Context, messageID string, pinned bool) error
 	DeleteMessage(ctx context.Context, messageID string) error
 	DeleteSession(ctx context.Context, sessionID string) error
+	// EvictMessages 从缓存中移除已被彻底删除的消息，并让所属会话的缓存失效
+	EvictMessages(ctx context.Context, messages []*Message) error
+	// EvictSessions 从缓存中移除已被彻底删除的会话及其消息列表
+	EvictSessions(ctx context.Context, sessions []*Session) error
+	// EvictUserSessions 删除用户在所有工作空间中的会话列表缓存
+	EvictUserSessions(ctx context.Context, userID string) error
+}
+
+// MemoryRepository 定义用户长期记忆的存取
+type MemoryRepository interface {
+	SaveMemory(ctx context.Context, memory *Memory) error
+	UpdateMemoryContent(ctx context.Context, memoryID, content string) error
+	GetMemory(ctx context.Context, memoryID string) (*Memory, error)
+	// ListMemories 按更新时间倒序返回用户在工作空间中的全部记忆
+	ListMemories(ctx context.Context, userID, workspaceID string) ([]*Memory, error)
+	DeleteMemory(ctx context.Context, memoryID string) error
+	// IsMemoryEnabled 返回用户是否开启记忆，未设置时默认开启
+	IsMemoryEnabled(ctx context.Context, userID string) (bool, error)
+	SetMemoryEnabled(ctx context.Context, userID string, enabled bool) error
+}
+
+// FolderRepository 定义会话文件夹的存取
+type FolderRepository interface {
+	SaveFolder(ctx context.Context, folder *Folder) error
+	GetFolder(ctx context.Context, folderID string) (*Folder, error)
+	// ListFolders 按名称排序返回用户在工作空间中的全部文件夹
+	ListFolders(ctx context.Context, userID, workspaceID string) ([]*Folder, error)
+	RenameFolder(ctx context.Context, folderID, name string) error
+	DeleteFolder(ctx context.Context, folderID string) error
+}
+
+// ShareRepository 定义会话公开分享及其消息快照的存取
+type ShareRepository interface {
+	// SaveShare 在一个事务中保存分享及其消息快照
+	SaveShare(ctx context.Context, share *Share, messages []*Message) error
+	GetShare(ctx context.Context, shareID string) (*Share, error)
+	GetShareByToken(ctx context.Context, tokenHash string) (*Share, error)
+	// ListShares 按创建时间倒序返回会话的全部分享（含已过期和已撤销的）
+	ListShares(ctx context.Context, sessionID string) ([]*Share, error)
+	// GetShareMessages 按原顺序返回分享的消息快照
+	GetShareMessages(ctx context.Context, shareID string) ([]*Message, error)
+	RevokeShare(ctx context.Context, shareID string, at time.Time) error
+}
+
+// ParticipantRepository 定义会话协作者的存取
+type ParticipantRepository interface {
+	// SaveParticipant 添加协作者，已存在时更新角色
+	SaveParticipant(ctx context.Context, p *Participant) error
+	// GetParticipant 不存在时返回 nil
+	GetParticipant(ctx context.Context, sessionID, userID string) (*Participant, error)
+	// ListParticipants 按加入时间返回会话的全部协作者
+	ListParticipants(ctx context.Context, sessionID string) ([]*Participant, error)
+	DeleteParticipant(ctx context.Context, sessionID, userID string) error
+}
+
+// DocumentRepository 定义文档、切片与知识库的存取
+type DocumentRepository interface {
+	// SaveDocument 在一个事务中保存文档及其切片
+	SaveDocument(ctx context.Context, doc *Document, chunks []*DocumentChunk) error
+	GetDocument(ctx context.Context, documentID string) (*Document, error)
+	// ListDocuments 列出用户在工作空间中的文档，按 sessionID 或 collectionID 过滤（为空则不过滤），按创建时间倒序
+	ListDocuments(ctx context.Context, userID, workspaceID, sessionID, collectionID string) ([]*Document, error)
+	// DeleteDocument 删除文档及其切片
+	DeleteDocument(ctx context.Context, documentID string) error
+	// GetChunks 返回属于会话或任一知识库的全部切片，最多 limit 条
+	GetChunks(ctx context.Context, sessionID string, collectionIDs []string, limit int) ([]*DocumentChunk, error)
+
+	SaveCollection(ctx context.Context, collection *Collection) error
+	GetCollection(ctx context.Context, collectionID string) (*Collection, error)
+	ListCollections(ctx context.Context, userID, workspaceID string) ([]*Collection, error)
+	// DeleteCollection 删除知识库及其中的文档和切片
+	DeleteCollection(ctx context.Context, collectionID string) error
+}
+
+// EmbeddingRepository 定义消息向量的存取
+type EmbeddingRepository interface {
+	// SaveEmbeddings 保存消息向量，同一消息重复保存时覆盖
+	SaveEmbeddings(ctx context.Context, embeddings []*MessageEmbedding) error
+	// ListEmbeddings 返回用户在工作空间中最近的 limit 条消息向量
+	ListEmbeddings(ctx context.Context, userID, workspaceID string, limit int) ([]*MessageEmbedding, error)
+	DeleteSessionEmbeddings(ctx context.Context, sessionID string) error
 }
 
 // type MessageRepository interface {
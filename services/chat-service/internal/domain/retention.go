@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MaxRetentionDays 保留天数的上限
+const MaxRetentionDays = 3650
+
+// RetentionPolicy 是消息的保留策略：工作空间的策略（WorkspaceID 非空，UserID 为空）覆盖空间内全部会话，
+// 个人策略（WorkspaceID 为空）覆盖用户个人空间中的会话
+type RetentionPolicy struct {
+	WorkspaceID string
+	UserID      string
+	// MessageDays 消息保留的天数，超过后彻底删除；0 表示永久保留
+	MessageDays int
+	// Default 为真表示没有单独设置，MessageDays 来自服务配置
+	Default   bool
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+// MessageCutoff 返回 now 时早于该时间的消息已过期，永久保留时返回 false
+func (p *RetentionPolicy) MessageCutoff(now time.Time) (time.Time, bool) {
+	if p.MessageDays <= 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -p.MessageDays), true
+}
+
+// Scope 返回策略覆盖的会话范围
+func (p *RetentionPolicy) Scope() RetentionScope {
+	return RetentionScope{WorkspaceID: p.WorkspaceID, UserID: p.UserID}
+}
+
+// RetentionScope 是一条保留策略覆盖的会话：工作空间中的全部会话，或用户个人空间中的会话。
+// Default 为真时是没有单独策略的全部会话，由服务配置决定保留天数
+type RetentionScope struct {
+	WorkspaceID string
+	UserID      string
+	Default     bool
+}
+
+// PurgeTarget 是清理的数据类别
+type PurgeTarget string
+
+const (
+	PurgeMessages    PurgeTarget = "messages"
+	PurgeSessions    PurgeTarget = "sessions"
+	PurgeFolders     PurgeTarget = "folders"
+	PurgeMemories    PurgeTarget = "memories"
+	PurgeDocuments   PurgeTarget = "documents"
+	PurgeCollections PurgeTarget = "collections"
+	PurgeShares      PurgeTarget = "shares"
+)
+
+// SoftDeletedTargets 是带软删除的数据类别，按清理顺序排列
+var SoftDeletedTargets = []PurgeTarget{
+	PurgeMessages, PurgeSessions, PurgeFolders, PurgeMemories, PurgeDocuments, PurgeCollections, PurgeShares,
+}
+
+// PurgeKind 区分清理的原因
+type PurgeKind string
+
+const (
+	// PurgeExpired 超过保留策略的消息
+	PurgeExpired PurgeKind = "expired"
+	// PurgeDeleted 软删除超过期限的记录
+	PurgeDeleted PurgeKind = "deleted"
+)
+
+// PurgeCount 是一类数据的清理条数
+type PurgeCount struct {
+	Target PurgeTarget
+	Count  int64
+}
+
+// RetentionReport 是清理任务的试运行结果：下一次执行时请求者范围内会被彻底删除的数据
+type RetentionReport struct {
+	Policy *RetentionPolicy
+	// MessageCutoff 早于该时间的消息会被删除，永久保留时为零值
+	MessageCutoff   time.Time
+	ExpiredMessages int64
+	// DeletedCutoff 早于该时间软删除的记录会被彻底删除，不清理时为零值
+	DeletedCutoff time.Time
+	Deleted       []PurgeCount
+}
+
+// RetentionAudit 记录一次实际执行的清理
+type RetentionAudit struct {
+	ID string
+	// RunID 同一轮清理任务的记录共用一个 RunID
+	RunID  string
+	Kind   PurgeKind
+	Target PurgeTarget
+	// WorkspaceID / UserID 为过期消息所属的策略范围，DefaultScope 为真时是配置中的默认策略；
+	// 软删除清理不区分范围，两者均为空
+	WorkspaceID  string
+	UserID       string
+	DefaultScope bool
+	Cutoff       time.Time
+	Count        int64
+	CreatedAt    time.Time
+}
+
+// RetentionRepository 定义保留策略、数据清理与清理审计的存取
+type RetentionRepository interface {
+	// GetRetentionPolicy 返回工作空间或个人的策略，没有单独设置时返回 nil
+	GetRetentionPolicy(ctx context.Context, workspaceID, userID string) (*RetentionPolicy, error)
+	SaveRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error
+	ListRetentionPolicies(ctx context.Context) ([]*RetentionPolicy, error)
+	CountExpiredMessages(ctx context.Context, scope RetentionScope, cutoff time.Time) (int64, error)
+	// PurgeExpiredMessages 彻底删除范围内早于 cutoff 的至多 limit 条消息（连同其向量与分享快照），
+	// 返回被删除的消息；返回不足 limit 条说明已经删完
+	PurgeExpiredMessages(ctx context.Context, scope RetentionScope, cutoff time.Time, limit int) ([]*Message, error)
+	// CountDeleted 统计早于 cutoff 软删除的记录，scope 为空时统计全部
+	CountDeleted(ctx context.Context, scope *RetentionScope, cutoff time.Time) ([]PurgeCount, error)
+	// PurgeDeleted 彻底删除一类数据中早于 cutoff 软删除的至多 limit 条记录，返回删除的条数
+	PurgeDeleted(ctx context.Context, target PurgeTarget, cutoff time.Time, limit int) (int64, error)
+	SaveRetentionAudit(ctx context.Context, audit *RetentionAudit) error
+}
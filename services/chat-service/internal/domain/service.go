@@ -1,6 +1,10 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type InferenceService interface {
 	StreamInference(ctx context.Context, req *InferenceRequest) (<-chan *GeneratedToken, error)
@@ -12,7 +16,80 @@ type ModelBalanceService interface {
 }
 
 // ContextOptimizer builds optimized contexts under a token budget.
-// Implemented by the remote context-engine client (Python service).
+// Implemented by the remote context-engine client (Python service) and by the
+// in-process port in infrastructure/context.
 type ContextOptimizer interface {
 	BuildContext(ctx context.Context, text, query, strategy string, budget int) (string, error)
 }
+
+// DocumentChunker splits an uploaded document into retrieval chunks.
+type DocumentChunker interface {
+	Chunk(content string, format DocumentFormat) []string
+}
+
+// DocumentRetriever ranks document chunks against a query and returns the top k.
+type DocumentRetriever interface {
+	Retrieve(ctx context.Context, query string, chunks []*DocumentChunk, k int) ([]*DocumentChunk, error)
+}
+
+// Embedder turns texts into dense vectors. It is optional: retrieval falls back
+// to BM25 when no embedder is configured.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ConversationSearcher finds a user's past messages by meaning rather than
+// exact wording, grouped by session.
+type ConversationSearcher interface {
+	// Index embeds and stores messages so they become searchable.
+	Index(ctx context.Context, messages []*Message) error
+	// Search only considers sessions in the given workspace ("" is the personal space).
+	Search(ctx context.Context, userID, workspaceID, query string, k int) ([]*ConversationMatch, error)
+	Forget(ctx context.Context, sessionID string) error
+}
+
+// MessageRecaller retrieves older turns of a session that are relevant to a
+// query, so long sessions can bring back context beyond the recent window.
+type MessageRecaller interface {
+	// Recall returns relevant messages created before the given time, most relevant first.
+	Recall(ctx context.Context, sessionID, query string, before time.Time) ([]*Message, error)
+	// Forget drops any cached index for the session (e.g. after deletion).
+	Forget(sessionID string)
+}
+
+// SessionExporter renders a session as a downloadable document. Messages are
+// handed over in batches, so arbitrarily long sessions never have to be held
+// in memory at once.
+type SessionExporter interface {
+	NewWriter(w io.Writer, format ExportFormat, session *Session) (ExportWriter, error)
+}
+
+// ExportWriter receives a session's messages in chronological order.
+type ExportWriter interface {
+	WriteMessages(messages []*Message) error
+	// Close writes the trailer; it does not close the underlying writer.
+	Close() error
+}
+
+// TokenCounter counts tokens the same way the context builder does, so stored
+// counts can be reused when assembling context.
+type TokenCounter interface {
+	Count(text string) int
+}
+
+// ConversationDecoder parses a third-party chat export and hands over one
+// conversation at a time, so large exports are never fully held in memory.
+// Malformed input yields an error wrapping ErrInvalidImport.
+type ConversationDecoder interface {
+	Decode(r io.Reader, format ImportFormat, fn func(*ImportedConversation) error) error
+}
+
+// SessionEventBus fans session events out to every open stream of a
+// collaborative session, across service instances. Delivery is best effort:
+// events published while nobody is subscribed are dropped.
+type SessionEventBus interface {
+	Publish(ctx context.Context, event *SessionEvent) error
+	// Subscribe delivers the session's events until ctx is done, then closes
+	// the channel.
+	Subscribe(ctx context.Context, sessionID string) (<-chan *SessionEvent, error)
+}
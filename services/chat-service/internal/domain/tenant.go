@@ -0,0 +1,37 @@
+package domain
+
+import "context"
+
+// WorkspaceRole 是用户在工作空间中的角色，由认证服务签发在令牌中
+type WorkspaceRole string
+
+const (
+	WorkspaceOwner  WorkspaceRole = "owner"
+	WorkspaceAdmin  WorkspaceRole = "admin"
+	WorkspaceMember WorkspaceRole = "member"
+)
+
+// Tenant 是请求所在的工作空间。会话、文件夹、文档、知识库与记忆都属于某个工作空间，
+// 只在同一空间内可见；WorkspaceID 为空表示用户的个人空间
+type Tenant struct {
+	WorkspaceID string
+	Role        WorkspaceRole
+}
+
+// IsWorkspaceAdmin 返回请求者能否管理空间内所有人的会话，个人空间没有管理员
+func (t Tenant) IsWorkspaceAdmin() bool {
+	return t.WorkspaceID != "" && (t.Role == WorkspaceOwner || t.Role == WorkspaceAdmin)
+}
+
+type tenantKey struct{}
+
+// WithTenant 返回携带 t 的 context
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// TenantFromContext 返回 ctx 中的工作空间，没有时为个人空间
+func TenantFromContext(ctx context.Context) Tenant {
+	t, _ := ctx.Value(tenantKey{}).(Tenant)
+	return t
+}
@@ -2,12 +2,14 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"free-chat/services/chat-service/internal/domain"
 	"free-chat/services/chat-service/internal/infrastructure/mq"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/cache"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/repository"
 	"log"
+	"time"
 )
 
 type ChatRepositoryAdapter struct {
@@ -35,8 +37,24 @@ func NewChatRepositoryAdapter(
 }
 
 func (adp *ChatRepositoryAdapter) SaveMessage(ctx context.Context, msg *domain.Message) error {
+	// 无痕会话的消息只写入缓存，不经过 MQ 也不落库
+	if msg.Ephemeral {
+		return adp.saveEphemeralMessage(ctx, msg)
+	}
+	// 会话集合属于会话所有者，消息作者可能是协作者，需要先取得会话
+	session, err := adp.GetSession(ctx, msg.SessionID)
+	if err == nil && session != nil && session.Ephemeral {
+		return adp.saveEphemeralMessage(ctx, msg)
+	}
 	if err := adp.cache.SaveMessage(ctx, msg); err != nil {
 		log.Printf("[WARN] cache save message failed: %v", err)
+		// 缓存中的消息集合缺少这条消息，不能再当作完整的集合
+		_ = adp.cache.InvalidateSessionMessages(ctx, msg.SessionID)
+	}
+	if err == nil && session != nil {
+		if err := adp.cache.TouchSession(ctx, session, msg); err != nil {
+			log.Printf("[WARN] cache touch session failed: %v", err)
+		}
 	}
 	if adp.producer != nil {
 		if err := adp.producer.SendSaveMessageEvent(msg); err != nil {
@@ -50,10 +68,28 @@ func (adp *ChatRepositoryAdapter) SaveMessage(ctx context.Context, msg *domain.M
 	return nil
 }
 
+// saveEphemeralMessage 无痕会话已过期或缓存不可读时返回错误，消息不会改写到数据库
+func (adp *ChatRepositoryAdapter) saveEphemeralMessage(ctx context.Context, msg *domain.Message) error {
+	if _, err := adp.cache.GetSession(ctx, msg.SessionID); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return domain.ErrSessionNotFound
+		}
+		return fmt.Errorf("read ephemeral session: %w", err)
+	}
+	return adp.cache.SaveEphemeralMessage(ctx, msg)
+}
+
 func (adp *ChatRepositoryAdapter) SaveSession(ctx context.Context, session *domain.Session) error {
+	if session.Ephemeral {
+		return adp.cache.SaveEphemeralSession(ctx, session)
+	}
 	if err := adp.cache.SaveSession(ctx, session); err != nil {
 		log.Printf("[WARN] cache save session failed: %v", err)
 	}
+	// 新建的会话还没有消息，之后的消息都会写入缓存，缓存中的消息集合从一开始就是完整的
+	if err := adp.cache.SaveSessionMessages(ctx, session.ID, nil, true); err != nil {
+		log.Printf("[WARN] cache mark session messages failed: %v", err)
+	}
 	if adp.producer != nil {
 		if err := adp.producer.SendSaveSessionEvent(session); err != nil {
 			log.Printf("[ERROR] send session to MQ failed, fallback to sync write: %v", err)
@@ -88,38 +124,114 @@ func (adp *ChatRepositoryAdapter) GetSession(ctx context.Context, sessionID stri
 	return session, nil
 }
 
-func (adp *ChatRepositoryAdapter) GetSessionMessages(ctx context.Context, sessionID string, limit, offset int) ([]*domain.Message, error) {
-	// 读缓存
-	messages, err := adp.cache.GetSessionMessages(ctx, sessionID, limit, offset)
-	if err == nil && len(messages) > 0 {
-		return messages, nil
+func (adp *ChatRepositoryAdapter) GetSessionMessages(ctx context.Context, sessionID string, limit int, cursor string) (*domain.MessagePage, error) {
+	ephemeral, err := adp.isEphemeral(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if ephemeral {
+		return adp.cache.GetEphemeralMessages(ctx, sessionID, limit, cursor)
+	}
+	// 数据库经 MQ 异步写入，命中时页面与总数都取自缓存，刚保存的消息也能读到
+	page, err := adp.cache.GetSessionMessages(ctx, sessionID, limit, cursor)
+	if err == nil {
+		return page, nil
+	}
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		return nil, err
 	}
 
 	// Miss
-	messages, err = adp.msgRepo.FindBySessionID(ctx, sessionID, limit, offset)
+	page, err = adp.msgRepo.FindBySessionID(ctx, sessionID, limit, cursor)
 	if err != nil {
 		return nil, err
 	}
 
-	// 回写缓存
+	// 回写缓存：第一页就是最后一页时读到的是会话的全部消息，回写后缓存中的集合是完整的
+	complete := cursor == "" && page.NextCursor == ""
 	go func(msgs []*domain.Message) {
-		for _, m := range msgs {
-			_ = adp.cache.SaveMessage(context.Background(), m)
+		_ = adp.cache.SaveSessionMessages(context.Background(), sessionID, msgs, complete)
+	}(page.Messages)
+
+	return page, nil
+}
+
+// GetSessionMessagesSince 直接读库，供召回索引增量同步（缓存只保留最近消息，不适合全量扫描）
+func (adp *ChatRepositoryAdapter) GetSessionMessagesSince(ctx context.Context, sessionID string, since time.Time) ([]*domain.Message, error) {
+	return adp.msgRepo.FindBySessionIDSince(ctx, sessionID, since)
+}
+
+// GetSessionMessagesUntil 直接读库，供分叉复制历史
+func (adp *ChatRepositoryAdapter) GetSessionMessagesUntil(ctx context.Context, until *domain.Message) ([]*domain.Message, error) {
+	return adp.msgRepo.FindBySessionIDUntil(ctx, until)
+}
+
+// GetSessionMessagesAfter 直接读库，供导出逐批读取完整历史
+func (adp *ChatRepositoryAdapter) GetSessionMessagesAfter(ctx context.Context, sessionID string, after *domain.Message, limit int) ([]*domain.Message, error) {
+	return adp.msgRepo.FindBySessionIDAfter(ctx, sessionID, after, limit)
+}
+
+// ForkSession 同步写库（不经 MQ），保证返回后分叉会话即可读取；消息缓存留待首次读取时回源
+func (adp *ChatRepositoryAdapter) ForkSession(ctx context.Context, session *domain.Session, messages []*domain.Message) error {
+	if err := adp.sessionRepo.SaveFork(ctx, session, messages); err != nil {
+		return err
+	}
+	if err := adp.cache.SaveSession(ctx, session); err != nil {
+		log.Printf("[WARN] cache save session failed: %v", err)
+	}
+	return nil
+}
+
+// ImportSession 同步写库：先保存会话再分批写入消息，消息写入失败时删除已保存的会话
+func (adp *ChatRepositoryAdapter) ImportSession(ctx context.Context, session *domain.Session, messages []*domain.Message) error {
+	if err := adp.sessionRepo.Save(ctx, session); err != nil {
+		return err
+	}
+	if err := adp.msgRepo.SaveBatch(ctx, messages); err != nil {
+		if delErr := adp.sessionRepo.DeleteByID(ctx, session.ID); delErr != nil {
+			log.Printf("[ERROR] rollback imported session %s failed: %v", session.ID, delErr)
 		}
-	}(messages)
+		return err
+	}
+	if err := adp.cache.SaveSession(ctx, session); err != nil {
+		log.Printf("[WARN] cache save session failed: %v", err)
+	}
+	return nil
+}
 
-	return messages, nil
+func (adp *ChatRepositoryAdapter) HasImportedSession(ctx context.Context, userID, workspaceID, importedFrom string) (bool, error) {
+	return adp.sessionRepo.ExistsImported(ctx, userID, workspaceID, importedFrom)
 }
 
-func (adp *ChatRepositoryAdapter) GetSessions(ctx context.Context, userID string, limit, offset int) ([]*domain.Session, error) {
-	// 尝试从缓存读取
-	sessions, err := adp.cache.GetUserSessions(ctx, userID, limit, offset)
-	if err == nil && len(sessions) > 0 {
-		return sessions, nil
+// SearchMessages 直接读库做全文检索，数据库不可用时检索关闭
+func (adp *ChatRepositoryAdapter) SearchMessages(ctx context.Context, query domain.MessageSearchQuery) (*domain.MessageSearchPage, error) {
+	if adp.msgRepo == nil {
+		return nil, domain.ErrSearchUnavailable
+	}
+	return adp.msgRepo.Search(ctx, query)
+}
+
+func (adp *ChatRepositoryAdapter) GetSessions(ctx context.Context, query domain.SessionQuery) (*domain.SessionPage, error) {
+	// 缓存只保存默认列表（未归档、按最后活动时间），其他条件直接读库；总数以数据库为准
+	var sessions []*domain.Session
+	var next string
+	err := cache.ErrCacheMiss
+	if query.Sort == domain.SessionSortActivity && query.FolderID == "" && query.Tag == "" && !query.Archived && !query.Joined {
+		sessions, next, err = adp.cache.GetUserSessions(ctx, query.UserID, query.WorkspaceID, query.Limit, query.Cursor)
+	}
+	if err == nil {
+		total, err := adp.sessionRepo.CountByUserID(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.SessionPage{Sessions: sessions, Total: total, NextCursor: next}, nil
+	}
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		return nil, err
 	}
 
 	// 缓存miss, 则从数据库读取
-	sessions, err = adp.sessionRepo.FindByUserID(ctx, userID, limit, offset)
+	page, err := adp.sessionRepo.FindByUserID(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -129,9 +241,68 @@ func (adp *ChatRepositoryAdapter) GetSessions(ctx context.Context, userID string
 		for _, s := range ss {
 			_ = adp.cache.SaveSession(context.Background(), s)
 		}
-	}(sessions)
+	}(page.Sessions)
 
-	return sessions, nil
+	return page, nil
+}
+
+// UpdateSession 同步写库（不经过 MQ，避免与会话创建事件乱序覆盖），再以库中最新状态刷新缓存
+func (adp *ChatRepositoryAdapter) UpdateSession(ctx context.Context, session *domain.Session) error {
+	if err := adp.sessionRepo.UpdateOrganization(ctx, session); err != nil {
+		return err
+	}
+	fresh, err := adp.sessionRepo.FindByID(ctx, session.ID)
+	if err != nil || fresh == nil {
+		// 读不到最新状态时删除缓存，由下次读取回源
+		if err := adp.cache.InvalidateSessions(ctx, []string{session.ID}); err != nil {
+			log.Printf("[WARN] cache invalidate session failed: %v", err)
+		}
+		return err
+	}
+	if err := adp.cache.SaveSession(ctx, fresh); err != nil {
+		log.Printf("[WARN] cache save session failed: %v", err)
+	}
+	return nil
+}
+
+// ClearSessionFolder 把文件夹中的会话移出该文件夹，并删除这些会话的缓存
+func (adp *ChatRepositoryAdapter) ClearSessionFolder(ctx context.Context, userID, folderID string) error {
+	sessionIDs, err := adp.sessionRepo.ClearFolder(ctx, userID, folderID)
+	if err != nil {
+		return err
+	}
+	if err := adp.cache.InvalidateSessions(ctx, sessionIDs); err != nil {
+		log.Printf("[WARN] cache invalidate sessions failed: %v", err)
+	}
+	return nil
+}
+
+func (adp *ChatRepositoryAdapter) GetMessage(ctx context.Context, messageID string) (*domain.Message, error) {
+	msg, err := adp.cache.GetMessage(ctx, messageID)
+	if err == nil && msg != nil {
+		return msg, nil
+	}
+	return adp.msgRepo.FindByMessageID(ctx, messageID)
+}
+
+func (adp *ChatRepositoryAdapter) GetPinnedMessages(ctx context.Context, sessionID string) ([]*domain.Message, error) {
+	return adp.msgRepo.FindPinnedBySessionID(ctx, sessionID)
+}
+
+func (adp *ChatRepositoryAdapter) SetMessagePinned(ctx context.Context, messageID string, pinned bool) error {
+	if err := adp.msgRepo.UpdatePinned(ctx, messageID, pinned); err != nil {
+		return err
+	}
+
+	// 同步缓存中的消息，避免读到旧的置顶状态
+	msg, err := adp.cache.GetMessage(ctx, messageID)
+	if err == nil && msg != nil {
+		msg.Pinned = pinned
+		if err := adp.cache.SaveMessage(ctx, msg); err != nil {
+			log.Printf("[WARN] cache update message failed: %v", err)
+		}
+	}
+	return nil
 }
 
 func (adp *ChatRepositoryAdapter) DeleteMessage(ctx context.Context, messageID string) error {
@@ -154,15 +325,18 @@ func (adp *ChatRepositoryAdapter) DeleteMessage(ctx context.Context, messageID s
 }
 
 func (adp *ChatRepositoryAdapter) DeleteSession(ctx context.Context, sessionID string) error {
-	// 1. Get Session to find UserID
+	// 1. Get Session to find UserID and WorkspaceID
 	session, _ := adp.cache.GetSession(ctx, sessionID)
+	if session != nil && session.Ephemeral {
+		return adp.cache.DeleteEphemeralSession(ctx, sessionID)
+	}
 	if session == nil {
 		session, _ = adp.sessionRepo.FindByID(ctx, sessionID)
 	}
 
 	// 2. Delete from Cache
 	if session != nil {
-		if err := adp.cache.DeleteSession(ctx, session.UserID, sessionID); err != nil {
+		if err := adp.cache.DeleteSession(ctx, session); err != nil {
 			log.Printf("[WARN] cache delete session failed: %v", err)
 		}
 	} else {
@@ -178,3 +352,37 @@ func (adp *ChatRepositoryAdapter) DeleteSession(ctx context.Context, sessionID s
 	// 4. Delete Session from DB
 	return adp.sessionRepo.DeleteByID(ctx, sessionID)
 }
+
+// EvictSessions 账户删除彻底删除会话后调用
+func (adp *ChatRepositoryAdapter) EvictSessions(ctx context.Context, sessions []*domain.Session) error {
+	return adp.cache.EvictSessions(ctx, sessions)
+}
+
+func (adp *ChatRepositoryAdapter) EvictUserSessions(ctx context.Context, userID string) error {
+	return adp.cache.EvictUserSessions(ctx, userID)
+}
+
+// EvictMessages 清理任务彻底删除消息后调用，缓存中的会话统计随之失效，下次读取时回源
+func (adp *ChatRepositoryAdapter) EvictMessages(ctx context.Context, messages []*domain.Message) error {
+	return adp.cache.EvictMessages(ctx, messages)
+}
+
+// isEphemeral 无痕会话只存在于缓存中。缓存不可读时无法判断，返回错误而不是按持久会话读库；
+// 缓存中没有的会话回源确认，已过期的无痕会话在库中同样不存在，返回 ErrSessionNotFound
+func (adp *ChatRepositoryAdapter) isEphemeral(ctx context.Context, sessionID string) (bool, error) {
+	session, err := adp.cache.GetSession(ctx, sessionID)
+	if err == nil {
+		return session.Ephemeral, nil
+	}
+	if !errors.Is(err, cache.ErrCacheMiss) {
+		return false, fmt.Errorf("read session from cache: %w", err)
+	}
+	session, err = adp.GetSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil {
+		return false, domain.ErrSessionNotFound
+	}
+	return session.Ephemeral, nil
+}
@@ -0,0 +1,101 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestAdapter 只接入缓存：无痕会话的任何操作都不应访问数据库或 MQ，访问即空指针 panic
+func newTestAdapter(t *testing.T) (*ChatRepositoryAdapter, *cache.RedisCache, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	c, err := cache.NewRedisCache(client)
+	if err != nil {
+		t.Fatalf("NewRedisCache: %v", err)
+	}
+	return NewChatRepositoryAdapter(c, nil, nil, nil, nil), c, mr
+}
+
+func TestSaveMessage_EphemeralSessionExpired(t *testing.T) {
+	ctx := context.Background()
+	adp, c, mr := newTestAdapter(t)
+	if err := c.SaveEphemeralSession(ctx, &domain.Session{ID: "s1", UserID: "u1", Ephemeral: true}); err != nil {
+		t.Fatalf("SaveEphemeralSession: %v", err)
+	}
+	msg := &domain.Message{ID: "m1", SessionID: "s1", Content: "secret", Ephemeral: true, CreatedAt: time.Now()}
+	if err := adp.SaveMessage(ctx, msg); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+
+	// 会话在对话中途过期，后续消息既不能写库也不能在缓存中留下孤立的消息
+	mr.FastForward(cache.EphemeralTTL + time.Minute)
+	msg = &domain.Message{ID: "m2", SessionID: "s1", Content: "secret", Ephemeral: true, CreatedAt: time.Now()}
+	if err := adp.SaveMessage(ctx, msg); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Fatalf("SaveMessage after expiry err = %v, want ErrSessionNotFound", err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Fatalf("keys left after expiry: %v", keys)
+	}
+}
+
+func TestEphemeralSession_CacheUnavailable(t *testing.T) {
+	ctx := context.Background()
+	adp, c, mr := newTestAdapter(t)
+	if err := c.SaveEphemeralSession(ctx, &domain.Session{ID: "s1", UserID: "u1", Ephemeral: true}); err != nil {
+		t.Fatalf("SaveEphemeralSession: %v", err)
+	}
+	mr.Close()
+
+	msg := &domain.Message{ID: "m1", SessionID: "s1", Content: "secret", Ephemeral: true, CreatedAt: time.Now()}
+	if err := adp.SaveMessage(ctx, msg); err == nil {
+		t.Fatal("SaveMessage succeeded with the cache down")
+	}
+	if _, err := adp.GetSessionMessages(ctx, "s1", 10, ""); err == nil {
+		t.Fatal("GetSessionMessages succeeded with the cache down")
+	}
+}
+
+// 新会话的消息经 MQ 异步落库，读历史只走缓存：未接入数据库的适配器也能读到刚保存的消息
+func TestGetSessionMessages_ReadYourWrites(t *testing.T) {
+	ctx := context.Background()
+	adp, c, _ := newTestAdapter(t)
+	session := &domain.Session{ID: "s1", UserID: "u1", CreatedAt: time.Now()}
+	if err := c.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	// 与 SaveSession 新建会话时相同
+	if err := c.SaveSessionMessages(ctx, "s1", nil, true); err != nil {
+		t.Fatalf("SaveSessionMessages: %v", err)
+	}
+	for i, content := range []string{"hello", "hi there"} {
+		msg := &domain.Message{ID: "m" + content, SessionID: "s1", Content: content, CreatedAt: time.Now().Add(time.Duration(i) * time.Millisecond)}
+		if err := c.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+		if err := c.TouchSession(ctx, session, msg); err != nil {
+			t.Fatalf("TouchSession: %v", err)
+		}
+	}
+	page, err := adp.GetSessionMessages(ctx, "s1", 10, "")
+	if err != nil {
+		t.Fatalf("GetSessionMessages: %v", err)
+	}
+	if page.Total != 2 || len(page.Messages) != 2 || page.Messages[0].Content != "hi there" || page.NextCursor != "" {
+		t.Fatalf("page = %+v", page)
+	}
+}
@@ -2,7 +2,10 @@ package context
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"free-chat/services/chat-service/internal/domain"
 )
@@ -16,6 +19,15 @@ const sinkToken = "\n\n"
 // 利用首位效应（sink 后立即出现）和近因效应（当前输入前重申）提高命中率。
 const globalInstruction = "You are a helpful assistant. Respond concisely and accurately."
 
+// recalledHeader 引出长期召回片段，并提示模型这些是早先的对话原文
+const recalledHeader = "Earlier in this conversation (quoted, recalled for relevance):"
+
+// recallBudgetRatio 召回片段最多占用的剩余预算比例，避免挤占最近的对话
+const recallBudgetRatio = 0.25
+
+// ErrPinnedExceedsBudget 表示置顶消息本身已经超出上下文预算，无法再容纳任何历史。
+var ErrPinnedExceedsBudget = errors.New("pinned messages exceed context budget")
+
 // Budget manages token budget calculation for context window.
 type Budget struct {
 	MaxContextWindow int
@@ -66,6 +78,10 @@ type Topic struct {
 }
 
 // ContextBuilder assembles conversation context with token budget management.
+// Messages marked Pinned in history are always kept verbatim right after the
+// system prefix and are charged against the budget before anything else.
+// Messages marked Recalled are older turns brought back by long-term recall;
+// they are quoted in a single segment after the pinned ones, space permitting.
 type ContextBuilder interface {
 	Build(ctx context.Context, history []*domain.Message, userMessage string, modelMaxTokens int) (*BuiltContext, error)
 }
@@ -91,37 +107,52 @@ func NewDefaultBuilder(compressor Compressor, tokenizer TokenCounter) ContextBui
 func (b *defaultBuilder) Build(ctx context.Context, history []*domain.Message, userMessage string, modelMaxTokens int) (*BuiltContext, error) {
 	_ = ctx
 
-	// Step 1: 构建注意力优化后的消息前缀
-	messages := b.buildPrefixedContext(history)
+	recalled, history := splitRecalled(history)
+	pinned, rest := splitPinned(history)
+	budget := NewBudget(modelMaxTokens, 2048, 256)
 
-	// Step 2: 估算 token 用量
-	usedTokens := 0
-	for _, msg := range messages {
-		if msg.TokenCount > 0 {
-			usedTokens += msg.TokenCount
-		} else if b.tokenizer != nil {
-			usedTokens += b.tokenizer.Count(msg.Content)
-		}
+	// Step 1: 置顶消息优先占用预算，放不下时直接报错而不是悄悄丢弃
+	pinnedTokens := b.countMessages(pinned)
+	budget.UsedTokens = pinnedTokens
+	if len(pinned) > 0 && budget.IsExhausted() {
+		return nil, fmt.Errorf("%w: pinned %d tokens, budget %d tokens",
+			ErrPinnedExceedsBudget, pinnedTokens, budget.MaxContextWindow-budget.ReservedOutput-budget.SafetyMargin)
+	}
+
+	// Step 1.5: 召回的旧消息以引用形式紧跟置顶消息，与置顶一样不参与压缩
+	recallLimit := int(float64(budget.Available()) * recallBudgetRatio)
+	recallSeg, recalledTokens := recalledSegment(recalled, recallLimit, func(text string) int {
+		return countTokens(b.tokenizer, text)
+	})
+	anchored := pinned
+	if recallSeg != nil {
+		anchored = append(append([]*domain.Message(nil), pinned...), recallSeg)
 	}
+
+	// Step 2: 构建注意力优化后的消息前缀
+	messages := b.buildPrefixedContext(anchored, rest)
+
+	// Step 3: 估算 token 用量
+	usedTokens := b.countMessages(messages)
 	inputTokens := 0
 	if b.tokenizer != nil {
 		inputTokens = b.tokenizer.Count(userMessage)
 	}
 	usedTokens += inputTokens
-
-	budget := NewBudget(modelMaxTokens, 2048, 256)
 	budget.UsedTokens = usedTokens
 
-	// Step 3: 预算不足时压缩（仅压缩历史部分，保留 prefix 结构）
-	if budget.IsExhausted() && b.compressor != nil && len(history) > 5 {
-		targetBudget := budget.MaxContextWindow - budget.ReservedOutput - budget.SafetyMargin
-		segments, err := b.compressor.Compress(ctx, "", history, targetBudget)
+	// Step 4: 预算不足时压缩（仅压缩非置顶历史，保留 prefix 和置顶结构）
+	// 压缩目标 = 总预算 - 前缀/置顶/当前输入等固定开销 - 结尾重申的指令
+	if budget.IsExhausted() && b.compressor != nil && len(rest) > 5 {
+		fixedTokens := usedTokens - b.countMessages(rest) + b.countText(globalInstruction)
+		targetBudget := budget.MaxContextWindow - budget.ReservedOutput - budget.SafetyMargin - fixedTokens
+		segments, err := b.compressor.Compress(ctx, "", rest, targetBudget)
 		if err == nil {
-			return b.buildFromSegments(segments, userMessage, "compressed", budget)
+			return b.buildFromSegments(anchored, segments, userMessage, "compressed", budget)
 		}
 	}
 
-	// Step 4: 追加当前用户消息（近因效应：关键指令在用户输入前重申）
+	// Step 5: 追加当前用户消息（近因效应：关键指令在用户输入前重申）
 	if len(messages) > 0 {
 		// 重申关键约束（近因效应）
 		messages = append(messages, &domain.Message{
@@ -140,8 +171,10 @@ func (b *defaultBuilder) Build(ctx context.Context, history []*domain.Message, u
 		Messages: messages,
 		Strategy: "full",
 		Compression: map[string]interface{}{
-			"ratio":       0.0,
-			"used_tokens": usedTokens,
+			"ratio":           0.0,
+			"used_tokens":     usedTokens,
+			"pinned_tokens":   pinnedTokens,
+			"recalled_tokens": recalledTokens,
 		},
 		TokenBudget: budget,
 	}, nil
@@ -151,8 +184,9 @@ func (b *defaultBuilder) Build(ctx context.Context, history []*domain.Message, u
 //
 //	位置 0: [SINK_TOKEN]       ← 吸收 attention sink
 //	位置 1: [SYSTEM_PROMPT]   ← 首位效应：核心指令
-//	位置 2+: [HISTORY]        ← 对话历史（从旧到新）
-func (b *defaultBuilder) buildPrefixedContext(history []*domain.Message) []*domain.Message {
+//	位置 2+: [PINNED]         ← 置顶消息（原文）
+//	之后:   [HISTORY]         ← 对话历史（从旧到新）
+func (b *defaultBuilder) buildPrefixedContext(pinned, history []*domain.Message) []*domain.Message {
 	var messages []*domain.Message
 
 	// 位置 0: sink token（吸收 attention sink 效应）
@@ -167,20 +201,22 @@ func (b *defaultBuilder) buildPrefixedContext(history []*domain.Message) []*doma
 		Content: fmt.Sprintf("%s\n\n%s", globalInstruction, "When in doubt, think step by step."),
 	})
 
-	// 位置 2+: 对话历史（从旧到新）
+	// 位置 2+: 置顶消息，然后是对话历史（从旧到新）
+	messages = append(messages, pinned...)
 	messages = append(messages, history...)
 
 	return messages
 }
 
-func (b *defaultBuilder) buildFromSegments(segments []*CompressedSegment, userMessage string, strategy string, budget *Budget) (*BuiltContext, error) {
+func (b *defaultBuilder) buildFromSegments(pinned []*domain.Message, segments []*CompressedSegment, userMessage string, strategy string, budget *Budget) (*BuiltContext, error) {
 	var messages []*domain.Message
 	originalTokens := 0
 	compressedTokens := 0
 
-	// 保持前缀结构：sink → system → compressed history
+	// 保持前缀结构：sink → system → pinned → compressed history
 	messages = append(messages, &domain.Message{Role: domain.RoleSystem, Content: sinkToken})
 	messages = append(messages, &domain.Message{Role: domain.RoleSystem, Content: globalInstruction})
+	messages = append(messages, pinned...)
 
 	for _, seg := range segments {
 		msg := &domain.Message{
@@ -216,4 +252,103 @@ func (b *defaultBuilder) buildFromSegments(segments []*CompressedSegment, userMe
 	}, nil
 }
 
+// countMessages 统计消息的 token 数，优先使用已存储的 TokenCount
+func (b *defaultBuilder) countMessages(messages []*domain.Message) int {
+	total := 0
+	for _, msg := range messages {
+		if msg.TokenCount > 0 {
+			total += msg.TokenCount
+		} else if b.tokenizer != nil {
+			total += b.tokenizer.Count(msg.Content)
+		}
+	}
+	return total
+}
+
+func (b *defaultBuilder) countText(text string) int {
+	if b.tokenizer == nil {
+		return 0
+	}
+	return b.tokenizer.Count(text)
+}
+
+// splitPinned 将历史拆分为置顶消息和普通消息，两者都保持原有顺序
+func splitPinned(history []*domain.Message) (pinned, rest []*domain.Message) {
+	for _, msg := range history {
+		if msg.Pinned {
+			pinned = append(pinned, msg)
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+	return pinned, rest
+}
+
+// splitRecalled 取出长期召回带回的消息（保持相关度顺序），其余消息原样返回
+func splitRecalled(history []*domain.Message) (recalled, rest []*domain.Message) {
+	for _, msg := range history {
+		if msg.Recalled {
+			recalled = append(recalled, msg)
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+	return recalled, rest
+}
+
+// recalledSegment 按相关度依次选取召回消息直到用满 limit，再按时间顺序渲染为一条引用消息：
+//
+//	Earlier in this conversation (quoted, recalled for relevance):
+//	> user: ...
+//	> assistant: ...
+func recalledSegment(recalled []*domain.Message, limit int, count func(string) int) (*domain.Message, int) {
+	if len(recalled) == 0 || limit <= 0 {
+		return nil, 0
+	}
+
+	used := count(recalledHeader)
+	var selected []*domain.Message
+	var quoted []string
+	for _, msg := range recalled {
+		q := quoteMessage(msg)
+		tokens := count("\n\n" + q)
+		if used+tokens > limit {
+			continue
+		}
+		used += tokens
+		selected = append(selected, msg)
+		quoted = append(quoted, q)
+	}
+	if len(selected) == 0 {
+		return nil, 0
+	}
+
+	order := make([]int, len(selected))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return selected[order[a]].CreatedAt.Before(selected[order[b]].CreatedAt)
+	})
+
+	var sb strings.Builder
+	sb.WriteString(recalledHeader)
+	for _, i := range order {
+		sb.WriteString("\n\n")
+		sb.WriteString(quoted[i])
+	}
+	content := sb.String()
+	return &domain.Message{Role: domain.RoleSystem, Content: content}, count(content)
+}
+
+// quoteMessage 以 Markdown 引用格式渲染一条消息，多行内容逐行加 "> "
+func quoteMessage(msg *domain.Message) string {
+	lines := strings.Split(msg.Content, "\n")
+	lines[0] = fmt.Sprintf("%s: %s", msg.Role, lines[0])
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
 var _ ContextBuilder = (*defaultBuilder)(nil)
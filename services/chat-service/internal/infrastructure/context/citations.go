@@ -0,0 +1,169 @@
+package context
+
+import (
+	"strconv"
+	"unicode"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// 模型没有标注 [n] 时按词项重合度回退：句子至少 citeMinTerms 个词项，
+// 且其中 citeOverlapRatio 以上出现在某个来源中
+const (
+	citeMinTerms     = 3
+	citeOverlapRatio = 0.5
+)
+
+// Citation 把回答中 [Start, End)（rune 偏移）的一句话对应到第 Source 个来源（从 1 开始，
+// 与 DocumentSegment 中的编号一致）
+type Citation struct {
+	Start  int
+	End    int
+	Source int
+	Chunk  *domain.DocumentChunk
+}
+
+// Cite 根据回答中的 [n] 标记生成引用，每个标记对应其所在的句子；
+// 整个回答都没有有效标记时，按句子与来源的词项重合度推断引用。
+func Cite(answer string, sources []*domain.DocumentChunk) []Citation {
+	if len(sources) == 0 {
+		return nil
+	}
+	runes := []rune(answer)
+	spans := sentenceSpans(runes)
+
+	var citations []Citation
+	for _, sp := range spans {
+		seen := make(map[int]bool)
+		for _, n := range citationMarkers(runes[sp[0]:sp[1]]) {
+			if n < 1 || n > len(sources) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			citations = append(citations, Citation{Start: sp[0], End: sp[1], Source: n, Chunk: sources[n-1]})
+		}
+	}
+	if len(citations) > 0 {
+		return citations
+	}
+
+	sourceTerms := make([]map[string]bool, len(sources))
+	for i, s := range sources {
+		sourceTerms[i] = termSet(s.Content)
+	}
+	for _, sp := range spans {
+		terms := termSet(string(runes[sp[0]:sp[1]]))
+		if len(terms) < citeMinTerms {
+			continue
+		}
+		best, bestOverlap := -1, 0
+		for i, set := range sourceTerms {
+			overlap := 0
+			for t := range terms {
+				if set[t] {
+					overlap++
+				}
+			}
+			if overlap > bestOverlap {
+				best, bestOverlap = i, overlap
+			}
+		}
+		if best >= 0 && float64(bestOverlap) >= citeOverlapRatio*float64(len(terms)) {
+			citations = append(citations, Citation{Start: sp[0], End: sp[1], Source: best + 1, Chunk: sources[best]})
+		}
+	}
+	return citations
+}
+
+// sentenceSpans 返回去掉首尾空白后的句子区间。句末标点之后紧跟的 [n] 标记归入该句，
+// 因为模型常把引用写在句号后面。
+func sentenceSpans(runes []rune) [][2]int {
+	var spans [][2]int
+	emit := func(start, end int) {
+		for start < end && unicode.IsSpace(runes[start]) {
+			start++
+		}
+		for end > start && unicode.IsSpace(runes[end-1]) {
+			end--
+		}
+		if start < end {
+			spans = append(spans, [2]int{start, end})
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		end := sentenceTerminators[r]
+		if r == '.' && (i+1 == len(runes) || unicode.IsSpace(runes[i+1]) || runes[i+1] == '[') {
+			end = true
+		}
+		if !end {
+			continue
+		}
+		stop := i + 1
+		if r != '\n' {
+			stop = skipMarkers(runes, stop)
+		}
+		emit(start, stop)
+		start = stop
+		i = stop - 1
+	}
+	emit(start, len(runes))
+	return spans
+}
+
+// skipMarkers 越过 from 之后的空格与连续的 [n] 标记，返回标记结束位置；没有标记时返回 from
+func skipMarkers(runes []rune, from int) int {
+	end := from
+	for {
+		j := end
+		for j < len(runes) && (runes[j] == ' ' || runes[j] == '\t') {
+			j++
+		}
+		next, ok := parseMarker(runes, j)
+		if !ok {
+			return end
+		}
+		end = next
+	}
+}
+
+// citationMarkers 返回句子中所有 [n] 标记的编号
+func citationMarkers(runes []rune) []int {
+	var markers []int
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '[' {
+			continue
+		}
+		if next, ok := parseMarker(runes, i); ok {
+			n, _ := strconv.Atoi(string(runes[i+1 : next-1]))
+			markers = append(markers, n)
+			i = next - 1
+		}
+	}
+	return markers
+}
+
+// parseMarker 解析 at 处的 [n]（n 为 1~3 位数字），返回标记之后的位置
+func parseMarker(runes []rune, at int) (int, bool) {
+	if at >= len(runes) || runes[at] != '[' {
+		return 0, false
+	}
+	j := at + 1
+	for j < len(runes) && j-at <= 3 && runes[j] >= '0' && runes[j] <= '9' {
+		j++
+	}
+	if j == at+1 || j >= len(runes) || runes[j] != ']' {
+		return 0, false
+	}
+	return j + 1, true
+}
+
+func termSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range tokenizeTerms(text) {
+		set[t] = true
+	}
+	return set
+}
@@ -29,94 +29,206 @@ type CompressedSegment struct {
 // Compressor summarizes old conversation turns to save token budget.
 type Compressor interface {
 	// Compress returns a compressed representation of messages.
+	// The sum of CompressedTokens of the returned segments never exceeds targetBudget.
 	Compress(ctx context.Context, sessionID string, messages []*domain.Message, targetBudget int) ([]*CompressedSegment, error)
 }
 
-type defaultCompressor struct{}
+const (
+	// recencyDecay 每往前一条消息，其信息价值乘以该系数
+	recencyDecay = 0.85
+	// minRecencyWeight 防止很旧的消息价值衰减到 0
+	minRecencyWeight = 0.05
+	// headlineTokens 标题级压缩的 token 上限
+	headlineTokens = 16
+)
+
+// levelRetention 各压缩级别保留的信息比例（相对原文）。
+// 比例是凹的：短摘要保留的信息/token 比原文更高，
+// 这样在预算紧张时会优先让更多旧消息以摘要形式保留下来。
+var levelRetention = map[CompressLevel]float64{
+	CompressLevelNone:   1.0,
+	CompressLevelLight:  0.7,
+	CompressLevelMedium: 0.45,
+	CompressLevelHeavy:  0.2,
+}
+
+type defaultCompressor struct {
+	counter TokenCounter
+}
 
-// NewDefaultCompressor creates a compressor that uses heuristic truncation.
+// NewDefaultCompressor creates a compressor that allocates a compression level per
+// message to retain as much information as possible within the budget.
+// counter is used for exact token costs; nil falls back to a rune-based estimate.
 // For production, swap in a Compressor that calls Python's LLM for summarization.
-func NewDefaultCompressor() Compressor {
-	return &defaultCompressor{}
+func NewDefaultCompressor(counter TokenCounter) Compressor {
+	return &defaultCompressor{counter: counter}
 }
 
-// Compress implements a simple level-based strategy:
-//   - Keep last 5 messages verbatim (Level 0)
-//   - Messages 6-20 get title-only (Level 3)
-//   - Messages 21+ get discarded (Level 4)
+// candidate 是某条消息在某个压缩级别下的候选结果
+type candidate struct {
+	level   CompressLevel
+	content string
+	tokens  int
+	value   float64
+}
+
+// Compress 为每条消息生成各压缩级别的候选，再交给 allocateLevels 在预算内分配。
 func (c *defaultCompressor) Compress(ctx context.Context, sessionID string, messages []*domain.Message, targetBudget int) ([]*CompressedSegment, error) {
 	_ = ctx
 	_ = sessionID
 
-	var segments []*CompressedSegment
-	var totalTokens int
-
-	for i := 0; i < len(messages); i++ {
-		idx := len(messages) - 1 - i // 从最新到最旧
-		m := messages[idx]
-		level := c.decideLevel(i, len(messages))
-
-		var seg *CompressedSegment
-		switch level {
-		case CompressLevelNone:
-			seg = &CompressedSegment{
-				OriginalTokens:   m.TokenCount,
-				CompressedTokens: m.TokenCount,
-				Content:          m.Content,
-				Role:             m.Role.String(),
-				Level:            level,
-			}
-		case CompressLevelLight, CompressLevelMedium:
-			content := m.Content
-			clippedLen := 100
-			if level == CompressLevelMedium {
-				clippedLen = 50
-			}
-			if len(content) > clippedLen {
-				content = content[:clippedLen] + "..."
-			}
-			seg = &CompressedSegment{
-				OriginalTokens:   m.TokenCount,
-				CompressedTokens: max(len(content)/2, 1),
-				Content:          content,
-				Role:             m.Role.String(),
-				Level:            level,
+	if targetBudget <= 0 || len(messages) == 0 {
+		return nil, nil
+	}
+
+	options := make([][]candidate, len(messages))
+	originals := make([]int, len(messages))
+	for i, m := range messages {
+		indexFromNewest := len(messages) - 1 - i
+		originals[i] = c.originalTokens(m)
+		options[i] = c.candidates(m, originals[i], recencyWeight(indexFromNewest))
+	}
+
+	chosen := allocateLevels(options, originals, targetBudget)
+
+	return assembleSegments(messages, options, originals, chosen), nil
+}
+
+// allocateLevels 把级别分配看作分组背包问题：每条消息从 Discard 开始，
+// 反复选择“单位 token 信息增益”最高且仍放得下的升级（更轻的压缩级别），
+// 直到没有可行升级为止。越新的消息权重越高，且最近一条消息放得下时固定保留原文。
+// options[i] 须按 token 成本递增排列，最后一项为原文；返回值中 -1 表示丢弃。
+func allocateLevels(options [][]candidate, originals []int, budget int) []int {
+	chosen := make([]int, len(options))
+	for i := range chosen {
+		chosen[i] = -1
+	}
+	remaining := budget
+
+	// 最近一条消息与当前输入衔接最紧密，放得下时直接保留原文
+	newest := len(options) - 1
+	if originals[newest] <= remaining {
+		chosen[newest] = len(options[newest]) - 1
+		remaining -= originals[newest]
+	}
+
+	for {
+		bestMsg, bestOpt := -1, -1
+		bestRatio := 0.0
+		for i, opts := range options {
+			curTokens, curValue := 0, 0.0
+			if chosen[i] >= 0 {
+				curTokens, curValue = opts[chosen[i]].tokens, opts[chosen[i]].value
 			}
-		case CompressLevelHeavy:
-			seg = &CompressedSegment{
-				OriginalTokens:   m.TokenCount,
-				CompressedTokens: 1,
-				Content:          "[compressed]",
-				Role:             m.Role.String(),
-				Level:            level,
+			for j := chosen[i] + 1; j < len(opts); j++ {
+				deltaTokens := opts[j].tokens - curTokens
+				if deltaTokens > remaining {
+					continue
+				}
+				ratio := (opts[j].value - curValue) / float64(max(deltaTokens, 1))
+				if ratio > bestRatio {
+					bestMsg, bestOpt, bestRatio = i, j, ratio
+				}
 			}
-		case CompressLevelDiscard:
+		}
+		if bestMsg < 0 {
+			return chosen
+		}
+		if chosen[bestMsg] >= 0 {
+			remaining += options[bestMsg][chosen[bestMsg]].tokens
+		}
+		remaining -= options[bestMsg][bestOpt].tokens
+		chosen[bestMsg] = bestOpt
+	}
+}
+
+// assembleSegments 按从旧到新的顺序输出分配结果，丢弃的消息不产生片段
+func assembleSegments(messages []*domain.Message, options [][]candidate, originals, chosen []int) []*CompressedSegment {
+	var segments []*CompressedSegment
+	for i, m := range messages {
+		if chosen[i] < 0 {
 			continue
 		}
+		opt := options[i][chosen[i]]
+		segments = append(segments, &CompressedSegment{
+			OriginalTokens:   originals[i],
+			CompressedTokens: opt.tokens,
+			Content:          opt.content,
+			Role:             m.Role.String(),
+			Level:            opt.level,
+		})
+	}
+	return segments
+}
 
-		segments = append(segments, seg)
-		totalTokens += seg.CompressedTokens
+// candidates 生成一条消息从最激进到原文的候选级别，token 成本严格递增；
+// 内容相同或不更省的级别会被跳过。
+func (c *defaultCompressor) candidates(m *domain.Message, original int, weight float64) []candidate {
+	levels := []struct {
+		level     CompressLevel
+		maxTokens int
+	}{
+		{CompressLevelHeavy, min(headlineTokens, original/8)},
+		{CompressLevelMedium, original / 4},
+		{CompressLevelLight, original / 2},
+	}
 
-		// 如果预算仍然不足且已经处理了足够多的旧消息，丢弃更多
-		if totalTokens >= targetBudget && i >= 5 {
-			break
+	var opts []candidate
+	lastTokens := 0
+	for _, l := range levels {
+		content := clipToTokens(m.Content, l.maxTokens, c.count)
+		if content == "" || content == m.Content {
+			continue
 		}
+		tokens := c.count(content)
+		if tokens <= lastTokens || tokens >= original {
+			continue
+		}
+		opts = append(opts, candidate{
+			level:   l.level,
+			content: content,
+			tokens:  tokens,
+			value:   weight * levelRetention[l.level] * float64(original),
+		})
+		lastTokens = tokens
 	}
 
-	// 反转回从旧到新
-	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
-		segments[i], segments[j] = segments[j], segments[i]
-	}
+	return append(opts, candidate{
+		level:   CompressLevelNone,
+		content: m.Content,
+		tokens:  original,
+		value:   weight * float64(original),
+	})
+}
+
+// originalTokens 优先使用存储的 TokenCount，否则实时计数
+func (c *defaultCompressor) originalTokens(m *domain.Message) int {
+	return originalTokens(c.counter, m)
+}
 
-	return segments, nil
+func (c *defaultCompressor) count(text string) int {
+	return countTokens(c.counter, text)
 }
 
-func (c *defaultCompressor) decideLevel(indexFromNewest, totalCount int) CompressLevel {
-	if indexFromNewest < 5 {
-		return CompressLevelNone
+func originalTokens(counter TokenCounter, m *domain.Message) int {
+	if m.TokenCount > 0 {
+		return m.TokenCount
 	}
-	if indexFromNewest < 20 {
-		return CompressLevelHeavy
+	return max(countTokens(counter, m.Content), 1)
+}
+
+// countTokens 有分词器时精确计数，否则退化为估算
+func countTokens(counter TokenCounter, text string) int {
+	if counter != nil {
+		return counter.Count(text)
+	}
+	return estimateTokens(text)
+}
+
+func recencyWeight(indexFromNewest int) float64 {
+	w := 1.0
+	for i := 0; i < indexFromNewest && w > minRecencyWeight; i++ {
+		w *= recencyDecay
 	}
-	return CompressLevelDiscard
+	return max(w, minRecencyWeight)
 }
@@ -0,0 +1,158 @@
+package context
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// runeCounter 是测试用的确定性分词器：每个 rune 计 1 token
+type runeCounter struct{}
+
+func (runeCounter) Count(text string) int { return utf8.RuneCountInString(text) }
+
+var sampleSentences = []string{
+	"微服务是一种将应用拆分为多个独立服务的架构风格。",
+	"每个服务都可以独立部署和扩展！",
+	"DDD 的限界上下文是拆分的理论基础？",
+	"Kafka partitions are the unit of parallelism.",
+	"Consumers in a group split partitions between them; ordering is per partition.",
+	"使用 Docker Compose 可以在本地启动全部依赖；",
+	"The answer is 3.14 for this example.",
+	"注意：金额统一以分为单位存储。",
+}
+
+func randomMessages(r *rand.Rand, n int) []*domain.Message {
+	messages := make([]*domain.Message, n)
+	for i := range messages {
+		var sb strings.Builder
+		for j := r.Intn(6) + 1; j > 0; j-- {
+			sb.WriteString(sampleSentences[r.Intn(len(sampleSentences))])
+			if r.Intn(3) == 0 {
+				sb.WriteString("\n")
+			}
+		}
+		role := domain.RoleUser
+		if i%2 == 1 {
+			role = domain.RoleAssistant
+		}
+		messages[i] = &domain.Message{Role: role, Content: sb.String()}
+	}
+	return messages
+}
+
+// TestCompressNeverExceedsBudget 属性测试：任意输入下压缩结果都不超出预算，且内容是合法 UTF-8
+func TestCompressNeverExceedsBudget(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	counters := map[string]TokenCounter{"rune": runeCounter{}, "estimate": nil}
+
+	for name, counter := range counters {
+		compressor := NewDefaultCompressor(counter)
+		for iter := 0; iter < 300; iter++ {
+			messages := randomMessages(r, r.Intn(40)+1)
+			budget := r.Intn(2000) + 1
+
+			segments, err := compressor.Compress(context.Background(), "s1", messages, budget)
+			if err != nil {
+				t.Fatalf("[%s] Compress failed: %v", name, err)
+			}
+
+			total := 0
+			for _, seg := range segments {
+				total += seg.CompressedTokens
+				if !utf8.ValidString(seg.Content) {
+					t.Fatalf("[%s] segment content is not valid UTF-8: %q", name, seg.Content)
+				}
+				if counter != nil && counter.Count(seg.Content) != seg.CompressedTokens {
+					t.Fatalf("[%s] CompressedTokens=%d but content counts %d", name, seg.CompressedTokens, counter.Count(seg.Content))
+				}
+			}
+			if total > budget {
+				t.Fatalf("[%s] iter %d: compressed tokens %d exceed budget %d", name, iter, total, budget)
+			}
+		}
+	}
+}
+
+// TestCompressUsesIntermediateLevels 验证预算适中时会用到 Light/Medium，而不是只有原文或丢弃
+func TestCompressUsesIntermediateLevels(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	messages := randomMessages(r, 30)
+
+	total := 0
+	for _, m := range messages {
+		total += runeCounter{}.Count(m.Content)
+	}
+
+	compressor := NewDefaultCompressor(runeCounter{})
+	segments, err := compressor.Compress(context.Background(), "s1", messages, total/3)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	used := map[CompressLevel]int{}
+	for _, seg := range segments {
+		used[seg.Level]++
+	}
+	if used[CompressLevelLight]+used[CompressLevelMedium] == 0 {
+		t.Errorf("expected some Light/Medium segments, got levels %v", used)
+	}
+}
+
+// TestCompressKeepsNewestVerbatim 验证预算允许时最新的消息保留原文
+func TestCompressKeepsNewestVerbatim(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	messages := randomMessages(r, 20)
+	newest := messages[len(messages)-1]
+
+	compressor := NewDefaultCompressor(runeCounter{})
+	segments, err := compressor.Compress(context.Background(), "s1", messages, runeCounter{}.Count(newest.Content)*2)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if len(segments) == 0 {
+		t.Fatal("expected at least one segment")
+	}
+	last := segments[len(segments)-1]
+	if last.Level != CompressLevelNone || last.Content != newest.Content {
+		t.Errorf("newest message should be verbatim, got level=%d content=%q", last.Level, last.Content)
+	}
+}
+
+func TestClipToTokensIsRuneSafe(t *testing.T) {
+	text := "这是一段很长的中文内容没有任何标点符号用来测试截断时是否会切坏多字节字符"
+	for limit := 1; limit < 20; limit++ {
+		clipped := clipToTokens(text, limit, runeCounter{}.Count)
+		if !utf8.ValidString(clipped) {
+			t.Fatalf("limit %d produced invalid UTF-8: %q", limit, clipped)
+		}
+		if n := (runeCounter{}).Count(clipped); n > limit {
+			t.Fatalf("limit %d produced %d tokens", limit, n)
+		}
+	}
+}
+
+func TestClipToTokensPrefersSentenceBoundary(t *testing.T) {
+	text := "第一句话。第二句话比较长一些。第三句。"
+	clipped := clipToTokens(text, 8, runeCounter{}.Count)
+	if clipped != "第一句话。"+ellipsis {
+		t.Errorf("expected clip at first sentence, got %q", clipped)
+	}
+}
+
+func TestSplitSentencesMixedPunctuation(t *testing.T) {
+	got := splitSentences("你好！What is 3.14? 这是第二句。Last one")
+	want := []string{"你好！", "What is 3.14?", "这是第二句。", "Last one"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d sentences, got %d: %q", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sentence %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
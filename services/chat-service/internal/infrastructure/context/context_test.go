@@ -116,7 +116,7 @@ func TestCompressorKeepsRecentVerbatim(t *testing.T) {
 		messages[i] = &domain.Message{Role: domain.RoleUser, Content: "msg", TokenCount: 2, CreatedAt: time.Now()}
 	}
 
-	compressor := NewDefaultCompressor()
+	compressor := NewDefaultCompressor(nil)
 	segments, err := compressor.Compress(context.Background(), "s1", messages, 1000)
 	if err != nil {
 		t.Fatalf("Compress failed: %v", err)
@@ -134,7 +134,7 @@ func TestCompressorReturnsRoleString(t *testing.T) {
 	messages := []*domain.Message{
 		{Role: domain.RoleUser, Content: "hello", TokenCount: 1, CreatedAt: time.Now()},
 	}
-	compressor := NewDefaultCompressor()
+	compressor := NewDefaultCompressor(nil)
 	segments, err := compressor.Compress(context.Background(), "s1", messages, 100)
 	if err != nil {
 		t.Fatalf("Compress failed: %v", err)
@@ -150,19 +150,26 @@ func TestCompressorReturnsRoleString(t *testing.T) {
 func TestCompressorDiscardsOldest(t *testing.T) {
 	messages := make([]*domain.Message, 25)
 	for i := 0; i < 25; i++ {
-		messages[i] = &domain.Message{Role: domain.RoleUser, Content: "test", TokenCount: 1, CreatedAt: time.Now()}
+		messages[i] = &domain.Message{Role: domain.RoleUser, Content: "test", TokenCount: 10, CreatedAt: time.Now()}
 	}
 
-	compressor := NewDefaultCompressor()
-	segments, err := compressor.Compress(context.Background(), "s1", messages, 1000)
+	compressor := NewDefaultCompressor(nil)
+	segments, err := compressor.Compress(context.Background(), "s1", messages, 100)
 	if err != nil {
 		t.Fatalf("Compress failed: %v", err)
 	}
 
-	// 25 messages, last 5 verbatim, messages 6-20 heavy-compressed, messages 21-24 discarded
-	// Total segments: 5 (verbatim) + 15 (heavy) = 20 (not 25)
-	if len(segments) > 20 {
-		t.Errorf("expected at most 20 segments after compression (5 verbatim + 15 heavy), got %d", len(segments))
+	// 25 messages × 10 tokens under a 100 token budget: only the newest 10 fit,
+	// the oldest ones are discarded first.
+	if len(segments) > 10 {
+		t.Errorf("expected at most 10 segments within budget, got %d", len(segments))
+	}
+	total := 0
+	for _, seg := range segments {
+		total += seg.CompressedTokens
+	}
+	if total > 100 {
+		t.Errorf("compressed tokens %d exceed budget 100", total)
 	}
 }
 
@@ -0,0 +1,310 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// 与 services/rag 的 RecursiveChunker 默认值一致，长度按字符（rune）计
+const (
+	defaultChunkSize    = 512
+	defaultChunkOverlap = 64
+)
+
+// chunkSeparators 从粗到细的切分符，"" 表示按字符硬切
+var chunkSeparators = []string{"\n\n", "\n", ".", "!", "?", " ", ""}
+
+// denseWeight 混合检索时稠密向量得分的权重，与 rag HybridRetriever 默认值一致
+const denseWeight = 0.5
+
+// documentHeader 引出检索到的文档片段，并要求模型以 [n] 标注引用
+const documentHeader = "Relevant excerpts from the user's documents. Use them when they help answer, and cite the excerpt you rely on with its number in square brackets, e.g. [1]:"
+
+// recursiveChunker 移植自 services/rag/src/chunker.py 的 RecursiveChunker。
+// 与 Python 版本不同，超长的片段会继续用更细的分隔符切分，而不是原样保留。
+type recursiveChunker struct {
+	size    int
+	overlap int
+}
+
+// NewDocumentChunker 创建递归切分器，size/overlap 非正时使用默认值
+func NewDocumentChunker(size, overlap int) domain.DocumentChunker {
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = defaultChunkOverlap
+		if overlap >= size {
+			overlap = 0
+		}
+	}
+	return &recursiveChunker{size: size, overlap: overlap}
+}
+
+func (c *recursiveChunker) Chunk(content string, format domain.DocumentFormat) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if format != domain.DocumentMarkdown {
+		return c.split(content, chunkSeparators)
+	}
+	// Markdown 先按标题分节，避免一个切片横跨两个章节
+	var chunks []string
+	for _, section := range markdownSections(content) {
+		chunks = append(chunks, c.split(section, chunkSeparators)...)
+	}
+	return chunks
+}
+
+func (c *recursiveChunker) split(text string, separators []string) []string {
+	if utf8.RuneCountInString(text) <= c.size {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return []string{text}
+	}
+
+	for i, sep := range separators {
+		if sep == "" {
+			return c.splitRunes(text)
+		}
+		parts := strings.Split(text, sep)
+		if len(parts) == 1 {
+			continue
+		}
+
+		var result []string
+		current := ""
+		flush := func() {
+			if strings.TrimSpace(current) != "" {
+				result = append(result, current)
+			}
+			current = ""
+		}
+		sepLen := utf8.RuneCountInString(sep)
+		for _, part := range parts {
+			partLen := utf8.RuneCountInString(part)
+			if partLen > c.size {
+				flush()
+				result = append(result, c.split(part, separators[i+1:])...)
+				continue
+			}
+			switch {
+			case current == "":
+				current = part
+			case utf8.RuneCountInString(current)+sepLen+partLen <= c.size:
+				current += sep + part
+			default:
+				// 新切片以上一个切片的末尾作为重叠部分开头
+				overlap := tailRunes(current, c.overlap)
+				flush()
+				if overlap != "" && utf8.RuneCountInString(overlap)+sepLen+partLen <= c.size {
+					current = overlap + sep + part
+				} else {
+					current = part
+				}
+			}
+		}
+		flush()
+		return result
+	}
+	return []string{text}
+}
+
+func (c *recursiveChunker) splitRunes(text string) []string {
+	runes := []rune(text)
+	step := c.size - c.overlap
+	var chunks []string
+	for i := 0; i < len(runes); i += step {
+		end := min(i+c.size, len(runes))
+		if chunk := string(runes[i:end]); strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
+
+// markdownSections 在每个 ATX 标题（# ~ ######）前断开，代码块内的 # 不算标题
+func markdownSections(text string) []string {
+	var sections []string
+	var current strings.Builder
+	inFence := false
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence && isMarkdownHeading(trimmed) && current.Len() > 0 {
+			sections = append(sections, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		sections = append(sections, current.String())
+	}
+	return sections
+}
+
+func isMarkdownHeading(line string) bool {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return false
+	}
+	return level == len(line) || line[level] == ' ' || line[level] == '\t' || line[level] == '\n'
+}
+
+func tailRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[len(runes)-n:])
+}
+
+// documentRetriever 对文档切片做 BM25 检索；配置了 Embedder 时与向量相似度做
+// min-max 归一化后加权融合（移植自 rag HybridRetriever）。
+type documentRetriever struct {
+	embedder domain.Embedder
+}
+
+// NewDocumentRetriever 创建文档检索器，embedder 可为 nil
+func NewDocumentRetriever(embedder domain.Embedder) domain.DocumentRetriever {
+	return &documentRetriever{embedder: embedder}
+}
+
+func (r *documentRetriever) Retrieve(ctx context.Context, query string, chunks []*domain.DocumentChunk, k int) ([]*domain.DocumentChunk, error) {
+	if k <= 0 || len(chunks) == 0 {
+		return nil, nil
+	}
+
+	docs := make([][]string, len(chunks))
+	for i, c := range chunks {
+		docs[i] = tokenizeTerms(c.Title + "\n" + c.Content)
+	}
+	sparse := newBM25Index(docs).search(tokenizeTerms(query), len(chunks), nil)
+
+	scores := make([]float64, len(chunks))
+	if r.embedder == nil {
+		for _, hit := range sparse {
+			scores[hit.Index] = hit.Score
+		}
+	} else {
+		dense, err := r.denseScores(ctx, query, chunks)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range normalizeScores(dense) {
+			scores[i] += denseWeight * s
+		}
+		hits := make([]float64, len(sparse))
+		for i, hit := range sparse {
+			hits[i] = hit.Score
+		}
+		for i, s := range normalizeScores(hits) {
+			scores[sparse[i].Index] += (1 - denseWeight) * s
+		}
+	}
+
+	order := make([]int, 0, len(chunks))
+	for i, s := range scores {
+		if s > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	if len(order) > k {
+		order = order[:k]
+	}
+	result := make([]*domain.DocumentChunk, len(order))
+	for i, idx := range order {
+		result[i] = chunks[idx]
+	}
+	return result, nil
+}
+
+func (r *documentRetriever) denseScores(ctx context.Context, query string, chunks []*domain.DocumentChunk) ([]float64, error) {
+	texts := make([]string, 0, len(chunks)+1)
+	texts = append(texts, query)
+	for _, c := range chunks {
+		texts = append(texts, c.Content)
+	}
+	vectors, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embed document chunks: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	scores := make([]float64, len(chunks))
+	for i := range chunks {
+		scores[i] = cosine(vectors[0], vectors[i+1])
+	}
+	return scores, nil
+}
+
+// normalizeScores 把得分 min-max 归一化到 [0, 1]，全部相等时都记为 1
+func normalizeScores(scores []float64) []float64 {
+	if len(scores) == 0 {
+		return nil
+	}
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores {
+		lo = math.Min(lo, s)
+		hi = math.Max(hi, s)
+	}
+	norm := make([]float64, len(scores))
+	for i, s := range scores {
+		if hi == lo {
+			norm[i] = 1
+		} else {
+			norm[i] = (s - lo) / (hi - lo)
+		}
+	}
+	return norm
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// DocumentSegment 把检索到的切片渲染为一条编号的 system 消息，编号从 1 开始，
+// 与 Cite 的来源序号对应。消息标记为 Pinned，没有切片时返回 nil。
+func DocumentSegment(chunks []*domain.DocumentChunk) *domain.Message {
+	if len(chunks) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(documentHeader)
+	for i, c := range chunks {
+		fmt.Fprintf(&sb, "\n\n[%d] (%s)\n%s", i+1, c.Title, strings.TrimSpace(c.Content))
+	}
+	return &domain.Message{Role: domain.RoleSystem, Content: sb.String(), Pinned: true}
+}
@@ -0,0 +1,161 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestDocumentChunkerRespectsSizeAndOverlap(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 40; i++ {
+		sb.WriteString("Kafka partitions are replicated across brokers for durability. ")
+	}
+	// 一整段没有换行，需要退化到句号和空格切分
+	text := sb.String()
+	chunks := NewDocumentChunker(200, 40).Chunk(text, domain.DocumentText)
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 200 {
+			t.Errorf("chunk %d has %d runes, want <= 200", i, n)
+		}
+	}
+	if !strings.HasPrefix(chunks[1], tailRunes(chunks[0], 40)) {
+		t.Errorf("second chunk should start with the overlap of the first:\n%q\n%q", chunks[0], chunks[1])
+	}
+
+	// 无分隔符的长文本按字符硬切
+	cjk := strings.Repeat("分布式系统", 100)
+	for i, c := range NewDocumentChunker(64, 8).Chunk(cjk, domain.DocumentPDF) {
+		if n := utf8.RuneCountInString(c); n > 64 {
+			t.Errorf("cjk chunk %d has %d runes", i, n)
+		}
+	}
+
+	if got := NewDocumentChunker(0, 0).Chunk("  \n\n ", domain.DocumentText); len(got) != 0 {
+		t.Errorf("blank document should produce no chunks, got %q", got)
+	}
+}
+
+func TestDocumentChunkerSplitsMarkdownSections(t *testing.T) {
+	md := "# Install\nRun the installer.\n\n```sh\n# not a heading\nmake\n```\n## Configure\nEdit config.yml.\n"
+	chunks := NewDocumentChunker(0, 0).Chunk(md, domain.DocumentMarkdown)
+	if len(chunks) != 2 {
+		t.Fatalf("expected one chunk per section, got %d: %q", len(chunks), chunks)
+	}
+	if !strings.Contains(chunks[0], "# not a heading") || !strings.HasPrefix(chunks[1], "## Configure") {
+		t.Errorf("unexpected sections: %q", chunks)
+	}
+}
+
+type stubEmbedder struct {
+	vectors map[string][]float32
+	err     error
+}
+
+func (e *stubEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = e.vectors[t]
+	}
+	return out, nil
+}
+
+func TestDocumentRetrieverRanksChunks(t *testing.T) {
+	chunks := []*domain.DocumentChunk{
+		{ID: "c1", Title: "ops", Content: "Redis is used as a cache in front of PostgreSQL."},
+		{ID: "c2", Title: "ops", Content: "Kafka partitions are assigned to consumers in a group."},
+		{ID: "c3", Title: "ops", Content: "Consul provides service discovery."},
+	}
+	ctx := context.Background()
+
+	got, err := NewDocumentRetriever(nil).Retrieve(ctx, "how are kafka partitions assigned?", chunks, 2)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "c2" {
+		t.Fatalf("BM25 should only return matching chunks, got %v", got)
+	}
+
+	// 稠密向量让语义相近但无词项重合的切片也能被召回
+	emb := &stubEmbedder{vectors: map[string][]float32{
+		"message broker sharding": {1, 0},
+		chunks[0].Content:         {0, 1},
+		chunks[1].Content:         {0.9, 0.1},
+		chunks[2].Content:         {0.1, 0.9},
+	}}
+	got, err = NewDocumentRetriever(emb).Retrieve(ctx, "message broker sharding", chunks, 1)
+	if err != nil {
+		t.Fatalf("hybrid Retrieve failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "c2" {
+		t.Fatalf("hybrid retrieval should prefer the semantic match, got %v", got)
+	}
+
+	emb.err = errors.New("model down")
+	if _, err := NewDocumentRetriever(emb).Retrieve(ctx, "kafka", chunks, 1); err == nil {
+		t.Error("embedder failure should be reported")
+	}
+}
+
+func TestCiteMapsMarkersToSentences(t *testing.T) {
+	sources := []*domain.DocumentChunk{
+		{ID: "c1", Content: "Redis is used as a cache."},
+		{ID: "c2", Content: "Kafka partitions are assigned to consumers."},
+	}
+	answer := "Partitions go to consumers in the group. [2] 缓存使用 Redis[1]。Out of range [7]."
+	citations := Cite(answer, sources)
+	if len(citations) != 2 {
+		t.Fatalf("expected 2 citations, got %+v", citations)
+	}
+	runes := []rune(answer)
+	if span := string(runes[citations[0].Start:citations[0].End]); span != "Partitions go to consumers in the group. [2]" || citations[0].Chunk.ID != "c2" {
+		t.Errorf("unexpected first citation %q -> %s", span, citations[0].Chunk.ID)
+	}
+	if span := string(runes[citations[1].Start:citations[1].End]); span != "缓存使用 Redis[1]。" || citations[1].Source != 1 {
+		t.Errorf("unexpected second citation %q -> %d", span, citations[1].Source)
+	}
+}
+
+func TestCiteFallsBackToTermOverlap(t *testing.T) {
+	sources := []*domain.DocumentChunk{
+		{ID: "c1", Content: "Kafka partitions are assigned to consumers in a group."},
+	}
+	answer := "Hello! Kafka assigns partitions to group consumers."
+	citations := Cite(answer, sources)
+	if len(citations) != 1 {
+		t.Fatalf("expected 1 inferred citation, got %+v", citations)
+	}
+	if span := string([]rune(answer)[citations[0].Start:citations[0].End]); span != "Kafka assigns partitions to group consumers." {
+		t.Errorf("unexpected span %q", span)
+	}
+	if Cite(answer, nil) != nil {
+		t.Error("no sources should produce no citations")
+	}
+}
+
+func TestDocumentSegmentNumbersSources(t *testing.T) {
+	seg := DocumentSegment([]*domain.DocumentChunk{
+		{Title: "guide.md", Content: "  Step one.  "},
+		{Title: "faq", Content: "Answer."},
+	})
+	if seg == nil || !seg.Pinned || seg.Role != domain.RoleSystem {
+		t.Fatalf("expected a pinned system message, got %+v", seg)
+	}
+	want := documentHeader + "\n\n[1] (guide.md)\nStep one.\n\n[2] (faq)\nAnswer."
+	if seg.Content != want {
+		t.Errorf("unexpected segment:\n%q\nwant:\n%q", seg.Content, want)
+	}
+	if DocumentSegment(nil) != nil {
+		t.Error("no chunks should produce no segment")
+	}
+}
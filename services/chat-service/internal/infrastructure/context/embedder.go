@@ -0,0 +1,109 @@
+package context
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+const (
+	// defaultEmbeddingDim hashing embedder 的默认维度
+	defaultEmbeddingDim = 256
+	// trigramWeight 字符三元组特征的权重，词项本身记 1。
+	// 三元组让 partition / partitioning 这类词形变化也能相互匹配。
+	trigramWeight = 0.5
+	// minTrigramWordRunes 只对足够长的词提取三元组（中文按二元组切分，不会达到该长度）
+	minTrigramWordRunes = 4
+)
+
+// hashingEmbedder 离线的特征哈希向量：词项与字符三元组哈希到固定维度后 L2 归一化。
+// 结果是确定的，不依赖模型，适合作为没有向量服务时的默认实现。
+type hashingEmbedder struct {
+	dim int
+}
+
+// NewHashingEmbedder 创建 hashing embedder，dim 非正时使用默认维度
+func NewHashingEmbedder(dim int) domain.Embedder {
+	if dim <= 0 {
+		dim = defaultEmbeddingDim
+	}
+	return &hashingEmbedder{dim: dim}
+}
+
+func (e *hashingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *hashingEmbedder) embed(text string) []float32 {
+	vec := make([]float64, e.dim)
+	for _, term := range tokenizeTerms(text) {
+		e.add(vec, term, 1)
+		if utf8.RuneCountInString(term) < minTrigramWordRunes {
+			continue
+		}
+		padded := []rune("<" + term + ">")
+		for i := 0; i+3 <= len(padded); i++ {
+			e.add(vec, "#"+string(padded[i:i+3]), trigramWeight)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	out := make([]float32, e.dim)
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vec {
+		out[i] = float32(v / norm)
+	}
+	return out
+}
+
+// add 把特征哈希到一个维度上，用哈希的另一位决定符号以抵消碰撞
+func (e *hashingEmbedder) add(vec []float64, feature string, weight float64) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vec[sum%uint64(e.dim)] += weight
+}
+
+// VectorHit 是向量检索的一条结果
+type VectorHit struct {
+	Index int
+	Score float64
+}
+
+// NearestVectors 暴力计算 query 与每个向量的余弦相似度，返回得分不低于 minScore 的前 k 个，
+// 得分相同时保持原顺序
+func NearestVectors(query []float32, vectors [][]float32, k int, minScore float64) []VectorHit {
+	if k <= 0 {
+		return nil
+	}
+	var hits []VectorHit
+	for i, v := range vectors {
+		if s := cosine(query, v); s >= minScore {
+			hits = append(hits, VectorHit{Index: i, Score: s})
+		}
+	}
+	sort.SliceStable(hits, func(a, b int) bool {
+		return hits[a].Score > hits[b].Score
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
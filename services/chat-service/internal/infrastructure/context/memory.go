@@ -0,0 +1,54 @@
+package context
+
+import (
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// memoryHeader 引出用户长期记忆段落
+const memoryHeader = "Known facts about the user from earlier conversations (may be outdated; the user's latest messages take precedence):"
+
+// SelectMemories 选出注入上下文的记忆：先按与 query 的 BM25 相关度取，
+// 不足 k 条时用最近更新的记忆补齐（称呼、环境等通用事实即使不相关也有用）。
+// memories 需按更新时间倒序。
+func SelectMemories(memories []*domain.Memory, query string, k int) []*domain.Memory {
+	if k <= 0 || len(memories) == 0 {
+		return nil
+	}
+	if len(memories) <= k {
+		return memories
+	}
+
+	docs := make([][]string, len(memories))
+	for i, m := range memories {
+		docs[i] = tokenizeTerms(m.Content)
+	}
+	picked := make(map[int]bool, k)
+	selected := make([]*domain.Memory, 0, k)
+	for _, hit := range newBM25Index(docs).search(tokenizeTerms(query), k, nil) {
+		picked[hit.Index] = true
+		selected = append(selected, memories[hit.Index])
+	}
+	for i := 0; i < len(memories) && len(selected) < k; i++ {
+		if !picked[i] {
+			selected = append(selected, memories[i])
+		}
+	}
+	return selected
+}
+
+// MemorySegment 把记忆渲染为一条 system 消息。消息标记为 Pinned，
+// 由 ContextBuilder 当作置顶内容放在前缀中并优先计入预算；没有记忆时返回 nil。
+func MemorySegment(memories []*domain.Memory) *domain.Message {
+	if len(memories) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(memoryHeader)
+	for _, m := range memories {
+		sb.WriteString("\n- ")
+		sb.WriteString(strings.ReplaceAll(m.Content, "\n", " "))
+	}
+	return &domain.Message{Role: domain.RoleSystem, Content: sb.String(), Pinned: true}
+}
@@ -0,0 +1,61 @@
+package context
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestSelectMemoriesPrefersRelevantThenRecent(t *testing.T) {
+	// 按更新时间倒序
+	memories := []*domain.Memory{
+		{ID: "name", Content: "希望被称呼为小王"},
+		{ID: "lang", Content: "Prefers answers in Chinese"},
+		{ID: "k8s", Content: "Runs a Kubernetes cluster on bare metal with Cilium"},
+		{ID: "go", Content: "Main project is a Go microservice chat app"},
+	}
+
+	selected := SelectMemories(memories, "How do I debug Cilium network policies in Kubernetes?", 2)
+	if len(selected) != 2 {
+		t.Fatalf("expected 2 memories, got %d", len(selected))
+	}
+	if selected[0].ID != "k8s" {
+		t.Errorf("most relevant memory should come first, got %s", selected[0].ID)
+	}
+	if selected[1].ID != "name" {
+		t.Errorf("remaining slot should be filled with the most recent memory, got %s", selected[1].ID)
+	}
+
+	if got := SelectMemories(memories, "anything", 10); len(got) != len(memories) {
+		t.Errorf("all memories should be kept when under k, got %d", len(got))
+	}
+}
+
+// TestMemorySegmentIsPinnedPrefix 记忆段落作为置顶内容出现在 system 前缀之后
+func TestMemorySegmentIsPinnedPrefix(t *testing.T) {
+	seg := MemorySegment([]*domain.Memory{
+		{Content: "Prefers answers in Chinese"},
+		{Content: "项目使用 Go\n和 gRPC"},
+	})
+	if seg == nil || !seg.Pinned || seg.Role != domain.RoleSystem {
+		t.Fatalf("expected a pinned system message, got %+v", seg)
+	}
+	want := memoryHeader + "\n- Prefers answers in Chinese\n- 项目使用 Go 和 gRPC"
+	if seg.Content != want {
+		t.Errorf("unexpected segment:\n%q\nwant:\n%q", seg.Content, want)
+	}
+	if MemorySegment(nil) != nil {
+		t.Error("no memories should produce no segment")
+	}
+
+	history := []*domain.Message{seg, {ID: "h1", Role: domain.RoleUser, Content: "你好"}}
+	built, err := NewDefaultBuilder(nil, nil).Build(context.Background(), history, "继续", 32768)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.HasPrefix(built.Messages[2].Content, memoryHeader) {
+		t.Errorf("memory segment should follow the system prefix, got %q", built.Messages[2].Content)
+	}
+}
@@ -0,0 +1,81 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// TestPinnedMessagesFollowSystemPrefix 验证置顶消息紧跟在 sink + system 前缀之后
+func TestPinnedMessagesFollowSystemPrefix(t *testing.T) {
+	builder := NewDefaultBuilder(nil, nil)
+
+	history := []*domain.Message{
+		{ID: "m1", Role: domain.RoleUser, Content: "第一轮用户"},
+		{ID: "m2", Role: domain.RoleUser, Content: "需求：接口必须幂等", Pinned: true},
+		{ID: "m3", Role: domain.RoleAssistant, Content: "第一轮回复"},
+	}
+
+	built, err := builder.Build(context.Background(), history, "继续", 32768)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if len(built.Messages) < 3 {
+		t.Fatalf("expected at least 3 messages, got %d", len(built.Messages))
+	}
+	if built.Messages[2].Content != "需求：接口必须幂等" {
+		t.Errorf("pinned message should be at position 2, got '%s'", built.Messages[2].Content)
+	}
+	if built.Messages[3].Content != "第一轮用户" {
+		t.Errorf("history should follow pinned messages, got '%s'", built.Messages[3].Content)
+	}
+}
+
+// TestPinnedMessagesSurviveCompression 验证压缩时置顶消息原文保留，不会被丢弃
+func TestPinnedMessagesSurviveCompression(t *testing.T) {
+	builder := NewDefaultBuilder(NewDefaultCompressor(nil), nil)
+
+	spec := &domain.Message{ID: "spec", Role: domain.RoleUser, Content: "SPEC: 所有金额使用分为单位", TokenCount: 50, Pinned: true}
+	history := []*domain.Message{spec}
+	for i := 0; i < 30; i++ {
+		history = append(history, &domain.Message{Role: domain.RoleUser, Content: "闲聊", TokenCount: 200})
+	}
+
+	built, err := builder.Build(context.Background(), history, "总结一下", 4096)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if built.Strategy != "compressed" {
+		t.Fatalf("expected compressed strategy, got %s", built.Strategy)
+	}
+
+	found := 0
+	for _, msg := range built.Messages {
+		if msg.Content == spec.Content {
+			found++
+		}
+	}
+	if found != 1 {
+		t.Errorf("pinned message should appear exactly once verbatim, found %d", found)
+	}
+	if built.Messages[2].Content != spec.Content {
+		t.Errorf("pinned message should precede compressed history, got '%s'", built.Messages[2].Content)
+	}
+}
+
+// TestPinnedMessagesExceedBudget 验证置顶内容单独超出预算时返回明确错误
+func TestPinnedMessagesExceedBudget(t *testing.T) {
+	builder := NewDefaultBuilder(NewDefaultCompressor(nil), nil)
+
+	history := []*domain.Message{
+		{ID: "big", Role: domain.RoleUser, Content: "超长规格说明", TokenCount: 5000, Pinned: true},
+	}
+
+	_, err := builder.Build(context.Background(), history, "继续", 4096)
+	if !errors.Is(err, ErrPinnedExceedsBudget) {
+		t.Fatalf("expected ErrPinnedExceedsBudget, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package context
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+const (
+	// recallSyncLag 增量同步时回看的时间窗口。消息经 MQ 异步落库，
+	// 晚到的消息 created_at 可能早于水位线，回看一段时间并按 ID 去重避免漏索引
+	recallSyncLag = time.Minute
+	// recallIndexTTL 索引最长复用时间，过期后全量重建以反映删除等变更
+	recallIndexTTL = 10 * time.Minute
+	// recallMaxSessions 内存中最多保留的会话索引数，超出时淘汰最久未使用的
+	recallMaxSessions = 512
+)
+
+// RecallSource 提供会话的全量历史，由 domain.ChatRepository 实现
+type RecallSource interface {
+	GetSessionMessagesSince(ctx context.Context, sessionID string, since time.Time) ([]*domain.Message, error)
+}
+
+// sessionIndex 是单个会话的 BM25 倒排索引，文档下标与 messages 一一对应
+type sessionIndex struct {
+	mu        sync.Mutex
+	bm25      *bm25Index
+	messages  []*domain.Message
+	seen      map[string]bool
+	watermark time.Time
+	builtAt   time.Time
+	lastUsed  time.Time
+}
+
+// sessionRecaller 为每个会话维护进程内的增量 BM25 索引（对应研究中的 bm25_top1，
+// 但检索对象是已存储的聊天记录），按当前用户输入召回窗口之外的相关旧消息。
+type sessionRecaller struct {
+	source RecallSource
+	topK   int
+
+	mu       sync.Mutex
+	sessions map[string]*sessionIndex
+}
+
+// NewSessionRecaller creates a domain.MessageRecaller backed by an in-process
+// BM25 index per session, synced incrementally from source.
+func NewSessionRecaller(source RecallSource, topK int) domain.MessageRecaller {
+	return &sessionRecaller{
+		source:   source,
+		topK:     topK,
+		sessions: make(map[string]*sessionIndex),
+	}
+}
+
+func (r *sessionRecaller) Recall(ctx context.Context, sessionID, query string, before time.Time) ([]*domain.Message, error) {
+	terms := tokenizeTerms(query)
+	if r.topK <= 0 || sessionID == "" || len(terms) == 0 {
+		return nil, nil
+	}
+
+	idx := r.session(sessionID)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.sync(ctx, r.source, sessionID); err != nil {
+		return nil, err
+	}
+
+	hits := idx.bm25.search(terms, r.topK, func(i int) bool {
+		m := idx.messages[i]
+		return before.IsZero() || m.CreatedAt.Before(before)
+	})
+	recalled := make([]*domain.Message, len(hits))
+	for i, hit := range hits {
+		// 返回副本，调用方会设置 Recalled 等标记
+		msg := *idx.messages[hit.Index]
+		recalled[i] = &msg
+	}
+	return recalled, nil
+}
+
+func (r *sessionRecaller) Forget(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, sessionID)
+}
+
+// session 取出（或新建）会话索引，并在超出容量时淘汰最久未使用的索引
+func (r *sessionRecaller) session(sessionID string) *sessionIndex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	idx, ok := r.sessions[sessionID]
+	if !ok {
+		if len(r.sessions) >= recallMaxSessions {
+			r.evictLocked()
+		}
+		idx = &sessionIndex{}
+		r.sessions[sessionID] = idx
+	}
+	idx.lastUsed = now
+	return idx
+}
+
+func (r *sessionRecaller) evictLocked() {
+	var oldestID string
+	var oldest time.Time
+	for id, idx := range r.sessions {
+		if oldestID == "" || idx.lastUsed.Before(oldest) {
+			oldestID, oldest = id, idx.lastUsed
+		}
+	}
+	delete(r.sessions, oldestID)
+}
+
+// sync 拉取水位线之后的新消息并追加到索引，索引过期时全量重建
+func (idx *sessionIndex) sync(ctx context.Context, source RecallSource, sessionID string) error {
+	now := time.Now()
+	if idx.bm25 == nil || now.Sub(idx.builtAt) > recallIndexTTL {
+		idx.bm25 = newBM25Index(nil)
+		idx.messages = nil
+		idx.seen = make(map[string]bool)
+		idx.watermark = time.Time{}
+		idx.builtAt = now
+	}
+
+	since := idx.watermark
+	if !since.IsZero() {
+		since = since.Add(-recallSyncLag)
+	}
+	messages, err := source.GetSessionMessagesSince(ctx, sessionID, since)
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if m.CreatedAt.After(idx.watermark) {
+			idx.watermark = m.CreatedAt
+		}
+		key := m.ID
+		if key == "" || idx.seen[key] {
+			continue
+		}
+		idx.seen[key] = true
+		idx.bm25.add(tokenizeTerms(m.Content))
+		idx.messages = append(idx.messages, m)
+	}
+	return nil
+}
+
+var _ domain.MessageRecaller = (*sessionRecaller)(nil)
@@ -0,0 +1,147 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// fakeRecallSource 模拟按时间正序存储的会话消息
+type fakeRecallSource struct {
+	messages []*domain.Message
+	calls    int
+}
+
+func (s *fakeRecallSource) GetSessionMessagesSince(ctx context.Context, sessionID string, since time.Time) ([]*domain.Message, error) {
+	s.calls++
+	var out []*domain.Message
+	for _, m := range s.messages {
+		if m.SessionID == sessionID && !m.CreatedAt.Before(since) {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func longSession(base time.Time) []*domain.Message {
+	var messages []*domain.Message
+	add := func(role domain.Role, content string) {
+		messages = append(messages, &domain.Message{
+			ID:        fmt.Sprintf("m%d", len(messages)),
+			SessionID: "s1",
+			Role:      role,
+			Content:   content,
+			CreatedAt: base.Add(time.Duration(len(messages)) * time.Minute),
+		})
+	}
+	add(domain.RoleUser, "我们的数据库密码轮换策略是每 90 天更换一次 PostgreSQL 密码。")
+	add(domain.RoleAssistant, "好的，已记录 PostgreSQL 密码每 90 天轮换。")
+	for i := 0; i < 40; i++ {
+		add(domain.RoleUser, fmt.Sprintf("聊聊前端样式问题 %d", i))
+		add(domain.RoleAssistant, "可以调整 CSS 布局。")
+	}
+	return messages
+}
+
+func TestSessionRecallerFindsOldRelevantTurn(t *testing.T) {
+	base := time.Now().Add(-2 * time.Hour)
+	source := &fakeRecallSource{messages: longSession(base)}
+	recaller := NewSessionRecaller(source, 2)
+
+	windowStart := source.messages[len(source.messages)-10].CreatedAt
+	recalled, err := recaller.Recall(context.Background(), "s1", "PostgreSQL 密码多久轮换一次？", windowStart)
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	if len(recalled) == 0 {
+		t.Fatal("expected the password policy turn to be recalled")
+	}
+	if recalled[0].ID != "m0" && recalled[0].ID != "m1" {
+		t.Errorf("expected policy turn first, got %s: %q", recalled[0].ID, recalled[0].Content)
+	}
+	for _, m := range recalled {
+		if !m.CreatedAt.Before(windowStart) {
+			t.Errorf("recalled message %s is inside the recent window", m.ID)
+		}
+	}
+}
+
+// TestSessionRecallerIndexesIncrementally 新消息落库后下一次召回即可检索到
+func TestSessionRecallerIndexesIncrementally(t *testing.T) {
+	base := time.Now().Add(-2 * time.Hour)
+	source := &fakeRecallSource{messages: longSession(base)}
+	recaller := NewSessionRecaller(source, 3)
+
+	if recalled, _ := recaller.Recall(context.Background(), "s1", "Kubernetes ingress", time.Time{}); len(recalled) != 0 {
+		t.Fatalf("expected no hits before the turn exists, got %d", len(recalled))
+	}
+
+	source.messages = append(source.messages, &domain.Message{
+		ID: "late", SessionID: "s1", Role: domain.RoleUser,
+		Content:   "Kubernetes ingress 需要配置 TLS",
+		CreatedAt: base.Add(3 * time.Hour),
+	})
+	recalled, err := recaller.Recall(context.Background(), "s1", "Kubernetes ingress", time.Time{})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	if len(recalled) != 1 || recalled[0].ID != "late" {
+		t.Fatalf("expected the new turn to be recalled, got %v", recalled)
+	}
+}
+
+// TestRecalledMessagesAreQuotedAfterPinned 召回消息以引用段落出现在置顶消息之后、最近历史之前
+func TestRecalledMessagesAreQuotedAfterPinned(t *testing.T) {
+	builder := NewDefaultBuilder(nil, nil)
+	base := time.Now()
+
+	history := []*domain.Message{
+		{ID: "p", Role: domain.RoleUser, Content: "需求：接口必须幂等", Pinned: true},
+		{ID: "r2", Role: domain.RoleAssistant, Content: "密码每 90 天轮换。\n由运维执行。", Recalled: true, CreatedAt: base.Add(time.Minute)},
+		{ID: "r1", Role: domain.RoleUser, Content: "密码怎么轮换？", Recalled: true, CreatedAt: base},
+		{ID: "h", Role: domain.RoleUser, Content: "最近的一轮"},
+	}
+
+	built, err := builder.Build(context.Background(), history, "再说一下密码策略", 32768)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if built.Messages[2].Content != "需求：接口必须幂等" {
+		t.Fatalf("pinned message should stay at position 2, got %q", built.Messages[2].Content)
+	}
+	seg := built.Messages[3]
+	want := recalledHeader + "\n\n> user: 密码怎么轮换？\n\n> assistant: 密码每 90 天轮换。\n> 由运维执行。"
+	if seg.Role != domain.RoleSystem || seg.Content != want {
+		t.Fatalf("unexpected recalled segment:\n%q\nwant:\n%q", seg.Content, want)
+	}
+	if built.Messages[4].Content != "最近的一轮" {
+		t.Errorf("recent history should follow the recalled segment, got %q", built.Messages[4].Content)
+	}
+	for _, m := range built.Messages {
+		if m.Recalled {
+			t.Error("recalled messages must not appear verbatim as separate turns")
+		}
+	}
+}
+
+func TestRecalledSegmentRespectsLimit(t *testing.T) {
+	recalled := []*domain.Message{
+		{Role: domain.RoleUser, Content: strings.Repeat("长", 500), Recalled: true},
+		{Role: domain.RoleUser, Content: "短消息", Recalled: true},
+	}
+	seg, tokens := recalledSegment(recalled, 100, runeCounter{}.Count)
+	if seg == nil {
+		t.Fatal("expected the short message to fit")
+	}
+	if tokens > 100 {
+		t.Errorf("segment uses %d tokens, limit 100", tokens)
+	}
+	if strings.Contains(seg.Content, "长长") {
+		t.Error("oversized recalled message should be skipped")
+	}
+}
@@ -0,0 +1,141 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+const (
+	// searchMaxVectors 每次检索最多比较的消息向量数（取用户最近的消息）
+	searchMaxVectors = 20000
+	// searchMinScore 低于该余弦相似度的消息不视为命中
+	searchMinScore = 0.1
+	// searchMessagesPerSession 每个会话最多返回的命中消息数
+	searchMessagesPerSession = 3
+	// embedMaxRunes 参与向量化的消息前缀长度
+	embedMaxRunes = 2000
+)
+
+// SearchSource 提供检索结果所需的消息与会话，由 domain.ChatRepository 实现
+type SearchSource interface {
+	GetMessage(ctx context.Context, messageID string) (*domain.Message, error)
+	GetSession(ctx context.Context, sessionID string) (*domain.Session, error)
+}
+
+// conversationSearcher 把消息向量存入 EmbeddingRepository，检索时取出用户的向量
+// 在进程内暴力计算余弦相似度，再按会话聚合。
+type conversationSearcher struct {
+	store    domain.EmbeddingRepository
+	source   SearchSource
+	embedder domain.Embedder
+}
+
+// NewConversationSearcher 创建跨会话语义检索
+func NewConversationSearcher(store domain.EmbeddingRepository, source SearchSource, embedder domain.Embedder) domain.ConversationSearcher {
+	return &conversationSearcher{store: store, source: source, embedder: embedder}
+}
+
+func (s *conversationSearcher) Index(ctx context.Context, messages []*domain.Message) error {
+	var indexed []*domain.Message
+	var texts []string
+	for _, m := range messages {
+		if m == nil || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		indexed = append(indexed, m)
+		texts = append(texts, headRunes(m.Content, embedMaxRunes))
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed messages: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedder returned %d vectors for %d messages", len(vectors), len(texts))
+	}
+	embeddings := make([]*domain.MessageEmbedding, len(indexed))
+	for i, m := range indexed {
+		embeddings[i] = &domain.MessageEmbedding{
+			MessageID: m.ID,
+			SessionID: m.SessionID,
+			UserID:    m.UserID,
+			Vector:    vectors[i],
+			CreatedAt: m.CreatedAt,
+		}
+	}
+	return s.store.SaveEmbeddings(ctx, embeddings)
+}
+
+func (s *conversationSearcher) Search(ctx context.Context, userID, workspaceID, query string, k int) ([]*domain.ConversationMatch, error) {
+	if k <= 0 || strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	embeddings, err := s.store.ListEmbeddings(ctx, userID, workspaceID, searchMaxVectors)
+	if err != nil || len(embeddings) == 0 {
+		return nil, err
+	}
+	qv, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(qv) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for the query", len(qv))
+	}
+
+	vectors := make([][]float32, len(embeddings))
+	for i, e := range embeddings {
+		vectors[i] = e.Vector
+	}
+	// 命中按得分降序，会话得分即其最佳消息的得分
+	var results []*domain.ConversationMatch
+	bySession := make(map[string]*domain.ConversationMatch)
+	skipped := make(map[string]bool)
+	for _, hit := range NearestVectors(qv[0], vectors, len(vectors), searchMinScore) {
+		e := embeddings[hit.Index]
+		if skipped[e.SessionID] {
+			continue
+		}
+		match := bySession[e.SessionID]
+		if match == nil {
+			if len(results) == k {
+				continue
+			}
+			session, err := s.source.GetSession(ctx, e.SessionID)
+			if err != nil {
+				return nil, err
+			}
+			if session == nil || session.UserID != userID || session.WorkspaceID != workspaceID {
+				skipped[e.SessionID] = true
+				continue
+			}
+			match = &domain.ConversationMatch{Session: session}
+		}
+		if len(match.Messages) == searchMessagesPerSession {
+			continue
+		}
+		msg, err := s.source.GetMessage(ctx, e.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			continue
+		}
+		if bySession[e.SessionID] == nil {
+			match.Score = hit.Score
+			bySession[e.SessionID] = match
+			results = append(results, match)
+		}
+		match.Messages = append(match.Messages, &domain.MessageMatch{Message: msg, Score: hit.Score})
+	}
+	return results, nil
+}
+
+func (s *conversationSearcher) Forget(ctx context.Context, sessionID string) error {
+	return s.store.DeleteSessionEmbeddings(ctx, sessionID)
+}
@@ -0,0 +1,156 @@
+package context
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestHashingEmbedderIsDeterministicAndMorphologyAware(t *testing.T) {
+	emb := NewHashingEmbedder(0)
+	vecs, err := emb.Embed(context.Background(), []string{
+		"How does Kafka partitioning work?",
+		"kafka partitions and consumer groups",
+		"best pizza dough recipe",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vecs[0]) != defaultEmbeddingDim {
+		t.Fatalf("expected dim %d, got %d", defaultEmbeddingDim, len(vecs[0]))
+	}
+	again, _ := emb.Embed(context.Background(), []string{"How does Kafka partitioning work?"})
+	for i := range again[0] {
+		if again[0][i] != vecs[0][i] {
+			t.Fatal("hashing embedder should be deterministic")
+		}
+	}
+
+	related, unrelated := cosine(vecs[0], vecs[1]), cosine(vecs[0], vecs[2])
+	if related <= unrelated || related < 0.3 {
+		t.Errorf("partitioning/partitions should be close: related=%.3f unrelated=%.3f", related, unrelated)
+	}
+	if cosine(vecs[0], vecs[3]) != 0 {
+		t.Error("empty text should embed to the zero vector")
+	}
+}
+
+func TestNearestVectors(t *testing.T) {
+	vectors := [][]float32{{0, 1}, {1, 0}, {0.7, 0.7}, {-1, 0}}
+	hits := NearestVectors([]float32{1, 0}, vectors, 2, 0.1)
+	if len(hits) != 2 || hits[0].Index != 1 || hits[1].Index != 2 {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+	if got := NearestVectors([]float32{1, 0}, vectors, 10, 0.1); len(got) != 2 {
+		t.Errorf("vectors below minScore should be dropped, got %+v", got)
+	}
+}
+
+type memEmbeddingStore struct {
+	embeddings []*domain.MessageEmbedding
+}
+
+func (s *memEmbeddingStore) SaveEmbeddings(_ context.Context, es []*domain.MessageEmbedding) error {
+	s.embeddings = append(s.embeddings, es...)
+	return nil
+}
+
+// ListEmbeddings 不按工作空间过滤，由 Search 按会话所属的工作空间复核
+func (s *memEmbeddingStore) ListEmbeddings(_ context.Context, userID, _ string, limit int) ([]*domain.MessageEmbedding, error) {
+	var out []*domain.MessageEmbedding
+	for _, e := range s.embeddings {
+		if e.UserID == userID && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *memEmbeddingStore) DeleteSessionEmbeddings(_ context.Context, sessionID string) error {
+	kept := s.embeddings[:0]
+	for _, e := range s.embeddings {
+		if e.SessionID != sessionID {
+			kept = append(kept, e)
+		}
+	}
+	s.embeddings = kept
+	return nil
+}
+
+type memSearchSource struct {
+	sessions map[string]*domain.Session
+	messages map[string]*domain.Message
+}
+
+func (s *memSearchSource) GetMessage(_ context.Context, id string) (*domain.Message, error) {
+	return s.messages[id], nil
+}
+
+func (s *memSearchSource) GetSession(_ context.Context, id string) (*domain.Session, error) {
+	return s.sessions[id], nil
+}
+
+func TestConversationSearcherGroupsBySession(t *testing.T) {
+	now := time.Now()
+	source := &memSearchSource{
+		sessions: map[string]*domain.Session{
+			"kafka": {ID: "kafka", UserID: "u1", Title: "Kafka"},
+			"food":  {ID: "food", UserID: "u1", Title: "Dinner"},
+		},
+		messages: map[string]*domain.Message{},
+	}
+	msgs := []*domain.Message{
+		{ID: "m1", SessionID: "kafka", UserID: "u1", Content: "How many partitions should my Kafka topic have?"},
+		{ID: "m2", SessionID: "kafka", UserID: "u1", Content: "Partitioning in Kafka decides consumer parallelism."},
+		{ID: "m3", SessionID: "food", UserID: "u1", Content: "Suggest a quick pasta recipe for dinner."},
+		{ID: "m4", SessionID: "kafka", UserID: "u1", Content: "Kafka partition leaders move on broker failure."},
+	}
+	for i, m := range msgs {
+		m.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		source.messages[m.ID] = m
+	}
+	store := &memEmbeddingStore{}
+	searcher := NewConversationSearcher(store, source, NewHashingEmbedder(0))
+	ctx := context.Background()
+	if err := searcher.Index(ctx, append(msgs, &domain.Message{ID: "blank", Content: "  "})); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if len(store.embeddings) != len(msgs) {
+		t.Fatalf("blank messages should not be indexed, got %d embeddings", len(store.embeddings))
+	}
+
+	// 已删除的消息不出现在结果中
+	delete(source.messages, "m4")
+	results, err := searcher.Search(ctx, "u1", "", "that chat about kafka partitioning", 5)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) == 0 || results[0].Session.ID != "kafka" {
+		t.Fatalf("kafka session should rank first, got %+v", results)
+	}
+	if n := len(results[0].Messages); n != 2 {
+		t.Errorf("expected the 2 remaining kafka messages, got %d", n)
+	}
+	for _, r := range results {
+		if r.Session.ID == "food" {
+			t.Error("unrelated session should fall below the score threshold")
+		}
+	}
+
+	if other, _ := searcher.Search(ctx, "u2", "", "kafka", 5); len(other) != 0 {
+		t.Errorf("other users must not see u1's conversations, got %+v", other)
+	}
+	if other, _ := searcher.Search(ctx, "u1", "ws1", "kafka", 5); len(other) != 0 {
+		t.Errorf("personal conversations must not be found in a workspace, got %+v", other)
+	}
+
+	if err := searcher.Forget(ctx, "kafka"); err != nil {
+		t.Fatalf("Forget failed: %v", err)
+	}
+	if results, _ := searcher.Search(ctx, "u1", "", "kafka partitioning", 5); len(results) != 0 {
+		t.Errorf("forgotten session should not be found, got %+v", results)
+	}
+}
@@ -0,0 +1,98 @@
+package context
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ellipsis 标记被截断的内容
+const ellipsis = "…"
+
+// sentenceTerminators 同时覆盖中文与英文的句末标点
+var sentenceTerminators = map[rune]bool{
+	'。': true, '！': true, '？': true, '；': true,
+	'!': true, '?': true, ';': true, '\n': true,
+}
+
+// splitSentences 按中英文句末标点切分文本，标点保留在句尾。
+// 英文句号只有在其后是空白或文本结尾时才视为句末，避免切开小数和缩写。
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		end := sentenceTerminators[r]
+		if r == '.' && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			end = true
+		}
+		if !end {
+			continue
+		}
+		if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = i + 1
+	}
+	if start < len(runes) {
+		if s := strings.TrimSpace(string(runes[start:])); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// clipToTokens 将文本裁剪到不超过 maxTokens：优先保留完整句子，
+// 第一句就放不下时按 rune 二分截断，保证不会切坏多字节字符。
+// 发生截断时追加省略号（其 token 也计入上限）。
+func clipToTokens(text string, maxTokens int, count func(string) int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	if count(text) <= maxTokens {
+		return text
+	}
+
+	var kept []string
+	for _, s := range splitSentences(text) {
+		candidate := strings.Join(append(kept, s), " ") + ellipsis
+		if count(candidate) > maxTokens {
+			break
+		}
+		kept = append(kept, s)
+	}
+	if len(kept) > 0 {
+		return strings.Join(kept, " ") + ellipsis
+	}
+
+	runes := []rune(strings.TrimSpace(text))
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if count(string(runes[:mid])+ellipsis) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return ""
+	}
+	return string(runes[:lo]) + ellipsis
+}
+
+// estimateTokens 在没有分词器时粗略估算 token 数：
+// CJK 字符按 1 token 计，其余字符按 4 个 1 token 计。
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	cjk, other := 0, 0
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return max(cjk+(other+3)/4, 1)
+}
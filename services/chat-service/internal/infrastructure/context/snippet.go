@@ -0,0 +1,106 @@
+package context
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 命中词项的高亮标记，片段其余部分做 HTML 转义，客户端可直接渲染
+const (
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+)
+
+// HighlightSnippet 截取 content 中第一个命中附近最多 maxRunes 个字符，
+// HTML 转义后用 <mark> 标出 query 的词项（英文不区分大小写，中文按二元组匹配）。
+// 没有命中时返回开头的片段。
+func HighlightSnippet(content, query string, maxRunes int) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var spans [][2]int
+	seen := make(map[string]bool)
+	for _, term := range tokenizeTerms(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		spans = append(spans, findAll(lower, []rune(term))...)
+	}
+	spans = mergeSpans(spans)
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		if len(spans) > 0 {
+			// 命中前保留少量上文
+			start = max(spans[0][0]-maxRunes/4, 0)
+		}
+		end = min(start+maxRunes, len(runes))
+		start = max(end-maxRunes, 0)
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString(ellipsis)
+	}
+	pos := start
+	for _, sp := range spans {
+		s, e := max(sp[0], start), min(sp[1], end)
+		if s >= e {
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[pos:s])))
+		sb.WriteString(highlightOpen)
+		sb.WriteString(html.EscapeString(string(runes[s:e])))
+		sb.WriteString(highlightClose)
+		pos = e
+	}
+	sb.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		sb.WriteString(ellipsis)
+	}
+	return sb.String()
+}
+
+func findAll(text, term []rune) [][2]int {
+	var spans [][2]int
+	if len(term) == 0 {
+		return nil
+	}
+	for i := 0; i+len(term) <= len(text); i++ {
+		match := true
+		for j, r := range term {
+			if text[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			spans = append(spans, [2]int{i, i + len(term)})
+		}
+	}
+	return spans
+}
+
+// mergeSpans 合并重叠或相邻的区间，使连续的中文二元组高亮为一整段
+func mergeSpans(spans [][2]int) [][2]int {
+	if len(spans) == 0 {
+		return nil
+	}
+	sort.Slice(spans, func(a, b int) bool { return spans[a][0] < spans[b][0] })
+	merged := [][2]int{spans[0]}
+	for _, sp := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if sp[0] <= last[1] {
+			last[1] = max(last[1], sp[1])
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
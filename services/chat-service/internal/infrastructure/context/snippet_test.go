@@ -0,0 +1,35 @@
+package context
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightSnippetMarksTerms(t *testing.T) {
+	got := HighlightSnippet("Kafka <partitions> are\nreplicated; kafka rocks", "kafka partitions", 0)
+	want := "<mark>Kafka</mark> &lt;<mark>partitions</mark>&gt; are replicated; <mark>kafka</mark> rocks"
+	if got != want {
+		t.Errorf("unexpected snippet:\n%q\nwant:\n%q", got, want)
+	}
+
+	// 中文二元组连续命中合并为一段高亮
+	got = HighlightSnippet("我们讨论了分布式系统的一致性", "分布式系统", 0)
+	if want := "我们讨论了<mark>分布式系统</mark>的一致性"; got != want {
+		t.Errorf("unexpected cjk snippet: %q, want %q", got, want)
+	}
+}
+
+func TestHighlightSnippetWindowsAroundFirstMatch(t *testing.T) {
+	content := strings.Repeat("filler ", 50) + "the kafka broker" + strings.Repeat(" tail", 50)
+	got := HighlightSnippet(content, "broker", 40)
+	if !strings.HasPrefix(got, ellipsis) || !strings.HasSuffix(got, ellipsis) {
+		t.Errorf("clipped snippet should be wrapped in ellipses: %q", got)
+	}
+	if !strings.Contains(got, "<mark>broker</mark>") {
+		t.Errorf("snippet should contain the match: %q", got)
+	}
+
+	if got := HighlightSnippet("short text", "missing", 40); got != "short text" {
+		t.Errorf("no match should return the head of the content, got %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package context
+
+import (
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// speakersHeader 告诉模型多人会话中用户消息的前缀是发言人
+const speakersHeader = "Several people take part in this conversation. Each user message starts with the speaker's name followed by a colon; address people by name when it helps."
+
+// speakerIDRunes 没有显示名时用用户 ID 的前几位区分发言人
+const speakerIDRunes = 8
+
+// LabelSpeakers 为多人会话中的用户消息加上 "发言人: " 前缀，并在最前面置顶一条说明，
+// 返回标注后的历史与本轮输入。历史中的消息会被复制，不修改调用方（及缓存）中的对象；
+// 置顶的 system 片段（记忆、文档）不是发言，保持原样。
+func LabelSpeakers(history []*domain.Message, current *domain.Message) ([]*domain.Message, string) {
+	labeled := make([]*domain.Message, 0, len(history)+1)
+	labeled = append(labeled, &domain.Message{Role: domain.RoleSystem, Content: speakersHeader, Pinned: true})
+	for _, msg := range history {
+		if !msg.IsUser() || msg.UserID == "" {
+			labeled = append(labeled, msg)
+			continue
+		}
+		cp := *msg
+		cp.Content = labelContent(&cp)
+		// 前缀改变了长度，交给 ContextBuilder 重新计数
+		cp.TokenCount = 0
+		labeled = append(labeled, &cp)
+	}
+	return labeled, labelContent(current)
+}
+
+func labelContent(msg *domain.Message) string {
+	return SpeakerName(msg) + ": " + msg.Content
+}
+
+// SpeakerName 返回用户消息的发言人名称：优先使用显示名，否则用用户 ID 的前几位
+func SpeakerName(msg *domain.Message) string {
+	if name := strings.Join(strings.Fields(msg.AuthorName), " "); name != "" {
+		return name
+	}
+	id := []rune(msg.UserID)
+	if len(id) > speakerIDRunes {
+		id = id[:speakerIDRunes]
+	}
+	return "user-" + string(id)
+}
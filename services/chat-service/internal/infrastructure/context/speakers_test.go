@@ -0,0 +1,40 @@
+package context
+
+import (
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestLabelSpeakersPrefixesUserMessages(t *testing.T) {
+	memory := &domain.Message{Role: domain.RoleSystem, Content: "facts", Pinned: true}
+	alice := &domain.Message{ID: "1", UserID: "u-alice", AuthorName: "Alice", Role: domain.RoleUser, Content: "Hi", TokenCount: 3}
+	reply := &domain.Message{ID: "2", UserID: "u-alice", Role: domain.RoleAssistant, Content: "Hello"}
+	anon := &domain.Message{ID: "3", UserID: "0123456789abcdef", Role: domain.RoleUser, Content: "Me too"}
+	current := &domain.Message{UserID: "u-bob", AuthorName: " Bob\nSmith ", Role: domain.RoleUser, Content: "What now?"}
+
+	labeled, prompt := LabelSpeakers([]*domain.Message{memory, alice, reply, anon}, current)
+
+	if len(labeled) != 5 || !labeled[0].Pinned || labeled[0].Role != domain.RoleSystem {
+		t.Fatalf("expected a pinned system header followed by the history, got %d messages", len(labeled))
+	}
+	if labeled[1] != memory || labeled[3] != reply {
+		t.Errorf("system and assistant messages should be passed through unchanged")
+	}
+	if got := labeled[2].Content; got != "Alice: Hi" {
+		t.Errorf("user message label = %q", got)
+	}
+	if labeled[2].TokenCount != 0 {
+		t.Errorf("labeled message should be recounted, got TokenCount %d", labeled[2].TokenCount)
+	}
+	if got := labeled[4].Content; !strings.HasPrefix(got, "user-01234567: ") {
+		t.Errorf("speaker without a name should fall back to the user ID, got %q", got)
+	}
+	if prompt != "Bob Smith: What now?" {
+		t.Errorf("current message label = %q", prompt)
+	}
+	if alice.Content != "Hi" || alice.TokenCount != 3 {
+		t.Errorf("input messages must not be modified")
+	}
+}
@@ -0,0 +1,484 @@
+package context
+
+// Go port of services/context-engine/src/strategies.py (and the retrieval
+// strategies of pipeline.py / retriever.py). Outputs are kept byte-identical
+// with the Python implementation; see testdata/strategies_golden.json, which
+// is generated by testdata/gen_strategies_golden.py.
+//
+// Go's regexp (RE2) has no look-around and its \b / \w are ASCII-only, while
+// the Python code relies on both, so the regex-based splitters are ported as
+// hand-written scanners with the same semantics.
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Strategy names accepted by buildStrategyContext, same as the Python pipeline.
+const (
+	StrategyTruncation    = "truncation"
+	StrategyProjectTopic  = "project_topic"
+	StrategyAttentionSink = "attention_sink"
+	StrategySinkTopic     = "sink_topic"
+	StrategyBM25Top1      = "bm25_top1"
+	StrategyKeywordTop1   = "keyword_top1"
+)
+
+// TokenCodec encodes text to tokens and back. Strategies that cut text at
+// token boundaries (truncation) need it in addition to counting.
+type TokenCodec interface {
+	TokenCounter
+	Encode(text string) []int
+	Decode(tokens []int) string
+}
+
+// defaultParagraphBoundary 对应 Python 的 (?=Paragraph \d+:)，按匹配起点切分
+var defaultParagraphBoundary = regexp.MustCompile(`Paragraph \d+:`)
+
+// tiered compression defaults, same as compress_tiered / build_context
+var (
+	defaultTierLevels   = [3]int{5, 20, 50}
+	defaultTierMaxChars = [2]int{100, 50}
+)
+
+var strategyEnStopwords = map[string]bool{
+	"the": true, "that": true, "this": true, "these": true, "those": true, "with": true,
+	"from": true, "were": true, "have": true, "been": true, "their": true, "they": true,
+	"there": true, "about": true, "text": true, "according": true, "article": true,
+	"question": true, "answer": true, "based": true, "following": true, "passage": true,
+	"main": true, "character": true, "summarizes": true, "discusses": true, "whose": true,
+	"name": true, "named": true, "what": true, "which": true, "where": true, "when": true,
+	"how": true, "why": true, "who": true, "does": true, "do": true, "did": true,
+	"are": true, "is": true,
+}
+
+var strategyCnStopwords = map[string]bool{
+	"一个": true, "什么": true, "如何": true, "关于": true, "根据": true, "描述": true,
+	"下列": true, "其中": true, "哪些": true, "为什么": true, "上面": true, "以下": true,
+	"文本": true, "请": true, "回答": true, "找出": true, "匹配": true, "请根据": true,
+}
+
+// ---------------------------------------------------------------------------
+// Chunking
+// ---------------------------------------------------------------------------
+
+// chunkSentences 对应 chunk_sentences：在 .!?。！？ 之后切分并吞掉其后的空白，
+// 标点保留在句尾，丢弃只含空白的片段。
+func chunkSentences(text string) []string {
+	if text == "" {
+		return nil
+	}
+	var chunks []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '.', '!', '?', '。', '！', '？':
+		default:
+			continue
+		}
+		chunks = appendNonBlank(chunks, string(runes[start:i+1]))
+		j := i + 1
+		for j < len(runes) && isPySpace(runes[j]) {
+			j++
+		}
+		start = j
+		i = j - 1
+	}
+	return appendNonBlank(chunks, string(runes[start:]))
+}
+
+// chunkParagraphs 对应 chunk_paragraphs：boundary 的每个匹配起点都是一个切分点
+// （等价于 Python 中的前瞻断言）。boundary 为 nil 时使用 "Paragraph N:"。
+func chunkParagraphs(text string, boundary *regexp.Regexp) []string {
+	if text == "" {
+		return nil
+	}
+	if boundary == nil {
+		boundary = defaultParagraphBoundary
+	}
+	var chunks []string
+	start := 0
+	for _, loc := range boundary.FindAllStringIndex(text, -1) {
+		chunks = appendNonBlank(chunks, text[start:loc[0]])
+		start = loc[0]
+	}
+	return appendNonBlank(chunks, text[start:])
+}
+
+func appendNonBlank(chunks []string, s string) []string {
+	if strings.TrimFunc(s, isPySpace) == "" {
+		return chunks
+	}
+	return append(chunks, s)
+}
+
+// ---------------------------------------------------------------------------
+// Keyword extraction
+// ---------------------------------------------------------------------------
+
+// extractQueryWords 对应 extract_query_words：
+// 英文取两侧为 Unicode 词边界的 4 个及以上 ASCII 字母，去停用词；
+// 中文取 [一-龥]{2,6} 的贪婪不重叠匹配，去停用词后最多 5 个。
+func extractQueryWords(query string) []string {
+	if query == "" {
+		return nil
+	}
+	runes := []rune(query)
+
+	var words []string
+	for i := 0; i < len(runes); {
+		if !isASCIILetter(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isASCIILetter(runes[j]) {
+			j++
+		}
+		bounded := (i == 0 || !isPyWordRune(runes[i-1])) && (j == len(runes) || !isPyWordRune(runes[j]))
+		if w := string(runes[i:j]); bounded && j-i >= 4 && !strategyEnStopwords[strings.ToLower(w)] {
+			words = append(words, w)
+		}
+		i = j
+	}
+
+	var cnWords []string
+	for i := 0; i < len(runes); {
+		if !isCommonHan(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isCommonHan(runes[j]) {
+			j++
+		}
+		for k := i; j-k >= 2; k += 6 {
+			end := min(k+6, j)
+			if w := string(runes[k:end]); !strategyCnStopwords[w] {
+				cnWords = append(cnWords, w)
+			}
+		}
+		i = j
+	}
+	if len(cnWords) > 5 {
+		cnWords = cnWords[:5]
+	}
+	return append(words, cnWords...)
+}
+
+// isPySpace 对应 Python 的 \s / str.isspace，比 unicode.IsSpace 多出 \x1c-\x1f
+func isPySpace(r rune) bool {
+	return unicode.IsSpace(r) || (r >= 0x1c && r <= 0x1f)
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// isPyWordRune 对应 Python re 在 str 模式下的 \w
+func isPyWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+func isCommonHan(r rune) bool {
+	return r >= '一' && r <= '龥'
+}
+
+// ---------------------------------------------------------------------------
+// Truncation
+// ---------------------------------------------------------------------------
+
+// truncateTokens 对应 truncate：保留最后 budget 个 token（近因）
+func truncateTokens(text string, codec TokenCodec, budget int) string {
+	if text == "" {
+		return ""
+	}
+	tokens := codec.Encode(text)
+	if len(tokens) <= budget {
+		return text
+	}
+	// 与 Python 的 tokens[-budget:] 保持一致：budget 为 0 时保留全部
+	start := len(tokens) - budget
+	if budget <= 0 {
+		start = min(-budget, len(tokens))
+	}
+	return codec.Decode(tokens[start:])
+}
+
+// ---------------------------------------------------------------------------
+// Topic selection
+// ---------------------------------------------------------------------------
+
+// selectRelevant 对应 select_relevant：按查询词命中数打分，稳定排序后取前 topK
+func selectRelevant(chunks []string, query string, topK int) []string {
+	if len(chunks) == 0 || query == "" {
+		return nil
+	}
+	queryWords := extractQueryWords(query)
+	if len(queryWords) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		hits  int
+		chunk string
+	}
+	var ranked []scored
+	for _, chunk := range chunks {
+		if hits := countHits(queryWords, chunk); hits > 0 {
+			ranked = append(ranked, scored{hits: hits, chunk: chunk})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].hits > ranked[j].hits })
+
+	selected := make([]string, 0, min(topK, len(ranked)))
+	for i := 0; i < len(ranked) && i < topK; i++ {
+		selected = append(selected, ranked[i].chunk)
+	}
+	return selected
+}
+
+func countHits(words []string, text string) int {
+	lower := strings.ToLower(text)
+	hits := 0
+	for _, w := range words {
+		if strings.Contains(lower, strings.ToLower(w)) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// ---------------------------------------------------------------------------
+// Hierarchical compression
+// ---------------------------------------------------------------------------
+
+// compressTiered 对应 compress_tiered：chunks 从旧到新；最近 levels[0] 条原文，
+// levels[1] 条以内截到 maxChars[0] 个字符，levels[2] 条以内截到 maxChars[1]，
+// 更旧的丢弃；从新到旧累加，超出预算即停止。
+func compressTiered(chunks []string, codec TokenCodec, budget int, levels [3]int, maxChars [2]int) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+	var result []string
+	total := 0
+	for i := len(chunks) - 1; i >= 0; i-- {
+		turn := len(chunks) - i // 1 = newest
+		var ct string
+		switch {
+		case turn <= levels[0]:
+			ct = chunks[i]
+		case turn <= levels[1]:
+			ct = headRunes(chunks[i], maxChars[0])
+		case turn <= levels[2]:
+			ct = headRunes(chunks[i], maxChars[1])
+		}
+		if ct == "" {
+			continue
+		}
+		nt := len(codec.Encode(ct))
+		if total+nt > budget {
+			break
+		}
+		result = append([]string{ct}, result...)
+		total += nt
+	}
+	return strings.Join(result, " ")
+}
+
+// headRunes 对应 Python 的 s[:n]（按字符，n 为负时从尾部去掉 -n 个）
+func headRunes(s string, n int) string {
+	runes := []rune(s)
+	if n < 0 {
+		n = max(len(runes)+n, 0)
+	}
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// ---------------------------------------------------------------------------
+// Layout
+// ---------------------------------------------------------------------------
+
+// applyAttentionSink 对应 apply_attention_sink：sink → 关键信息 → 其他内容
+func applyAttentionSink(keyText, otherText string) string {
+	if otherText != "" {
+		return sinkToken + keyText + "\n\n" + otherText
+	}
+	return sinkToken + keyText
+}
+
+// ---------------------------------------------------------------------------
+// Retrieval (retriever.py)
+// ---------------------------------------------------------------------------
+
+const (
+	bm25K1 = 1.5
+	bm25B  = 0.75
+)
+
+// bm25Index 对应 BM25ContextRetriever 的索引部分，词项切分方式由调用方决定。
+// 支持增量 add，供会话长期召回复用。
+type bm25Index struct {
+	docs     [][]string
+	counts   []map[string]int
+	docFreq  map[string]int
+	totalLen int
+	avgLen   float64
+}
+
+func newBM25Index(docs [][]string) *bm25Index {
+	idx := &bm25Index{docFreq: make(map[string]int)}
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+	return idx
+}
+
+// add 追加一个文档，返回其下标
+func (idx *bm25Index) add(doc []string) int {
+	counts := make(map[string]int, len(doc))
+	for _, term := range doc {
+		if counts[term] == 0 {
+			idx.docFreq[term]++
+		}
+		counts[term]++
+	}
+	idx.docs = append(idx.docs, doc)
+	idx.counts = append(idx.counts, counts)
+	idx.totalLen += len(doc)
+	idx.avgLen = float64(idx.totalLen) / float64(len(idx.docs))
+	return len(idx.docs) - 1
+}
+
+// scoredDoc 是检索结果：文档下标与得分
+type scoredDoc struct {
+	Index int
+	Score float64
+}
+
+// search 返回得分大于 0 的前 k 个文档，得分相同保持文档顺序；keep 非 nil 时只考虑其接受的文档
+func (idx *bm25Index) search(query []string, k int, keep func(i int) bool) []scoredDoc {
+	if len(idx.docs) == 0 || len(query) == 0 {
+		return nil
+	}
+	uniq := make([]string, 0, len(query))
+	seen := make(map[string]bool, len(query))
+	for _, term := range query {
+		if !seen[term] {
+			seen[term] = true
+			uniq = append(uniq, term)
+		}
+	}
+
+	var results []scoredDoc
+	for i := range idx.docs {
+		if keep != nil && !keep(i) {
+			continue
+		}
+		if score := idx.score(uniq, i); score > 0 {
+			results = append(results, scoredDoc{Index: i, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].Score > results[b].Score })
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+func (idx *bm25Index) score(uniqueQuery []string, i int) float64 {
+	n := float64(len(idx.docs))
+	docLen := float64(len(idx.docs[i]))
+	score := 0.0
+	for _, term := range uniqueQuery {
+		tf := float64(idx.counts[i][term])
+		if tf == 0 {
+			continue
+		}
+		df := float64(max(idx.docFreq[term], 1))
+		idf := math.Log((n-df+0.5)/(df+0.5) + 1.0)
+		denom := tf + bm25K1*(1-bm25B+bm25B*docLen/idx.avgLen)
+		score += idf * tf * (bm25K1 + 1) / denom
+	}
+	return score
+}
+
+// wordTokens 对应 BM25ContextRetriever._tokenize：re.findall(r'\w+', text.lower())
+func wordTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !isPyWordRune(r) })
+}
+
+// ---------------------------------------------------------------------------
+// Strategy dispatch
+// ---------------------------------------------------------------------------
+
+// buildStrategyContext 对应 build_context 以及 ContextPipeline 中的检索策略
+// （bm25_top1 / keyword_top1，按段落切分后取 top-1，超预算时截断）。
+func buildStrategyContext(text string, codec TokenCodec, budget int, strategy, query string) (string, error) {
+	switch strategy {
+	case StrategyTruncation:
+		return truncateTokens(text, codec, budget), nil
+
+	case StrategyProjectTopic, StrategyAttentionSink, StrategySinkTopic:
+		chunks := chunkSentences(text)
+		if len(chunks) == 0 {
+			return headRunes(text, budget), nil
+		}
+		key := selectRelevant(chunks, query, 3)
+		isKey := make(map[string]bool, len(key))
+		for _, k := range key {
+			isKey[k] = true
+		}
+		var other []string
+		for _, c := range chunks {
+			if !isKey[c] {
+				other = append(other, c)
+			}
+		}
+
+		// 关键片段同样受预算约束
+		keyText := strings.Join(key, " ")
+		keyTokens := len(codec.Encode(keyText))
+		if keyTokens > budget {
+			keyText = truncateTokens(keyText, codec, budget)
+			keyTokens = budget
+		}
+		compressedOther := compressTiered(other, codec, budget-keyTokens, defaultTierLevels, defaultTierMaxChars)
+
+		if strategy == StrategyProjectTopic {
+			return strings.TrimSpace(keyText + " " + compressedOther), nil
+		}
+		return applyAttentionSink(keyText, compressedOther), nil
+
+	case StrategyBM25Top1, StrategyKeywordTop1:
+		paras := chunkParagraphs(text, nil)
+		var ctx string
+		if strategy == StrategyBM25Top1 {
+			docs := make([][]string, len(paras))
+			for i, p := range paras {
+				docs[i] = wordTokens(p)
+			}
+			if query != "" {
+				if hits := newBM25Index(docs).search(wordTokens(query), 1, nil); len(hits) > 0 {
+					ctx = paras[hits[0].Index]
+				}
+			}
+		} else if top := selectRelevant(paras, query, 1); len(top) > 0 {
+			ctx = top[0]
+		}
+		if len(codec.Encode(ctx)) > budget {
+			ctx = truncateTokens(ctx, codec, budget)
+		}
+		return ctx, nil
+	}
+
+	return "", fmt.Errorf("unknown strategy: %s", strategy)
+}
@@ -0,0 +1,186 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// Encode / Decode 让 runeCounter 同时满足 TokenCodec，与生成金标时的 CharTokenizer 一致
+func (runeCounter) Encode(text string) []int {
+	tokens := make([]int, 0, len(text))
+	for _, r := range text {
+		tokens = append(tokens, int(r))
+	}
+	return tokens
+}
+
+func (runeCounter) Decode(tokens []int) string {
+	runes := make([]rune, len(tokens))
+	for i, tok := range tokens {
+		runes[i] = rune(tok)
+	}
+	return string(runes)
+}
+
+// strategiesGolden 对应 testdata/gen_strategies_golden.py 的输出
+type strategiesGolden struct {
+	Texts             []string   `json:"texts"`
+	Queries           []string   `json:"queries"`
+	ChunkSentences    [][]string `json:"chunk_sentences"`
+	ChunkParagraphs   [][]string `json:"chunk_paragraphs"`
+	ExtractQueryWords []struct {
+		Query string   `json:"query"`
+		Words []string `json:"words"`
+	} `json:"extract_query_words"`
+	Build []struct {
+		Strategy string `json:"strategy"`
+		Text     int    `json:"text"`
+		Query    int    `json:"query"`
+		Budget   int    `json:"budget"`
+		Context  string `json:"context"`
+	} `json:"build"`
+}
+
+func loadStrategiesGolden(t *testing.T) *strategiesGolden {
+	t.Helper()
+	data, err := os.ReadFile("testdata/strategies_golden.json")
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	var golden strategiesGolden
+	if err := json.Unmarshal(data, &golden); err != nil {
+		t.Fatalf("parse golden file: %v", err)
+	}
+	return &golden
+}
+
+// TestStrategiesMatchPythonGolden 逐条比对 Python context-engine 生成的金标输出
+func TestStrategiesMatchPythonGolden(t *testing.T) {
+	golden := loadStrategiesGolden(t)
+
+	for i, text := range golden.Texts {
+		if got := chunkSentences(text); !equalChunks(got, golden.ChunkSentences[i]) {
+			t.Errorf("chunkSentences(%q) = %q, want %q", text, got, golden.ChunkSentences[i])
+		}
+		if got := chunkParagraphs(text, nil); !equalChunks(got, golden.ChunkParagraphs[i]) {
+			t.Errorf("chunkParagraphs(%q) = %q, want %q", text, got, golden.ChunkParagraphs[i])
+		}
+	}
+
+	for _, c := range golden.ExtractQueryWords {
+		if got := extractQueryWords(c.Query); !equalChunks(got, c.Words) {
+			t.Errorf("extractQueryWords(%q) = %q, want %q", c.Query, got, c.Words)
+		}
+	}
+
+	optimizer := NewStrategyOptimizer(runeCounter{})
+	for _, c := range golden.Build {
+		text, query := golden.Texts[c.Text], golden.Queries[c.Query]
+		got, err := optimizer.BuildContext(context.Background(), text, query, c.Strategy, c.Budget)
+		if err != nil {
+			t.Fatalf("%s text#%d query#%d budget %d: %v", c.Strategy, c.Text, c.Query, c.Budget, err)
+		}
+		if got != c.Context {
+			t.Errorf("%s text#%d query#%d budget %d:\n got: %q\nwant: %q", c.Strategy, c.Text, c.Query, c.Budget, got, c.Context)
+		}
+	}
+}
+
+func TestBuildStrategyContextUnknownStrategy(t *testing.T) {
+	if _, err := buildStrategyContext("text", runeCounter{}, 10, "dense_top1", "q"); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+	if _, err := NewStrategyBuilder("dense_top1", runeCounter{}); err == nil {
+		t.Fatal("expected NewStrategyBuilder to reject unknown strategy")
+	}
+}
+
+// TestStrategyBuilderCompressesOverBudget 超预算时历史被压缩为一条 system 消息，置顶消息保持原文
+func TestStrategyBuilderCompressesOverBudget(t *testing.T) {
+	builder, err := NewStrategyBuilder(StrategySinkTopic, runeCounter{})
+	if err != nil {
+		t.Fatalf("NewStrategyBuilder failed: %v", err)
+	}
+
+	history := []*domain.Message{{Role: domain.RoleUser, Content: "置顶：回答请使用中文。", Pinned: true}}
+	for i := 0; i < 40; i++ {
+		history = append(history,
+			&domain.Message{Role: domain.RoleUser, Content: strings.Repeat("Tell me about Kafka partitions. ", 4)},
+			&domain.Message{Role: domain.RoleAssistant, Content: strings.Repeat("Partitions are ordered logs. ", 4)},
+		)
+	}
+
+	const maxTokens = 3500
+	built, err := builder.Build(context.Background(), history, "How does Kafka keep ordering?", maxTokens)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if built.Strategy != StrategySinkTopic {
+		t.Fatalf("expected strategy %s, got %s", StrategySinkTopic, built.Strategy)
+	}
+	if built.TokenBudget.IsExhausted() {
+		t.Fatalf("context exceeds budget: used %d, available %d", built.TokenBudget.UsedTokens, built.TokenBudget.Available())
+	}
+
+	// sink, system, pinned, condensed history, reaffirm, user
+	if len(built.Messages) != 6 {
+		t.Fatalf("expected 6 messages, got %d", len(built.Messages))
+	}
+	if !built.Messages[2].Pinned {
+		t.Error("pinned message should follow the system prefix")
+	}
+	if !strings.HasPrefix(built.Messages[3].Content, sinkToken) {
+		t.Errorf("sink_topic output should start with the sink token, got %q", built.Messages[3].Content[:20])
+	}
+
+	total := 0
+	for _, m := range built.Messages {
+		total += runeCounter{}.Count(m.Content)
+	}
+	if limit := maxTokens - 2048 - 256; total > limit {
+		t.Errorf("assembled context %d tokens exceeds limit %d", total, limit)
+	}
+}
+
+func TestStrategyBuilderKeepsHistoryWithinBudget(t *testing.T) {
+	builder, err := NewStrategyBuilder(StrategyBM25Top1, runeCounter{})
+	if err != nil {
+		t.Fatalf("NewStrategyBuilder failed: %v", err)
+	}
+	history := []*domain.Message{
+		{Role: domain.RoleUser, Content: "Hi"},
+		{Role: domain.RoleAssistant, Content: "Hello!"},
+	}
+	built, err := builder.Build(context.Background(), history, "Thanks", 8192)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if built.Strategy != "full" {
+		t.Errorf("expected full strategy when history fits, got %s", built.Strategy)
+	}
+	if !reflect.DeepEqual(built.Messages[2:4], history) {
+		t.Error("history within budget should be kept verbatim")
+	}
+}
+
+func TestStrategyBuilderPinnedExceedsBudget(t *testing.T) {
+	builder, _ := NewStrategyBuilder(StrategyTruncation, runeCounter{})
+	history := []*domain.Message{{Role: domain.RoleUser, Content: strings.Repeat("x", 5000), Pinned: true}}
+	if _, err := builder.Build(context.Background(), history, "hi", 4096); !errors.Is(err, ErrPinnedExceedsBudget) {
+		t.Fatalf("expected ErrPinnedExceedsBudget, got %v", err)
+	}
+}
+
+func equalChunks(got, want []string) bool {
+	if len(got) == 0 && len(want) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,190 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// strategyBuilder implements ContextBuilder on top of the ported
+// context-engine strategies, so the hot path needs no gRPC hop.
+//
+// 历史（不含置顶消息）在预算内时原样保留；超出预算时拍平成文本，
+// 以当前用户输入为 query 交给所选策略压缩，结果作为一条 system 消息放在置顶消息之后。
+type strategyBuilder struct {
+	strategy string
+	codec    TokenCodec
+}
+
+// NewStrategyBuilder creates a ContextBuilder that compresses history with one
+// of the Strategy* strategies. codec must not be nil.
+func NewStrategyBuilder(strategy string, codec TokenCodec) (ContextBuilder, error) {
+	if codec == nil {
+		return nil, fmt.Errorf("strategy %s requires a token codec", strategy)
+	}
+	if !isKnownStrategy(strategy) {
+		return nil, fmt.Errorf("unknown strategy: %s", strategy)
+	}
+	return &strategyBuilder{strategy: strategy, codec: codec}, nil
+}
+
+func (b *strategyBuilder) Build(ctx context.Context, history []*domain.Message, userMessage string, modelMaxTokens int) (*BuiltContext, error) {
+	_ = ctx
+
+	recalled, history := splitRecalled(history)
+	pinned, rest := splitPinned(history)
+	budget := NewBudget(modelMaxTokens, 2048, 256)
+
+	// 置顶消息优先占用预算，与 defaultBuilder 一致
+	pinnedTokens := b.countMessages(pinned)
+	budget.UsedTokens = pinnedTokens
+	if len(pinned) > 0 && budget.IsExhausted() {
+		return nil, fmt.Errorf("%w: pinned %d tokens, budget %d tokens",
+			ErrPinnedExceedsBudget, pinnedTokens, budget.MaxContextWindow-budget.ReservedOutput-budget.SafetyMargin)
+	}
+
+	messages := []*domain.Message{
+		{Role: domain.RoleSystem, Content: sinkToken},
+		{Role: domain.RoleSystem, Content: fmt.Sprintf("%s\n\n%s", globalInstruction, "When in doubt, think step by step.")},
+	}
+	messages = append(messages, pinned...)
+
+	// 召回的旧消息以引用形式紧跟置顶消息
+	recallLimit := int(float64(budget.Available()) * recallBudgetRatio)
+	recallSeg, recalledTokens := recalledSegment(recalled, recallLimit, b.codec.Count)
+	if recallSeg != nil {
+		messages = append(messages, recallSeg)
+	}
+
+	fixedTokens := b.countMessages(messages) + b.codec.Count(userMessage) + b.codec.Count(globalInstruction)
+	historyTokens := b.countMessages(rest)
+	budget.UsedTokens = fixedTokens + historyTokens
+
+	strategy := "full"
+	compression := map[string]interface{}{
+		"ratio":           0.0,
+		"used_tokens":     budget.UsedTokens,
+		"pinned_tokens":   pinnedTokens,
+		"recalled_tokens": recalledTokens,
+	}
+
+	if !budget.IsExhausted() {
+		messages = append(messages, rest...)
+	} else {
+		// 策略预算 = 总预算 - 前缀/置顶/当前输入/重申指令
+		target := budget.MaxContextWindow - budget.ReservedOutput - budget.SafetyMargin - fixedTokens
+		condensed, err := b.condense(flattenHistory(rest, b.strategy), userMessage, target)
+		if err != nil {
+			return nil, err
+		}
+		condensedTokens := 0
+		if strings.TrimSpace(condensed) != "" {
+			condensedTokens = b.codec.Count(condensed)
+			messages = append(messages, &domain.Message{Role: domain.RoleSystem, Content: condensed})
+		}
+		budget.UsedTokens = fixedTokens + condensedTokens
+
+		strategy = b.strategy
+		compression = map[string]interface{}{
+			"ratio":             float64(condensedTokens) / float64(max(historyTokens, 1)),
+			"original_tokens":   historyTokens,
+			"compressed_tokens": condensedTokens,
+			"pinned_tokens":     pinnedTokens,
+			"recalled_tokens":   recalledTokens,
+		}
+	}
+
+	// 近因效应：重申关键指令，然后是当前用户输入
+	messages = append(messages,
+		&domain.Message{Role: domain.RoleSystem, Content: globalInstruction},
+		&domain.Message{Role: domain.RoleUser, Content: userMessage},
+	)
+
+	return &BuiltContext{
+		Messages:    messages,
+		Strategy:    strategy,
+		Compression: compression,
+		TokenBudget: budget,
+	}, nil
+}
+
+// condense 运行所选策略。Python 版的布局（sink、分隔符、拼接空格）不计入预算，
+// 输出可能略超 target，此时按超出量收紧预算重试，最后兜底截断。
+func (b *strategyBuilder) condense(text, query string, target int) (string, error) {
+	limit := target
+	for attempt := 0; attempt < 3 && limit > 0; attempt++ {
+		condensed, err := buildStrategyContext(text, b.codec, limit, b.strategy, query)
+		if err != nil {
+			return "", err
+		}
+		over := b.codec.Count(condensed) - target
+		if over <= 0 {
+			return condensed, nil
+		}
+		limit -= over
+	}
+	if target <= 0 {
+		return "", nil
+	}
+	return truncateTokens(text, b.codec, target), nil
+}
+
+func (b *strategyBuilder) countMessages(messages []*domain.Message) int {
+	total := 0
+	for _, msg := range messages {
+		if msg.TokenCount > 0 {
+			total += msg.TokenCount
+		} else {
+			total += b.codec.Count(msg.Content)
+		}
+	}
+	return total
+}
+
+// flattenHistory 把历史拍平为策略输入文本（从旧到新，一行一条）。
+// 检索类策略按 "Paragraph N:" 切段，因此每条消息加上段落前缀，使一条消息成为一个检索单元。
+func flattenHistory(history []*domain.Message, strategy string) string {
+	var sb strings.Builder
+	for i, msg := range history {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if strategy == StrategyBM25Top1 || strategy == StrategyKeywordTop1 {
+			fmt.Fprintf(&sb, "Paragraph %d: ", i+1)
+		}
+		fmt.Fprintf(&sb, "%s: %s", msg.Role, msg.Content)
+	}
+	return sb.String()
+}
+
+func isKnownStrategy(strategy string) bool {
+	switch strategy {
+	case StrategyTruncation, StrategyProjectTopic, StrategyAttentionSink, StrategySinkTopic,
+		StrategyBM25Top1, StrategyKeywordTop1:
+		return true
+	}
+	return false
+}
+
+// strategyOptimizer 是 context-engine gRPC 客户端的进程内替代实现
+type strategyOptimizer struct {
+	codec TokenCodec
+}
+
+// NewStrategyOptimizer creates an in-process domain.ContextOptimizer with the
+// same behaviour as the remote context-engine service.
+func NewStrategyOptimizer(codec TokenCodec) domain.ContextOptimizer {
+	return &strategyOptimizer{codec: codec}
+}
+
+func (o *strategyOptimizer) BuildContext(ctx context.Context, text, query, strategy string, budget int) (string, error) {
+	_ = ctx
+	return buildStrategyContext(text, o.codec, budget, strategy, query)
+}
+
+var (
+	_ ContextBuilder          = (*strategyBuilder)(nil)
+	_ domain.ContextOptimizer = (*strategyOptimizer)(nil)
+)
@@ -0,0 +1,69 @@
+package context
+
+import (
+	"strings"
+	"unicode"
+)
+
+// englishStopwords 过滤高频虚词，避免它们主导相似度
+var englishStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true,
+	"to": true, "in": true, "on": true, "for": true, "is": true, "are": true,
+	"was": true, "were": true, "be": true, "it": true, "this": true, "that": true,
+	"with": true, "as": true, "at": true, "by": true, "we": true, "you": true,
+	"i": true, "do": true, "does": true, "can": true, "what": true, "how": true,
+}
+
+// tokenizeTerms 将文本切分为检索/相似度计算用的词项：
+// 英文和数字按单词小写切分并去掉停用词；中文等 CJK 文本没有分词器，
+// 按连续字符的二元组切分（单个汉字保留单字）。
+func tokenizeTerms(text string) []string {
+	var terms []string
+	var word strings.Builder
+	var cjkRun []rune
+
+	flushWord := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := strings.ToLower(word.String())
+		if !englishStopwords[w] {
+			terms = append(terms, w)
+		}
+		word.Reset()
+	}
+	flushCJK := func() {
+		switch len(cjkRun) {
+		case 0:
+		case 1:
+			terms = append(terms, string(cjkRun))
+		default:
+			for i := 0; i+1 < len(cjkRun); i++ {
+				terms = append(terms, string(cjkRun[i:i+2]))
+			}
+		}
+		cjkRun = cjkRun[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjkRun = append(cjkRun, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word.WriteRune(r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
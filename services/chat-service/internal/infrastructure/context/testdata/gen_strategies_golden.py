@@ -0,0 +1,103 @@
+"""
+Generate strategies_golden.json from the Python context-engine.
+
+The Go port in strategies.go must reproduce these outputs byte for byte.
+A character tokenizer (one token per code point) is used on both sides so
+that the golden data does not depend on a model vocabulary.
+
+Usage (from the repository root):
+    python3 services/chat-service/internal/infrastructure/context/testdata/gen_strategies_golden.py
+"""
+
+import json
+import os
+import sys
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+ROOT = os.path.abspath(os.path.join(HERE, "..", "..", "..", "..", "..", ".."))
+sys.path.insert(0, os.path.join(ROOT, "services", "context-engine", "src"))
+
+from strategies import chunk_sentences, chunk_paragraphs, extract_query_words  # noqa: E402
+from pipeline import ContextPipeline, PipelineConfig  # noqa: E402
+
+
+class CharTokenizer:
+    def encode(self, text, add_special_tokens=False):
+        return [ord(c) for c in text]
+
+    def decode(self, tokens, skip_special_tokens=True):
+        return "".join(chr(t) for t in tokens)
+
+
+TEXTS = [
+    "",
+    "   ",
+    "No terminator here at all",
+    "Kafka partitions enable parallel consumption. Each consumer reads assigned partitions!  "
+    "Ordering is preserved per partition? Yes.",
+    "Wait... what?! Really.Next sentence\there.",
+    "Kafka 的分区是并行的基本单位。消费者组内的消费者分摊分区！分区内的消息是有序的？今天天气不错。",
+    "Paragraph 1: Alice met the Dragon near the river. "
+    "Paragraph 2: Bob studied quantum chromodynamics at night. "
+    "Paragraph 3: The Dragon returned to the river with Alice. "
+    "Paragraph 4: Nothing relevant happens here.",
+    "Intro text before paragraphs. Paragraph 1: 小明在北京学习机器学习。Paragraph 2: 小红在上海研究分布式系统。"
+    "Paragraph 10: 北京的机器学习课程很受欢迎。",
+    " ".join("Sentence number %d talks about topic_%d and Kafka." % (i, i % 3) for i in range(30)),
+    "user: 我们来讨论 Redis 缓存穿透。\nassistant: 缓存穿透是指查询不存在的数据。可以用布隆过滤器。\n"
+    "user: 那缓存雪崩呢？\nassistant: 雪崩是大量 key 同时过期。可以加随机过期时间。",
+    "café naïve résumé. Über große Straße! Ĉu vi parolas Esperanton?",
+]
+
+QUERIES = [
+    "",
+    "What does the Dragon do with Alice?",
+    "Explain Kafka partitions ordering",
+    "请根据文本回答：机器学习在北京的课程",
+    "缓存雪崩和缓存穿透有什么区别",
+    "topic_2 sentences",
+    "abc résumé Straße Esperanton",
+]
+
+QUERY_WORD_CASES = QUERIES + [
+    "x1abcd abcd_ efgh éabcd abcdé ijkl-mnop",
+    "一二三四五六七八九十一二三 甲 乙丙",
+    "the THIS Those answers Which",
+]
+
+STRATEGIES = ["truncation", "project_topic", "attention_sink", "sink_topic", "bm25_top1", "keyword_top1"]
+BUDGETS = [0, 7, 40, 150, 100000]
+
+
+def main():
+    tok = CharTokenizer()
+    golden = {
+        "texts": TEXTS,
+        "queries": QUERIES,
+        "chunk_sentences": [chunk_sentences(t) for t in TEXTS],
+        "chunk_paragraphs": [chunk_paragraphs(t) for t in TEXTS],
+        "extract_query_words": [{"query": q, "words": extract_query_words(q)} for q in QUERY_WORD_CASES],
+        "build": [],
+    }
+    for strategy in STRATEGIES:
+        for ti, text in enumerate(TEXTS):
+            for qi, query in enumerate(QUERIES):
+                for budget in BUDGETS:
+                    pipeline = ContextPipeline(PipelineConfig(strategy=strategy, budget=budget))
+                    golden["build"].append({
+                        "strategy": strategy,
+                        "text": ti,
+                        "query": qi,
+                        "budget": budget,
+                        "context": pipeline.build(text, tok, query),
+                    })
+
+    out = os.path.join(HERE, "strategies_golden.json")
+    with open(out, "w", encoding="utf-8") as f:
+        json.dump(golden, f, ensure_ascii=False, indent=0)
+        f.write("\n")
+    print("wrote %d build cases to %s" % (len(golden["build"]), out))
+
+
+if __name__ == "__main__":
+    main()
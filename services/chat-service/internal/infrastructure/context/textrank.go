@@ -0,0 +1,225 @@
+package context
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+const (
+	// textRankDamping PageRank 阻尼系数
+	textRankDamping = 0.85
+	// textRankIterations 迭代上限，通常十几轮就收敛
+	textRankIterations = 50
+	// textRankEpsilon 收敛阈值
+	textRankEpsilon = 1e-6
+)
+
+// textRankCompressor 是不依赖 LLM 的抽取式压缩器：把每条消息切成句子，
+// 用基于 TF-IDF 余弦相似度的 TextRank 给句子打分，按分数保留前若干句（保持原顺序）。
+// 每条消息的候选为“前 1 句、前 2 句……、原文”，再由 allocateLevels 在预算内分配，
+// 因此它天然处于原文与丢弃之间，推理集群繁忙时可以替代 LLM 摘要。
+type textRankCompressor struct {
+	counter TokenCounter
+}
+
+// NewTextRankCompressor creates an extractive Compressor that needs no LLM.
+// counter is used for exact token costs; nil falls back to a rune-based estimate.
+func NewTextRankCompressor(counter TokenCounter) Compressor {
+	return &textRankCompressor{counter: counter}
+}
+
+func (c *textRankCompressor) Compress(ctx context.Context, sessionID string, messages []*domain.Message, targetBudget int) ([]*CompressedSegment, error) {
+	_ = ctx
+	_ = sessionID
+
+	if targetBudget <= 0 || len(messages) == 0 {
+		return nil, nil
+	}
+
+	// IDF 以本次参与压缩的所有句子为语料
+	sentences := make([][]string, len(messages))
+	var corpus [][]string
+	for i, m := range messages {
+		sentences[i] = splitSentences(m.Content)
+		for _, s := range sentences[i] {
+			corpus = append(corpus, tokenizeTerms(s))
+		}
+	}
+	idf := inverseDocumentFrequency(corpus)
+
+	options := make([][]candidate, len(messages))
+	originals := make([]int, len(messages))
+	for i, m := range messages {
+		indexFromNewest := len(messages) - 1 - i
+		originals[i] = originalTokens(c.counter, m)
+		options[i] = c.candidates(m, sentences[i], idf, originals[i], recencyWeight(indexFromNewest))
+	}
+
+	chosen := allocateLevels(options, originals, targetBudget)
+	return assembleSegments(messages, options, originals, chosen), nil
+}
+
+// candidates 按 TextRank 分数依次加入句子，生成 token 成本递增的抽取式摘要，最后一项为原文
+func (c *textRankCompressor) candidates(m *domain.Message, sentences []string, idf map[string]float64, original int, weight float64) []candidate {
+	var opts []candidate
+	if len(sentences) > 1 {
+		vectors := make([]map[string]float64, len(sentences))
+		for i, s := range sentences {
+			vectors[i] = tfidfVector(tokenizeTerms(s), idf)
+		}
+		scores := textRank(vectors)
+
+		ranked := make([]int, len(sentences))
+		for i := range ranked {
+			ranked[i] = i
+		}
+		sort.SliceStable(ranked, func(a, b int) bool {
+			return scores[ranked[a]] > scores[ranked[b]]
+		})
+
+		totalScore := 0.0
+		for _, s := range scores {
+			totalScore += s
+		}
+
+		lastTokens := 0
+		keptScore := 0.0
+		for k := 1; k < len(sentences); k++ {
+			keptScore += scores[ranked[k-1]]
+			kept := append([]int(nil), ranked[:k]...)
+			sort.Ints(kept)
+			parts := make([]string, len(kept))
+			for i, idx := range kept {
+				parts[i] = sentences[idx]
+			}
+			content := strings.Join(parts, " ")
+			tokens := countTokens(c.counter, content)
+			if tokens <= lastTokens || tokens >= original {
+				continue
+			}
+
+			level := CompressLevelMedium
+			if tokens*2 >= original {
+				level = CompressLevelLight
+			}
+			opts = append(opts, candidate{
+				level:   level,
+				content: content,
+				tokens:  tokens,
+				value:   weight * float64(original) * keptScore / totalScore,
+			})
+			lastTokens = tokens
+		}
+	}
+
+	return append(opts, candidate{
+		level:   CompressLevelNone,
+		content: m.Content,
+		tokens:  original,
+		value:   weight * float64(original),
+	})
+}
+
+// textRank 在句子相似度图上运行加权 PageRank，返回每个句子的分数
+func textRank(vectors []map[string]float64) []float64 {
+	n := len(vectors)
+	sim := make([][]float64, n)
+	outWeight := make([]float64, n)
+	for i := range sim {
+		sim[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			s := cosineSimilarity(vectors[i], vectors[j])
+			sim[i][j], sim[j][i] = s, s
+			outWeight[i] += s
+			outWeight[j] += s
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1.0 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < textRankIterations; iter++ {
+		delta := 0.0
+		for i := 0; i < n; i++ {
+			sum := 0.0
+			for j := 0; j < n; j++ {
+				if sim[j][i] > 0 && outWeight[j] > 0 {
+					sum += sim[j][i] / outWeight[j] * scores[j]
+				}
+			}
+			next[i] = (1-textRankDamping)/float64(n) + textRankDamping*sum
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores, next = next, scores
+		if delta < textRankEpsilon {
+			break
+		}
+	}
+	return scores
+}
+
+// inverseDocumentFrequency 计算平滑 IDF：log((1+N)/(1+df)) + 1
+func inverseDocumentFrequency(docs [][]string) map[string]float64 {
+	df := make(map[string]int)
+	for _, doc := range docs {
+		seen := make(map[string]bool, len(doc))
+		for _, term := range doc {
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+	}
+	idf := make(map[string]float64, len(df))
+	n := float64(len(docs))
+	for term, count := range df {
+		idf[term] = math.Log((1+n)/(1+float64(count))) + 1
+	}
+	return idf
+}
+
+func tfidfVector(terms []string, idf map[string]float64) map[string]float64 {
+	vec := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		vec[term]++
+	}
+	for term, tf := range vec {
+		vec[term] = tf * idf[term]
+	}
+	return vec
+}
+
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	dot := 0.0
+	for term, w := range a {
+		dot += w * b[term]
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (vectorNorm(a) * vectorNorm(b))
+}
+
+func vectorNorm(v map[string]float64) float64 {
+	sum := 0.0
+	for _, w := range v {
+		sum += w * w
+	}
+	return math.Sqrt(sum)
+}
+
+var _ Compressor = (*textRankCompressor)(nil)
@@ -0,0 +1,111 @@
+package context
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// TestTextRankNeverExceedsBudget 属性测试：抽取式压缩同样不能超出预算
+func TestTextRankNeverExceedsBudget(t *testing.T) {
+	r := rand.New(rand.NewSource(99))
+	compressor := NewTextRankCompressor(runeCounter{})
+
+	for iter := 0; iter < 200; iter++ {
+		messages := randomMessages(r, r.Intn(30)+1)
+		budget := r.Intn(1500) + 1
+
+		segments, err := compressor.Compress(context.Background(), "s1", messages, budget)
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+		total := 0
+		for _, seg := range segments {
+			total += seg.CompressedTokens
+		}
+		if total > budget {
+			t.Fatalf("iter %d: compressed tokens %d exceed budget %d", iter, total, budget)
+		}
+	}
+}
+
+// TestTextRankKeepsOnlyOriginalSentences 验证抽取式摘要只包含原文中的句子，不会截断句子
+func TestTextRankKeepsOnlyOriginalSentences(t *testing.T) {
+	content := "Kafka 的分区是并行的基本单位。消费者组内的消费者分摊分区。分区内的消息是有序的。今天天气不错！"
+	messages := []*domain.Message{
+		{Role: domain.RoleUser, Content: content},
+		{Role: domain.RoleAssistant, Content: "好的。"},
+	}
+
+	compressor := NewTextRankCompressor(runeCounter{})
+	segments, err := compressor.Compress(context.Background(), "s1", messages, 40)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if len(segments) == 0 {
+		t.Fatal("expected at least one segment")
+	}
+
+	original := splitSentences(content)
+	for _, seg := range segments {
+		if seg.Level == CompressLevelNone {
+			continue
+		}
+		for _, s := range splitSentences(seg.Content) {
+			found := false
+			for _, o := range original {
+				if s == o {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("extracted sentence %q is not an original sentence", s)
+			}
+		}
+	}
+}
+
+// TestTextRankPrefersCentralSentence 验证与其他句子最相关的句子得分最高，离题句子得分最低
+func TestTextRankPrefersCentralSentence(t *testing.T) {
+	sentences := []string{
+		"Kafka partitions enable parallel consumption.",
+		"Each Kafka consumer reads from assigned partitions.",
+		"Kafka partitions preserve ordering per partition for each consumer.",
+		"My cat likes sunny windows.",
+	}
+	docs := make([][]string, len(sentences))
+	for i, s := range sentences {
+		docs[i] = tokenizeTerms(s)
+	}
+	idf := inverseDocumentFrequency(docs)
+	vectors := make([]map[string]float64, len(sentences))
+	for i, d := range docs {
+		vectors[i] = tfidfVector(d, idf)
+	}
+
+	scores := textRank(vectors)
+	for i := 0; i < 3; i++ {
+		if scores[3] >= scores[i] {
+			t.Errorf("off-topic sentence should rank below sentence %d: %.4f >= %.4f", i, scores[3], scores[i])
+		}
+	}
+}
+
+func TestTokenizeTermsMixedScript(t *testing.T) {
+	terms := tokenizeTerms("Kafka 分区策略 is great")
+	joined := strings.Join(terms, "|")
+	for _, want := range []string{"kafka", "分区", "区策", "策略", "great"} {
+		if !strings.Contains("|"+joined+"|", "|"+want+"|") {
+			t.Errorf("expected term %q in %v", want, terms)
+		}
+	}
+	for _, stop := range []string{"is"} {
+		if strings.Contains("|"+joined+"|", "|"+stop+"|") {
+			t.Errorf("stopword %q should be removed, got %v", stop, terms)
+		}
+	}
+}
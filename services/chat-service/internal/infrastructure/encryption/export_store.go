@@ -0,0 +1,213 @@
+package encryption
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+const (
+	// archiveChunkSize 是导出压缩包加密的分块大小
+	archiveChunkSize = 64 << 10
+	// finalChunk 是分块长度中标记最后一块的位
+	finalChunk = 1 << 31
+)
+
+// SealedExportStore 用用户个人范围的数据密钥加密导出压缩包，读取时解密。
+// 文件以一行 enc:v<版本>:<范围> 开头，之后是若干个分块，每块是 4 字节长度（最高位标记最后一块）加密文；
+// 每块的附加数据包含序号与是否为最后一块，分块被调换或截断时读取失败。账户删除粉碎密钥后，已写出的压缩包（包括存储的备份）都无法再解密
+type SealedExportStore struct {
+	store domain.ExportStore
+	ring  *KeyRing
+}
+
+func NewSealedExportStore(store domain.ExportStore, ring *KeyRing) *SealedExportStore {
+	return &SealedExportStore{store: store, ring: ring}
+}
+
+func (s *SealedExportStore) Create(ctx context.Context, userID, exportID string) (io.WriteCloser, error) {
+	scope := domain.KeyScope("", userID)
+	key, err := s.ring.currentKey(ctx, scope, true)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.store.Create(ctx, userID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(file, sealedPrefix+"v"+strconv.Itoa(key.version)+":"+scope+"\n"); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sealedWriter{file: file, key: key, ad: additionalData(scope, key.version)}, nil
+}
+
+func (s *SealedExportStore) Open(ctx context.Context, userID, exportID string) (io.ReadCloser, error) {
+	file, err := s.store.Open(ctx, userID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(file)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read archive header: %w", err)
+	}
+	rest, sealed := strings.CutPrefix(strings.TrimSuffix(header, "\n"), sealedPrefix+"v")
+	v, scope, ok := strings.Cut(rest, ":")
+	version, err := strconv.Atoi(v)
+	if !sealed || !ok || err != nil {
+		file.Close()
+		return nil, errors.New("archive is not sealed")
+	}
+	// 先取得密钥，密钥已销毁时在开始下载之前返回 domain.ErrKeyDestroyed
+	key, err := s.ring.key(ctx, scope, version)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sealedReader{file: file, r: r, key: key, ad: additionalData(scope, version)}, nil
+}
+
+func (s *SealedExportStore) Delete(ctx context.Context, userID, exportID string) error {
+	return s.store.Delete(ctx, userID, exportID)
+}
+
+func (s *SealedExportStore) DeleteUser(ctx context.Context, userID string) error {
+	return s.store.DeleteUser(ctx, userID)
+}
+
+// chunkData 是分块的附加数据：范围与版本、序号、是否为最后一块
+func chunkData(ad []byte, index uint64, final bool) []byte {
+	out := append([]byte(nil), ad...)
+	out = binary.BigEndian.AppendUint64(append(out, ':'), index)
+	if final {
+		return append(out, 1)
+	}
+	return append(out, 0)
+}
+
+type sealedWriter struct {
+	file  io.WriteCloser
+	key   *dataKey
+	ad    []byte
+	buf   []byte
+	index uint64
+	err   error
+}
+
+func (w *sealedWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		take := min(archiveChunkSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:take]...)
+		p = p[take:]
+		// 缓冲满时不立即写出，保证 Close 时总有一块可以标记为最后一块
+		if len(w.buf) == archiveChunkSize && len(p) > 0 {
+			if w.err = w.flush(false); w.err != nil {
+				return n - len(p), w.err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *sealedWriter) flush(final bool) error {
+	sealed, err := seal(w.key.aead, w.buf, chunkData(w.ad, w.index, final))
+	if err != nil {
+		return fmt.Errorf("encrypt archive: %w", err)
+	}
+	size := uint32(len(sealed))
+	if final {
+		size |= finalChunk
+	}
+	if _, err := w.file.Write(binary.BigEndian.AppendUint32(nil, size)); err != nil {
+		return err
+	}
+	if _, err := w.file.Write(sealed); err != nil {
+		return err
+	}
+	w.buf, w.index = w.buf[:0], w.index+1
+	return nil
+}
+
+// Close 写出最后一块后关闭文件；写入失败时文件同样关闭，由调用方删除
+func (w *sealedWriter) Close() error {
+	if w.err == nil {
+		w.err = w.flush(true)
+	}
+	if err := w.file.Close(); w.err == nil {
+		w.err = err
+	}
+	if w.err != nil {
+		return w.err
+	}
+	w.err = errors.New("archive writer closed")
+	return nil
+}
+
+type sealedReader struct {
+	file  io.Closer
+	r     *bufio.Reader
+	key   *dataKey
+	ad    []byte
+	buf   []byte
+	index uint64
+	done  bool
+}
+
+func (r *sealedReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next 解密下一块。不是最后一块却没有后续数据时，压缩包被截断
+func (r *sealedReader) next() error {
+	var size [4]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	final := n&finalChunk != 0
+	if n &^= finalChunk; n > archiveChunkSize+1024 {
+		return fmt.Errorf("archive chunk %d is too large", r.index)
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		return err
+	}
+	plaintext, err := open(r.key.aead, sealed, chunkData(r.ad, r.index, final))
+	if err != nil {
+		return fmt.Errorf("decrypt archive chunk %d: %w", r.index, err)
+	}
+	r.buf, r.done, r.index = plaintext, final, r.index+1
+	return nil
+}
+
+func (r *sealedReader) Close() error {
+	return r.file.Close()
+}
+
+var _ domain.ExportStore = (*SealedExportStore)(nil)
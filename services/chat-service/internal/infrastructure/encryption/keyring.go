@@ -0,0 +1,450 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// 密文格式为 enc:v<版本>:<范围>:<base64(nonce||密文)>，范围与版本同时作为附加数据参与认证。
+// 范围写在密文中，解密不依赖会话当前所属的范围；不带前缀的值视为加密前写入的明文
+const sealedPrefix = "enc:"
+
+const (
+	// keyCacheTTL 解包后的数据密钥在内存中的缓存时间，其他实例轮换或粉碎密钥后最多这么久生效
+	keyCacheTTL = time.Minute
+	// maxOwners 会话所属范围缓存的上限，满了整体清空
+	maxOwners = 10000
+	// rewrapBatchSize 重新包装数据密钥时每批的个数
+	rewrapBatchSize = 100
+)
+
+// StoredKey 是数据库中的一个数据密钥版本，Wrapped 是被主密钥包装后的密钥，销毁时清空
+type StoredKey struct {
+	Scope       string
+	Version     int
+	MasterKeyID string
+	Wrapped     []byte
+	CreatedAt   time.Time
+	RetiredAt   time.Time
+	DestroyedAt time.Time
+}
+
+// KeyStore persists wrapped data keys.
+type KeyStore interface {
+	// CurrentKey 返回范围内未退役也未销毁的版本，没有时返回 nil
+	CurrentKey(ctx context.Context, scope string) (*StoredKey, error)
+	// GetKey 返回范围内的指定版本，不存在时返回 nil
+	GetKey(ctx context.Context, scope string, version int) (*StoredKey, error)
+	ListKeys(ctx context.Context, scope string) ([]*StoredKey, error)
+	// CreateKey 以范围内最大版本加一保存 key 并退役其他版本，返回当前版本；
+	// rotate 为 false 且范围内已有当前版本时不创建，直接返回已有的
+	CreateKey(ctx context.Context, key *StoredKey, rotate bool) (*StoredKey, error)
+	// DestroyKeys 销毁范围内的全部版本，返回本次销毁的个数
+	DestroyKeys(ctx context.Context, scope string) (int64, error)
+	// ListStaleWrapped 返回未销毁且不是由 masterKeyID 包装的密钥
+	ListStaleWrapped(ctx context.Context, masterKeyID string, limit int) ([]*StoredKey, error)
+	Rewrap(ctx context.Context, key *StoredKey, masterKeyID string, wrapped []byte) error
+	// SessionOwner 返回会话的工作空间与所有者，会话不存在时 found 为 false
+	SessionOwner(ctx context.Context, sessionID string) (workspaceID, userID string, found bool, err error)
+}
+
+// dataKey 是解包后的数据密钥：内容密钥与检索密钥都由数据密钥派生
+type dataKey struct {
+	version   int
+	aead      cipher.AEAD
+	index     []byte
+	destroyed bool
+	loaded    time.Time
+}
+
+type keyRef struct {
+	scope   string
+	version int
+}
+
+// KeyRing encrypts message content and session titles with per-scope data
+// keys (envelope encryption) and derives the blind search index.
+type KeyRing struct {
+	store       KeyStore
+	master      MasterKey
+	searchIndex bool
+
+	mu      sync.Mutex
+	keys    map[keyRef]*dataKey
+	current map[string]*dataKey
+	owners  map[string]string
+}
+
+// NewKeyRing searchIndex 为 true 时为消息写入盲索引，否则加密后不再保存检索文本，全文检索关闭
+func NewKeyRing(store KeyStore, master MasterKey, searchIndex bool) *KeyRing {
+	return &KeyRing{
+		store:       store,
+		master:      master,
+		searchIndex: searchIndex,
+		keys:        make(map[keyRef]*dataKey),
+		current:     make(map[string]*dataKey),
+		owners:      make(map[string]string),
+	}
+}
+
+func (r *KeyRing) SearchIndexEnabled() bool {
+	return r.searchIndex
+}
+
+// Seal 用范围的当前数据密钥加密，范围还没有数据密钥时创建。空字符串不加密
+func (r *KeyRing) Seal(ctx context.Context, scope, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	key, err := r.currentKey(ctx, scope, true)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(key.aead, []byte(plaintext), additionalData(scope, key.version))
+	if err != nil {
+		return "", fmt.Errorf("encrypt: %w", err)
+	}
+	return sealedPrefix + "v" + strconv.Itoa(key.version) + ":" + scope + ":" +
+		base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 的结果，明文原样返回；数据密钥已销毁时返回 domain.ErrKeyDestroyed
+func (r *KeyRing) Open(ctx context.Context, value string) (string, error) {
+	scope, version, sealed, ok := parseSealed(value)
+	if !ok {
+		return value, nil
+	}
+	key, err := r.key(ctx, scope, version)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(key.aead, sealed, additionalData(scope, version))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s v%d: %w", scope, version, err)
+	}
+	return string(plaintext), nil
+}
+
+// Stale 返回值是否需要重新加密：仍为明文、属于其他范围或不是范围的当前版本
+func (r *KeyRing) Stale(ctx context.Context, scope, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	sealedScope, version, _, ok := parseSealed(value)
+	if !ok || sealedScope != scope {
+		return true, nil
+	}
+	key, err := r.currentKey(ctx, scope, false)
+	if err != nil {
+		return false, err
+	}
+	return key == nil || key.version != version, nil
+}
+
+// BlindIndex 把文本按字母数字切分为词，用当前检索密钥把每个词替换为 HMAC，
+// 结果仍可用 to_tsvector('simple', ...) 建立索引，但不泄露原词
+func (r *KeyRing) BlindIndex(ctx context.Context, scope, text string) (string, error) {
+	key, err := r.currentKey(ctx, scope, true)
+	if err != nil {
+		return "", err
+	}
+	return blind(key.index, tokens(text, true)), nil
+}
+
+// BlindQueries 为范围内每个未销毁的版本生成一个查询，重新加密完成前旧版本的盲索引仍可检索
+func (r *KeyRing) BlindQueries(ctx context.Context, scope, query string) ([]string, error) {
+	words := tokens(query, false)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	stored, err := r.store.ListKeys(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	var queries []string
+	for _, sk := range stored {
+		if !sk.DestroyedAt.IsZero() {
+			continue
+		}
+		key, err := r.key(ctx, scope, sk.Version)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, blind(key.index, words))
+	}
+	return queries, nil
+}
+
+// SessionScope 返回会话所属的密钥范围。会话尚未落库（消息经 MQ 先到达）或是无痕会话时使用 userID 的个人范围，
+// 之后由重新加密任务移到会话的范围
+func (r *KeyRing) SessionScope(ctx context.Context, sessionID, userID string) (string, error) {
+	r.mu.Lock()
+	scope, ok := r.owners[sessionID]
+	r.mu.Unlock()
+	if ok {
+		return scope, nil
+	}
+	workspaceID, owner, found, err := r.store.SessionOwner(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return domain.KeyScope("", userID), nil
+	}
+	scope = domain.KeyScope(workspaceID, owner)
+	r.mu.Lock()
+	if len(r.owners) >= maxOwners {
+		r.owners = make(map[string]string)
+	}
+	r.owners[sessionID] = scope
+	r.mu.Unlock()
+	return scope, nil
+}
+
+func (r *KeyRing) Keys(ctx context.Context, scope string) ([]*domain.DataKey, error) {
+	stored, err := r.store.ListKeys(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*domain.DataKey, len(stored))
+	for i, sk := range stored {
+		keys[i] = toDataKey(sk)
+	}
+	return keys, nil
+}
+
+func (r *KeyRing) Rotate(ctx context.Context, scope string) (*domain.DataKey, error) {
+	sk, err := r.createKey(ctx, scope, true)
+	if err != nil {
+		return nil, err
+	}
+	r.forget(scope)
+	return toDataKey(sk), nil
+}
+
+func (r *KeyRing) Shred(ctx context.Context, scope string) (int64, error) {
+	n, err := r.store.DestroyKeys(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+	r.forget(scope)
+	return n, nil
+}
+
+func (r *KeyRing) RewrapKeys(ctx context.Context) (int, error) {
+	active := r.master.KeyID()
+	total := 0
+	for {
+		stale, err := r.store.ListStaleWrapped(ctx, active, rewrapBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(stale) == 0 {
+			return total, nil
+		}
+		for _, sk := range stale {
+			raw, err := r.master.Unwrap(ctx, sk.MasterKeyID, sk.Wrapped)
+			if err != nil {
+				return total, fmt.Errorf("unwrap %s v%d: %w", sk.Scope, sk.Version, err)
+			}
+			wrapped, err := r.master.Wrap(ctx, raw)
+			if err != nil {
+				return total, fmt.Errorf("wrap %s v%d: %w", sk.Scope, sk.Version, err)
+			}
+			if err := r.store.Rewrap(ctx, sk, active, wrapped); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+// currentKey 返回范围的当前版本，create 为 false 且范围没有当前版本时返回 nil
+func (r *KeyRing) currentKey(ctx context.Context, scope string, create bool) (*dataKey, error) {
+	r.mu.Lock()
+	key, ok := r.current[scope]
+	r.mu.Unlock()
+	if ok && time.Since(key.loaded) < keyCacheTTL {
+		return key, nil
+	}
+
+	sk, err := r.store.CurrentKey(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	if sk == nil {
+		if !create {
+			return nil, nil
+		}
+		if sk, err = r.createKey(ctx, scope, false); err != nil {
+			return nil, err
+		}
+	}
+	if key, err = r.unwrap(ctx, sk); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.current[scope] = key
+	r.keys[keyRef{scope, key.version}] = key
+	r.mu.Unlock()
+	return key, nil
+}
+
+// key 返回范围的指定版本，已销毁或不存在时返回 domain.ErrKeyDestroyed
+func (r *KeyRing) key(ctx context.Context, scope string, version int) (*dataKey, error) {
+	ref := keyRef{scope, version}
+	r.mu.Lock()
+	key, ok := r.keys[ref]
+	r.mu.Unlock()
+	if !ok || time.Since(key.loaded) >= keyCacheTTL {
+		sk, err := r.store.GetKey(ctx, scope, version)
+		if err != nil {
+			return nil, err
+		}
+		if sk == nil || !sk.DestroyedAt.IsZero() {
+			key = &dataKey{version: version, destroyed: true, loaded: time.Now()}
+		} else if key, err = r.unwrap(ctx, sk); err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		r.keys[ref] = key
+		r.mu.Unlock()
+	}
+	if key.destroyed {
+		return nil, domain.ErrKeyDestroyed
+	}
+	return key, nil
+}
+
+func (r *KeyRing) createKey(ctx context.Context, scope string, rotate bool) (*StoredKey, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	wrapped, err := r.master.Wrap(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	return r.store.CreateKey(ctx, &StoredKey{
+		Scope:       scope,
+		MasterKeyID: r.master.KeyID(),
+		Wrapped:     wrapped,
+	}, rotate)
+}
+
+func (r *KeyRing) unwrap(ctx context.Context, sk *StoredKey) (*dataKey, error) {
+	raw, err := r.master.Unwrap(ctx, sk.MasterKeyID, sk.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap %s v%d: %w", sk.Scope, sk.Version, err)
+	}
+	aead, err := newAEAD(derive(raw, "content"))
+	if err != nil {
+		return nil, err
+	}
+	return &dataKey{
+		version: sk.Version,
+		aead:    aead,
+		index:   derive(raw, "search"),
+		loaded:  time.Now(),
+	}, nil
+}
+
+// forget 丢弃范围在本实例中缓存的密钥
+func (r *KeyRing) forget(scope string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.current, scope)
+	for ref := range r.keys {
+		if ref.scope == scope {
+			delete(r.keys, ref)
+		}
+	}
+}
+
+func toDataKey(sk *StoredKey) *domain.DataKey {
+	return &domain.DataKey{
+		Scope:     sk.Scope,
+		Version:   sk.Version,
+		RetiredAt: sk.RetiredAt,
+		Destroyed: !sk.DestroyedAt.IsZero(),
+		CreatedAt: sk.CreatedAt,
+	}
+}
+
+func parseSealed(value string) (scope string, version int, sealed []byte, ok bool) {
+	rest, found := strings.CutPrefix(value, sealedPrefix+"v")
+	if !found {
+		return "", 0, nil, false
+	}
+	v, rest, found := strings.Cut(rest, ":")
+	if !found {
+		return "", 0, nil, false
+	}
+	i := strings.LastIndexByte(rest, ':')
+	if i <= 0 {
+		return "", 0, nil, false
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return "", 0, nil, false
+	}
+	if sealed, err = base64.StdEncoding.DecodeString(rest[i+1:]); err != nil {
+		return "", 0, nil, false
+	}
+	return rest[:i], version, sealed, true
+}
+
+func additionalData(scope string, version int) []byte {
+	return []byte("v" + strconv.Itoa(version) + ":" + scope)
+}
+
+func derive(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// tokens 按与 PostgreSQL simple 分词相近的规则切分为小写的字母数字词，dedupe 时去掉重复的词
+func tokens(text string, dedupe bool) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if !dedupe {
+		return words
+	}
+	seen := make(map[string]bool, len(words))
+	unique := words[:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
+
+// blind 把每个词替换为 "h" 加 HMAC 的前 16 位十六进制，保证 simple 分词后仍是一个词
+func blind(key []byte, words []string) string {
+	hashed := make([]string, len(words))
+	for i, w := range words {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(w))
+		hashed[i] = "h" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	return strings.Join(hashed, " ")
+}
+
+var _ domain.KeyManager = (*KeyRing)(nil)
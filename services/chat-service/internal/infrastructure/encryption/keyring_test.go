@@ -0,0 +1,305 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/export"
+)
+
+// memoryStore 是内存中的 KeyStore
+type memoryStore struct {
+	keys     []*StoredKey
+	sessions map[string][2]string
+}
+
+func (s *memoryStore) CurrentKey(ctx context.Context, scope string) (*StoredKey, error) {
+	for _, k := range s.keys {
+		if k.Scope == scope && k.RetiredAt.IsZero() && k.DestroyedAt.IsZero() {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) GetKey(ctx context.Context, scope string, version int) (*StoredKey, error) {
+	for _, k := range s.keys {
+		if k.Scope == scope && k.Version == version {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) ListKeys(ctx context.Context, scope string) ([]*StoredKey, error) {
+	var keys []*StoredKey
+	for _, k := range s.keys {
+		if k.Scope == scope {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) CreateKey(ctx context.Context, key *StoredKey, rotate bool) (*StoredKey, error) {
+	current, _ := s.CurrentKey(ctx, key.Scope)
+	if current != nil && !rotate {
+		return current, nil
+	}
+	keys, _ := s.ListKeys(ctx, key.Scope)
+	for _, k := range keys {
+		if k.RetiredAt.IsZero() {
+			k.RetiredAt = time.Now()
+		}
+	}
+	key.Version = len(keys) + 1
+	key.CreatedAt = time.Now()
+	s.keys = append(s.keys, key)
+	return key, nil
+}
+
+func (s *memoryStore) DestroyKeys(ctx context.Context, scope string) (int64, error) {
+	var n int64
+	for _, k := range s.keys {
+		if k.Scope == scope && k.DestroyedAt.IsZero() {
+			k.DestroyedAt, k.Wrapped = time.Now(), nil
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryStore) ListStaleWrapped(ctx context.Context, masterKeyID string, limit int) ([]*StoredKey, error) {
+	var keys []*StoredKey
+	for _, k := range s.keys {
+		if k.MasterKeyID != masterKeyID && k.DestroyedAt.IsZero() && len(keys) < limit {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) Rewrap(ctx context.Context, key *StoredKey, masterKeyID string, wrapped []byte) error {
+	key.MasterKeyID, key.Wrapped = masterKeyID, wrapped
+	return nil
+}
+
+func (s *memoryStore) SessionOwner(ctx context.Context, sessionID string) (string, string, bool, error) {
+	owner, ok := s.sessions[sessionID]
+	return owner[0], owner[1], ok, nil
+}
+
+// NewTestKeyRing 供 encryption_test 包中需要导入 model 的测试使用，sessions 是会话 ID 到 {空间, 所有者} 的映射
+func NewTestKeyRing(t *testing.T, sessions map[string][2]string) *KeyRing {
+	return NewKeyRing(&memoryStore{sessions: sessions}, writeKeyfile(t, "m1"), false)
+}
+
+func writeKeyfile(t *testing.T, ids ...string) *Keyfile {
+	t.Helper()
+	var sb strings.Builder
+	sb.WriteString("# test keys\n")
+	for _, id := range ids {
+		// 同一 ID 在不同的 keyfile 中是同一个密钥
+		key := make([]byte, 32)
+		copy(key, id)
+		sb.WriteString(id + " " + base64.StdEncoding.EncodeToString(key) + "\n")
+	}
+	path := filepath.Join(t.TempDir(), "keyfile")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kf, err := LoadKeyfile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyfile: %v", err)
+	}
+	return kf
+}
+
+func TestKeyRingSealOpen(t *testing.T) {
+	ctx := context.Background()
+	ring := NewKeyRing(&memoryStore{}, writeKeyfile(t, "m1"), true)
+	scope := domain.KeyScope("", "u1")
+
+	sealed, err := ring.Seal(ctx, scope, "hello 世界")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:user:u1:") || strings.Contains(sealed, "hello") {
+		t.Fatalf("sealed = %q", sealed)
+	}
+	if got, err := ring.Open(ctx, sealed); err != nil || got != "hello 世界" {
+		t.Fatalf("Open = %q, %v", got, err)
+	}
+
+	// 加密前写入的明文原样返回，并需要重新加密
+	if got, err := ring.Open(ctx, "plain text"); err != nil || got != "plain text" {
+		t.Fatalf("Open plaintext = %q, %v", got, err)
+	}
+	if stale, _ := ring.Stale(ctx, scope, "plain text"); !stale {
+		t.Fatal("plaintext should be stale")
+	}
+	if stale, _ := ring.Stale(ctx, scope, sealed); stale {
+		t.Fatal("current ciphertext should not be stale")
+	}
+	// 密文属于其他范围时移到会话的范围
+	if stale, _ := ring.Stale(ctx, domain.KeyScope("w1", "u1"), sealed); !stale {
+		t.Fatal("ciphertext of another scope should be stale")
+	}
+
+	// 密文被改动后认证失败
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if _, err := ring.Open(ctx, tampered); err == nil {
+		t.Fatal("tampered ciphertext opened")
+	}
+	if empty, _ := ring.Seal(ctx, scope, ""); empty != "" {
+		t.Fatalf("empty string sealed to %q", empty)
+	}
+}
+
+func TestKeyRingRotateAndShred(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	ring := NewKeyRing(store, writeKeyfile(t, "m1"), true)
+	scope := domain.KeyScope("w1", "")
+
+	old, _ := ring.Seal(ctx, scope, "quarterly report")
+	index, _ := ring.BlindIndex(ctx, scope, "Quarterly report, quarterly")
+	if strings.Contains(index, "report") || len(strings.Fields(index)) != 2 {
+		t.Fatalf("blind index = %q", index)
+	}
+
+	key, err := ring.Rotate(ctx, scope)
+	if err != nil || key.Version != 2 {
+		t.Fatalf("Rotate = %+v, %v", key, err)
+	}
+	// 旧版本仍可解密，但需要重新加密
+	if got, err := ring.Open(ctx, old); err != nil || got != "quarterly report" {
+		t.Fatalf("Open old = %q, %v", got, err)
+	}
+	if stale, _ := ring.Stale(ctx, scope, old); !stale {
+		t.Fatal("ciphertext of retired key should be stale")
+	}
+	// 每个版本一个查询，旧盲索引在重新加密前仍能命中
+	queries, err := ring.BlindQueries(ctx, scope, "REPORT")
+	if err != nil || len(queries) != 2 {
+		t.Fatalf("BlindQueries = %v, %v", queries, err)
+	}
+	if !strings.Contains(index, queries[0]) {
+		t.Fatalf("query %q does not match index %q", queries[0], index)
+	}
+
+	keys, _ := ring.Keys(ctx, scope)
+	if len(keys) != 2 || keys[0].Current() || !keys[1].Current() {
+		t.Fatalf("keys = %+v", keys)
+	}
+
+	if n, err := ring.Shred(ctx, scope); err != nil || n != 2 {
+		t.Fatalf("Shred = %d, %v", n, err)
+	}
+	if _, err := ring.Open(ctx, old); !errors.Is(err, domain.ErrKeyDestroyed) {
+		t.Fatalf("Open after shred: %v", err)
+	}
+	if queries, _ := ring.BlindQueries(ctx, scope, "report"); len(queries) != 0 {
+		t.Fatalf("destroyed keys still queried: %v", queries)
+	}
+}
+
+func TestKeyRingRewrap(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	ring := NewKeyRing(store, writeKeyfile(t, "m1"), false)
+	sealed, _ := ring.Seal(ctx, "user:u1", "secret")
+
+	// 新主密钥放在第一行，旧主密钥保留用于解包
+	rotated := NewKeyRing(store, writeKeyfile(t, "m2", "m1"), false)
+	if n, err := rotated.RewrapKeys(ctx); err != nil || n != 1 {
+		t.Fatalf("RewrapKeys = %d, %v", n, err)
+	}
+	if store.keys[0].MasterKeyID != "m2" {
+		t.Fatalf("key wrapped by %s", store.keys[0].MasterKeyID)
+	}
+	if got, err := rotated.Open(ctx, sealed); err != nil || got != "secret" {
+		t.Fatalf("Open after rewrap = %q, %v", got, err)
+	}
+}
+
+func TestKeyRingSessionScope(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{sessions: map[string][2]string{"s1": {"w1", "u1"}}}
+	ring := NewKeyRing(store, writeKeyfile(t, "m1"), false)
+
+	if scope, _ := ring.SessionScope(ctx, "s1", "u2"); scope != "workspace:w1" {
+		t.Fatalf("scope = %s", scope)
+	}
+	// 会话尚未落库时使用消息作者的个人范围
+	if scope, _ := ring.SessionScope(ctx, "s2", "u2"); scope != "user:u2" {
+		t.Fatalf("scope = %s", scope)
+	}
+}
+
+func TestSealedExportStore(t *testing.T) {
+	ctx := context.Background()
+	ring := NewKeyRing(&memoryStore{}, writeKeyfile(t, "m1"), false)
+	files, err := export.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewSealedExportStore(files, ring)
+
+	// 跨越多个分块，且最后一块不满
+	archive := bytes.Repeat([]byte("zip archive bytes "), archiveChunkSize/6)
+	w, err := store.Create(ctx, "u1", "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(archive); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := files.Open(ctx, "u1", "e1")
+	stored, _ := io.ReadAll(raw)
+	raw.Close()
+	if !bytes.HasPrefix(stored, []byte("enc:v1:user:u1\n")) || bytes.Contains(stored, []byte("zip archive")) {
+		t.Fatalf("archive stored in plaintext: %q", stored[:64])
+	}
+
+	r, err := store.Open(ctx, "u1", "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, archive) {
+		t.Fatalf("read %d bytes, %v; want %d", len(got), err, len(archive))
+	}
+
+	// 截断到最后一块之前时读取失败，而不是返回不完整的压缩包
+	truncated, _ := files.Create(ctx, "u1", "e2")
+	truncated.Write(stored[:len(stored)-archiveChunkSize/2])
+	truncated.Close()
+	r, _ = store.Open(ctx, "u1", "e2")
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("truncated archive read without error")
+	}
+	r.Close()
+
+	// 粉碎用户的密钥后，已写出的压缩包无法再解密
+	if _, err := ring.Shred(ctx, domain.KeyScope("", "u1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, "u1", "e1"); !errors.Is(err, domain.ErrKeyDestroyed) {
+		t.Fatalf("Open after shred = %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package encryption
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MasterKey wraps and unwraps data keys. A keyfile is the local
+// implementation; a KMS client can implement the same interface.
+type MasterKey interface {
+	// KeyID 返回包装新数据密钥使用的主密钥 ID
+	KeyID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	// Unwrap 用 keyID 对应的主密钥解开数据密钥，旧主密钥只用于解包
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Keyfile 是本地文件中的主密钥。每行为 "<id> <base64 编码的 32 字节密钥>"，# 开头为注释，
+// 第一行为当前主密钥，其余行是轮换前的旧主密钥
+type Keyfile struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+func LoadKeyfile(path string) (*Keyfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open keyfile: %w", err)
+	}
+	defer f.Close()
+
+	kf := &Keyfile{keys: make(map[string]cipher.AEAD)}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("keyfile line %d: want \"<id> <base64 key>\"", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("keyfile line %d: key must be 32 bytes in base64", line)
+		}
+		if _, ok := kf.keys[fields[0]]; ok {
+			return nil, fmt.Errorf("keyfile line %d: duplicate key id %q", line, fields[0])
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		kf.keys[fields[0]] = aead
+		if kf.active == "" {
+			kf.active = fields[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read keyfile: %w", err)
+	}
+	if kf.active == "" {
+		return nil, errors.New("keyfile has no keys")
+	}
+	return kf, nil
+}
+
+func (k *Keyfile) KeyID() string {
+	return k.active
+}
+
+func (k *Keyfile) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return seal(k.keys[k.active], dataKey, []byte(k.active))
+}
+
+func (k *Keyfile) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not in the keyfile", keyID)
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 返回 nonce||密文
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, sealed[:n], sealed[n:], aad)
+}
+
+var _ MasterKey = (*Keyfile)(nil)
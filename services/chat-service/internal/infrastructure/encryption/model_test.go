@@ -0,0 +1,58 @@
+package encryption_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/encryption"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm/schema"
+)
+
+// 会话内容的副本（分享快照、记忆、文档分块）与会话本身一样加密
+func TestKeyRingEncryptsContentCopies(t *testing.T) {
+	ctx := context.Background()
+	ring := encryption.NewTestKeyRing(t, map[string][2]string{"s1": {"w1", "u1"}})
+	model.UseCipher(ring)
+	t.Cleanup(func() { model.UseCipher(nil) })
+
+	share := &domain.Share{ID: "sh1", UserID: "u1", SessionID: "s1"}
+	doc := &domain.Document{ID: "d1", UserID: "u2"}
+	cases := []struct {
+		row    interface{}
+		column string
+		scope  string
+	}{
+		{&model.ShareModel{UserID: "u1", SessionID: "s1"}, "title", "workspace:w1"},
+		{model.ToShareMessageModel(share, 0, &domain.Message{ID: "m1", Role: domain.RoleUser}), "content", "workspace:w1"},
+		{&model.MemoryModel{UserID: "u1", WorkspaceID: "w2"}, "content", "workspace:w2"},
+		{&model.MemoryModel{UserID: "u1"}, "content", "user:u1"},
+		{model.ToDocumentChunkModel(doc, &domain.DocumentChunk{ID: "c1"}), "content", "user:u2"},
+	}
+	for _, c := range cases {
+		sch, err := schema.Parse(c.row, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		field := sch.LookUpField(c.column)
+		if field == nil || field.Serializer == nil {
+			t.Fatalf("%s.%s is not encrypted", sch.Name, c.column)
+		}
+		value, err := field.Serializer.Value(ctx, field, reflect.ValueOf(c.row), "private text")
+		if err != nil {
+			t.Fatalf("%s.%s: %v", sch.Name, c.column, err)
+		}
+		sealed, _ := value.(string)
+		if !strings.HasPrefix(sealed, "enc:v1:"+c.scope+":") {
+			t.Fatalf("%s.%s = %q, want scope %s", sch.Name, c.column, sealed, c.scope)
+		}
+		if plaintext, err := ring.Open(ctx, sealed); err != nil || plaintext != "private text" {
+			t.Fatalf("Open = %q, %v", plaintext, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// timeLayout 是 Markdown 与 HTML 中展示的时间格式，统一使用 UTC
+const timeLayout = "2006-01-02 15:04:05 UTC"
+
+// Exporter 实现 domain.SessionExporter，按格式创建对应的写入器
+type Exporter struct{}
+
+func NewExporter() *Exporter {
+	return &Exporter{}
+}
+
+// NewWriter 写出会话头部并返回写入器，格式不支持时返回 domain.ErrInvalidExportFormat
+func (e *Exporter) NewWriter(w io.Writer, format domain.ExportFormat, session *domain.Session) (domain.ExportWriter, error) {
+	switch format {
+	case domain.ExportMarkdown:
+		return newMarkdownWriter(w, session)
+	case domain.ExportJSON:
+		return newJSONWriter(w, session)
+	case domain.ExportHTML:
+		return newHTMLWriter(w, session)
+	}
+	return nil, domain.ErrInvalidExportFormat
+}
+
+// segment 是消息正文中的一段：普通文本或围栏代码块
+type segment struct {
+	code bool
+	// marker 为代码块的开头围栏标记（如 ```），lang 为其后的语言标记
+	marker string
+	lang   string
+	text   string
+	// closed 为 false 表示代码块直到正文末尾都没有闭合
+	closed bool
+}
+
+// splitFences 按 ``` 或 ~~~ 围栏把正文切分为文本段与代码段。闭合围栏须与开头同字符且不短于开头；
+// 未闭合的代码块延续到正文末尾，由调用方补齐闭合围栏，避免吞掉后续消息。
+func splitFences(content string) []segment {
+	var segments []segment
+	var cur strings.Builder
+	var open *segment
+	flush := func(s segment) {
+		s.text = strings.TrimSuffix(cur.String(), "\n")
+		cur.Reset()
+		if s.code || strings.TrimSpace(s.text) != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		marker := fenceMarker(trimmed)
+		switch {
+		case open == nil && marker != "":
+			flush(segment{})
+			open = &segment{code: true, marker: marker, lang: strings.TrimSpace(trimmed[len(marker):])}
+			continue
+		case open != nil && trimmed == marker && marker[0] == open.marker[0] && len(marker) >= len(open.marker):
+			open.closed = true
+			flush(*open)
+			open = nil
+			continue
+		}
+		cur.WriteString(line)
+	}
+	if open != nil {
+		flush(*open)
+	} else {
+		flush(segment{})
+	}
+	return segments
+}
+
+// fenceMarker 返回行首的围栏标记（至少三个 ` 或 ~），不是围栏时返回空
+func fenceMarker(line string) string {
+	if len(line) < 3 || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return line[:n]
+}
+
+func roleLabel(role domain.Role) string {
+	switch role {
+	case domain.RoleUser:
+		return "User"
+	case domain.RoleAssistant:
+		return "Assistant"
+	case domain.RoleSystem:
+		return "System"
+	}
+	return string(role)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func testSession() *domain.Session {
+	at := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+	return &domain.Session{
+		ID:            "session-1",
+		Title:         "Go <generics>",
+		CreatedAt:     at,
+		UpdatedAt:     at,
+		LastMessageAt: at.Add(time.Minute),
+		MessageCount:  2,
+		Tags:          []string{"go"},
+	}
+}
+
+func testMessages() []*domain.Message {
+	at := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+	return []*domain.Message{
+		{ID: "m1", Role: domain.RoleUser, Content: "How do I write a generic Map?", CreatedAt: at},
+		{ID: "m2", Role: domain.RoleAssistant, Model: "qwen2.5", Content: "Like this:\n```go\nfunc Map[T, U any](s []T, f func(T) U) []U\n```\nDone & dusted.", CreatedAt: at.Add(time.Minute)},
+	}
+}
+
+func export(t *testing.T, format domain.ExportFormat, batches ...[]*domain.Message) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewExporter().NewWriter(&buf, format, testSession())
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for _, batch := range batches {
+		if err := w.WriteMessages(batch); err != nil {
+			t.Fatalf("WriteMessages: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.String()
+}
+
+func TestSplitFences(t *testing.T) {
+	segments := splitFences("intro\n```python\nprint(1)\n````\noutro")
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %+v", segments)
+	}
+	if segments[0].code || segments[0].text != "intro" {
+		t.Errorf("unexpected first segment %+v", segments[0])
+	}
+	// 闭合围栏可以比开头更长
+	if !segments[1].code || segments[1].lang != "python" || segments[1].text != "print(1)" || !segments[1].closed {
+		t.Errorf("unexpected code segment %+v", segments[1])
+	}
+
+	open := splitFences("~~~~\ncode\n~~~\nmore")
+	if len(open) != 1 || !open[0].code || open[0].closed || open[0].text != "code\n~~~\nmore" {
+		t.Errorf("expected a single unclosed block, got %+v", open)
+	}
+}
+
+func TestMarkdownClosesUnterminatedFence(t *testing.T) {
+	msgs := []*domain.Message{
+		{ID: "m1", Role: domain.RoleAssistant, Content: "```sh\nls -la", CreatedAt: time.Now()},
+		{ID: "m2", Role: domain.RoleUser, Content: "thanks", CreatedAt: time.Now()},
+	}
+	out := export(t, domain.ExportMarkdown, msgs)
+	if !strings.HasPrefix(out, "# Go <generics>\n") {
+		t.Errorf("missing title:\n%s", out)
+	}
+	if !strings.Contains(out, "ls -la\n```\n\n### User") {
+		t.Errorf("unterminated fence was not closed:\n%s", out)
+	}
+}
+
+func TestMarkdownIncludesModelAndTimestamps(t *testing.T) {
+	out := export(t, domain.ExportMarkdown, testMessages())
+	for _, want := range []string{"### User · 2026-10-19 08:30:00 UTC", "### Assistant (qwen2.5) · 2026-10-19 08:31:00 UTC", "```go\nfunc Map"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestJSONAcrossBatches(t *testing.T) {
+	msgs := testMessages()
+	out := export(t, domain.ExportJSON, msgs[:1], nil, msgs[1:])
+	var doc struct {
+		Session  jsonSession   `json:"session"`
+		Messages []jsonMessage `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if doc.Session.Title != "Go <generics>" || len(doc.Messages) != 2 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	if doc.Messages[1].Model != "qwen2.5" || !strings.Contains(doc.Messages[1].Content, "```go") {
+		t.Errorf("unexpected message %+v", doc.Messages[1])
+	}
+	if strings.Contains(out, `\u003c`) {
+		t.Error("expected HTML characters to stay unescaped")
+	}
+}
+
+func TestJSONEmptySession(t *testing.T) {
+	out := export(t, domain.ExportJSON)
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+}
+
+func TestHTMLEscapesAndRendersCode(t *testing.T) {
+	out := export(t, domain.ExportHTML, testMessages())
+	if !strings.Contains(out, "<title>Go &lt;generics&gt;</title>") {
+		t.Errorf("title not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, `<pre><code class="language-go">func Map[T, U any](s []T, f func(T) U) []U</code></pre>`) {
+		t.Errorf("code block not rendered:\n%s", out)
+	}
+	if !strings.Contains(out, "Done &amp; dusted.") || !strings.HasSuffix(out, "</html>\n") {
+		t.Errorf("unexpected HTML:\n%s", out)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewExporter().NewWriter(&bytes.Buffer{}, "pdf", testSession()); err != domain.ErrInvalidExportFormat {
+		t.Errorf("expected ErrInvalidExportFormat, got %v", err)
+	}
+}
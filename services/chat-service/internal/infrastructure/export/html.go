@@ -0,0 +1,84 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// htmlStyle 让导出的页面不依赖外部资源即可阅读
+const htmlStyle = `body{font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;max-width:860px;margin:2rem auto;padding:0 1rem;color:#1f2328;line-height:1.5}
+header{border-bottom:1px solid #d0d7de;margin-bottom:1.5rem}
+.meta{color:#59636e;font-size:.875rem}
+article{margin:1.25rem 0;padding:.75rem 1rem;border-radius:8px;background:#f6f8fa}
+article.user{background:#ddf4ff}
+.text{white-space:pre-wrap}
+pre{background:#0d1117;color:#e6edf3;padding:.75rem;border-radius:6px;overflow-x:auto}
+`
+
+// htmlWriter 输出单文件 HTML 页面，正文转义后保留换行，围栏代码块渲染为 <pre><code>
+type htmlWriter struct {
+	w io.Writer
+}
+
+func newHTMLWriter(w io.Writer, s *domain.Session) (*htmlWriter, error) {
+	var sb strings.Builder
+	title := html.EscapeString(s.Title)
+	fmt.Fprintf(&sb, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n", title, htmlStyle)
+	fmt.Fprintf(&sb, "<header>\n<h1>%s</h1>\n<p class=\"meta\">Created %s · Last activity %s · %d messages",
+		title, timeTag(s.CreatedAt), timeTag(s.LastMessageAt), s.MessageCount)
+	if len(s.Tags) > 0 {
+		fmt.Fprintf(&sb, " · Tags: %s", html.EscapeString(strings.Join(s.Tags, ", ")))
+	}
+	if s.ForkedFrom != "" {
+		fmt.Fprintf(&sb, " · Forked from <code>%s</code>", html.EscapeString(s.ForkedFrom))
+	}
+	sb.WriteString("</p>\n</header>\n")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return nil, err
+	}
+	return &htmlWriter{w: w}, nil
+}
+
+func (h *htmlWriter) WriteMessages(messages []*domain.Message) error {
+	var sb strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&sb, "<article class=\"%s\" id=\"%s\">\n<p class=\"meta\"><strong>%s</strong>",
+			html.EscapeString(msg.Role.String()), html.EscapeString(msg.ID), roleLabel(msg.Role))
+		if msg.Model != "" {
+			fmt.Fprintf(&sb, " · %s", html.EscapeString(msg.Model))
+		}
+		fmt.Fprintf(&sb, " · %s", timeTag(msg.CreatedAt))
+		if msg.Pinned {
+			sb.WriteString(" · pinned")
+		}
+		sb.WriteString("</p>\n")
+		for _, seg := range splitFences(msg.Content) {
+			if seg.code {
+				class := ""
+				if lang, _, _ := strings.Cut(seg.lang, " "); lang != "" {
+					class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(lang))
+				}
+				fmt.Fprintf(&sb, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(seg.text))
+			} else {
+				fmt.Fprintf(&sb, "<div class=\"text\">%s</div>\n", html.EscapeString(strings.Trim(seg.text, "\n")))
+			}
+		}
+		sb.WriteString("</article>\n")
+	}
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *htmlWriter) Close() error {
+	_, err := io.WriteString(h.w, "</body>\n</html>\n")
+	return err
+}
+
+func timeTag(t time.Time) string {
+	return fmt.Sprintf("<time datetime=\"%s\">%s</time>", t.UTC().Format(time.RFC3339), formatTime(t))
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// jsonSession 与 jsonMessage 是 JSON 导出的结构，时间为 RFC 3339（UTC）
+type jsonSession struct {
+	SessionID     string    `json:"session_id"`
+	Title         string    `json:"title"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	MessageCount  int       `json:"message_count"`
+	Tags          []string  `json:"tags,omitempty"`
+	ForkedFrom    string    `json:"forked_from,omitempty"`
+	ForkMessageID string    `json:"fork_message_id,omitempty"`
+}
+
+type jsonMessage struct {
+	MessageID  string    `json:"message_id"`
+	Role       string    `json:"role"`
+	Content    string    `json:"content"`
+	Model      string    `json:"model,omitempty"`
+	TokenCount int       `json:"token_count,omitempty"`
+	Pinned     bool      `json:"pinned,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// jsonWriter 输出 {"session": {...}, "messages": [...]}，消息逐条追加到数组中
+type jsonWriter struct {
+	w     io.Writer
+	first bool
+}
+
+func newJSONWriter(w io.Writer, s *domain.Session) (*jsonWriter, error) {
+	head, err := marshal(jsonSession{
+		SessionID:     s.ID,
+		Title:         s.Title,
+		CreatedAt:     s.CreatedAt.UTC(),
+		UpdatedAt:     s.UpdatedAt.UTC(),
+		LastMessageAt: s.LastMessageAt.UTC(),
+		MessageCount:  s.MessageCount,
+		Tags:          s.Tags,
+		ForkedFrom:    s.ForkedFrom,
+		ForkMessageID: s.ForkMessageID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, `{"session":`+string(head)+`,"messages":[`); err != nil {
+		return nil, err
+	}
+	return &jsonWriter{w: w, first: true}, nil
+}
+
+func (j *jsonWriter) WriteMessages(messages []*domain.Message) error {
+	var buf bytes.Buffer
+	for _, msg := range messages {
+		data, err := marshal(jsonMessage{
+			MessageID:  msg.ID,
+			Role:       msg.Role.String(),
+			Content:    msg.Content,
+			Model:      msg.Model,
+			TokenCount: msg.TokenCount,
+			Pinned:     msg.Pinned,
+			CreatedAt:  msg.CreatedAt.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		if !j.first {
+			buf.WriteByte(',')
+		}
+		j.first = false
+		buf.WriteString("\n")
+		buf.Write(data)
+	}
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	_, err := io.WriteString(j.w, "\n]}\n")
+	return err
+}
+
+// marshal 编码 JSON 但不转义 <、>、&，代码片段保持可读
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,64 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// markdownWriter 输出 Markdown：标题与元信息列表，随后每条消息一个小节，正文原样保留
+type markdownWriter struct {
+	w io.Writer
+}
+
+func newMarkdownWriter(w io.Writer, s *domain.Session) (*markdownWriter, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", s.Title)
+	fmt.Fprintf(&sb, "- Session: `%s`\n", s.ID)
+	fmt.Fprintf(&sb, "- Created: %s\n", formatTime(s.CreatedAt))
+	fmt.Fprintf(&sb, "- Last activity: %s\n", formatTime(s.LastMessageAt))
+	fmt.Fprintf(&sb, "- Messages: %d\n", s.MessageCount)
+	if len(s.Tags) > 0 {
+		fmt.Fprintf(&sb, "- Tags: %s\n", strings.Join(s.Tags, ", "))
+	}
+	if s.ForkedFrom != "" {
+		fmt.Fprintf(&sb, "- Forked from: `%s` at message `%s`\n", s.ForkedFrom, s.ForkMessageID)
+	}
+	sb.WriteString("\n---\n")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return nil, err
+	}
+	return &markdownWriter{w: w}, nil
+}
+
+func (m *markdownWriter) WriteMessages(messages []*domain.Message) error {
+	var sb strings.Builder
+	for _, msg := range messages {
+		sb.WriteString("\n### ")
+		sb.WriteString(roleLabel(msg.Role))
+		if msg.Model != "" {
+			fmt.Fprintf(&sb, " (%s)", msg.Model)
+		}
+		fmt.Fprintf(&sb, " · %s", formatTime(msg.CreatedAt))
+		if msg.Pinned {
+			sb.WriteString(" · pinned")
+		}
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.TrimRight(msg.Content, "\n"))
+		// 补齐未闭合的代码块，否则后续消息都会被当成代码
+		if segments := splitFences(msg.Content); len(segments) > 0 {
+			if last := segments[len(segments)-1]; last.code && !last.closed {
+				sb.WriteString("\n" + last.marker)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(m.w, sb.String())
+	return err
+}
+
+func (m *markdownWriter) Close() error {
+	return nil
+}
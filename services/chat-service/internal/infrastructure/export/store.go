@@ -0,0 +1,113 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// FileStore 把账户导出的压缩包保存在本地目录 <dir>/<userID>/<exportID>.zip。
+// 多个实例需要共享同一目录（例如挂载的卷），下载才能由任一实例处理
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore 创建导出目录，dir 为空时使用系统临时目录下的 free-chat-exports
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "free-chat-exports")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create export dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Create 先写入同目录下的临时文件，Close 时改名，读取方不会看到写了一半的压缩包
+func (s *FileStore) Create(ctx context.Context, userID, exportID string) (io.WriteCloser, error) {
+	name, err := s.path(userID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return nil, fmt.Errorf("create export dir: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), exportID+".*.part")
+	if err != nil {
+		return nil, fmt.Errorf("create export file: %w", err)
+	}
+	return &pendingFile{File: f, name: name}, nil
+}
+
+func (s *FileStore) Open(ctx context.Context, userID, exportID string) (io.ReadCloser, error) {
+	name, err := s.path(userID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open export file: %w", err)
+	}
+	return f, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, userID, exportID string) error {
+	name, err := s.path(userID, exportID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete export file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) DeleteUser(ctx context.Context, userID string) error {
+	if !validName(userID) {
+		return fmt.Errorf("invalid export owner %q", userID)
+	}
+	if err := os.RemoveAll(filepath.Join(s.dir, userID)); err != nil {
+		return fmt.Errorf("delete export files: %w", err)
+	}
+	return nil
+}
+
+// path 用户 ID 与导出 ID 都来自服务端，这里仍拒绝任何能跳出导出目录的名字
+func (s *FileStore) path(userID, exportID string) (string, error) {
+	if !validName(userID) || !validName(exportID) {
+		return "", fmt.Errorf("invalid export %q of %q", exportID, userID)
+	}
+	return filepath.Join(s.dir, userID, exportID+".zip"), nil
+}
+
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
+
+// pendingFile Close 时把临时文件改名为压缩包，失败时删除临时文件
+type pendingFile struct {
+	*os.File
+	name string
+}
+
+func (f *pendingFile) Close() error {
+	err := f.File.Close()
+	if err == nil {
+		err = os.Rename(f.File.Name(), f.name)
+	}
+	if err != nil {
+		os.Remove(f.File.Name())
+		return fmt.Errorf("save export file: %w", err)
+	}
+	return nil
+}
+
+var _ domain.ExportStore = (*FileStore)(nil)
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := s.Create(ctx, "u1", "x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "zip data"); err != nil {
+		t.Fatal(err)
+	}
+	// 写完之前读不到
+	if _, err := s.Open(ctx, "u1", "x1"); !errors.Is(err, domain.ErrExportNotFound) {
+		t.Fatalf("Open before Close err = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Open(ctx, "u1", "x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "zip data" {
+		t.Fatalf("read %q", data)
+	}
+
+	if _, err := s.Create(ctx, "../u2", "x1"); err == nil {
+		t.Fatal("Create accepted a path outside the export dir")
+	}
+	if err := s.DeleteUser(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.dir + "/u1"); !os.IsNotExist(err) {
+		t.Fatalf("user dir left: %v", err)
+	}
+	if err := s.Delete(ctx, "u1", "x1"); err != nil {
+		t.Fatalf("Delete of a missing archive: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// chatgptConversation 是 ChatGPT 导出 conversations.json 中的一个会话。
+// 消息组成一棵树（重新生成或编辑会产生分支），current_node 指向界面上当前显示的分支末端。
+type chatgptConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	UpdateTime     float64                `json:"update_time"`
+	CurrentNode    string                 `json:"current_node"`
+	Mapping        map[string]chatgptNode `json:"mapping"`
+}
+
+type chatgptNode struct {
+	ID       string          `json:"id"`
+	Message  *chatgptMessage `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatgptMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+		Language    string            `json:"language"`
+	} `json:"content"`
+	// Recipient 不是 all 的消息是发给工具（代码解释器、浏览等）的调用
+	Recipient string `json:"recipient"`
+	Metadata  struct {
+		ModelSlug      string `json:"model_slug"`
+		VisuallyHidden bool   `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+// decodeChatGPT 流式读取会话数组，每解析出一个会话就交给 fn
+func decodeChatGPT(r io.Reader, fn func(*domain.ImportedConversation) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return invalid("expected a JSON array of conversations")
+	}
+	for i := 0; dec.More(); i++ {
+		var c chatgptConversation
+		if err := dec.Decode(&c); err != nil {
+			return invalid("conversation %d: %v", i, err)
+		}
+		if err := fn(c.toImported()); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return invalid("unterminated conversation array: %v", err)
+	}
+	return nil
+}
+
+func (c *chatgptConversation) toImported() *domain.ImportedConversation {
+	id := c.ConversationID
+	if id == "" {
+		id = c.ID
+	}
+	conv := &domain.ImportedConversation{
+		SourceID:  id,
+		Title:     strings.TrimSpace(c.Title),
+		CreatedAt: unixTime(c.CreateTime),
+		UpdatedAt: unixTime(c.UpdateTime),
+	}
+	for _, m := range c.activePath() {
+		content := m.text()
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		role := domain.Role(m.Author.Role)
+		if (role != domain.RoleUser && role != domain.RoleAssistant) || m.Metadata.VisuallyHidden ||
+			(m.Recipient != "" && m.Recipient != "all") {
+			conv.Skipped++
+			continue
+		}
+		msg := &domain.Message{
+			Role:      role,
+			Content:   content,
+			CreatedAt: unixTime(m.CreateTime),
+		}
+		if role == domain.RoleAssistant {
+			msg.Model = m.Metadata.ModelSlug
+		}
+		conv.Messages = append(conv.Messages, msg)
+	}
+	return conv
+}
+
+// activePath 从 current_node 沿 parent 回溯到根，返回按时间顺序排列的消息，其余分支被丢弃。
+// 缺少 current_node 时沿每个节点的最后一个子节点走到叶子。
+func (c *chatgptConversation) activePath() []*chatgptMessage {
+	node := c.CurrentNode
+	if _, ok := c.Mapping[node]; !ok {
+		node = c.lastLeaf()
+	}
+	var path []*chatgptMessage
+	seen := make(map[string]bool)
+	for node != "" && !seen[node] {
+		n, ok := c.Mapping[node]
+		if !ok {
+			break
+		}
+		seen[node] = true
+		if n.Message != nil {
+			path = append(path, n.Message)
+		}
+		node = n.Parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func (c *chatgptConversation) lastLeaf() string {
+	node := ""
+	for id, n := range c.Mapping {
+		if _, ok := c.Mapping[n.Parent]; !ok && (node == "" || id < node) {
+			node = id
+		}
+	}
+	seen := make(map[string]bool)
+	for !seen[node] {
+		seen[node] = true
+		n, ok := c.Mapping[node]
+		if !ok || len(n.Children) == 0 {
+			break
+		}
+		node = n.Children[len(n.Children)-1]
+	}
+	return node
+}
+
+// text 提取可展示的正文：文本与多模态消息取字符串部分，代码消息包成围栏代码块，其他类型忽略
+func (m *chatgptMessage) text() string {
+	switch m.Content.ContentType {
+	case "text", "multimodal_text":
+		var parts []string
+		for _, raw := range m.Content.Parts {
+			var s string
+			if json.Unmarshal(raw, &s) == nil && strings.TrimSpace(s) != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	case "code":
+		if strings.TrimSpace(m.Content.Text) == "" {
+			return ""
+		}
+		lang := m.Content.Language
+		if lang == "unknown" {
+			lang = ""
+		}
+		return "```" + lang + "\n" + m.Content.Text + "\n```"
+	}
+	return ""
+}
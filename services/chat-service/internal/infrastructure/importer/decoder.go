@@ -0,0 +1,39 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// Decoder 实现 domain.ConversationDecoder
+type Decoder struct{}
+
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (d *Decoder) Decode(r io.Reader, format domain.ImportFormat, fn func(*domain.ImportedConversation) error) error {
+	switch format {
+	case domain.ImportChatGPT:
+		return decodeChatGPT(r, fn)
+	case domain.ImportJSONL:
+		return decodeJSONL(r, fn)
+	}
+	return fmt.Errorf("%w: unsupported format %q", domain.ErrInvalidImport, format)
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", domain.ErrInvalidImport, fmt.Sprintf(format, args...))
+}
+
+// unixTime 把 Unix 秒（可带小数）转换为微秒精度的 UTC 时间，0 表示缺失
+func unixTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(int64(math.Round(seconds * 1e6))).UTC()
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// chatgptExport 中 n3 与 n3b 是对 n2 的两次回答，current_node 指向 n4 所在的分支
+const chatgptExport = `[{
+  "id": "conv-1",
+  "title": "Regex help",
+  "create_time": 1700000000.5,
+  "update_time": 1700000100,
+  "current_node": "n4",
+  "mapping": {
+    "root": {"id": "root", "message": null, "parent": null, "children": ["n1"]},
+    "n1": {"id": "n1", "parent": "root", "children": ["n2"], "message": {
+      "author": {"role": "system"}, "create_time": null,
+      "content": {"content_type": "text", "parts": ["You are ChatGPT"]},
+      "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "n2": {"id": "n2", "parent": "n1", "children": ["n3", "n3b"], "message": {
+      "author": {"role": "user"}, "create_time": 1700000001,
+      "content": {"content_type": "text", "parts": ["Match an email"]}, "metadata": {}}},
+    "n3": {"id": "n3", "parent": "n2", "children": [], "message": {
+      "author": {"role": "assistant"}, "create_time": 1700000002,
+      "content": {"content_type": "text", "parts": ["discarded branch"]}, "metadata": {"model_slug": "gpt-4"}}},
+    "n3b": {"id": "n3b", "parent": "n2", "children": ["n3c"], "message": {
+      "author": {"role": "assistant"}, "create_time": 1700000003, "recipient": "python",
+      "content": {"content_type": "code", "language": "python", "text": "import re"}, "metadata": {}}},
+    "n3c": {"id": "n3c", "parent": "n3b", "children": ["n4"], "message": {
+      "author": {"role": "tool"}, "create_time": 1700000004,
+      "content": {"content_type": "execution_output", "text": "ok"}, "metadata": {}}},
+    "n4": {"id": "n4", "parent": "n3c", "children": [], "message": {
+      "author": {"role": "assistant"}, "create_time": 1700000005, "recipient": "all",
+      "content": {"content_type": "text", "parts": ["Use ` + "`\\\\S+@\\\\S+`" + `", {"asset_pointer": "file-1"}]},
+      "metadata": {"model_slug": "gpt-4o"}}}
+  }
+}]`
+
+func decodeAll(t *testing.T, format domain.ImportFormat, input string) []*domain.ImportedConversation {
+	t.Helper()
+	var convs []*domain.ImportedConversation
+	err := NewDecoder().Decode(strings.NewReader(input), format, func(c *domain.ImportedConversation) error {
+		convs = append(convs, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return convs
+}
+
+func TestChatGPTActivePath(t *testing.T) {
+	convs := decodeAll(t, domain.ImportChatGPT, chatgptExport)
+	if len(convs) != 1 {
+		t.Fatalf("expected 1 conversation, got %d", len(convs))
+	}
+	c := convs[0]
+	if c.SourceID != "conv-1" || c.Title != "Regex help" || c.CreatedAt.UnixMicro() != 1700000000500000 {
+		t.Errorf("unexpected conversation %+v", c)
+	}
+	if len(c.Messages) != 2 {
+		t.Fatalf("expected user and final assistant message, got %d", len(c.Messages))
+	}
+	if c.Messages[0].Role != domain.RoleUser || c.Messages[0].Content != "Match an email" {
+		t.Errorf("unexpected first message %+v", c.Messages[0])
+	}
+	last := c.Messages[1]
+	if last.Content != `Use `+"`\\S+@\\S+`" || last.Model != "gpt-4o" || last.CreatedAt.Unix() != 1700000005 {
+		t.Errorf("unexpected last message %+v", last)
+	}
+	// 隐藏的系统消息、工具调用与工具输出被跳过；未选中的分支不计入
+	if c.Skipped != 2 {
+		t.Errorf("expected 2 skipped messages, got %d", c.Skipped)
+	}
+}
+
+func TestChatGPTWithoutCurrentNode(t *testing.T) {
+	input := strings.Replace(chatgptExport, `"current_node": "n4",`, "", 1)
+	c := decodeAll(t, domain.ImportChatGPT, input)[0]
+	// 沿最后一个子节点走到 n4
+	if len(c.Messages) != 2 || c.Messages[1].Model != "gpt-4o" {
+		t.Errorf("unexpected messages %+v", c.Messages)
+	}
+}
+
+func TestChatGPTRejectsNonArray(t *testing.T) {
+	err := NewDecoder().Decode(strings.NewReader(`{"title": "x"}`), domain.ImportChatGPT, func(*domain.ImportedConversation) error { return nil })
+	if !errors.Is(err, domain.ErrInvalidImport) {
+		t.Errorf("expected ErrInvalidImport, got %v", err)
+	}
+}
+
+func TestJSONLGroupsAndOrders(t *testing.T) {
+	input := `{"conversation_id": "a", "title": "First", "role": "user", "content": "hi", "created_at": "2024-05-01T10:00:00Z"}
+{"conversation_id": "b", "role": "user", "content": "other", "timestamp": 1714557600000}
+
+{"conversation_id": "a", "role": "assistant", "content": "hello", "model": "llama3", "created_at": 1714557599}
+{"conversation_id": "a", "role": "function", "content": "{}", "created_at": 1714557601}
+`
+	convs := decodeAll(t, domain.ImportJSONL, input)
+	if len(convs) != 2 || convs[0].SourceID != "a" || convs[1].SourceID != "b" {
+		t.Fatalf("unexpected conversations %+v", convs)
+	}
+	a := convs[0]
+	// 都带时间时按时间排序：assistant 消息早于 user 消息一秒
+	if a.Title != "First" || len(a.Messages) != 2 || a.Messages[0].Model != "llama3" || a.Skipped != 1 {
+		t.Errorf("unexpected conversation %+v", a)
+	}
+	if !a.CreatedAt.Equal(a.Messages[0].CreatedAt) || !a.UpdatedAt.Equal(a.Messages[1].CreatedAt) {
+		t.Errorf("conversation times not taken from messages: %+v", a)
+	}
+	if convs[1].Messages[0].CreatedAt.Unix() != 1714557600 {
+		t.Errorf("millisecond timestamp not parsed: %v", convs[1].Messages[0].CreatedAt)
+	}
+}
+
+func TestJSONLReportsLine(t *testing.T) {
+	input := "{\"role\": \"user\", \"content\": \"ok\"}\n{\"role\": \"user\", \"content\": \"x\", \"created_at\": \"yesterday\"}\n"
+	err := NewDecoder().Decode(strings.NewReader(input), domain.ImportJSONL, func(*domain.ImportedConversation) error { return nil })
+	if !errors.Is(err, domain.ErrInvalidImport) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected invalid import at line 2, got %v", err)
+	}
+}
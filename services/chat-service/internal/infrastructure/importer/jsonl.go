@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// jsonlLine 是通用 JSONL 导入的一行。conversation_id（或 session_id）相同的行属于同一会话，
+// 可以不连续；created_at（或 timestamp）为 RFC 3339 字符串或 Unix 秒/毫秒。
+type jsonlLine struct {
+	ConversationID string          `json:"conversation_id"`
+	SessionID      string          `json:"session_id"`
+	Title          string          `json:"title"`
+	Role           string          `json:"role"`
+	Content        string          `json:"content"`
+	Model          string          `json:"model"`
+	CreatedAt      json.RawMessage `json:"created_at"`
+	Timestamp      json.RawMessage `json:"timestamp"`
+}
+
+// decodeJSONL 读完全部行后按会话首次出现的顺序交给 fn。同一会话的消息在都带时间时按时间排序，否则保持文件顺序。
+func decodeJSONL(r io.Reader, fn func(*domain.ImportedConversation) error) error {
+	var order []string
+	convs := make(map[string]*domain.ImportedConversation)
+	reader := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var l jsonlLine
+			if err := json.Unmarshal(trimmed, &l); err != nil {
+				return invalid("line %d: %v", n, err)
+			}
+			at, err := parseTime(l.CreatedAt, l.Timestamp)
+			if err != nil {
+				return invalid("line %d: %v", n, err)
+			}
+			id := l.ConversationID
+			if id == "" {
+				id = l.SessionID
+			}
+			conv, ok := convs[id]
+			if !ok {
+				conv = &domain.ImportedConversation{SourceID: id}
+				convs[id] = conv
+				order = append(order, id)
+			}
+			if conv.Title == "" {
+				conv.Title = strings.TrimSpace(l.Title)
+			}
+			role := domain.Role(strings.ToLower(strings.TrimSpace(l.Role)))
+			switch {
+			case strings.TrimSpace(l.Content) == "":
+			case role != domain.RoleUser && role != domain.RoleAssistant && role != domain.RoleSystem:
+				conv.Skipped++
+			default:
+				conv.Messages = append(conv.Messages, &domain.Message{
+					Role:      role,
+					Content:   l.Content,
+					Model:     l.Model,
+					CreatedAt: at,
+				})
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	for _, id := range order {
+		conv := convs[id]
+		if timed(conv.Messages) {
+			sort.SliceStable(conv.Messages, func(i, j int) bool {
+				return conv.Messages[i].CreatedAt.Before(conv.Messages[j].CreatedAt)
+			})
+		}
+		if len(conv.Messages) > 0 {
+			conv.CreatedAt = conv.Messages[0].CreatedAt
+			conv.UpdatedAt = conv.Messages[len(conv.Messages)-1].CreatedAt
+		}
+		if err := fn(conv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func timed(messages []*domain.Message) bool {
+	for _, m := range messages {
+		if m.CreatedAt.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTime 解析第一个非空的时间字段；数值大于 1e12 视为毫秒
+func parseTime(fields ...json.RawMessage) (time.Time, error) {
+	for _, raw := range fields {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return t.UTC(), nil
+		}
+		var f float64
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return time.Time{}, err
+		}
+		if f > 1e12 {
+			f /= 1000
+		}
+		return unixTime(f), nil
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,93 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// classifierRule 是分类器命中在复核队列中的规则名
+const classifierRule = "classifier"
+
+// HTTPClassifier 调用外部审核模型。请求为 POST {"text": "...", "stage": "input"}，
+// 响应为 {"scores": {"<category>": 0.93, ...}}，分数不低于阈值的类别视为命中
+type HTTPClassifier struct {
+	url    string
+	client *http.Client
+	action domain.ModerationAction
+	// threshold 是未单独配置的类别的阈值，thresholds 按类别覆盖
+	threshold  float64
+	thresholds map[string]float64
+}
+
+func NewHTTPClassifier(url string, timeout time.Duration, action domain.ModerationAction, threshold float64, thresholds map[string]float64) *HTTPClassifier {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	if threshold <= 0 {
+		threshold = 0.5
+	}
+	return &HTTPClassifier{
+		url:        url,
+		client:     &http.Client{Timeout: timeout},
+		action:     action,
+		threshold:  threshold,
+		thresholds: thresholds,
+	}
+}
+
+type classifyRequest struct {
+	Text  string `json:"text"`
+	Stage string `json:"stage"`
+}
+
+type classifyResponse struct {
+	Scores map[string]float64 `json:"scores"`
+}
+
+// Classify 按分数从高到低返回命中的类别
+func (c *HTTPClassifier) Classify(ctx context.Context, stage domain.ModerationStage, text string) ([]domain.ModerationHit, error) {
+	body, err := json.Marshal(classifyRequest{Text: text, Stage: string(stage)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build classify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call moderation classifier: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("moderation classifier returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	var out classifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode classify response: %w", err)
+	}
+	var hits []domain.ModerationHit
+	for category, score := range out.Scores {
+		threshold, ok := c.thresholds[category]
+		if !ok {
+			threshold = c.threshold
+		}
+		if score >= threshold {
+			hits = append(hits, domain.ModerationHit{Rule: classifierRule, Category: category, Action: c.action, Score: score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits, nil
+}
+
+var _ domain.ModerationClassifier = (*HTTPClassifier)(nil)
@@ -0,0 +1,143 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// RuleSpec 是配置中的一条规则：命中任意一个关键词或正则即视为命中
+type RuleSpec struct {
+	Name     string
+	Category string
+	// Action 为空时按 flag 处理
+	Action string
+	// Stages 为空时对输入与回答都生效
+	Stages   []string
+	Keywords []string
+	Patterns []string
+}
+
+type rule struct {
+	name     string
+	category string
+	action   domain.ModerationAction
+	stages   []domain.ModerationStage
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// RuleSet 按配置顺序检查关键词与正则规则
+type RuleSet struct {
+	rules []*rule
+}
+
+// NewRuleSet 校验并编译规则，关键词不区分大小写
+func NewRuleSet(specs []RuleSpec) (*RuleSet, error) {
+	rs := &RuleSet{}
+	for i, spec := range specs {
+		r := &rule{name: spec.Name, category: spec.Category}
+		if r.name == "" {
+			r.name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if r.category == "" {
+			r.category = r.name
+		}
+		action, err := ParseAction(spec.Action)
+		if err != nil {
+			return nil, fmt.Errorf("moderation rule %s: %w", r.name, err)
+		}
+		r.action = action
+		for _, s := range spec.Stages {
+			stage := domain.ModerationStage(strings.ToLower(strings.TrimSpace(s)))
+			if stage != domain.ModerationInput && stage != domain.ModerationOutput {
+				return nil, fmt.Errorf("moderation rule %s: unknown stage %q", r.name, s)
+			}
+			r.stages = append(r.stages, stage)
+		}
+		for _, kw := range spec.Keywords {
+			if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" {
+				r.keywords = append(r.keywords, kw)
+			}
+		}
+		for _, p := range spec.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("moderation rule %s: %w", r.name, err)
+			}
+			r.patterns = append(r.patterns, re)
+		}
+		if len(r.keywords) == 0 && len(r.patterns) == 0 {
+			return nil, fmt.Errorf("moderation rule %s has no keywords or patterns", r.name)
+		}
+		rs.rules = append(rs.rules, r)
+	}
+	return rs, nil
+}
+
+// ParseAction 解析配置中的处理方式，空字符串为 flag
+func ParseAction(s string) (domain.ModerationAction, error) {
+	switch a := domain.ModerationAction(strings.ToLower(strings.TrimSpace(s))); a {
+	case "":
+		return domain.ModerationActionFlag, nil
+	case domain.ModerationActionFlag, domain.ModerationActionBlock:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown moderation action %q", s)
+}
+
+// Match 每条规则至多返回一个命中
+func (rs *RuleSet) Match(stage domain.ModerationStage, text string) []domain.ModerationHit {
+	var hits []domain.ModerationHit
+	lower := strings.ToLower(text)
+	for _, r := range rs.rules {
+		if len(r.stages) > 0 && !slices.Contains(r.stages, stage) {
+			continue
+		}
+		if r.matches(text, lower) {
+			hits = append(hits, domain.ModerationHit{Rule: r.name, Category: r.category, Action: r.action, Score: 1})
+		}
+	}
+	return hits
+}
+
+func (r *rule) matches(text, lower string) bool {
+	for _, kw := range r.keywords {
+		if containsKeyword(lower, kw) {
+			return true
+		}
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeyword 以字母或数字开头/结尾的拉丁关键词需要完整的词边界，避免 "class" 命中 "ass"；
+// 中文等没有词边界的关键词按子串匹配
+func containsKeyword(text, kw string) bool {
+	for from := 0; ; {
+		i := strings.Index(text[from:], kw)
+		if i < 0 {
+			return false
+		}
+		start := from + i
+		end := start + len(kw)
+		if !(isWordByte(kw[0]) && start > 0 && isWordByte(text[start-1])) &&
+			!(isWordByte(kw[len(kw)-1]) && end < len(text) && isWordByte(text[end])) {
+			return true
+		}
+		from = start + 1
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+var _ domain.ModerationRules = (*RuleSet)(nil)
@@ -0,0 +1,92 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+func TestRuleSetMatch(t *testing.T) {
+	rs, err := NewRuleSet([]RuleSpec{
+		{Name: "weapons", Category: "violence", Action: "block", Keywords: []string{"Pipe Bomb", "炸药"}},
+		{Name: "slurs", Keywords: []string{"ass"}, Stages: []string{"output"}},
+		{Name: "cards", Category: "fraud", Patterns: []string{`(?i)cvv\s*[:=]\s*\d{3}`}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		stage domain.ModerationStage
+		text  string
+		want  []string
+	}{
+		{"keyword ignores case", domain.ModerationInput, "how to build a PIPE BOMB", []string{"weapons"}},
+		{"cjk keyword without boundaries", domain.ModerationOutput, "自制炸药的方法", []string{"weapons"}},
+		{"latin keyword needs word boundary", domain.ModerationOutput, "this class is assigned", nil},
+		{"latin keyword at boundary", domain.ModerationOutput, "what an ass.", []string{"slurs"}},
+		{"rule limited to other stage", domain.ModerationInput, "what an ass", nil},
+		{"regex", domain.ModerationInput, "card 4111, CVV: 123", []string{"cards"}},
+		{"several rules", domain.ModerationOutput, "炸药 ass", []string{"weapons", "slurs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := rs.Match(tt.stage, tt.text)
+			if len(hits) != len(tt.want) {
+				t.Fatalf("Match = %+v, want rules %v", hits, tt.want)
+			}
+			for i, h := range hits {
+				if h.Rule != tt.want[i] {
+					t.Fatalf("hit %d = %+v, want rule %s", i, h, tt.want[i])
+				}
+			}
+		})
+	}
+	if hits := rs.Match(domain.ModerationInput, "pipe bomb"); hits[0].Action != domain.ModerationActionBlock || hits[0].Category != "violence" {
+		t.Fatalf("hit = %+v", hits[0])
+	}
+	if hits := rs.Match(domain.ModerationOutput, "ass"); hits[0].Action != domain.ModerationActionFlag || hits[0].Category != "slurs" {
+		t.Fatalf("defaults: hit = %+v", hits[0])
+	}
+}
+
+func TestNewRuleSetRejectsInvalidRules(t *testing.T) {
+	for _, spec := range []RuleSpec{
+		{Name: "empty"},
+		{Name: "action", Action: "delete", Keywords: []string{"x"}},
+		{Name: "stage", Stages: []string{"prompt"}, Keywords: []string{"x"}},
+		{Name: "regex", Patterns: []string{"("}},
+	} {
+		if _, err := NewRuleSet([]RuleSpec{spec}); err == nil {
+			t.Errorf("NewRuleSet(%s) succeeded", spec.Name)
+		}
+	}
+}
+
+func TestHTTPClassifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req classifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Stage != "output" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(classifyResponse{Scores: map[string]float64{"hate": 0.7, "violence": 0.9, "sexual": 0.2}})
+	}))
+	defer srv.Close()
+
+	c := NewHTTPClassifier(srv.URL, 0, domain.ModerationActionBlock, 0.6, map[string]float64{"hate": 0.8})
+	hits, err := c.Classify(context.Background(), domain.ModerationOutput, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Category != "violence" || hits[0].Action != domain.ModerationActionBlock || hits[0].Score != 0.9 {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if _, err := c.Classify(context.Background(), domain.ModerationInput, "text"); err == nil {
+		t.Fatal("expected error for non-200 response")
+	}
+}
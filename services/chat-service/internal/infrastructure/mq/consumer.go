@@ -17,17 +17,21 @@ type Consumer struct {
 	client      rocketmq.PushConsumer
 	msgRepo     *repository.MessageRepository
 	sessionRepo *repository.SessionRepository
+	// accountRepo 为空时不检查账户删除
+	accountRepo *repository.AccountRepository
 }
 
 func NewConsumer(
 	client rocketmq.PushConsumer,
 	msgRepo *repository.MessageRepository,
 	sessionRepo *repository.SessionRepository,
+	accountRepo *repository.AccountRepository,
 ) *Consumer {
 	return &Consumer{
 		client:      client,
 		msgRepo:     msgRepo,
 		sessionRepo: sessionRepo,
+		accountRepo: accountRepo,
 	}
 }
 
@@ -73,6 +77,9 @@ func (c *Consumer) handleSaveMessage(ctx context.Context, body []byte) error {
 		log.Printf("[ERROR] unmarshal message error: %v", err)
 		return nil
 	}
+	if erased, err := c.erased(ctx, msg.UserID); err != nil || erased {
+		return err
+	}
 
 	if err := c.msgRepo.Save(ctx, &msg); err != nil {
 		return err
@@ -87,6 +94,9 @@ func (c *Consumer) handleSaveSession(ctx context.Context, body []byte) error {
 		log.Printf("[ERROR] unmarshal session error: %v", err)
 		return nil
 	}
+	if erased, err := c.erased(ctx, session.UserID); err != nil || erased {
+		return err
+	}
 
 	if err := c.sessionRepo.Save(ctx, &session); err != nil {
 		return err
@@ -95,6 +105,18 @@ func (c *Consumer) handleSaveSession(ctx context.Context, body []byte) error {
 	return nil
 }
 
+// erased 返回事件所属用户是否已提交账户删除，已删除用户的在途事件直接丢弃
+func (c *Consumer) erased(ctx context.Context, userID string) (bool, error) {
+	if c.accountRepo == nil || userID == "" {
+		return false, nil
+	}
+	erased, err := c.accountRepo.IsErased(ctx, userID)
+	if erased {
+		log.Printf("[INFO] drop persistence event of erased user %s", userID)
+	}
+	return erased, err
+}
+
 func (c *Consumer) Start() error {
 	return c.client.Start()
 }
@@ -47,7 +47,7 @@ func InitProducer(cfg *config.AppConfig) (*Producer, error) {
 }
 
 // InitConsumer initializes the RocketMQ consumer
-func InitConsumer(cfg *config.AppConfig, msgRepo *repository.MessageRepository, sessionRepo *repository.SessionRepository) (*Consumer, error) {
+func InitConsumer(cfg *config.AppConfig, msgRepo *repository.MessageRepository, sessionRepo *repository.SessionRepository, accountRepo *repository.AccountRepository) (*Consumer, error) {
 	resolvedNameServers := resolveNameServers(cfg.RocketMQ.NameServers)
 	if len(resolvedNameServers) == 0 {
 		log.Println("RocketMQ name servers not configured, skipping consumer initialization")
@@ -63,7 +63,7 @@ func InitConsumer(cfg *config.AppConfig, msgRepo *repository.MessageRepository,
 		return nil, fmt.Errorf("failed to create RocketMQ consumer: %w", err)
 	}
 
-	mqConsumer := NewConsumer(c, msgRepo, sessionRepo)
+	mqConsumer := NewConsumer(c, msgRepo, sessionRepo, accountRepo)
 
 	// Subscribe to topics
 	if err := mqConsumer.SubscribePersistence(); err != nil {
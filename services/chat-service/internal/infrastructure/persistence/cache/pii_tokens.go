@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+)
+
+// GetPIITokens 读取会话的占位符表（占位符 -> 原值），启用加密时原值为密文
+func (r *RedisCache) GetPIITokens(ctx context.Context, session *domain.Session) (map[string]string, error) {
+	values, err := r.client.HGetAll(ctx, r.piiTokensKey(session.ID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get pii tokens: %w", err)
+	}
+	for token, value := range values {
+		plain, err := model.Open(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt pii token: %w", err)
+		}
+		values[token] = plain
+	}
+	return values, nil
+}
+
+// SavePIITokens 追加占位符并顺延过期时间：普通会话保留 SessionTTL，无痕会话随会话一起在 EphemeralTTL 后过期
+func (r *RedisCache) SavePIITokens(ctx context.Context, session *domain.Session, tokens map[string]string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	scope := domain.KeyScope(session.WorkspaceID, session.UserID)
+	fields := make(map[string]interface{}, len(tokens))
+	for token, value := range tokens {
+		sealed, err := model.Seal(ctx, scope, value)
+		if err != nil {
+			return fmt.Errorf("encrypt pii token: %w", err)
+		}
+		fields[token] = sealed
+	}
+	ttl := SessionTTL
+	if session.Ephemeral {
+		ttl = EphemeralTTL
+	}
+	key := r.piiTokensKey(session.ID)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, fields)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// piiTokensKey 会话的占位符表（哈希，占位符 -> 原值）
+func (r *RedisCache) piiTokensKey(sessionID string) string {
+	return fmt.Sprintf("pii_tokens:%s", sessionID)
+}
+
+var _ domain.PIIVault = (*RedisCache)(nil)
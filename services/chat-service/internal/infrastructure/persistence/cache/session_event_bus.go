@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// sessionEventBuffer 每个订阅者缓冲的事件数；缓冲满时丢弃增量事件，完整消息仍会送达
+const sessionEventBuffer = 64
+
+// SessionEventBus 通过 Redis pub/sub 在服务实例之间转发会话事件
+type SessionEventBus struct {
+	client *redis.Client
+}
+
+func NewSessionEventBus(client *redis.Client) *SessionEventBus {
+	return &SessionEventBus{client: client}
+}
+
+func (b *SessionEventBus) Publish(ctx context.Context, event *domain.SessionEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal session event: %w", err)
+	}
+	if err := b.client.Publish(ctx, sessionEventsKey(event.SessionID), data).Err(); err != nil {
+		return fmt.Errorf("publish session event: %w", err)
+	}
+	return nil
+}
+
+func (b *SessionEventBus) Subscribe(ctx context.Context, sessionID string) (<-chan *domain.SessionEvent, error) {
+	pubsub := b.client.Subscribe(ctx, sessionEventsKey(sessionID))
+	// 等待订阅确认，保证返回后发布的事件不会丢失
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("subscribe session events: %w", err)
+	}
+	out := make(chan *domain.SessionEvent, sessionEventBuffer)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var event domain.SessionEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					log.Printf("[WARN] invalid session event: %v", err)
+					continue
+				}
+				if event.Type == domain.SessionEventDelta {
+					select {
+					case out <- &event:
+					default:
+					}
+					continue
+				}
+				select {
+				case out <- &event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func sessionEventsKey(sessionID string) string {
+	return fmt.Sprintf("session_events:%s", sessionID)
+}
+
+var _ domain.SessionEventBus = (*SessionEventBus)(nil)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"free-chat/services/chat-service/internal/domain"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -18,6 +19,8 @@ const (
 	MessageTTL        = 24 * time.Hour
 	SessionTTL        = 48 * time.Hour
 	SessionMessageTTL = 24 * time.Hour
+	// EphemeralTTL 无痕会话在没有新消息后保留的时间，过期后会话与消息一起消失
+	EphemeralTTL = 30 * time.Minute
 )
 
 type RedisCache struct {
@@ -36,7 +39,7 @@ func NewRedisCache(client *redis.Client) (*RedisCache, error) {
 }
 
 func (r *RedisCache) SaveMessage(ctx context.Context, message *domain.Message) error {
-	msgData, err := json.Marshal(model.ToMessageModel(message))
+	msgData, err := marshalMessage(ctx, message)
 	if err != nil {
 		return err
 	}
@@ -49,10 +52,36 @@ func (r *RedisCache) SaveMessage(ctx context.Context, message *domain.Message) e
 		Member: message.ID,
 	})
 	pipe.Expire(ctx, sessionMsgKey, SessionMessageTTL)
+	// 完整标记与消息集合同时顺延，集合过期后重建时标记也已不在
+	pipe.Expire(ctx, r.sessionMessagesCompleteKey(message.SessionID), SessionMessageTTL)
 	_, err = pipe.Exec(ctx)
 	return err
 }
 
+// SaveSessionMessages 回写一批从数据库读到的消息。complete 表示 messages 是会话在数据库中的全部消息
+// （新建的会话为空），此时会话消息集合加上之后写入的消息就是完整的，标记为完整后短于一页的最后一页也能从缓存读取
+func (r *RedisCache) SaveSessionMessages(ctx context.Context, sessionID string, messages []*domain.Message, complete bool) error {
+	pipe := r.client.TxPipeline()
+	sessionMsgKey := r.sessionMessagesKey(sessionID)
+	for _, message := range messages {
+		msgData, err := marshalMessage(ctx, message)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, r.messageKey(message.ID), msgData, MessageTTL)
+		pipe.ZAdd(ctx, sessionMsgKey, &redis.Z{
+			Score:  float64(message.CreatedAt.UnixMicro()),
+			Member: message.ID,
+		})
+	}
+	pipe.Expire(ctx, sessionMsgKey, SessionMessageTTL)
+	if complete {
+		pipe.Set(ctx, r.sessionMessagesCompleteKey(sessionID), 1, SessionMessageTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 func (r *RedisCache) GetMessage(ctx context.Context, messageID string) (*domain.Message, error) {
 	msgKey := r.messageKey(messageID)
 	data, err := r.client.Get(ctx, msgKey).Result()
@@ -63,25 +92,55 @@ func (r *RedisCache) GetMessage(ctx context.Context, messageID string) (*domain.
 		return nil, fmt.Errorf("get message from cache: %w", err)
 	}
 
-	var msgModel model.MessageModel
-	if err := json.Unmarshal([]byte(data), &msgModel); err != nil {
+	message, err := unmarshalMessage(ctx, data)
+	if err != nil {
 		return nil, fmt.Errorf("unmarshal message: %w", err)
 	}
-	return msgModel.ToDomain(), nil
+	return message, nil
 }
 
-func (r *RedisCache) GetSessionMessages(ctx context.Context, sessionID string, limit, offset int) ([]*domain.Message, error) {
-	ssMsgsKey := r.sessionMessagesKey(sessionID)
-	start := int64(offset)
-	stop := int64(offset + limit - 1)
-	msgIDs, err := r.client.ZRevRange(ctx, ssMsgsKey, start, stop).Result()
+// GetSessionMessages 从缓存按 (created_at, id) 倒序读取 cursor 之后的一页消息与消息总数。
+// 缓存只保留近期消息，不一定完整：整页都来自缓存，或会话消息集合已标记为完整（见 SaveSessionMessages）时才算命中，
+// 否则返回 ErrCacheMiss 由调用方回源数据库。数据库经 MQ 异步写入，命中时不读库，刚保存的消息也能读到。
+// 集合完整时总数为集合大小，否则取缓存中会话的消息数（与集合同由 SaveMessage 与 TouchSession 维护）
+func (r *RedisCache) GetSessionMessages(ctx context.Context, sessionID string, limit int, cursor string) (*domain.MessagePage, error) {
+	orderKey := r.sessionMessagesKey(sessionID)
+	msgIDs, err := r.revRangeBefore(ctx, orderKey, cursor, limit+1)
 	if err != nil {
 		return nil, err
 	}
-	if len(msgIDs) == 0 {
+	pipe := r.client.Pipeline()
+	completeCmd := pipe.Exists(ctx, r.sessionMessagesCompleteKey(sessionID))
+	cardCmd := pipe.ZCard(ctx, orderKey)
+	sessionCmd := pipe.Get(ctx, r.sessionKey(sessionID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	complete := completeCmd.Val() == 1
+	hasMore := len(msgIDs) > limit
+	if !hasMore && !complete {
 		return nil, ErrCacheMiss
 	}
-	messages := make([]*domain.Message, 0, len(msgIDs))
+	page := &domain.MessagePage{Total: int(cardCmd.Val())}
+	if !complete {
+		data, err := sessionCmd.Result()
+		if err != nil {
+			return nil, ErrCacheMiss
+		}
+		session, err := unmarshalSession(ctx, data)
+		if err != nil {
+			return nil, ErrCacheMiss
+		}
+		// 集合是全部消息的子集，会话消息数落后于集合时以集合为准
+		page.Total = max(page.Total, session.MessageCount)
+	}
+	if hasMore {
+		msgIDs = msgIDs[:limit]
+	}
+	if len(msgIDs) == 0 {
+		return page, nil
+	}
+
 	keys := make([]string, len(msgIDs))
 	for i, id := range msgIDs {
 		keys[i] = r.messageKey(id)
@@ -90,21 +149,28 @@ func (r *RedisCache) GetSessionMessages(ctx context.Context, sessionID string, l
 	if err != nil {
 		return nil, err
 	}
+	messages := make([]*domain.Message, 0, len(results))
 	for _, result := range results {
 		if result == nil {
-			continue
+			// 消息体已过期，这一页不完整
+			return nil, ErrCacheMiss
 		}
-		var msgModel model.MessageModel
-		if err := json.Unmarshal([]byte(result.(string)), &msgModel); err != nil {
-			continue
+		message, err := unmarshalMessage(ctx, result.(string))
+		if err != nil {
+			return nil, ErrCacheMiss
 		}
-		messages = append(messages, msgModel.ToDomain())
+		messages = append(messages, message)
 	}
-	return messages, nil
+	page.Messages = messages
+	if hasMore {
+		last := messages[len(messages)-1]
+		page.NextCursor = model.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
 }
 
 func (r *RedisCache) SaveSession(ctx context.Context, session *domain.Session) error {
-	data, err := json.Marshal(model.ToSessionModel(session))
+	data, err := marshalSession(ctx, session)
 	if err != nil {
 		return fmt.Errorf("marshal session: %w", err)
 	}
@@ -114,12 +180,17 @@ func (r *RedisCache) SaveSession(ctx context.Context, session *domain.Session) e
 	sessionKey := r.sessionKey(session.ID)
 	pipe.Set(ctx, sessionKey, data, SessionTTL)
 
-	userSessionKey := r.userSessionsKey(session.UserID)
-	pipe.ZAdd(ctx, userSessionKey, &redis.Z{
-		Score:  float64(session.CreatedAt.UnixMicro()),
-		Member: session.ID,
-	})
-	pipe.Expire(ctx, userSessionKey, SessionTTL)
+	// 用户会话集合对应默认的会话列表：只含未归档的会话，按置顶与最后活动时间排序
+	userSessionKey := r.userSessionsKey(session.UserID, session.WorkspaceID)
+	if session.Archived {
+		pipe.ZRem(ctx, userSessionKey, session.ID)
+	} else {
+		pipe.ZAdd(ctx, userSessionKey, &redis.Z{
+			Score:  float64(sessionScore(session)),
+			Member: session.ID,
+		})
+		pipe.Expire(ctx, userSessionKey, SessionTTL)
+	}
 
 	_, err = pipe.Exec(ctx)
 	return err
@@ -135,51 +206,162 @@ func (r *RedisCache) GetSession(ctx context.Context, sessionID string) (*domain.
 		return nil, fmt.Errorf("get session from cache: %w", err)
 	}
 
-	var sessionModel model.SessionModel
-	if err := json.Unmarshal([]byte(data), &sessionModel); err != nil {
+	session, err := unmarshalSession(ctx, data)
+	if err != nil {
 		return nil, fmt.Errorf("unmarshal session: %w", err)
 	}
-	return sessionModel.ToDomain(), nil
+	return session, nil
 }
 
-func (r *RedisCache) GetUserSessions(ctx context.Context, userID string, limit, offset int) ([]*domain.Session, error) {
-	userSessionKey := r.userSessionsKey(userID)
-
-	start := int64(offset)
-	stop := int64(offset + limit - 1)
-
-	sessionIDs, err := r.client.ZRevRange(ctx, userSessionKey, start, stop).Result()
+// GetUserSessions 从缓存读取用户在工作空间中的默认会话列表（未归档、置顶在前、按最后活动时间倒序）中 cursor 之后的一页，
+// 命中规则同 GetSessionMessages
+func (r *RedisCache) GetUserSessions(ctx context.Context, userID, workspaceID string, limit int, cursor string) ([]*domain.Session, string, error) {
+	sessionIDs, err := r.revRangeBefore(ctx, r.userSessionsKey(userID, workspaceID), cursor, limit+1)
 	if err != nil {
-		return nil, fmt.Errorf("get user session ids: %w", err)
+		return nil, "", fmt.Errorf("get user session ids: %w", err)
 	}
-
-	if len(sessionIDs) == 0 {
-		return nil, ErrCacheMiss
+	if len(sessionIDs) <= limit {
+		return nil, "", ErrCacheMiss
 	}
+	sessionIDs = sessionIDs[:limit]
 
-	sessions := make([]*domain.Session, 0, len(sessionIDs))
 	keys := make([]string, len(sessionIDs))
 	for i, id := range sessionIDs {
 		keys[i] = r.sessionKey(id)
 	}
-
 	results, err := r.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("mget sessions: %w", err)
+		return nil, "", fmt.Errorf("mget sessions: %w", err)
 	}
-
+	sessions := make([]*domain.Session, 0, len(results))
 	for _, result := range results {
 		if result == nil {
-			continue
+			return nil, "", ErrCacheMiss
 		}
-		var sessionModel model.SessionModel
-		if err := json.Unmarshal([]byte(result.(string)), &sessionModel); err != nil {
-			continue
+		session, err := unmarshalSession(ctx, result.(string))
+		if err != nil {
+			return nil, "", ErrCacheMiss
 		}
-		sessions = append(sessions, sessionModel.ToDomain())
+		// 集合中的分数可能来自会话状态未知时的写入，与会话不一致时回源
+		if session.Archived {
+			return nil, "", ErrCacheMiss
+		}
+		sessions = append(sessions, session)
+	}
+	last := sessions[len(sessions)-1]
+	return sessions, model.EncodeScoreCursor(sessionScore(last), last.ID), nil
+}
+
+// touchSessionScript 原子地把一条新消息计入会话：消息数加一，更新最后活动时间与摘要，
+// 并按置顶状态刷新用户会话集合中的分数（已归档的会话不进入集合）。
+// 会话不在缓存中时只维护集合，置顶状态由集合中原有的分数推断。
+// KEYS: session:<id>, 会话所有者的会话集合（见 userSessionsKey）
+// ARGV: session id, 消息时间（微秒）, 消息时间（RFC3339）, 摘要, 当前时间（RFC3339）, 集合 TTL（秒）, 置顶加成
+var touchSessionScript = redis.NewScript(`
+local boost = tonumber(ARGV[7])
+local data = redis.call('GET', KEYS[1])
+local session
+local pinned, archived = false, false
+if data then
+	session = cjson.decode(data)
+	pinned = session.Pinned == true
+	archived = session.Archived == true
+else
+	local current = redis.call('ZSCORE', KEYS[2], ARGV[1])
+	pinned = current ~= false and tonumber(current) >= boost
+end
+if not archived then
+	local score = tonumber(ARGV[2])
+	if pinned then
+		score = score + boost
+	end
+	redis.call('ZADD', KEYS[2], string.format('%.0f', score), ARGV[1])
+	redis.call('EXPIRE', KEYS[2], ARGV[6])
+end
+if not session then
+	return 0
+end
+session.MessageCount = (session.MessageCount or 0) + 1
+session.UpdatedAt = ARGV[5]
+session.LastMessageAt = ARGV[3]
+session.Preview = ARGV[4]
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], cjson.encode(session), 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], cjson.encode(session))
+end
+return 1
+`)
+
+// TouchSession 把新消息计入缓存中的会话，与数据库中 MessageRepository.Save 的更新保持一致。
+// 消息可能来自协作者，会话集合按会话所有者与所属工作空间定位
+func (r *RedisCache) TouchSession(ctx context.Context, session *domain.Session, msg *domain.Message) error {
+	// 缓存中的会话 JSON 在启用加密时摘要为密文
+	preview, err := model.Seal(ctx, domain.KeyScope(session.WorkspaceID, session.UserID), domain.MessagePreview(msg.Content))
+	if err != nil {
+		return fmt.Errorf("encrypt session preview: %w", err)
 	}
+	keys := []string{r.sessionKey(msg.SessionID), r.userSessionsKey(session.UserID, session.WorkspaceID)}
+	err = touchSessionScript.Run(ctx, r.client, keys,
+		msg.SessionID,
+		msg.CreatedAt.UnixMicro(),
+		msg.CreatedAt.Format(time.RFC3339Nano),
+		preview,
+		time.Now().Format(time.RFC3339Nano),
+		int(SessionTTL.Seconds()),
+		model.PinnedScoreBoost,
+	).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("touch session: %w", err)
+	}
+	return nil
+}
 
-	return sessions, nil
+// sessionScore 返回会话在用户会话集合中的分数，还没有最后活动时间时取创建时间
+func sessionScore(s *domain.Session) int64 {
+	at := s.LastMessageAt
+	if at.IsZero() {
+		at = s.CreatedAt
+	}
+	return model.SessionScore(s.Pinned, at)
+}
+
+// revRangeBefore 返回有序集合中排在游标之后的至多 count 个成员。
+// 分数是排序值（消息为 created_at 微秒，会话为 SessionScore），同分成员按成员倒序排列（与 ZREVRANGE 一致），
+// 因此游标 (t, id) 之后是：分数等于 t 且成员小于 id 的，再接分数小于 t 的。
+func (r *RedisCache) revRangeBefore(ctx context.Context, key, cursor string, count int) ([]string, error) {
+	if cursor == "" {
+		return r.client.ZRevRange(ctx, key, 0, int64(count-1)).Result()
+	}
+	value, id, err := model.DecodeScoreCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	score := strconv.FormatInt(value, 10)
+
+	ties, err := r.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: score, Max: score}).Result()
+	if err != nil {
+		return nil, err
+	}
+	var members []string
+	for _, m := range ties {
+		if m < id && len(members) < count {
+			members = append(members, m)
+		}
+	}
+	if len(members) == count {
+		return members, nil
+	}
+	older, err := r.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + score,
+		Count: int64(count - len(members)),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return append(members, older...), nil
 }
 
 func (r *RedisCache) DeleteMessage(ctx context.Context, sessionID, messageID string) error {
@@ -190,21 +372,202 @@ func (r *RedisCache) DeleteMessage(ctx context.Context, sessionID, messageID str
 	return err
 }
 
-func (r *RedisCache) DeleteSession(ctx context.Context, userID, sessionID string) error {
+func (r *RedisCache) DeleteSession(ctx context.Context, session *domain.Session) error {
+	pipe := r.client.Pipeline()
+	pipe.Del(ctx, r.sessionKey(session.ID))
+	pipe.ZRem(ctx, r.userSessionsKey(session.UserID, session.WorkspaceID), session.ID)
+	pipe.Del(ctx, r.sessionMessagesKey(session.ID), r.sessionMessagesCompleteKey(session.ID), r.piiTokensKey(session.ID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// EvictMessages 删除缓存中的消息及其在会话消息集合中的位置，并删除所属会话的缓存
+func (r *RedisCache) EvictMessages(ctx context.Context, messages []*domain.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	sessions := make(map[string]bool)
+	for _, m := range messages {
+		pipe.Del(ctx, r.messageKey(m.ID))
+		pipe.ZRem(ctx, r.sessionMessagesKey(m.SessionID), m.ID)
+		if !sessions[m.SessionID] {
+			sessions[m.SessionID] = true
+			pipe.Del(ctx, r.sessionKey(m.SessionID))
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// EvictSessions 删除缓存中的会话、会话在所有者会话列表中的位置、会话的消息列表（含无痕会话的消息）以及占位符表
+func (r *RedisCache) EvictSessions(ctx context.Context, sessions []*domain.Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
 	pipe := r.client.Pipeline()
-	pipe.Del(ctx, r.sessionKey(sessionID))
-	pipe.ZRem(ctx, r.userSessionsKey(userID), sessionID)
-	pipe.Del(ctx, r.sessionMessagesKey(sessionID))
+	for _, s := range sessions {
+		pipe.Del(ctx, r.sessionKey(s.ID), r.sessionMessagesKey(s.ID), r.sessionMessagesCompleteKey(s.ID),
+			r.ephemeralMessagesKey(s.ID), r.piiTokensKey(s.ID))
+		pipe.ZRem(ctx, r.userSessionsKey(s.UserID, s.WorkspaceID), s.ID)
+	}
 	_, err := pipe.Exec(ctx)
 	return err
 }
 
+// EvictUserSessions 删除用户在个人空间与各工作空间中的会话列表
+func (r *RedisCache) EvictUserSessions(ctx context.Context, userID string) error {
+	keys := []string{r.userSessionsKey(userID, "")}
+	iter := r.client.Scan(ctx, 0, r.userSessionsKey(userID, "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// InvalidateSessions 删除缓存中的会话，下次读取时从数据库重新加载
+func (r *RedisCache) InvalidateSessions(ctx context.Context, sessionIDs []string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, len(sessionIDs))
+	for i, id := range sessionIDs {
+		keys[i] = r.sessionKey(id)
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// SaveEphemeralSession 保存无痕会话：只写入会话键（不进入用户会话列表），以 EphemeralTTL 过期
+func (r *RedisCache) SaveEphemeralSession(ctx context.Context, session *domain.Session) error {
+	data, err := marshalSession(ctx, session)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	return r.client.Set(ctx, r.sessionKey(session.ID), data, EphemeralTTL).Err()
+}
+
+// SaveEphemeralMessage 把消息写入无痕会话的消息哈希与有序集合，并把会话及其全部消息的过期时间顺延 EphemeralTTL。
+// 这些键是无痕会话消息的唯一存储，不会回源数据库
+func (r *RedisCache) SaveEphemeralMessage(ctx context.Context, message *domain.Message) error {
+	msgData, err := marshalMessage(ctx, message)
+	if err != nil {
+		return err
+	}
+	pipe := r.client.TxPipeline()
+	dataKey := r.ephemeralMessagesKey(message.SessionID)
+	orderKey := r.sessionMessagesKey(message.SessionID)
+	pipe.HSet(ctx, dataKey, message.ID, msgData)
+	pipe.ZAdd(ctx, orderKey, &redis.Z{
+		Score:  float64(message.CreatedAt.UnixMicro()),
+		Member: message.ID,
+	})
+	pipe.Expire(ctx, dataKey, EphemeralTTL)
+	pipe.Expire(ctx, orderKey, EphemeralTTL)
+	pipe.Expire(ctx, r.sessionKey(message.SessionID), EphemeralTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetEphemeralMessages 按 (created_at, id) 倒序读取无痕会话 cursor 之后的一页消息与消息总数。
+// 缓存是唯一的存储，读到多少就是多少，不存在未命中
+func (r *RedisCache) GetEphemeralMessages(ctx context.Context, sessionID string, limit int, cursor string) (*domain.MessagePage, error) {
+	orderKey := r.sessionMessagesKey(sessionID)
+	msgIDs, err := r.revRangeBefore(ctx, orderKey, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	total, err := r.client.ZCard(ctx, orderKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.MessagePage{Total: int(total)}
+	hasMore := len(msgIDs) > limit
+	if hasMore {
+		msgIDs = msgIDs[:limit]
+	}
+	if len(msgIDs) == 0 {
+		return page, nil
+	}
+	results, err := r.client.HMGet(ctx, r.ephemeralMessagesKey(sessionID), msgIDs...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		message, err := unmarshalMessage(ctx, result.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal message: %w", err)
+		}
+		page.Messages = append(page.Messages, message)
+	}
+	if hasMore && len(page.Messages) > 0 {
+		last := page.Messages[len(page.Messages)-1]
+		page.NextCursor = model.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// DeleteEphemeralSession 立即删除无痕会话及其消息
+func (r *RedisCache) DeleteEphemeralSession(ctx context.Context, sessionID string) error {
+	return r.client.Del(ctx,
+		r.sessionKey(sessionID),
+		r.sessionMessagesKey(sessionID),
+		r.ephemeralMessagesKey(sessionID),
+		r.piiTokensKey(sessionID),
+	).Err()
+}
+
 func (r *RedisCache) InvalidateSessionMessages(ctx context.Context, sessionID string) error {
-	return r.client.Del(ctx, r.sessionMessagesKey(sessionID)).Err()
+	return r.client.Del(ctx, r.sessionMessagesKey(sessionID), r.sessionMessagesCompleteKey(sessionID)).Err()
 }
 
 // Key generation helpers
 
+// marshalMessage 序列化写入缓存的消息，启用加密时内容为密文
+func marshalMessage(ctx context.Context, message *domain.Message) ([]byte, error) {
+	m := model.ToMessageModel(message)
+	if err := m.Seal(ctx); err != nil {
+		return nil, fmt.Errorf("encrypt message: %w", err)
+	}
+	return json.Marshal(m)
+}
+
+func unmarshalMessage(ctx context.Context, data string) (*domain.Message, error) {
+	var m model.MessageModel
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, err
+	}
+	if err := m.Open(ctx); err != nil {
+		return nil, err
+	}
+	return m.ToDomain(), nil
+}
+
+// marshalSession 序列化写入缓存的会话，启用加密时标题与摘要为密文
+func marshalSession(ctx context.Context, session *domain.Session) ([]byte, error) {
+	m := model.ToSessionModel(session)
+	if err := m.Seal(ctx); err != nil {
+		return nil, fmt.Errorf("encrypt session: %w", err)
+	}
+	return json.Marshal(m)
+}
+
+func unmarshalSession(ctx context.Context, data string) (*domain.Session, error) {
+	var m model.SessionModel
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, err
+	}
+	if err := m.Open(ctx); err != nil {
+		return nil, err
+	}
+	return m.ToDomain(), nil
+}
+
 func (r *RedisCache) messageKey(messageID string) string {
 	return fmt.Sprintf("message:%s", messageID)
 }
@@ -217,8 +580,22 @@ func (r *RedisCache) sessionMessagesKey(sessionID string) string {
 	return fmt.Sprintf("session_messages:%s", sessionID)
 }
 
-func (r *RedisCache) userSessionsKey(userID string) string {
-	return fmt.Sprintf("user_sessions:%s", userID)
+// sessionMessagesCompleteKey 存在时会话消息集合包含会话的全部消息
+func (r *RedisCache) sessionMessagesCompleteKey(sessionID string) string {
+	return fmt.Sprintf("session_messages_complete:%s", sessionID)
+}
+
+// ephemeralMessagesKey 无痕会话的消息体（哈希，消息 ID -> 消息）
+func (r *RedisCache) ephemeralMessagesKey(sessionID string) string {
+	return fmt.Sprintf("ephemeral_messages:%s", sessionID)
+}
+
+// userSessionsKey 个人空间沿用原有的键，工作空间中的会话列表按空间区分
+func (r *RedisCache) userSessionsKey(userID, workspaceID string) string {
+	if workspaceID == "" {
+		return fmt.Sprintf("user_sessions:%s", userID)
+	}
+	return fmt.Sprintf("user_sessions:%s:%s", workspaceID, userID)
 }
 
 func (r *RedisCache) Close() error {
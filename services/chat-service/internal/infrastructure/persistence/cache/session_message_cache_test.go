@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	c, err := NewRedisCache(client)
+	if err != nil {
+		t.Fatalf("NewRedisCache: %v", err)
+	}
+	return c, mr
+}
+
+func TestEphemeralSession_MessagesPagedFromCacheOnly(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	now := time.Now()
+	session := &domain.Session{ID: "s1", UserID: "u1", CreatedAt: now, Ephemeral: true}
+	if err := c.SaveEphemeralSession(ctx, session); err != nil {
+		t.Fatalf("SaveEphemeralSession: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		msg := &domain.Message{
+			ID:        fmt.Sprintf("m%d", i),
+			SessionID: "s1",
+			Role:      domain.RoleUser,
+			Content:   fmt.Sprintf("message %d", i),
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		}
+		if err := c.SaveEphemeralMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveEphemeralMessage: %v", err)
+		}
+	}
+
+	got, err := c.GetSession(ctx, "s1")
+	if err != nil || !got.Ephemeral {
+		t.Fatalf("GetSession = %+v, %v, want ephemeral session", got, err)
+	}
+	if mr.Exists("user_sessions:u1") {
+		t.Error("ephemeral session must not be added to the session list")
+	}
+
+	page, err := c.GetEphemeralMessages(ctx, "s1", 2, "")
+	if err != nil {
+		t.Fatalf("GetEphemeralMessages: %v", err)
+	}
+	if page.Total != 3 || len(page.Messages) != 2 || page.Messages[0].ID != "m2" || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	page, err = c.GetEphemeralMessages(ctx, "s1", 2, page.NextCursor)
+	if err != nil {
+		t.Fatalf("GetEphemeralMessages: %v", err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].ID != "m0" || page.NextCursor != "" {
+		t.Fatalf("second page = %+v", page)
+	}
+}
+
+func TestEphemeralSession_ExpiresAfterInactivity(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	if err := c.SaveEphemeralSession(ctx, &domain.Session{ID: "s1", UserID: "u1", Ephemeral: true}); err != nil {
+		t.Fatalf("SaveEphemeralSession: %v", err)
+	}
+
+	// 新消息顺延会话的过期时间
+	mr.FastForward(EphemeralTTL - time.Minute)
+	msg := &domain.Message{ID: "m1", SessionID: "s1", Role: domain.RoleUser, Content: "hi", CreatedAt: time.Now()}
+	if err := c.SaveEphemeralMessage(ctx, msg); err != nil {
+		t.Fatalf("SaveEphemeralMessage: %v", err)
+	}
+	mr.FastForward(2 * time.Minute)
+	if _, err := c.GetSession(ctx, "s1"); err != nil {
+		t.Fatalf("session expired despite recent activity: %v", err)
+	}
+
+	mr.FastForward(EphemeralTTL)
+	if _, err := c.GetSession(ctx, "s1"); err != ErrCacheMiss {
+		t.Fatalf("GetSession after inactivity err = %v, want ErrCacheMiss", err)
+	}
+	page, err := c.GetEphemeralMessages(ctx, "s1", 10, "")
+	if err != nil || page.Total != 0 || len(page.Messages) != 0 {
+		t.Fatalf("messages after inactivity = %+v, %v, want none", page, err)
+	}
+}
+
+func TestDeleteEphemeralSession(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	_ = c.SaveEphemeralSession(ctx, &domain.Session{ID: "s1", UserID: "u1", Ephemeral: true})
+	_ = c.SaveEphemeralMessage(ctx, &domain.Message{ID: "m1", SessionID: "s1", Content: "hi", CreatedAt: time.Now()})
+
+	if err := c.DeleteEphemeralSession(ctx, "s1"); err != nil {
+		t.Fatalf("DeleteEphemeralSession: %v", err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Fatalf("keys left after delete: %v", keys)
+	}
+}
+
+// prefixCipher 把明文编码为带范围前缀的 base64，用来检查缓存中不出现明文
+type prefixCipher struct{}
+
+func (prefixCipher) Seal(ctx context.Context, scope, plaintext string) (string, error) {
+	return "enc:" + scope + ":" + base64.StdEncoding.EncodeToString([]byte(plaintext)), nil
+}
+
+func (prefixCipher) Open(ctx context.Context, value string) (string, error) {
+	i := strings.LastIndexByte(value, ':')
+	if !strings.HasPrefix(value, "enc:") || i < 0 {
+		return value, nil
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(value[i+1:])
+	return string(plaintext), err
+}
+
+func (prefixCipher) Stale(ctx context.Context, scope, value string) (bool, error) { return false, nil }
+
+func (prefixCipher) SessionScope(ctx context.Context, sessionID, userID string) (string, error) {
+	return domain.KeyScope("", userID), nil
+}
+
+func (prefixCipher) SearchIndexEnabled() bool { return false }
+
+func (prefixCipher) BlindIndex(ctx context.Context, scope, text string) (string, error) {
+	return "", nil
+}
+
+func (prefixCipher) BlindQueries(ctx context.Context, scope, query string) ([]string, error) {
+	return nil, nil
+}
+
+func TestEncryptedCache(t *testing.T) {
+	model.UseCipher(prefixCipher{})
+	t.Cleanup(func() { model.UseCipher(nil) })
+
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	now := time.Now()
+	session := &domain.Session{ID: "s1", UserID: "u1", WorkspaceID: "w1", Title: "salary review", Preview: "raise", CreatedAt: now}
+	msg := &domain.Message{ID: "m1", SessionID: "s1", UserID: "u1", Role: domain.RoleUser, Content: "my salary is 100", CreatedAt: now}
+	if err := c.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	if err := c.SaveMessage(ctx, msg); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+
+	for _, key := range mr.Keys() {
+		if mr.Type(key) != "string" {
+			continue
+		}
+		raw, _ := mr.Get(key)
+		if strings.Contains(raw, "salary") || strings.Contains(raw, "raise") {
+			t.Fatalf("plaintext cached in %s: %s", key, raw)
+		}
+	}
+	raw, _ := mr.Get(c.sessionKey("s1"))
+	if !strings.Contains(raw, "enc:workspace:w1:") {
+		t.Fatalf("session title not sealed with the workspace scope: %s", raw)
+	}
+
+	gotSession, err := c.GetSession(ctx, "s1")
+	if err != nil || gotSession.Title != "salary review" || gotSession.Preview != "raise" {
+		t.Fatalf("GetSession = %+v, %v", gotSession, err)
+	}
+	gotMsg, err := c.GetMessage(ctx, "m1")
+	if err != nil || gotMsg.Content != "my salary is 100" {
+		t.Fatalf("GetMessage = %+v, %v", gotMsg, err)
+	}
+}
+
+// 短于一页的会话与最后一页：集合标记为完整时从缓存读取，否则回源数据库
+func TestSessionMessages_ShortSession(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	now := time.Now()
+	session := &domain.Session{ID: "s1", UserID: "u1", CreatedAt: now}
+	if err := c.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	save := func(i int) {
+		msg := &domain.Message{ID: fmt.Sprintf("m%d", i), SessionID: "s1", Role: domain.RoleUser,
+			Content: fmt.Sprintf("message %d", i), CreatedAt: now.Add(time.Duration(i) * time.Second)}
+		if err := c.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+		if err := c.TouchSession(ctx, session, msg); err != nil {
+			t.Fatalf("TouchSession: %v", err)
+		}
+	}
+
+	// 不知道集合是否完整：不足一页时未命中
+	save(0)
+	if _, err := c.GetSessionMessages(ctx, "s1", 10, ""); err != ErrCacheMiss {
+		t.Fatalf("unmarked short session err = %v, want ErrCacheMiss", err)
+	}
+
+	// 回写了数据库中的全部消息后，之后保存的消息也能立即读到
+	if err := c.SaveSessionMessages(ctx, "s1", nil, true); err != nil {
+		t.Fatalf("SaveSessionMessages: %v", err)
+	}
+	save(1)
+	save(2)
+	page, err := c.GetSessionMessages(ctx, "s1", 10, "")
+	if err != nil {
+		t.Fatalf("GetSessionMessages: %v", err)
+	}
+	if page.Total != 3 || len(page.Messages) != 3 || page.Messages[0].ID != "m2" || page.NextCursor != "" {
+		t.Fatalf("short session page = %+v", page)
+	}
+
+	// 完整集合的最后一页同样命中
+	page, err = c.GetSessionMessages(ctx, "s1", 2, "")
+	if err != nil || len(page.Messages) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %+v, %v", page, err)
+	}
+	page, err = c.GetSessionMessages(ctx, "s1", 2, page.NextCursor)
+	if err != nil || len(page.Messages) != 1 || page.Messages[0].ID != "m0" || page.NextCursor != "" || page.Total != 3 {
+		t.Fatalf("last page = %+v, %v", page, err)
+	}
+
+	// 集合过期后标记一起过期，重建的集合只有新消息，不能当作完整的
+	mr.FastForward(SessionMessageTTL + time.Minute)
+	if mr.Exists("session_messages_complete:s1") {
+		t.Fatal("complete marker outlived the message set")
+	}
+	save(3)
+	if _, err := c.GetSessionMessages(ctx, "s1", 10, ""); err != ErrCacheMiss {
+		t.Fatalf("rebuilt set err = %v, want ErrCacheMiss", err)
+	}
+}
+
+// 不完整的集合整页命中时，总数取缓存中会话的消息数，不少于集合大小
+func TestSessionMessages_TotalFromCachedSession(t *testing.T) {
+	c, _ := newTestCache(t)
+	ctx := context.Background()
+	now := time.Now()
+	if err := c.SaveSession(ctx, &domain.Session{ID: "s1", UserID: "u1", CreatedAt: now, MessageCount: 40}); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		msg := &domain.Message{ID: fmt.Sprintf("m%d", i), SessionID: "s1", Role: domain.RoleUser,
+			Content: "hi", CreatedAt: now.Add(time.Duration(i) * time.Second)}
+		if err := c.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+	}
+	page, err := c.GetSessionMessages(ctx, "s1", 2, "")
+	if err != nil || page.Total != 40 || len(page.Messages) != 2 || page.NextCursor == "" {
+		t.Fatalf("page = %+v, %v", page, err)
+	}
+	// 缓存中没有会话时无法给出总数，回源
+	if err := c.InvalidateSessions(ctx, []string{"s1"}); err != nil {
+		t.Fatalf("InvalidateSessions: %v", err)
+	}
+	if _, err := c.GetSessionMessages(ctx, "s1", 2, ""); err != ErrCacheMiss {
+		t.Fatalf("err = %v, want ErrCacheMiss", err)
+	}
+}
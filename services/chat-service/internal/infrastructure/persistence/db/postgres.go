@@ -1,6 +1,8 @@
 package db
 
 import (
+	"fmt"
+	"free-chat/services/chat-service/internal/domain"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
 
 	"gorm.io/driver/postgres"
@@ -14,9 +16,61 @@ func InitGorm(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = db.AutoMigrate(&model.MessageModel{}, &model.SessionModel{})
+	err = db.AutoMigrate(&model.MessageModel{}, &model.SessionModel{}, &model.MemoryModel{}, &model.MemorySettingModel{},
+		&model.DocumentModel{}, &model.DocumentChunkModel{}, &model.CollectionModel{},
+		&model.MessageEmbeddingModel{}, &model.FolderModel{}, &model.ShareModel{}, &model.ShareMessageModel{},
+		&model.ParticipantModel{}, &model.RetentionPolicyModel{}, &model.RetentionAuditModel{},
+		&model.AccountExportModel{}, &model.AccountErasureModel{}, &model.DataKeyModel{}, &model.PIIAuditModel{},
+		&model.ModerationFlagModel{})
 	if err != nil {
 		return nil, err
 	}
+	if err = initMessageSearch(db); err != nil {
+		return nil, err
+	}
+	if err = initSessionActivity(db); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
+
+// initMessageSearch 为旧消息补齐检索文本，并在其 simple 分词结果上建立 GIN 索引
+func initMessageSearch(db *gorm.DB) error {
+	var batch []*model.MessageModel
+	err := db.Unscoped().Where("search_text IS NULL").
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, m := range batch {
+				if err := tx.Model(m).UpdateColumn("search_text", model.SearchText(m.Content)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill message search text: %w", err)
+	}
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_search ON message_models
+		USING GIN (to_tsvector('simple', coalesce(search_text, '')))`).Error; err != nil {
+		return fmt.Errorf("failed to create message search index: %w", err)
+	}
+	return nil
+}
+
+// initSessionActivity 为新增活动字段之前创建的会话补齐消息数、最后活动时间与摘要。
+// 摘要的压缩方式与 domain.MessagePreview 一致。
+func initSessionActivity(db *gorm.DB) error {
+	err := db.Exec(`UPDATE session_models s SET
+		message_count = (SELECT count(*) FROM message_models m
+			WHERE m.session_id = s.session_id AND m.deleted_at IS NULL),
+		last_message_at = COALESCE((SELECT max(m.created_at) FROM message_models m
+			WHERE m.session_id = s.session_id AND m.deleted_at IS NULL), s.created_at),
+		preview = COALESCE((SELECT left(regexp_replace(btrim(m.content), '\s+', ' ', 'g'), ?) FROM message_models m
+			WHERE m.session_id = s.session_id AND m.deleted_at IS NULL
+			ORDER BY m.created_at DESC LIMIT 1), ''),
+		updated_at = COALESCE(s.updated_at, s.created_at)
+		WHERE s.last_message_at IS NULL`, domain.SessionPreviewRunes).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill session activity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+)
+
+// AccountExportModel 是账户数据导出任务，压缩包存放在 ExportStore 中
+type AccountExportModel struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement;column:id"`
+	ExportID    string     `gorm:"uniqueIndex:idx_account_export_id;size:36;not null;column:export_id"`
+	UserID      string     `gorm:"index:idx_account_exports_user_id;size:36;not null;column:user_id"`
+	Status      string     `gorm:"size:20;not null;column:status"`
+	Size        int64      `gorm:"not null;default:0;column:size"`
+	Error       string     `gorm:"type:text;not null;default:'';column:error"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;not null;column:created_at"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at"`
+}
+
+func (AccountExportModel) TableName() string {
+	return "account_export_models"
+}
+
+func (m *AccountExportModel) ToDomain() *domain.AccountExport {
+	return &domain.AccountExport{
+		ID:          m.ExportID,
+		UserID:      m.UserID,
+		Status:      domain.ExportStatus(m.Status),
+		Size:        m.Size,
+		Error:       m.Error,
+		CreatedAt:   m.CreatedAt,
+		CompletedAt: fromNullTime(m.CompletedAt),
+		ExpiresAt:   fromNullTime(m.ExpiresAt),
+	}
+}
+
+func ToAccountExportModel(d *domain.AccountExport) *AccountExportModel {
+	return &AccountExportModel{
+		ExportID:    d.ID,
+		UserID:      d.UserID,
+		Status:      string(d.Status),
+		Size:        d.Size,
+		Error:       d.Error,
+		CreatedAt:   d.CreatedAt,
+		CompletedAt: toNullTime(d.CompletedAt),
+		ExpiresAt:   toNullTime(d.ExpiresAt),
+	}
+}
+
+// AccountErasureModel 是账户删除任务与完成后的确认记录，每个用户一条
+type AccountErasureModel struct {
+	ID          uint                           `gorm:"primaryKey;autoIncrement;column:id"`
+	ErasureID   string                         `gorm:"uniqueIndex:idx_account_erasure_id;size:36;not null;column:erasure_id"`
+	UserID      string                         `gorm:"uniqueIndex:idx_account_erasures_user_id;size:36;not null;column:user_id"`
+	Done        []domain.ErasureStep           `gorm:"serializer:json;type:text;column:done"`
+	Purged      map[domain.ErasureTarget]int64 `gorm:"serializer:json;type:text;column:purged"`
+	LastError   string                         `gorm:"type:text;not null;default:'';column:last_error"`
+	RequestedAt time.Time                      `gorm:"not null;column:requested_at"`
+	DrainUntil  time.Time                      `gorm:"not null;column:drain_until"`
+	CompletedAt *time.Time                     `gorm:"index:idx_account_erasures_completed_at;column:completed_at"`
+}
+
+func (AccountErasureModel) TableName() string {
+	return "account_erasure_models"
+}
+
+func (m *AccountErasureModel) ToDomain() *domain.AccountErasure {
+	purged := m.Purged
+	if purged == nil {
+		purged = make(map[domain.ErasureTarget]int64)
+	}
+	return &domain.AccountErasure{
+		ID:          m.ErasureID,
+		UserID:      m.UserID,
+		Done:        m.Done,
+		Purged:      purged,
+		LastError:   m.LastError,
+		RequestedAt: m.RequestedAt,
+		DrainUntil:  m.DrainUntil,
+		CompletedAt: fromNullTime(m.CompletedAt),
+	}
+}
+
+func ToAccountErasureModel(d *domain.AccountErasure) *AccountErasureModel {
+	return &AccountErasureModel{
+		ErasureID:   d.ID,
+		UserID:      d.UserID,
+		Done:        d.Done,
+		Purged:      d.Purged,
+		LastError:   d.LastError,
+		RequestedAt: d.RequestedAt,
+		DrainUntil:  d.DrainUntil,
+		CompletedAt: toNullTime(d.CompletedAt),
+	}
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// EncodeCursor 把一页最后一条记录的 created_at（微秒）与排序键编码为对客户端不透明的游标。
+// 缓存与数据库使用同一编码，翻页时可以在两者之间切换。
+func EncodeCursor(createdAt time.Time, key string) string {
+	return EncodeScoreCursor(createdAt.UnixMicro(), key)
+}
+
+// EncodeScoreCursor 以整数排序值（如 SessionScore）编码游标，格式与 EncodeCursor 相同
+func EncodeScoreCursor(score int64, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", score, key)))
+}
+
+// DecodeCursor 解析 EncodeCursor 生成的游标，格式错误时返回 domain.ErrInvalidCursor
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	score, key, err := DecodeScoreCursor(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return time.UnixMicro(score), key, nil
+}
+
+// DecodeScoreCursor 解析 EncodeScoreCursor 生成的游标
+func DecodeScoreCursor(cursor string) (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", domain.ErrInvalidCursor
+	}
+	value, key, ok := strings.Cut(string(raw), ":")
+	if !ok || key == "" {
+		return 0, "", domain.ErrInvalidCursor
+	}
+	score, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, "", domain.ErrInvalidCursor
+	}
+	return score, key, nil
+}
+
+// textCursorPrefix 区分按文本（如标题）排序的游标；时间游标以数字开头，不会与之混淆
+const textCursorPrefix = "s:"
+
+// EncodeTextCursor 把一页最后一条记录的文本排序值与排序键编码为游标，key 中不能含冒号
+func EncodeTextCursor(value, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(textCursorPrefix + key + ":" + value))
+}
+
+// DecodeTextCursor 解析 EncodeTextCursor 生成的游标，格式错误时返回 domain.ErrInvalidCursor
+func DecodeTextCursor(cursor string) (string, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", domain.ErrInvalidCursor
+	}
+	rest, ok := strings.CutPrefix(string(raw), textCursorPrefix)
+	if !ok {
+		return "", "", domain.ErrInvalidCursor
+	}
+	key, value, ok := strings.Cut(rest, ":")
+	if !ok || key == "" {
+		return "", "", domain.ErrInvalidCursor
+	}
+	return value, key, nil
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CollectionModel struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement;column:id"`
+	CollectionID string         `gorm:"uniqueIndex:idx_collection_id;size:36;not null;column:collection_id"`
+	UserID       string         `gorm:"index:idx_collections_user_id;size:36;not null;column:user_id"`
+	WorkspaceID  string         `gorm:"index:idx_collections_workspace_id;size:36;not null;default:'';column:workspace_id"`
+	Name         string         `gorm:"size:255;not null;column:name"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;column:deleted_at"`
+}
+
+func (m *CollectionModel) ToDomain() *domain.Collection {
+	return &domain.Collection{
+		ID:          m.CollectionID,
+		UserID:      m.UserID,
+		WorkspaceID: m.WorkspaceID,
+		Name:        m.Name,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func ToCollectionModel(d *domain.Collection) *CollectionModel {
+	return &CollectionModel{
+		CollectionID: d.ID,
+		UserID:       d.UserID,
+		WorkspaceID:  d.WorkspaceID,
+		Name:         d.Name,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+type DocumentModel struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement;column:id"`
+	DocumentID   string         `gorm:"uniqueIndex:idx_document_id;size:36;not null;column:document_id"`
+	UserID       string         `gorm:"index:idx_documents_user_id;size:36;not null;column:user_id"`
+	WorkspaceID  string         `gorm:"index:idx_documents_workspace_id;size:36;not null;default:'';column:workspace_id"`
+	SessionID    string         `gorm:"index:idx_documents_session_id;size:36;column:session_id"`
+	CollectionID string         `gorm:"index:idx_documents_collection_id;size:36;column:collection_id"`
+	Title        string         `gorm:"size:255;not null;column:title"`
+	Format       string         `gorm:"size:20;not null;column:format"`
+	ChunkCount   int            `gorm:"not null;column:chunk_count"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;column:deleted_at"`
+}
+
+func (m *DocumentModel) ToDomain() *domain.Document {
+	return &domain.Document{
+		ID:           m.DocumentID,
+		UserID:       m.UserID,
+		WorkspaceID:  m.WorkspaceID,
+		SessionID:    m.SessionID,
+		CollectionID: m.CollectionID,
+		Title:        m.Title,
+		Format:       domain.DocumentFormat(m.Format),
+		ChunkCount:   m.ChunkCount,
+		CreatedAt:    m.CreatedAt,
+	}
+}
+
+func ToDocumentModel(d *domain.Document) *DocumentModel {
+	return &DocumentModel{
+		DocumentID:   d.ID,
+		UserID:       d.UserID,
+		WorkspaceID:  d.WorkspaceID,
+		SessionID:    d.SessionID,
+		CollectionID: d.CollectionID,
+		Title:        d.Title,
+		Format:       string(d.Format),
+		ChunkCount:   d.ChunkCount,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+// DocumentChunkModel 冗余存储 session_id / collection_id，检索时无需关联文档表
+type DocumentChunkModel struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement;column:id"`
+	ChunkID      string    `gorm:"uniqueIndex:idx_chunk_id;size:36;not null;column:chunk_id"`
+	DocumentID   string    `gorm:"index:idx_chunks_document_id;size:36;not null;column:document_id"`
+	SessionID    string    `gorm:"index:idx_chunks_session_id;size:36;column:session_id"`
+	CollectionID string    `gorm:"index:idx_chunks_collection_id;size:36;column:collection_id"`
+	ChunkIndex   int       `gorm:"not null;column:chunk_index"`
+	Title        string    `gorm:"size:255;not null;column:title"`
+	Content      string    `gorm:"serializer:encrypted;type:text;not null;column:content"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;not null;column:created_at"`
+	// WorkspaceID 与 UserID 是文档的空间与所有者，只在写入时用于确定加密范围
+	WorkspaceID string `gorm:"-"`
+	UserID      string `gorm:"-"`
+}
+
+func (m *DocumentChunkModel) ToDomain() *domain.DocumentChunk {
+	return &domain.DocumentChunk{
+		ID:         m.ChunkID,
+		DocumentID: m.DocumentID,
+		Index:      m.ChunkIndex,
+		Content:    m.Content,
+		Title:      m.Title,
+	}
+}
+
+func ToDocumentChunkModel(doc *domain.Document, c *domain.DocumentChunk) *DocumentChunkModel {
+	return &DocumentChunkModel{
+		ChunkID:      c.ID,
+		DocumentID:   doc.ID,
+		SessionID:    doc.SessionID,
+		CollectionID: doc.CollectionID,
+		ChunkIndex:   c.Index,
+		Title:        c.Title,
+		Content:      c.Content,
+		WorkspaceID:  doc.WorkspaceID,
+		UserID:       doc.UserID,
+	}
+}
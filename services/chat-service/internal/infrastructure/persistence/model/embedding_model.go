@@ -0,0 +1,94 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"free-chat/services/chat-service/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Float32Array 映射 PostgreSQL 的 real[] 列，以数组字面量 {1,2,3} 读写
+type Float32Array []float32
+
+func (a Float32Array) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, v := range a {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}
+
+func (a *Float32Array) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for real[]", src)
+	}
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return fmt.Errorf("invalid real[] literal %q", s)
+	}
+	body := s[1 : len(s)-1]
+	if body == "" {
+		*a = Float32Array{}
+		return nil
+	}
+	parts := strings.Split(body, ",")
+	out := make(Float32Array, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return fmt.Errorf("invalid real[] element %q: %w", p, err)
+		}
+		out[i] = float32(f)
+	}
+	*a = out
+	return nil
+}
+
+// MessageEmbeddingModel 消息向量，检索时按用户取出后在进程内暴力计算相似度
+type MessageEmbeddingModel struct {
+	ID        uint         `gorm:"primaryKey;autoIncrement;column:id"`
+	MessageID string       `gorm:"uniqueIndex:idx_embedding_message_id;size:36;not null;column:message_id"`
+	SessionID string       `gorm:"index:idx_embeddings_session_id;size:36;not null;column:session_id"`
+	UserID    string       `gorm:"index:idx_embeddings_user_created,priority:1;size:36;not null;column:user_id"`
+	Vector    Float32Array `gorm:"type:real[];not null;column:vector"`
+	CreatedAt time.Time    `gorm:"index:idx_embeddings_user_created,priority:2;not null;column:created_at"`
+}
+
+func (m *MessageEmbeddingModel) ToDomain() *domain.MessageEmbedding {
+	return &domain.MessageEmbedding{
+		MessageID: m.MessageID,
+		SessionID: m.SessionID,
+		UserID:    m.UserID,
+		Vector:    m.Vector,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+func ToMessageEmbeddingModel(d *domain.MessageEmbedding) *MessageEmbeddingModel {
+	return &MessageEmbeddingModel{
+		MessageID: d.MessageID,
+		SessionID: d.SessionID,
+		UserID:    d.UserID,
+		Vector:    d.Vector,
+		CreatedAt: d.CreatedAt,
+	}
+}
@@ -0,0 +1,232 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"free-chat/services/chat-service/internal/domain"
+
+	"gorm.io/gorm/schema"
+)
+
+// Cipher 加解密消息内容与会话标题，由 encryption.KeyRing 实现
+type Cipher interface {
+	Seal(ctx context.Context, scope, plaintext string) (string, error)
+	Open(ctx context.Context, value string) (string, error)
+	Stale(ctx context.Context, scope, value string) (bool, error)
+	SessionScope(ctx context.Context, sessionID, userID string) (string, error)
+	SearchIndexEnabled() bool
+	BlindIndex(ctx context.Context, scope, text string) (string, error)
+	BlindQueries(ctx context.Context, scope, query string) ([]string, error)
+}
+
+// activeCipher 在启动时设置一次，未设置时内容以明文保存
+var activeCipher Cipher
+
+// UseCipher 启用静态加密，需在读写消息与会话之前调用
+func UseCipher(c Cipher) {
+	activeCipher = c
+}
+
+// Encrypted 返回是否启用了静态加密
+func Encrypted() bool {
+	return activeCipher != nil
+}
+
+// Searchable 返回全文检索是否可用：启用加密后需要同时开启盲索引
+func Searchable() bool {
+	return activeCipher == nil || activeCipher.SearchIndexEnabled()
+}
+
+func Seal(ctx context.Context, scope, plaintext string) (string, error) {
+	if activeCipher == nil {
+		return plaintext, nil
+	}
+	return activeCipher.Seal(ctx, scope, plaintext)
+}
+
+// Open 解密 Seal 的结果，数据密钥已被粉碎时返回 domain.ErrKeyDestroyed
+func Open(ctx context.Context, value string) (string, error) {
+	if activeCipher == nil {
+		return value, nil
+	}
+	return activeCipher.Open(ctx, value)
+}
+
+// openShredded 读取时把已粉碎的数据当作空字符串
+func openShredded(ctx context.Context, value string) (string, error) {
+	plaintext, err := Open(ctx, value)
+	if errors.Is(err, domain.ErrKeyDestroyed) {
+		return "", nil
+	}
+	return plaintext, err
+}
+
+// Stale 返回值是否需要用 scope 的当前数据密钥重新加密
+func Stale(ctx context.Context, scope, value string) (bool, error) {
+	if activeCipher == nil {
+		return false, nil
+	}
+	return activeCipher.Stale(ctx, scope, value)
+}
+
+// MessageScope 返回消息使用的密钥范围，即所属会话的范围
+func MessageScope(ctx context.Context, sessionID, userID string) (string, error) {
+	if activeCipher == nil {
+		return domain.KeyScope("", userID), nil
+	}
+	return activeCipher.SessionScope(ctx, sessionID, userID)
+}
+
+// SearchIndex 返回消息内容的检索文本：未加密时为 SearchText，加密后为盲索引，未开启盲索引时为空
+func SearchIndex(ctx context.Context, scope, content string) (string, error) {
+	return searchIndex(ctx, scope, SearchText(content))
+}
+
+// SearchQueries 返回检索时需要匹配的查询文本，命中任意一个即可：
+// 加密后为每个数据密钥版本的盲查询，另加明文查询以命中尚未重新加密的旧消息
+func SearchQueries(ctx context.Context, scope, query string) ([]string, error) {
+	plain := SearchQuery(query)
+	if activeCipher == nil {
+		return []string{plain}, nil
+	}
+	queries, err := activeCipher.BlindQueries(ctx, scope, plain)
+	if err != nil {
+		return nil, err
+	}
+	return append(queries, plain), nil
+}
+
+func searchIndex(ctx context.Context, scope, text string) (string, error) {
+	switch {
+	case activeCipher == nil:
+		return text, nil
+	case !activeCipher.SearchIndexEnabled() || text == "":
+		return "", nil
+	}
+	return activeCipher.BlindIndex(ctx, scope, text)
+}
+
+// Seal 加密写入缓存的消息内容
+func (m *MessageModel) Seal(ctx context.Context) error {
+	if activeCipher == nil {
+		return nil
+	}
+	scope, err := MessageScope(ctx, m.SessionID, m.UserID)
+	if err != nil {
+		return err
+	}
+	m.Content, err = activeCipher.Seal(ctx, scope, m.Content)
+	return err
+}
+
+// Open 解密从缓存读出的消息内容
+func (m *MessageModel) Open(ctx context.Context) (err error) {
+	m.Content, err = openShredded(ctx, m.Content)
+	return err
+}
+
+// Seal 加密写入缓存的会话标题与摘要
+func (m *SessionModel) Seal(ctx context.Context) (err error) {
+	scope := domain.KeyScope(m.WorkspaceID, m.UserID)
+	if m.Title, err = Seal(ctx, scope, m.Title); err != nil {
+		return err
+	}
+	m.Preview, err = Seal(ctx, scope, m.Preview)
+	return err
+}
+
+// Open 解密从缓存读出的会话标题与摘要
+func (m *SessionModel) Open(ctx context.Context) (err error) {
+	if m.Title, err = openShredded(ctx, m.Title); err != nil {
+		return err
+	}
+	m.Preview, err = openShredded(ctx, m.Preview)
+	return err
+}
+
+func init() {
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+	schema.RegisterSerializer("searchindex", searchIndexSerializer{})
+}
+
+// encryptedSerializer 写入时用所在会话的数据密钥加密字段，读取时解密
+type encryptedSerializer struct{}
+
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	plaintext, err := openShredded(ctx, dbString(dbValue))
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	if activeCipher == nil || value == "" {
+		return value, nil
+	}
+	scope, err := rowScope(ctx, dst)
+	if err != nil {
+		return nil, err
+	}
+	return activeCipher.Seal(ctx, scope, value)
+}
+
+// searchIndexSerializer 写入时把 BeforeSave 生成的检索文本替换为盲索引，读取时原样返回
+type searchIndexSerializer struct{}
+
+func (searchIndexSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	return field.Set(ctx, dst, dbString(dbValue))
+}
+
+func (searchIndexSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	if activeCipher == nil {
+		return value, nil
+	}
+	scope, err := rowScope(ctx, dst)
+	if err != nil {
+		return nil, err
+	}
+	return searchIndex(ctx, scope, value)
+}
+
+// rowScope 返回正在写入的行所用的密钥范围
+func rowScope(ctx context.Context, dst reflect.Value) (string, error) {
+	for dst.Kind() == reflect.Ptr {
+		dst = dst.Elem()
+	}
+	switch m := dst.Interface().(type) {
+	case MessageModel:
+		return MessageScope(ctx, m.SessionID, m.UserID)
+	case SessionModel:
+		return domain.KeyScope(m.WorkspaceID, m.UserID), nil
+	case ModerationFlagModel:
+		return domain.KeyScope(m.WorkspaceID, m.UserID), nil
+	case ShareModel:
+		return MessageScope(ctx, m.SessionID, m.UserID)
+	case ShareMessageModel:
+		return MessageScope(ctx, m.SessionID, m.UserID)
+	case MemoryModel:
+		return domain.KeyScope(m.WorkspaceID, m.UserID), nil
+	case DocumentChunkModel:
+		if m.UserID == "" {
+			return "", fmt.Errorf("document chunk %s has no owner", m.ChunkID)
+		}
+		return domain.KeyScope(m.WorkspaceID, m.UserID), nil
+	}
+	return "", fmt.Errorf("no encryption scope for %s", dst.Type())
+}
+
+func dbString(dbValue interface{}) string {
+	switch v := dbValue.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/infrastructure/encryption"
+	"time"
+)
+
+// DataKeyModel 是一个范围（工作空间或个人空间）的一个数据密钥版本，只保存被主密钥包装后的密钥
+type DataKeyModel struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement;column:id"`
+	Scope       string     `gorm:"uniqueIndex:idx_data_keys_scope_version,priority:1;size:48;not null;column:scope"`
+	Version     int        `gorm:"uniqueIndex:idx_data_keys_scope_version,priority:2;not null;column:version"`
+	MasterKeyID string     `gorm:"index:idx_data_keys_master_key_id;size:64;not null;column:master_key_id"`
+	Wrapped     []byte     `gorm:"column:wrapped"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;not null;column:created_at"`
+	RetiredAt   *time.Time `gorm:"column:retired_at"`
+	DestroyedAt *time.Time `gorm:"column:destroyed_at"`
+}
+
+func (DataKeyModel) TableName() string {
+	return "data_key_models"
+}
+
+func (m *DataKeyModel) ToStoredKey() *encryption.StoredKey {
+	return &encryption.StoredKey{
+		Scope:       m.Scope,
+		Version:     m.Version,
+		MasterKeyID: m.MasterKeyID,
+		Wrapped:     m.Wrapped,
+		CreatedAt:   m.CreatedAt,
+		RetiredAt:   fromNullTime(m.RetiredAt),
+		DestroyedAt: fromNullTime(m.DestroyedAt),
+	}
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MemoryModel struct {
+	ID               uint           `gorm:"primaryKey;autoIncrement;column:id"`
+	MemoryID         string         `gorm:"uniqueIndex:idx_memory_id;size:36;not null;column:memory_id"`
+	UserID           string         `gorm:"index:idx_memories_user_id;size:36;not null;column:user_id"`
+	WorkspaceID      string         `gorm:"index:idx_memories_workspace_id;size:36;not null;default:'';column:workspace_id"`
+	Content          string         `gorm:"serializer:encrypted;type:text;not null;column:content"`
+	Category         string         `gorm:"size:20;not null;column:category"`
+	SourceSessionID  string         `gorm:"size:36;column:source_session_id"`
+	SourceMessageIDs []string       `gorm:"serializer:json;type:text;column:source_message_ids"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime;not null;column:updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index;column:deleted_at"`
+}
+
+func (m *MemoryModel) ToDomain() *domain.Memory {
+	return &domain.Memory{
+		ID:               m.MemoryID,
+		UserID:           m.UserID,
+		WorkspaceID:      m.WorkspaceID,
+		Content:          m.Content,
+		Category:         domain.MemoryCategory(m.Category),
+		SourceSessionID:  m.SourceSessionID,
+		SourceMessageIDs: m.SourceMessageIDs,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+	}
+}
+
+func ToMemoryModel(d *domain.Memory) *MemoryModel {
+	return &MemoryModel{
+		MemoryID:         d.ID,
+		UserID:           d.UserID,
+		WorkspaceID:      d.WorkspaceID,
+		Content:          d.Content,
+		Category:         string(d.Category),
+		SourceSessionID:  d.SourceSessionID,
+		SourceMessageIDs: d.SourceMessageIDs,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
+
+// MemorySettingModel 用户级记忆开关，没有记录时视为开启
+type MemorySettingModel struct {
+	UserID    string    `gorm:"primaryKey;size:36;column:user_id"`
+	Enabled   bool      `gorm:"not null;column:enabled"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;column:updated_at"`
+}
@@ -11,14 +11,24 @@ type MessageModel struct {
 	ID         uint           `gorm:"primaryKey;autoIncrement;column:id"`
 	MessageID  string         `gorm:"uniqueIndex:idx_message_id;size:36;not null;column:message_id"`
 	UserID     string         `gorm:"index:idx_user_id;size:36;not null;column:user_id"`
-	SessionID  string         `gorm:"index:idx_session_id;size:36;not null;column:session_id"`
-	Content    string         `gorm:"type:text;not null;column:content"`
+	SessionID  string         `gorm:"index:idx_session_id;index:idx_messages_session_created,priority:1;size:36;not null;column:session_id"`
+	Content    string         `gorm:"serializer:encrypted;type:text;not null;column:content"`
+	SearchText string         `gorm:"serializer:searchindex;type:text;column:search_text"`
 	Role       string         `gorm:"size:20;not null;column:role"`
 	TokenCount int            `gorm:"column:token_count;default:0"`
-	CreatedAt  time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
+	Pinned     bool           `gorm:"column:pinned;not null;default:false"`
+	Model      string         `gorm:"size:128;not null;default:'';column:model"`
+	AuthorName string         `gorm:"size:64;not null;default:'';column:author_name"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime;index:idx_messages_session_created,priority:2;not null;column:created_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index;column:deleted_at"`
 }
 
+// BeforeSave 保存前由 Content 派生全文检索文本，见 SearchText；启用加密时写入盲索引，见 searchIndexSerializer
+func (m *MessageModel) BeforeSave(tx *gorm.DB) error {
+	m.SearchText = SearchText(m.Content)
+	return nil
+}
+
 func (m *MessageModel) ToDomain() *domain.Message {
 	return &domain.Message{
 		ID:         m.MessageID,
@@ -27,6 +37,9 @@ func (m *MessageModel) ToDomain() *domain.Message {
 		Role:       domain.Role(m.Role),
 		Content:    m.Content,
 		TokenCount: m.TokenCount,
+		Pinned:     m.Pinned,
+		Model:      m.Model,
+		AuthorName: m.AuthorName,
 		CreatedAt:  m.CreatedAt,
 	}
 }
@@ -39,5 +52,9 @@ func ToMessageModel(d *domain.Message) *MessageModel {
 		Content:    d.Content,
 		Role:       d.Role.String(),
 		TokenCount: d.TokenCount,
+		Pinned:     d.Pinned,
+		Model:      d.Model,
+		AuthorName: d.AuthorName,
+		CreatedAt:  d.CreatedAt,
 	}
 }
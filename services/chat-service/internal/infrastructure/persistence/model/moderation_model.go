@@ -0,0 +1,70 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+)
+
+// ModerationFlagModel 是复核队列中的一条记录，命中的文本按所属空间的数据密钥加密
+type ModerationFlagModel struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement;column:id"`
+	FlagID      string     `gorm:"uniqueIndex:idx_moderation_flag_id;size:36;not null;column:flag_id"`
+	UserID      string     `gorm:"index:idx_moderation_flags_user_id;size:36;not null;column:user_id"`
+	WorkspaceID string     `gorm:"index:idx_moderation_flags_queue,priority:1;size:36;not null;default:'';column:workspace_id"`
+	SessionID   string     `gorm:"size:36;not null;default:'';column:session_id"`
+	MessageID   string     `gorm:"size:36;not null;default:'';column:message_id"`
+	Stage       string     `gorm:"size:20;not null;column:stage"`
+	Rule        string     `gorm:"size:100;not null;column:rule"`
+	Category    string     `gorm:"size:100;not null;column:category"`
+	Action      string     `gorm:"size:20;not null;column:action"`
+	Score       float64    `gorm:"not null;default:0;column:score"`
+	Excerpt     string     `gorm:"serializer:encrypted;type:text;not null;column:excerpt"`
+	Status      string     `gorm:"index:idx_moderation_flags_queue,priority:2;size:20;not null;column:status"`
+	ReviewedBy  string     `gorm:"size:36;not null;default:'';column:reviewed_by"`
+	ReviewedAt  *time.Time `gorm:"column:reviewed_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index:idx_moderation_flags_queue,priority:3;not null;column:created_at"`
+}
+
+func (ModerationFlagModel) TableName() string {
+	return "moderation_flag_models"
+}
+
+func (m *ModerationFlagModel) ToDomain() *domain.ModerationFlag {
+	return &domain.ModerationFlag{
+		ID:          m.FlagID,
+		UserID:      m.UserID,
+		WorkspaceID: m.WorkspaceID,
+		SessionID:   m.SessionID,
+		MessageID:   m.MessageID,
+		Stage:       domain.ModerationStage(m.Stage),
+		Rule:        m.Rule,
+		Category:    m.Category,
+		Action:      domain.ModerationAction(m.Action),
+		Score:       m.Score,
+		Excerpt:     m.Excerpt,
+		Status:      domain.FlagStatus(m.Status),
+		ReviewedBy:  m.ReviewedBy,
+		ReviewedAt:  fromNullTime(m.ReviewedAt),
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func ToModerationFlagModel(d *domain.ModerationFlag) *ModerationFlagModel {
+	return &ModerationFlagModel{
+		FlagID:      d.ID,
+		UserID:      d.UserID,
+		WorkspaceID: d.WorkspaceID,
+		SessionID:   d.SessionID,
+		MessageID:   d.MessageID,
+		Stage:       string(d.Stage),
+		Rule:        d.Rule,
+		Category:    d.Category,
+		Action:      string(d.Action),
+		Score:       d.Score,
+		Excerpt:     d.Excerpt,
+		Status:      string(d.Status),
+		ReviewedBy:  d.ReviewedBy,
+		ReviewedAt:  toNullTime(d.ReviewedAt),
+		CreatedAt:   d.CreatedAt,
+	}
+}
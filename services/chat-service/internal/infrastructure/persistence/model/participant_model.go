@@ -0,0 +1,41 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+)
+
+// ParticipantModel 是会话协作者，移除时直接删除以便之后重新邀请
+type ParticipantModel struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement;column:id"`
+	SessionID string    `gorm:"uniqueIndex:idx_participants_session_user,priority:1;size:36;not null;column:session_id"`
+	UserID    string    `gorm:"uniqueIndex:idx_participants_session_user,priority:2;index:idx_participants_user_id;size:36;not null;column:user_id"`
+	Role      string    `gorm:"size:20;not null;column:role"`
+	AddedBy   string    `gorm:"size:36;not null;default:'';column:added_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;column:created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;column:updated_at"`
+}
+
+func (ParticipantModel) TableName() string {
+	return "session_participant_models"
+}
+
+func (m *ParticipantModel) ToDomain() *domain.Participant {
+	return &domain.Participant{
+		SessionID: m.SessionID,
+		UserID:    m.UserID,
+		Role:      domain.ParticipantRole(m.Role),
+		AddedBy:   m.AddedBy,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+func ToParticipantModel(d *domain.Participant) *ParticipantModel {
+	return &ParticipantModel{
+		SessionID: d.SessionID,
+		UserID:    d.UserID,
+		Role:      string(d.Role),
+		AddedBy:   d.AddedBy,
+		CreatedAt: d.CreatedAt,
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+)
+
+// PIIAuditModel 是敏感信息检测的审计记录，只追加不修改，不保存原值
+type PIIAuditModel struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement;column:id"`
+	AuditID     string    `gorm:"uniqueIndex:idx_pii_audit_id;size:36;not null;column:audit_id"`
+	UserID      string    `gorm:"index:idx_pii_audits_user_id;size:36;not null;default:'';column:user_id"`
+	WorkspaceID string    `gorm:"size:36;not null;default:'';column:workspace_id"`
+	SessionID   string    `gorm:"size:36;not null;default:'';column:session_id"`
+	Stage       string    `gorm:"size:20;not null;column:stage"`
+	Kind        string    `gorm:"size:20;not null;column:kind"`
+	Action      string    `gorm:"size:20;not null;column:action"`
+	Count       int       `gorm:"not null;column:count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index:idx_pii_audits_created_at;not null;column:created_at"`
+}
+
+func (PIIAuditModel) TableName() string {
+	return "pii_audit_models"
+}
+
+func ToPIIAuditModel(d *domain.PIIAudit) *PIIAuditModel {
+	return &PIIAuditModel{
+		AuditID:     d.ID,
+		UserID:      d.UserID,
+		WorkspaceID: d.WorkspaceID,
+		SessionID:   d.SessionID,
+		Stage:       string(d.Stage),
+		Kind:        string(d.Kind),
+		Action:      string(d.Action),
+		Count:       d.Count,
+		CreatedAt:   d.CreatedAt,
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+)
+
+// RetentionPolicyModel 是工作空间（user_id 为空）或个人空间（workspace_id 为空）的保留策略
+type RetentionPolicyModel struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement;column:id"`
+	WorkspaceID string    `gorm:"uniqueIndex:idx_retention_policies_scope,priority:1;size:36;not null;default:'';column:workspace_id"`
+	UserID      string    `gorm:"uniqueIndex:idx_retention_policies_scope,priority:2;size:36;not null;default:'';column:user_id"`
+	MessageDays int       `gorm:"column:message_days;not null;default:0"`
+	UpdatedBy   string    `gorm:"size:36;not null;default:'';column:updated_by"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;not null;column:created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime;column:updated_at"`
+}
+
+func (RetentionPolicyModel) TableName() string {
+	return "retention_policy_models"
+}
+
+func (m *RetentionPolicyModel) ToDomain() *domain.RetentionPolicy {
+	return &domain.RetentionPolicy{
+		WorkspaceID: m.WorkspaceID,
+		UserID:      m.UserID,
+		MessageDays: m.MessageDays,
+		UpdatedBy:   m.UpdatedBy,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func ToRetentionPolicyModel(d *domain.RetentionPolicy) *RetentionPolicyModel {
+	return &RetentionPolicyModel{
+		WorkspaceID: d.WorkspaceID,
+		UserID:      d.UserID,
+		MessageDays: d.MessageDays,
+		UpdatedBy:   d.UpdatedBy,
+		UpdatedAt:   d.UpdatedAt,
+	}
+}
+
+// RetentionAuditModel 是清理任务的审计记录，只追加不修改
+type RetentionAuditModel struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement;column:id"`
+	AuditID      string    `gorm:"uniqueIndex:idx_retention_audit_id;size:36;not null;column:audit_id"`
+	RunID        string    `gorm:"index:idx_retention_audits_run_id;size:36;not null;column:run_id"`
+	Kind         string    `gorm:"size:20;not null;column:kind"`
+	Target       string    `gorm:"size:20;not null;column:target"`
+	WorkspaceID  string    `gorm:"size:36;not null;default:'';column:workspace_id"`
+	UserID       string    `gorm:"size:36;not null;default:'';column:user_id"`
+	DefaultScope bool      `gorm:"column:default_scope;not null;default:false"`
+	Cutoff       time.Time `gorm:"not null;column:cutoff"`
+	Count        int64     `gorm:"not null;column:count"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index:idx_retention_audits_created_at;not null;column:created_at"`
+}
+
+func (RetentionAuditModel) TableName() string {
+	return "retention_audit_models"
+}
+
+func ToRetentionAuditModel(d *domain.RetentionAudit) *RetentionAuditModel {
+	return &RetentionAuditModel{
+		AuditID:      d.ID,
+		RunID:        d.RunID,
+		Kind:         string(d.Kind),
+		Target:       string(d.Target),
+		WorkspaceID:  d.WorkspaceID,
+		UserID:       d.UserID,
+		DefaultScope: d.DefaultScope,
+		Cutoff:       d.Cutoff,
+		Count:        d.Count,
+		CreatedAt:    d.CreatedAt,
+	}
+}
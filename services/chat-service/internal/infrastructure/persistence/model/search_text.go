@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PostgreSQL 的 simple 配置按空白和标点分词，连续的中文会被当作一个词。
+// 写入时把中日韩文字展开为单字和相邻二元组，查询时同样切分，
+// 这样不依赖中文分词扩展也能用 to_tsvector('simple', ...) 检索。
+
+// SearchText 生成用于建立索引的检索文本：其他文字保持原样，中日韩文字同时输出单字与二元组
+func SearchText(content string) string {
+	return expandCJK(content, true)
+}
+
+// SearchQuery 生成与 SearchText 对应的查询文本：单个汉字匹配单字，两个及以上匹配二元组
+func SearchQuery(query string) string {
+	return expandCJK(query, false)
+}
+
+func expandCJK(text string, withUnigrams bool) string {
+	var sb strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		sb.WriteByte(' ')
+		if len(run) == 1 || withUnigrams {
+			for _, r := range run {
+				sb.WriteRune(r)
+				sb.WriteByte(' ')
+			}
+		}
+		for i := 0; i+1 < len(run); i++ {
+			sb.WriteString(string(run[i : i+2]))
+			sb.WriteByte(' ')
+		}
+		run = run[:0]
+	}
+	for _, r := range text {
+		if isCJK(r) {
+			run = append(run, r)
+			continue
+		}
+		flush()
+		sb.WriteRune(r)
+	}
+	flush()
+	return strings.TrimSpace(sb.String())
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
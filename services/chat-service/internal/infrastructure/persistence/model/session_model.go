@@ -7,33 +7,147 @@ import (
 	"gorm.io/gorm"
 )
 
+// SessionModel 同时是会话缓存的 JSON 格式。缓存中的 JSON 会被 Lua 脚本原地修改，
+// 而 cjson 会把空数组重新编码为 {}，因此 Tags 为空时不写入 JSON。
+// 启用静态加密时标题与摘要在数据库与缓存中都是密文，缓存读写见 Seal/Open。
 type SessionModel struct {
-	ID        uint           `gorm:"primaryKey;autoIncrement;column:id"`
-	SessionID string         `gorm:"uniqueIndex:idx_session_id;size:36;not null;column:session_id"`
-	UserID    string         `gorm:"index:idx_user_id;size:36;not null;column:user_id"`
-	Title     string         `gorm:"type:text;not null;column:title"`
-	CreatedAt time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index;column:deleted_at"`
+	ID            uint           `gorm:"primaryKey;autoIncrement;column:id"`
+	SessionID     string         `gorm:"uniqueIndex:idx_session_id;size:36;not null;column:session_id"`
+	UserID        string         `gorm:"index:idx_user_id;index:idx_sessions_user_created,priority:1;index:idx_sessions_user_activity,priority:1;index:idx_sessions_user_title,priority:1;size:36;not null;column:user_id"`
+	WorkspaceID   string         `gorm:"index:idx_sessions_workspace_id;size:36;not null;default:'';column:workspace_id"`
+	Title         string         `gorm:"serializer:encrypted;type:text;not null;index:idx_sessions_user_title,priority:2;column:title"`
+	MessageCount  int            `gorm:"column:message_count;not null;default:0"`
+	Preview       string         `gorm:"serializer:encrypted;type:text;not null;default:'';column:preview"`
+	Pinned        bool           `gorm:"column:pinned;not null;default:false"`
+	Archived      bool           `gorm:"column:archived;not null;default:false"`
+	FolderID      string         `gorm:"index:idx_sessions_folder_id;size:36;not null;default:'';column:folder_id"`
+	Tags          []string       `gorm:"serializer:json;type:jsonb;index:idx_sessions_tags,type:gin;column:tags" json:",omitempty"`
+	ForkedFrom    string         `gorm:"index:idx_sessions_forked_from;size:36;not null;default:'';column:forked_from"`
+	ForkMessageID string         `gorm:"size:36;not null;default:'';column:fork_message_id"`
+	ImportedFrom  string         `gorm:"index:idx_sessions_imported_from;size:160;not null;default:'';column:imported_from"`
+	LastMessageAt time.Time      `gorm:"index:idx_sessions_user_activity,priority:2;column:last_message_at"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime;index:idx_sessions_user_created,priority:2;not null;column:created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime;column:updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index;column:deleted_at"`
+	// Ephemeral 只出现在缓存 JSON 中，无痕会话不写入数据库
+	Ephemeral bool `gorm:"-" json:",omitempty"`
 }
 
+// BeforeSave 新会话还没有消息，最后活动时间取创建时间
+func (m *SessionModel) BeforeSave(tx *gorm.DB) error {
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	if m.LastMessageAt.IsZero() {
+		m.LastMessageAt = m.CreatedAt
+	}
+	return nil
+}
+
+// ToDomain 转换为领域对象，缺少活动字段的旧缓存数据以创建时间补齐
 func (m *SessionModel) ToDomain() *domain.Session {
-	return &domain.Session{
-		ID:        m.SessionID,
-		UserID:    m.UserID,
-		Title:     m.Title,
-		CreatedAt: m.CreatedAt,
+	s := &domain.Session{
+		ID:            m.SessionID,
+		UserID:        m.UserID,
+		WorkspaceID:   m.WorkspaceID,
+		Title:         m.Title,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		LastMessageAt: m.LastMessageAt,
+		MessageCount:  m.MessageCount,
+		Preview:       m.Preview,
+		Pinned:        m.Pinned,
+		Archived:      m.Archived,
+		FolderID:      m.FolderID,
+		Tags:          m.Tags,
+		ForkedFrom:    m.ForkedFrom,
+		ForkMessageID: m.ForkMessageID,
+		ImportedFrom:  m.ImportedFrom,
+		Ephemeral:     m.Ephemeral,
+	}
+	if s.LastMessageAt.IsZero() {
+		s.LastMessageAt = s.CreatedAt
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = s.LastMessageAt
 	}
+	return s
 }
 
 func ToSessionModel(d *domain.Session) *SessionModel {
 	return &SessionModel{
-		SessionID: d.ID,
-		UserID:    d.UserID,
-		Title:     d.Title,
-		CreatedAt: d.CreatedAt,
+		SessionID:     d.ID,
+		UserID:        d.UserID,
+		WorkspaceID:   d.WorkspaceID,
+		Title:         d.Title,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+		LastMessageAt: d.LastMessageAt,
+		MessageCount:  d.MessageCount,
+		Preview:       d.Preview,
+		Pinned:        d.Pinned,
+		Archived:      d.Archived,
+		FolderID:      d.FolderID,
+		Tags:          d.Tags,
+		ForkedFrom:    d.ForkedFrom,
+		ForkMessageID: d.ForkMessageID,
+		ImportedFrom:  d.ImportedFrom,
+		Ephemeral:     d.Ephemeral,
 	}
 }
 
 func (SessionModel) TableName() string {
 	return "session_models"
 }
+
+// PinnedScoreBoost 置顶会话排序分值的加成（2^52 微秒，约 142 年），
+// 使置顶会话总排在前面，同时分值仍小于 2^53，可以被 float64 精确表示
+const PinnedScoreBoost = int64(1) << 52
+
+// SessionScore 把置顶状态与时间合成为一个排序分值，
+// 既是 Redis 用户会话集合的分数，也是按时间排序时分页游标中的排序值
+func SessionScore(pinned bool, at time.Time) int64 {
+	score := at.UnixMicro()
+	if pinned {
+		score += PinnedScoreBoost
+	}
+	return score
+}
+
+// SplitSessionScore 是 SessionScore 的逆运算
+func SplitSessionScore(score int64) (bool, time.Time) {
+	if score >= PinnedScoreBoost {
+		return true, time.UnixMicro(score - PinnedScoreBoost)
+	}
+	return false, time.UnixMicro(score)
+}
+
+type FolderModel struct {
+	ID          uint           `gorm:"primaryKey;autoIncrement;column:id"`
+	FolderID    string         `gorm:"uniqueIndex:idx_folder_id;size:36;not null;column:folder_id"`
+	UserID      string         `gorm:"index:idx_folders_user_id;size:36;not null;column:user_id"`
+	WorkspaceID string         `gorm:"index:idx_folders_workspace_id;size:36;not null;default:'';column:workspace_id"`
+	Name        string         `gorm:"size:255;not null;column:name"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;column:deleted_at"`
+}
+
+func (m *FolderModel) ToDomain() *domain.Folder {
+	return &domain.Folder{
+		ID:          m.FolderID,
+		UserID:      m.UserID,
+		WorkspaceID: m.WorkspaceID,
+		Name:        m.Name,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func ToFolderModel(d *domain.Folder) *FolderModel {
+	return &FolderModel{
+		FolderID:    d.ID,
+		UserID:      d.UserID,
+		WorkspaceID: d.WorkspaceID,
+		Name:        d.Name,
+		CreatedAt:   d.CreatedAt,
+	}
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"free-chat/services/chat-service/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShareModel struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement;column:id"`
+	ShareID       string         `gorm:"uniqueIndex:idx_share_id;size:36;not null;column:share_id"`
+	UserID        string         `gorm:"index:idx_shares_user_id;size:36;not null;column:user_id"`
+	SessionID     string         `gorm:"index:idx_shares_session_id;size:36;not null;column:session_id"`
+	TokenHash     string         `gorm:"uniqueIndex:idx_shares_token_hash;size:64;not null;column:token_hash"`
+	Title         string         `gorm:"serializer:encrypted;type:text;not null;column:title"`
+	IncludeFuture bool           `gorm:"column:include_future;not null;default:false"`
+	MessageCount  int            `gorm:"column:message_count;not null;default:0"`
+	LastMessageID string         `gorm:"size:36;not null;default:'';column:last_message_id"`
+	LastMessageAt *time.Time     `gorm:"column:last_message_at"`
+	ExpiresAt     *time.Time     `gorm:"column:expires_at"`
+	RevokedAt     *time.Time     `gorm:"column:revoked_at"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime;not null;column:created_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index;column:deleted_at"`
+}
+
+func (m *ShareModel) ToDomain() *domain.Share {
+	return &domain.Share{
+		ID:            m.ShareID,
+		UserID:        m.UserID,
+		SessionID:     m.SessionID,
+		TokenHash:     m.TokenHash,
+		Title:         m.Title,
+		IncludeFuture: m.IncludeFuture,
+		MessageCount:  m.MessageCount,
+		LastMessageID: m.LastMessageID,
+		LastMessageAt: fromNullTime(m.LastMessageAt),
+		ExpiresAt:     fromNullTime(m.ExpiresAt),
+		RevokedAt:     fromNullTime(m.RevokedAt),
+		CreatedAt:     m.CreatedAt,
+	}
+}
+
+func ToShareModel(d *domain.Share) *ShareModel {
+	return &ShareModel{
+		ShareID:       d.ID,
+		UserID:        d.UserID,
+		SessionID:     d.SessionID,
+		TokenHash:     d.TokenHash,
+		Title:         d.Title,
+		IncludeFuture: d.IncludeFuture,
+		MessageCount:  d.MessageCount,
+		LastMessageID: d.LastMessageID,
+		LastMessageAt: toNullTime(d.LastMessageAt),
+		ExpiresAt:     toNullTime(d.ExpiresAt),
+		RevokedAt:     toNullTime(d.RevokedAt),
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+func (ShareModel) TableName() string {
+	return "share_models"
+}
+
+// ShareMessageModel 是分享创建时复制的消息快照，与原消息相互独立
+type ShareMessageModel struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement;column:id"`
+	ShareID   string    `gorm:"uniqueIndex:idx_share_messages_position,priority:1;size:36;not null;column:share_id"`
+	Position  int       `gorm:"uniqueIndex:idx_share_messages_position,priority:2;not null;column:position"`
+	MessageID string    `gorm:"size:36;not null;column:message_id"`
+	Role      string    `gorm:"size:20;not null;column:role"`
+	Content   string    `gorm:"serializer:encrypted;type:text;not null;column:content"`
+	Model     string    `gorm:"size:128;not null;default:'';column:model"`
+	CreatedAt time.Time `gorm:"not null;column:created_at"`
+	// SessionID 与 UserID 是分享所属的会话与创建者，只在写入时用于确定加密范围
+	SessionID string `gorm:"-"`
+	UserID    string `gorm:"-"`
+}
+
+func (m *ShareMessageModel) ToDomain() *domain.Message {
+	return &domain.Message{
+		ID:        m.MessageID,
+		Role:      domain.Role(m.Role),
+		Content:   m.Content,
+		Model:     m.Model,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+func ToShareMessageModel(s *domain.Share, position int, d *domain.Message) *ShareMessageModel {
+	return &ShareMessageModel{
+		ShareID:   s.ID,
+		Position:  position,
+		MessageID: d.ID,
+		Role:      d.Role.String(),
+		Content:   d.Content,
+		Model:     d.Model,
+		CreatedAt: d.CreatedAt,
+		SessionID: s.SessionID,
+		UserID:    s.UserID,
+	}
+}
+
+func (ShareMessageModel) TableName() string {
+	return "share_message_models"
+}
+
+func toNullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func fromNullTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+func (r *AccountRepository) SaveExport(ctx context.Context, export *domain.AccountExport) error {
+	if err := r.db.Create(model.ToAccountExportModel(export)).Error; err != nil {
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+	return nil
+}
+
+func (r *AccountRepository) CompleteExport(ctx context.Context, export *domain.AccountExport) error {
+	m := model.ToAccountExportModel(export)
+	if err := r.db.Model(&model.AccountExportModel{}).Where("export_id = ?", export.ID).
+		Updates(map[string]interface{}{
+			"status":       m.Status,
+			"size":         m.Size,
+			"error":        m.Error,
+			"completed_at": m.CompletedAt,
+			"expires_at":   m.ExpiresAt,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+	return nil
+}
+
+func (r *AccountRepository) GetExport(ctx context.Context, exportID string) (*domain.AccountExport, error) {
+	var m model.AccountExportModel
+	if err := r.db.Where("export_id = ?", exportID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to find export: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *AccountRepository) DeleteExports(ctx context.Context, userID string) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&model.AccountExportModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete exports: %w", err)
+	}
+	return nil
+}
+
+func (r *AccountRepository) DeleteExpiredExports(ctx context.Context, now, createdBefore time.Time) ([]*domain.AccountExport, error) {
+	var models []*model.AccountExportModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ? OR (expires_at IS NULL AND created_at < ?)", now, createdBefore).
+			Clauses(skipLocked).Find(&models).Error; err != nil {
+			return fmt.Errorf("failed to find expired exports: %w", err)
+		}
+		if len(models) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(models))
+		for i, m := range models {
+			ids[i] = m.ID
+		}
+		if err := tx.Delete(&model.AccountExportModel{}, ids).Error; err != nil {
+			return fmt.Errorf("failed to delete expired exports: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	exports := make([]*domain.AccountExport, len(models))
+	for i, m := range models {
+		exports[i] = m.ToDomain()
+	}
+	return exports, nil
+}
+
+func (r *AccountRepository) ListUserSessions(ctx context.Context, userID, afterID string, limit int) ([]*domain.Session, error) {
+	var models []*model.SessionModel
+	if err := r.db.Where("user_id = ? AND session_id > ?", userID, afterID).
+		Order("session_id asc").Limit(limit).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list user sessions: %w", err)
+	}
+	sessions := make([]*domain.Session, len(models))
+	for i, m := range models {
+		sessions[i] = m.ToDomain()
+	}
+	return sessions, nil
+}
+
+func (r *AccountRepository) GetErasure(ctx context.Context, userID string) (*domain.AccountErasure, error) {
+	var m model.AccountErasureModel
+	if err := r.db.Where("user_id = ?", userID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find erasure: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *AccountRepository) CreateErasure(ctx context.Context, erasure *domain.AccountErasure) (*domain.AccountErasure, error) {
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(model.ToAccountErasureModel(erasure)).Error; err != nil {
+		return nil, fmt.Errorf("failed to create erasure: %w", err)
+	}
+	return r.GetErasure(ctx, erasure.UserID)
+}
+
+func (r *AccountRepository) SaveErasure(ctx context.Context, erasure *domain.AccountErasure) error {
+	m := model.ToAccountErasureModel(erasure)
+	if err := r.db.Model(&model.AccountErasureModel{}).Where("erasure_id = ?", erasure.ID).
+		Select("done", "purged", "last_error", "completed_at").
+		Updates(m).Error; err != nil {
+		return fmt.Errorf("failed to save erasure: %w", err)
+	}
+	return nil
+}
+
+func (r *AccountRepository) ListPendingErasures(ctx context.Context) ([]*domain.AccountErasure, error) {
+	var models []*model.AccountErasureModel
+	if err := r.db.Where("completed_at IS NULL").Order("id asc").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list erasures: %w", err)
+	}
+	erasures := make([]*domain.AccountErasure, len(models))
+	for i, m := range models {
+		erasures[i] = m.ToDomain()
+	}
+	return erasures, nil
+}
+
+func (r *AccountRepository) IsErased(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.AccountErasureModel{}).Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check erasure: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *AccountRepository) EraseMessages(ctx context.Context, userID string, limit int) ([]*domain.Message, error) {
+	var batch []*model.MessageModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&model.MessageModel{}).
+			Where("user_id = ? OR session_id IN (?)", userID, userSessions(tx, userID)).
+			Select("id", "message_id", "session_id", "user_id", "created_at").
+			Order("id asc").Limit(limit).Clauses(skipLocked).
+			Find(&batch).Error; err != nil {
+			return fmt.Errorf("failed to find user messages: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(batch))
+		messageIDs := make([]string, len(batch))
+		for i, m := range batch {
+			ids[i], messageIDs[i] = m.ID, m.MessageID
+		}
+		if err := tx.Where("message_id IN ?", messageIDs).Delete(&model.MessageEmbeddingModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete message embeddings: %w", err)
+		}
+		// 他人会话的分享快照中也可能包含用户的消息
+		if err := tx.Where("message_id IN ?", messageIDs).Delete(&model.ShareMessageModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete share messages: %w", err)
+		}
+		if err := tx.Unscoped().Delete(&model.MessageModel{}, ids).Error; err != nil {
+			return fmt.Errorf("failed to delete user messages: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*domain.Message, len(batch))
+	for i, m := range batch {
+		messages[i] = m.ToDomain()
+	}
+	return messages, nil
+}
+
+func (r *AccountRepository) EraseSessions(ctx context.Context, userID string, limit int) ([]*domain.Session, error) {
+	var batch []*model.SessionModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).
+			Order("id asc").Limit(limit).Clauses(skipLocked).
+			Find(&batch).Error; err != nil {
+			return fmt.Errorf("failed to find user sessions: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(batch))
+		sessionIDs := make([]string, len(batch))
+		for i, m := range batch {
+			ids[i], sessionIDs[i] = m.ID, m.SessionID
+		}
+		// 会话的协作者、分享、向量与敏感信息审计（包括协作者消息的）随会话一起删除
+		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&model.ParticipantModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete participants: %w", err)
+		}
+		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&model.PIIAuditModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete pii audits: %w", err)
+		}
+		shares := tx.Unscoped().Model(&model.ShareModel{}).Select("share_id").Where("session_id IN ?", sessionIDs)
+		if err := tx.Where("share_id IN (?)", shares).Delete(&model.ShareMessageModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete share messages: %w", err)
+		}
+		if err := tx.Unscoped().Where("session_id IN ?", sessionIDs).Delete(&model.ShareModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete shares: %w", err)
+		}
+		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&model.MessageEmbeddingModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete session embeddings: %w", err)
+		}
+		if err := tx.Unscoped().Delete(&model.SessionModel{}, ids).Error; err != nil {
+			return fmt.Errorf("failed to delete user sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*domain.Session, len(batch))
+	for i, m := range batch {
+		sessions[i] = m.ToDomain()
+	}
+	return sessions, nil
+}
+
+func (r *AccountRepository) EraseRows(ctx context.Context, userID string, target domain.ErasureTarget, limit int) (int64, error) {
+	var erased int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch target {
+		case domain.EraseParticipants:
+			erased, err = eraseBatch(tx, &model.ParticipantModel{}, userID, limit, nil)
+		case domain.EraseShares:
+			erased, err = eraseBatch(tx, &model.ShareModel{}, userID, limit, func(ids []uint) error {
+				return tx.Where("share_id IN (?)",
+					tx.Unscoped().Model(&model.ShareModel{}).Select("share_id").Where("id IN ?", ids)).
+					Delete(&model.ShareMessageModel{}).Error
+			})
+		case domain.EraseFolders:
+			erased, err = eraseBatch(tx, &model.FolderModel{}, userID, limit, nil)
+		case domain.EraseMemories:
+			erased, err = eraseBatch(tx, &model.MemoryModel{}, userID, limit, nil)
+		case domain.EraseDocuments:
+			erased, err = eraseBatch(tx, &model.DocumentModel{}, userID, limit, func(ids []uint) error {
+				return tx.Where("document_id IN (?)",
+					tx.Unscoped().Model(&model.DocumentModel{}).Select("document_id").Where("id IN ?", ids)).
+					Delete(&model.DocumentChunkModel{}).Error
+			})
+		case domain.EraseCollections:
+			erased, err = eraseBatch(tx, &model.CollectionModel{}, userID, limit, func(ids []uint) error {
+				return tx.Where("collection_id IN (?)",
+					tx.Unscoped().Model(&model.CollectionModel{}).Select("collection_id").Where("id IN ?", ids)).
+					Delete(&model.DocumentChunkModel{}).Error
+			})
+		case domain.EraseEmbeddings:
+			erased, err = eraseBatch(tx, &model.MessageEmbeddingModel{}, userID, limit, nil)
+		case domain.EraseSettings:
+			// 每个用户至多各一条，不需要分批
+			res := tx.Where("user_id = ?", userID).Delete(&model.MemorySettingModel{})
+			if err = res.Error; err == nil {
+				erased = res.RowsAffected
+				res = tx.Where("workspace_id = '' AND user_id = ?", userID).Delete(&model.RetentionPolicyModel{})
+				err, erased = res.Error, erased+res.RowsAffected
+			}
+		case domain.EraseExports:
+			erased, err = eraseBatch(tx, &model.AccountExportModel{}, userID, limit, nil)
+		case domain.EraseFlags:
+			erased, err = eraseBatch(tx, &model.ModerationFlagModel{}, userID, limit, nil)
+		case domain.ErasePIIAudits:
+			erased, err = eraseBatch(tx, &model.PIIAuditModel{}, userID, limit, nil)
+		default:
+			return fmt.Errorf("unknown erasure target %q", target)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to erase %s: %w", target, err)
+		}
+		return nil
+	})
+	return erased, err
+}
+
+// eraseBatch 彻底删除 m 所在表中一批属于用户的行，before 在删除前清理附属数据
+func eraseBatch(tx *gorm.DB, m interface{}, userID string, limit int, before func(ids []uint) error) (int64, error) {
+	var ids []uint
+	if err := tx.Unscoped().Model(m).Where("user_id = ?", userID).
+		Order("id asc").Limit(limit).Clauses(skipLocked).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if before != nil {
+		if err := before(ids); err != nil {
+			return 0, err
+		}
+	}
+	res := tx.Unscoped().Delete(m, ids)
+	return res.RowsAffected, res.Error
+}
+
+// userSessions 是用户全部会话（含已软删除的）的 session_id 子查询
+func userSessions(db *gorm.DB, userID string) *gorm.DB {
+	return db.Unscoped().Model(&model.SessionModel{}).Select("session_id").Where("user_id = ?", userID)
+}
+
+var _ domain.AccountRepository = (*AccountRepository)(nil)
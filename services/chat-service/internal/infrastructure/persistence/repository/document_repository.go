@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// DocumentRepository 直接实现 domain.DocumentRepository，切片只在检索时整体读取，不经过缓存
+type DocumentRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentRepository(db *gorm.DB) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+func (r *DocumentRepository) SaveDocument(ctx context.Context, doc *domain.Document, chunks []*domain.DocumentChunk) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model.ToDocumentModel(doc)).Error; err != nil {
+			return fmt.Errorf("failed to create document: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		models := make([]*model.DocumentChunkModel, len(chunks))
+		for i, c := range chunks {
+			models[i] = model.ToDocumentChunkModel(doc, c)
+		}
+		if err := tx.CreateInBatches(models, 100).Error; err != nil {
+			return fmt.Errorf("failed to create document chunks: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *DocumentRepository) GetDocument(ctx context.Context, documentID string) (*domain.Document, error) {
+	var m model.DocumentModel
+	if err := r.db.Where("document_id = ?", documentID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find document: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *DocumentRepository) ListDocuments(ctx context.Context, userID, workspaceID, sessionID, collectionID string) ([]*domain.Document, error) {
+	query := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID)
+	if sessionID != "" {
+		query = query.Where("session_id = ?", sessionID)
+	}
+	if collectionID != "" {
+		query = query.Where("collection_id = ?", collectionID)
+	}
+	var models []*model.DocumentModel
+	if err := query.Order("created_at desc").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	docs := make([]*domain.Document, len(models))
+	for i, m := range models {
+		docs[i] = m.ToDomain()
+	}
+	return docs, nil
+}
+
+func (r *DocumentRepository) DeleteDocument(ctx context.Context, documentID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", documentID).Delete(&model.DocumentChunkModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete document chunks: %w", err)
+		}
+		if err := tx.Where("document_id = ?", documentID).Delete(&model.DocumentModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete document: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *DocumentRepository) GetChunks(ctx context.Context, sessionID string, collectionIDs []string, limit int) ([]*domain.DocumentChunk, error) {
+	if sessionID == "" && len(collectionIDs) == 0 {
+		return nil, nil
+	}
+	query := r.db.Model(&model.DocumentChunkModel{})
+	switch {
+	case sessionID != "" && len(collectionIDs) > 0:
+		query = query.Where("session_id = ? OR collection_id IN ?", sessionID, collectionIDs)
+	case sessionID != "":
+		query = query.Where("session_id = ?", sessionID)
+	default:
+		query = query.Where("collection_id IN ?", collectionIDs)
+	}
+	var models []*model.DocumentChunkModel
+	if err := query.Order("document_id, chunk_index").Limit(limit).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find document chunks: %w", err)
+	}
+	chunks := make([]*domain.DocumentChunk, len(models))
+	for i, m := range models {
+		chunks[i] = m.ToDomain()
+	}
+	return chunks, nil
+}
+
+func (r *DocumentRepository) SaveCollection(ctx context.Context, collection *domain.Collection) error {
+	if err := r.db.Create(model.ToCollectionModel(collection)).Error; err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+func (r *DocumentRepository) GetCollection(ctx context.Context, collectionID string) (*domain.Collection, error) {
+	var m model.CollectionModel
+	if err := r.db.Where("collection_id = ?", collectionID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find collection: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *DocumentRepository) ListCollections(ctx context.Context, userID, workspaceID string) ([]*domain.Collection, error) {
+	var models []*model.CollectionModel
+	if err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+		Order("created_at desc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	collections := make([]*domain.Collection, len(models))
+	for i, m := range models {
+		collections[i] = m.ToDomain()
+	}
+	return collections, nil
+}
+
+func (r *DocumentRepository) DeleteCollection(ctx context.Context, collectionID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collectionID).Delete(&model.DocumentChunkModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete collection chunks: %w", err)
+		}
+		if err := tx.Where("collection_id = ?", collectionID).Delete(&model.DocumentModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete collection documents: %w", err)
+		}
+		if err := tx.Where("collection_id = ?", collectionID).Delete(&model.CollectionModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete collection: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmbeddingRepository 直接实现 domain.EmbeddingRepository
+type EmbeddingRepository struct {
+	db *gorm.DB
+}
+
+func NewEmbeddingRepository(db *gorm.DB) *EmbeddingRepository {
+	return &EmbeddingRepository{db: db}
+}
+
+func (r *EmbeddingRepository) SaveEmbeddings(ctx context.Context, embeddings []*domain.MessageEmbedding) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+	models := make([]*model.MessageEmbeddingModel, len(embeddings))
+	for i, e := range embeddings {
+		models[i] = model.ToMessageEmbeddingModel(e)
+	}
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vector"}),
+	}).Create(&models).Error; err != nil {
+		return fmt.Errorf("failed to save embeddings: %w", err)
+	}
+	return nil
+}
+
+// ListEmbeddings 向量表不记录工作空间，按所属会话的工作空间过滤
+func (r *EmbeddingRepository) ListEmbeddings(ctx context.Context, userID, workspaceID string, limit int) ([]*domain.MessageEmbedding, error) {
+	sessions := r.db.Model(&model.SessionModel{}).Select("session_id").Where("workspace_id = ?", workspaceID)
+	var models []*model.MessageEmbeddingModel
+	if err := r.db.Where("user_id = ? AND session_id IN (?)", userID, sessions).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list embeddings: %w", err)
+	}
+	embeddings := make([]*domain.MessageEmbedding, len(models))
+	for i, m := range models {
+		embeddings[i] = m.ToDomain()
+	}
+	return embeddings, nil
+}
+
+func (r *EmbeddingRepository) DeleteSessionEmbeddings(ctx context.Context, sessionID string) error {
+	if err := r.db.Where("session_id = ?", sessionID).Delete(&model.MessageEmbeddingModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// EncryptionRepository 按 id 顺序扫描原始列（不经过加密序列化器），把明文、旧版本密钥或其他范围的密文
+// 用当前数据密钥重新加密。已软删除的行同样处理；数据密钥已粉碎的行无法解密，跳过
+type EncryptionRepository struct {
+	db *gorm.DB
+}
+
+func NewEncryptionRepository(db *gorm.DB) *EncryptionRepository {
+	return &EncryptionRepository{db: db}
+}
+
+func (r *EncryptionRepository) Reencrypt(ctx context.Context, target domain.EncryptTarget, afterID uint, limit int) (uint, int, error) {
+	switch target {
+	case domain.EncryptMessages:
+		return r.reencryptMessages(ctx, afterID, limit)
+	case domain.EncryptSessions:
+		return r.reencryptSessions(ctx, afterID, limit)
+	case domain.EncryptShares:
+		return r.reencryptShares(ctx, afterID, limit)
+	case domain.EncryptMemories:
+		return r.reencryptColumn(ctx, r.db.Table("memory_models").
+			Select("id, workspace_id, user_id, content AS value"), "memory_models", "content", afterID, limit, workspaceScope)
+	case domain.EncryptDocuments:
+		// 分块没有所有者列，从所属文档取得
+		return r.reencryptColumn(ctx, r.db.Table("document_chunk_models").
+			Joins("JOIN document_models ON document_models.document_id = document_chunk_models.document_id").
+			Select("document_chunk_models.id, document_models.workspace_id, document_models.user_id, document_chunk_models.content AS value"),
+			"document_chunk_models", "content", afterID, limit, workspaceScope)
+	}
+	return afterID, 0, fmt.Errorf("unknown encrypt target %q", target)
+}
+
+func (r *EncryptionRepository) reencryptMessages(ctx context.Context, afterID uint, limit int) (uint, int, error) {
+	var rows []struct {
+		ID         uint
+		SessionID  string
+		UserID     string
+		Content    string
+		SearchText string
+	}
+	if err := r.db.Table("message_models").
+		Select("id, session_id, user_id, content, COALESCE(search_text, '') AS search_text").
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return afterID, 0, fmt.Errorf("failed to scan messages to reencrypt: %w", err)
+	}
+
+	rewritten := 0
+	for _, row := range rows {
+		afterID = row.ID
+		scope, err := model.MessageScope(ctx, row.SessionID, row.UserID)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		stale, err := model.Stale(ctx, scope, row.Content)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		// 开启盲索引之前加密的消息没有检索文本
+		if !stale && !(model.Searchable() && row.SearchText == "" && row.Content != "") {
+			continue
+		}
+		plaintext, err := model.Open(ctx, row.Content)
+		if errors.Is(err, domain.ErrKeyDestroyed) {
+			continue
+		}
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		content, err := model.Seal(ctx, scope, plaintext)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		searchText, err := model.SearchIndex(ctx, scope, plaintext)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		// 只在内容未被并发修改时替换
+		result := r.db.Table("message_models").
+			Where("id = ? AND content = ?", row.ID, row.Content).
+			UpdateColumns(map[string]interface{}{"content": content, "search_text": searchText})
+		if result.Error != nil {
+			return afterID, rewritten, fmt.Errorf("failed to reencrypt message: %w", result.Error)
+		}
+		rewritten += int(result.RowsAffected)
+	}
+	return afterID, rewritten, nil
+}
+
+func (r *EncryptionRepository) reencryptSessions(ctx context.Context, afterID uint, limit int) (uint, int, error) {
+	var rows []struct {
+		ID          uint
+		WorkspaceID string
+		UserID      string
+		Title       string
+		Preview     string
+	}
+	if err := r.db.Table("session_models").
+		Select("id, workspace_id, user_id, title, preview").
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return afterID, 0, fmt.Errorf("failed to scan sessions to reencrypt: %w", err)
+	}
+
+	rewritten := 0
+	for _, row := range rows {
+		afterID = row.ID
+		scope := domain.KeyScope(row.WorkspaceID, row.UserID)
+		columns := make(map[string]interface{}, 2)
+		for column, value := range map[string]string{"title": row.Title, "preview": row.Preview} {
+			sealed, err := r.reseal(ctx, scope, value)
+			if errors.Is(err, domain.ErrKeyDestroyed) {
+				columns = nil
+				break
+			}
+			if err != nil {
+				return afterID, rewritten, err
+			}
+			if sealed != value {
+				columns[column] = sealed
+			}
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		result := r.db.Table("session_models").
+			Where("id = ? AND title = ? AND preview = ?", row.ID, row.Title, row.Preview).
+			UpdateColumns(columns)
+		if result.Error != nil {
+			return afterID, rewritten, fmt.Errorf("failed to reencrypt session: %w", result.Error)
+		}
+		rewritten += int(result.RowsAffected)
+	}
+	return afterID, rewritten, nil
+}
+
+// reencryptShares 依次处理分享标题与消息快照。两张表的 id 互不相关，消息快照以分享的 id 为游标逐个分享处理
+func (r *EncryptionRepository) reencryptShares(ctx context.Context, afterID uint, limit int) (uint, int, error) {
+	lastID, rewritten, err := r.reencryptColumn(ctx, r.db.Table("share_models").
+		Select("id, session_id, user_id, title AS value"), "share_models", "title", afterID, limit, sessionScope)
+	if err != nil || lastID == afterID {
+		return lastID, rewritten, err
+	}
+	var shares []struct {
+		ShareID   string
+		SessionID string
+		UserID    string
+	}
+	if err := r.db.Table("share_models").Select("share_id, session_id, user_id").
+		Where("id > ? AND id <= ?", afterID, lastID).Scan(&shares).Error; err != nil {
+		return afterID, rewritten, fmt.Errorf("failed to scan shares to reencrypt: %w", err)
+	}
+	for _, share := range shares {
+		scope, err := model.MessageScope(ctx, share.SessionID, share.UserID)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		var rows []struct {
+			ID      uint
+			Content string
+		}
+		if err := r.db.Table("share_message_models").Select("id, content").
+			Where("share_id = ?", share.ShareID).Order("id asc").Scan(&rows).Error; err != nil {
+			return afterID, rewritten, fmt.Errorf("failed to scan share messages to reencrypt: %w", err)
+		}
+		for _, row := range rows {
+			n, err := r.rewrite(ctx, "share_message_models", "content", row.ID, scope, row.Content)
+			if err != nil {
+				return afterID, rewritten, err
+			}
+			rewritten += n
+		}
+	}
+	return lastID, rewritten, nil
+}
+
+// encryptedRow 是 reencryptColumn 扫描的一行：一个加密列及确定加密范围所需的列
+type encryptedRow struct {
+	ID          uint
+	WorkspaceID string
+	SessionID   string
+	UserID      string
+	Value       string
+}
+
+func workspaceScope(_ context.Context, row encryptedRow) (string, error) {
+	return domain.KeyScope(row.WorkspaceID, row.UserID), nil
+}
+
+func sessionScope(ctx context.Context, row encryptedRow) (string, error) {
+	return model.MessageScope(ctx, row.SessionID, row.UserID)
+}
+
+// reencryptColumn 按 id 顺序扫描 query 选出的至多 limit 行，重新加密 table 中的单个加密列
+func (r *EncryptionRepository) reencryptColumn(ctx context.Context, query *gorm.DB, table, column string, afterID uint, limit int,
+	scopeOf func(context.Context, encryptedRow) (string, error)) (uint, int, error) {
+	idColumn := table + ".id"
+	var rows []encryptedRow
+	if err := query.Where(idColumn+" > ?", afterID).Order(idColumn + " asc").Limit(limit).Scan(&rows).Error; err != nil {
+		return afterID, 0, fmt.Errorf("failed to scan %s to reencrypt: %w", table, err)
+	}
+
+	rewritten := 0
+	for _, row := range rows {
+		afterID = row.ID
+		scope, err := scopeOf(ctx, row)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		n, err := r.rewrite(ctx, table, column, row.ID, scope, row.Value)
+		if err != nil {
+			return afterID, rewritten, err
+		}
+		rewritten += n
+	}
+	return afterID, rewritten, nil
+}
+
+// rewrite 在列值未被并发修改时替换为重新加密的结果，数据密钥已粉碎时跳过
+func (r *EncryptionRepository) rewrite(ctx context.Context, table, column string, id uint, scope, value string) (int, error) {
+	sealed, err := r.reseal(ctx, scope, value)
+	if errors.Is(err, domain.ErrKeyDestroyed) || (err == nil && sealed == value) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	result := r.db.Table(table).
+		Where("id = ? AND "+column+" = ?", id, value).
+		UpdateColumn(column, sealed)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reencrypt %s: %w", table, result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// reseal 返回 value 用 scope 当前数据密钥加密的结果，不需要重新加密时原样返回
+func (r *EncryptionRepository) reseal(ctx context.Context, scope, value string) (string, error) {
+	stale, err := model.Stale(ctx, scope, value)
+	if err != nil || !stale {
+		return value, err
+	}
+	plaintext, err := model.Open(ctx, value)
+	if err != nil {
+		return value, err
+	}
+	return model.Seal(ctx, scope, plaintext)
+}
+
+var _ domain.EncryptionRepository = (*EncryptionRepository)(nil)
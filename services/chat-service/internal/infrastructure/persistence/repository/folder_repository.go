@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+type FolderRepository struct {
+	db *gorm.DB
+}
+
+func NewFolderRepository(db *gorm.DB) *FolderRepository {
+	return &FolderRepository{db: db}
+}
+
+func (r *FolderRepository) SaveFolder(ctx context.Context, f *domain.Folder) error {
+	if err := r.db.Create(model.ToFolderModel(f)).Error; err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	return nil
+}
+
+func (r *FolderRepository) GetFolder(ctx context.Context, folderID string) (*domain.Folder, error) {
+	var m model.FolderModel
+	if err := r.db.Where("folder_id = ?", folderID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find folder: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *FolderRepository) ListFolders(ctx context.Context, userID, workspaceID string) ([]*domain.Folder, error) {
+	var models []*model.FolderModel
+	if err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+		Order("name asc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	folders := make([]*domain.Folder, len(models))
+	for i, m := range models {
+		folders[i] = m.ToDomain()
+	}
+	return folders, nil
+}
+
+func (r *FolderRepository) RenameFolder(ctx context.Context, folderID, name string) error {
+	if err := r.db.Model(&model.FolderModel{}).
+		Where("folder_id = ?", folderID).
+		Update("name", name).Error; err != nil {
+		return fmt.Errorf("failed to rename folder: %w", err)
+	}
+	return nil
+}
+
+func (r *FolderRepository) DeleteFolder(ctx context.Context, folderID string) error {
+	if err := r.db.Where("folder_id = ?", folderID).Delete(&model.FolderModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+	return nil
+}
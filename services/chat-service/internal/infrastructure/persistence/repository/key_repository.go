@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"free-chat/services/chat-service/internal/infrastructure/encryption"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+type KeyRepository struct {
+	db *gorm.DB
+}
+
+func NewKeyRepository(db *gorm.DB) *KeyRepository {
+	return &KeyRepository{db: db}
+}
+
+func (r *KeyRepository) CurrentKey(ctx context.Context, scope string) (*encryption.StoredKey, error) {
+	return r.findKey(r.db.Where("scope = ? AND retired_at IS NULL AND destroyed_at IS NULL", scope))
+}
+
+func (r *KeyRepository) GetKey(ctx context.Context, scope string, version int) (*encryption.StoredKey, error) {
+	return r.findKey(r.db.Where("scope = ? AND version = ?", scope, version))
+}
+
+func (r *KeyRepository) findKey(query *gorm.DB) (*encryption.StoredKey, error) {
+	var m model.DataKeyModel
+	if err := query.First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find data key: %w", err)
+	}
+	return m.ToStoredKey(), nil
+}
+
+func (r *KeyRepository) ListKeys(ctx context.Context, scope string) ([]*encryption.StoredKey, error) {
+	var models []*model.DataKeyModel
+	if err := r.db.Where("scope = ?", scope).Order("version asc").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list data keys: %w", err)
+	}
+	keys := make([]*encryption.StoredKey, len(models))
+	for i, m := range models {
+		keys[i] = m.ToStoredKey()
+	}
+	return keys, nil
+}
+
+// CreateKey 以范围为键加事务级咨询锁，多个实例同时为新范围创建密钥时只创建一个
+func (r *KeyRepository) CreateKey(ctx context.Context, key *encryption.StoredKey, rotate bool) (*encryption.StoredKey, error) {
+	var current model.DataKeyModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key.Scope).Error; err != nil {
+			return err
+		}
+		err := tx.Where("scope = ? AND retired_at IS NULL AND destroyed_at IS NULL", key.Scope).First(&current).Error
+		if err == nil && !rotate {
+			return nil
+		}
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		var version int
+		if err := tx.Model(&model.DataKeyModel{}).Where("scope = ?", key.Scope).
+			Select("COALESCE(MAX(version), 0)").Row().Scan(&version); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.DataKeyModel{}).
+			Where("scope = ? AND retired_at IS NULL", key.Scope).
+			UpdateColumn("retired_at", time.Now()).Error; err != nil {
+			return err
+		}
+		current = model.DataKeyModel{
+			Scope:       key.Scope,
+			Version:     version + 1,
+			MasterKeyID: key.MasterKeyID,
+			Wrapped:     key.Wrapped,
+		}
+		return tx.Create(&current).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data key: %w", err)
+	}
+	return current.ToStoredKey(), nil
+}
+
+// DestroyKeys 清空包装后的密钥并标记销毁，保留版本记录以便区分已粉碎与从未存在
+func (r *KeyRepository) DestroyKeys(ctx context.Context, scope string) (int64, error) {
+	result := r.db.Model(&model.DataKeyModel{}).
+		Where("scope = ? AND destroyed_at IS NULL", scope).
+		UpdateColumns(map[string]interface{}{
+			"wrapped":      nil,
+			"destroyed_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to destroy data keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *KeyRepository) ListStaleWrapped(ctx context.Context, masterKeyID string, limit int) ([]*encryption.StoredKey, error) {
+	var models []*model.DataKeyModel
+	if err := r.db.Where("master_key_id <> ? AND destroyed_at IS NULL", masterKeyID).
+		Order("id asc").
+		Limit(limit).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list data keys to rewrap: %w", err)
+	}
+	keys := make([]*encryption.StoredKey, len(models))
+	for i, m := range models {
+		keys[i] = m.ToStoredKey()
+	}
+	return keys, nil
+}
+
+// Rewrap 只替换仍由原主密钥包装的版本，并发执行时不会覆盖其他实例的结果
+func (r *KeyRepository) Rewrap(ctx context.Context, key *encryption.StoredKey, masterKeyID string, wrapped []byte) error {
+	if err := r.db.Model(&model.DataKeyModel{}).
+		Where("scope = ? AND version = ? AND master_key_id = ? AND destroyed_at IS NULL", key.Scope, key.Version, key.MasterKeyID).
+		UpdateColumns(map[string]interface{}{
+			"master_key_id": masterKeyID,
+			"wrapped":       wrapped,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to rewrap data key: %w", err)
+	}
+	return nil
+}
+
+// SessionOwner 包括已软删除的会话，其消息仍使用会话的范围
+func (r *KeyRepository) SessionOwner(ctx context.Context, sessionID string) (string, string, bool, error) {
+	var owner struct {
+		WorkspaceID string
+		UserID      string
+	}
+	result := r.db.Unscoped().Model(&model.SessionModel{}).
+		Select("workspace_id", "user_id").
+		Where("session_id = ?", sessionID).
+		Limit(1).
+		Scan(&owner)
+	if result.Error != nil {
+		return "", "", false, fmt.Errorf("failed to find session owner: %w", result.Error)
+	}
+	return owner.WorkspaceID, owner.UserID, result.RowsAffected > 0, nil
+}
+
+var _ encryption.KeyStore = (*KeyRepository)(nil)
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemoryRepository 直接实现 domain.MemoryRepository，记忆读写频率低，不经过缓存
+type MemoryRepository struct {
+	db *gorm.DB
+}
+
+func NewMemoryRepository(db *gorm.DB) *MemoryRepository {
+	return &MemoryRepository{db: db}
+}
+
+func (r *MemoryRepository) SaveMemory(ctx context.Context, m *domain.Memory) error {
+	if err := r.db.Create(model.ToMemoryModel(m)).Error; err != nil {
+		return fmt.Errorf("failed to create memory: %w", err)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpdateMemoryContent(ctx context.Context, memoryID, content string) error {
+	// 按列更新不经过加密序列化器，内容需要用记忆所在范围的密钥自行加密
+	var owner struct {
+		WorkspaceID string
+		UserID      string
+	}
+	if err := r.db.Model(&model.MemoryModel{}).Select("workspace_id, user_id").
+		Where("memory_id = ?", memoryID).Scan(&owner).Error; err != nil {
+		return fmt.Errorf("failed to find memory: %w", err)
+	}
+	sealed, err := model.Seal(ctx, domain.KeyScope(owner.WorkspaceID, owner.UserID), content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt memory: %w", err)
+	}
+	if err := r.db.Model(&model.MemoryModel{}).
+		Where("memory_id = ?", memoryID).
+		Update("content", sealed).Error; err != nil {
+		return fmt.Errorf("failed to update memory: %w", err)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetMemory(ctx context.Context, memoryID string) (*domain.Memory, error) {
+	var m model.MemoryModel
+	if err := r.db.Where("memory_id = ?", memoryID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find memory: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *MemoryRepository) ListMemories(ctx context.Context, userID, workspaceID string) ([]*domain.Memory, error) {
+	var models []*model.MemoryModel
+	if err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+		Order("updated_at desc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+	memories := make([]*domain.Memory, len(models))
+	for i, m := range models {
+		memories[i] = m.ToDomain()
+	}
+	return memories, nil
+}
+
+func (r *MemoryRepository) DeleteMemory(ctx context.Context, memoryID string) error {
+	if err := r.db.Where("memory_id = ?", memoryID).Delete(&model.MemoryModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) IsMemoryEnabled(ctx context.Context, userID string) (bool, error) {
+	var setting model.MemorySettingModel
+	if err := r.db.Where("user_id = ?", userID).First(&setting).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get memory setting: %w", err)
+	}
+	return setting.Enabled, nil
+}
+
+func (r *MemoryRepository) SetMemoryEnabled(ctx context.Context, userID string, enabled bool) error {
+	setting := &model.MemorySettingModel{UserID: userID, Enabled: enabled}
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(setting).Error; err != nil {
+		return fmt.Errorf("failed to save memory setting: %w", err)
+	}
+	return nil
+}
+
+var _ domain.MemoryRepository = (*MemoryRepository)(nil)
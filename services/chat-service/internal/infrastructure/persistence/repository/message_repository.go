@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
+
 	"free-chat/services/chat-service/internal/domain"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
 
@@ -17,10 +20,54 @@ func NewMessageRepository(db *gorm.DB) *MessageRepository {
 	return &MessageRepository{db: db}
 }
 
+// Save 在一个事务中写入消息并更新会话的消息数、最后活动时间与摘要。
+// 消息可能经 MQ 乱序到达，摘要只在消息不早于当前最后活动时间时替换。
 func (r *MessageRepository) Save(ctx context.Context, m *domain.Message) error {
 	message := model.ToMessageModel(m)
-	if err := r.db.Create(message).Error; err != nil {
-		return fmt.Errorf("failed to create message: %w", err)
+	// 按列更新不经过加密序列化器，摘要需要自行加密
+	scope, err := model.MessageScope(ctx, m.SessionID, m.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve message key scope: %w", err)
+	}
+	preview, err := model.Seal(ctx, scope, domain.MessagePreview(m.Content))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt session preview: %w", err)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return fmt.Errorf("failed to create message: %w", err)
+		}
+		at := message.CreatedAt
+		if err := tx.Model(&model.SessionModel{}).
+			Where("session_id = ?", m.SessionID).
+			UpdateColumns(map[string]interface{}{
+				"message_count":   gorm.Expr("message_count + 1"),
+				"preview":         gorm.Expr("CASE WHEN last_message_at IS NULL OR last_message_at <= ? THEN ? ELSE preview END", at, preview),
+				"last_message_at": gorm.Expr("GREATEST(COALESCE(last_message_at, ?), ?)", at, at),
+				"updated_at":      time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update session activity: %w", err)
+		}
+		return nil
+	})
+}
+
+// saveBatchSize 批量写入时每条 INSERT 的消息数
+const saveBatchSize = 500
+
+// SaveBatch 在一个事务中分批写入消息，不更新会话统计，调用方需自行保存会话的消息数与最后活动时间
+func (r *MessageRepository) SaveBatch(ctx context.Context, messages []*domain.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	models := make([]*model.MessageModel, len(messages))
+	for i, m := range messages {
+		models[i] = model.ToMessageModel(m)
+	}
+	if err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(models, saveBatchSize).Error
+	}); err != nil {
+		return fmt.Errorf("failed to save messages: %w", err)
 	}
 	return nil
 }
@@ -36,9 +83,55 @@ func (r *MessageRepository) FindByID(ctx context.Context, id string) (*domain.Me
 	return model.ToDomain(), nil
 }
 
-func (r *MessageRepository) FindBySessionID(ctx context.Context, sessionID string, limit, offset int) ([]*domain.Message, error) {
+// FindBySessionID 按 (created_at, message_id) 倒序返回一页消息，并附带会话的消息总数
+func (r *MessageRepository) FindBySessionID(ctx context.Context, sessionID string, limit int, cursor string) (*domain.MessagePage, error) {
+	query := r.db.Where("session_id = ?", sessionID)
+	if cursor != "" {
+		createdAt, messageID, err := model.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(created_at, message_id) < (?, ?)", createdAt, messageID)
+	}
+
+	var models []*model.MessageModel
+	if err := query.Order("created_at desc, message_id desc").
+		Limit(limit + 1).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	total, err := r.CountBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.MessagePage{Total: total}
+	if len(models) > limit {
+		models = models[:limit]
+		last := models[len(models)-1]
+		page.NextCursor = model.EncodeCursor(last.CreatedAt, last.MessageID)
+	}
+	page.Messages = make([]*domain.Message, len(models))
+	for i, entity := range models {
+		page.Messages[i] = entity.ToDomain()
+	}
+	return page, nil
+}
+
+// CountBySessionID 返回会话中的消息总数
+func (r *MessageRepository) CountBySessionID(ctx context.Context, sessionID string) (int, error) {
+	var total int64
+	if err := r.db.Model(&model.MessageModel{}).
+		Where("session_id = ?", sessionID).
+		Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return int(total), nil
+}
+
+func (r *MessageRepository) FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.Message, error) {
 	var models []*model.MessageModel
-	if err := r.db.Where("session_id = ?", sessionID).
+	if err := r.db.Where("user_id = ?", userID).
 		Order("created_at desc").
 		Limit(limit).
 		Offset(offset).
@@ -52,12 +145,71 @@ func (r *MessageRepository) FindBySessionID(ctx context.Context, sessionID strin
 	return messages, nil
 }
 
-func (r *MessageRepository) FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.Message, error) {
+func (r *MessageRepository) FindByMessageID(ctx context.Context, messageID string) (*domain.Message, error) {
+	var model model.MessageModel
+	if err := r.db.Where("message_id = ?", messageID).First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find message: %w", err)
+	}
+	return model.ToDomain(), nil
+}
+
+// FindPinnedBySessionID 按时间正序返回会话中所有被置顶的消息
+func (r *MessageRepository) FindPinnedBySessionID(ctx context.Context, sessionID string) ([]*domain.Message, error) {
 	var models []*model.MessageModel
-	if err := r.db.Where("user_id = ?", userID).
-		Order("created_at desc").
+	if err := r.db.Where("session_id = ? AND pinned = ?", sessionID, true).
+		Order("created_at asc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pinned messages: %w", err)
+	}
+	messages := make([]*domain.Message, len(models))
+	for i, entity := range models {
+		messages[i] = entity.ToDomain()
+	}
+	return messages, nil
+}
+
+// FindBySessionIDSince 按时间正序返回会话中 created_at >= since 的消息，用于增量构建索引
+func (r *MessageRepository) FindBySessionIDSince(ctx context.Context, sessionID string, since time.Time) ([]*domain.Message, error) {
+	var models []*model.MessageModel
+	if err := r.db.Where("session_id = ? AND created_at >= ?", sessionID, since).
+		Order("created_at asc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	messages := make([]*domain.Message, len(models))
+	for i, entity := range models {
+		messages[i] = entity.ToDomain()
+	}
+	return messages, nil
+}
+
+// FindBySessionIDUntil 按 (created_at, message_id) 正序返回会话中不晚于 until 的消息（含 until）
+func (r *MessageRepository) FindBySessionIDUntil(ctx context.Context, until *domain.Message) ([]*domain.Message, error) {
+	var models []*model.MessageModel
+	if err := r.db.Where("session_id = ? AND (created_at, message_id) <= (?, ?)", until.SessionID, until.CreatedAt, until.ID).
+		Order("created_at asc, message_id asc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	messages := make([]*domain.Message, len(models))
+	for i, entity := range models {
+		messages[i] = entity.ToDomain()
+	}
+	return messages, nil
+}
+
+// FindBySessionIDAfter 按 (created_at, message_id) 正序返回 after 之后的至多 limit 条消息，after 为 nil 时从头开始
+func (r *MessageRepository) FindBySessionIDAfter(ctx context.Context, sessionID string, after *domain.Message, limit int) ([]*domain.Message, error) {
+	query := r.db.Where("session_id = ?", sessionID)
+	if after != nil {
+		query = query.Where("(created_at, message_id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+	var models []*model.MessageModel
+	if err := query.Order("created_at asc, message_id asc").
 		Limit(limit).
-		Offset(offset).
 		Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
@@ -68,6 +220,15 @@ func (r *MessageRepository) FindByUserID(ctx context.Context, userID string, lim
 	return messages, nil
 }
 
+func (r *MessageRepository) UpdatePinned(ctx context.Context, messageID string, pinned bool) error {
+	if err := r.db.Model(&model.MessageModel{}).
+		Where("message_id = ?", messageID).
+		Update("pinned", pinned).Error; err != nil {
+		return fmt.Errorf("failed to update message pinned: %w", err)
+	}
+	return nil
+}
+
 func (r *MessageRepository) DeleteByID(ctx context.Context, id string) error {
 	if err := r.db.Where("id = ?", id).Delete(&model.MessageModel{}).Error; err != nil {
 		return fmt.Errorf("failed to delete message: %w", err)
@@ -83,3 +244,64 @@ func (r *MessageRepository) DeleteBySessionID(ctx context.Context, sessionID str
 	}
 	return nil
 }
+
+// Search 在 simple 分词的检索文本上做全文检索，按 (created_at, id) 倒序游标分页。
+// 启用加密后检索盲索引，未开启盲索引时检索关闭
+func (r *MessageRepository) Search(ctx context.Context, q domain.MessageSearchQuery) (*domain.MessageSearchPage, error) {
+	if !model.Searchable() {
+		return nil, domain.ErrSearchUnavailable
+	}
+	texts, err := model.SearchQueries(ctx, domain.KeyScope(q.WorkspaceID, q.UserID), q.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search query: %w", err)
+	}
+	matches := r.db.Where("to_tsvector('simple', coalesce(search_text, '')) @@ plainto_tsquery('simple', ?)", texts[0])
+	for _, text := range texts[1:] {
+		matches = matches.Or("to_tsvector('simple', coalesce(search_text, '')) @@ plainto_tsquery('simple', ?)", text)
+	}
+	// 只检索当前工作空间中的会话
+	sessions := r.db.Model(&model.SessionModel{}).Select("session_id").Where("workspace_id = ?", q.WorkspaceID)
+	query := r.db.Where("user_id = ? AND session_id IN (?)", q.UserID, sessions).Where(matches)
+	if q.SessionID != "" {
+		query = query.Where("session_id = ?", q.SessionID)
+	}
+	if q.Role != "" {
+		query = query.Where("role = ?", q.Role.String())
+	}
+	if !q.From.IsZero() {
+		query = query.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("created_at < ?", q.To)
+	}
+	if q.Cursor != "" {
+		createdAt, key, err := model.DecodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	var models []*model.MessageModel
+	if err := query.Order("created_at desc, id desc").
+		Limit(q.Limit + 1).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	page := &domain.MessageSearchPage{}
+	if len(models) > q.Limit {
+		models = models[:q.Limit]
+		last := models[len(models)-1]
+		page.NextCursor = model.EncodeCursor(last.CreatedAt, strconv.FormatUint(uint64(last.ID), 10))
+	}
+	page.Messages = make([]*domain.Message, len(models))
+	for i, m := range models {
+		page.Messages[i] = m.ToDomain()
+	}
+	return page, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+type ModerationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+func (r *ModerationRepository) SaveFlag(ctx context.Context, flag *domain.ModerationFlag) error {
+	if err := r.db.Create(model.ToModerationFlagModel(flag)).Error; err != nil {
+		return fmt.Errorf("failed to save moderation flag: %w", err)
+	}
+	return nil
+}
+
+func (r *ModerationRepository) ListFlags(ctx context.Context, q domain.ModerationFlagQuery) (*domain.ModerationFlagPage, error) {
+	query := r.db.Where("workspace_id = ?", q.WorkspaceID)
+	if q.Status != "" {
+		query = query.Where("status = ?", string(q.Status))
+	}
+	if q.Cursor != "" {
+		createdAt, key, err := model.DecodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	var models []*model.ModerationFlagModel
+	if err := query.Order("created_at desc, id desc").
+		Limit(q.Limit + 1).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list moderation flags: %w", err)
+	}
+
+	page := &domain.ModerationFlagPage{}
+	if len(models) > q.Limit {
+		models = models[:q.Limit]
+		last := models[len(models)-1]
+		page.NextCursor = model.EncodeCursor(last.CreatedAt, strconv.FormatUint(uint64(last.ID), 10))
+	}
+	page.Flags = make([]*domain.ModerationFlag, len(models))
+	for i, m := range models {
+		page.Flags[i] = m.ToDomain()
+	}
+	return page, nil
+}
+
+func (r *ModerationRepository) ReviewFlag(ctx context.Context, workspaceID, flagID string, status domain.FlagStatus, reviewerID string) (*domain.ModerationFlag, error) {
+	res := r.db.Model(&model.ModerationFlagModel{}).
+		Where("flag_id = ? AND workspace_id = ?", flagID, workspaceID).
+		Updates(map[string]interface{}{
+			"status":      string(status),
+			"reviewed_by": reviewerID,
+			"reviewed_at": time.Now(),
+		})
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to review moderation flag: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, domain.ErrFlagNotFound
+	}
+	var m model.ModerationFlagModel
+	if err := r.db.Where("flag_id = ?", flagID).First(&m).Error; err != nil {
+		return nil, fmt.Errorf("failed to find moderation flag: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+var _ domain.ModerationRepository = (*ModerationRepository)(nil)
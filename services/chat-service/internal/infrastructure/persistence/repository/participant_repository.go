@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ParticipantRepository struct {
+	db *gorm.DB
+}
+
+func NewParticipantRepository(db *gorm.DB) *ParticipantRepository {
+	return &ParticipantRepository{db: db}
+}
+
+func (r *ParticipantRepository) SaveParticipant(ctx context.Context, p *domain.Participant) error {
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(model.ToParticipantModel(p)).Error; err != nil {
+		return fmt.Errorf("failed to save participant: %w", err)
+	}
+	return nil
+}
+
+func (r *ParticipantRepository) GetParticipant(ctx context.Context, sessionID, userID string) (*domain.Participant, error) {
+	var m model.ParticipantModel
+	if err := r.db.Where("session_id = ? AND user_id = ?", sessionID, userID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find participant: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *ParticipantRepository) ListParticipants(ctx context.Context, sessionID string) ([]*domain.Participant, error) {
+	var models []*model.ParticipantModel
+	if err := r.db.Where("session_id = ?", sessionID).
+		Order("created_at asc, id asc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list participants: %w", err)
+	}
+	participants := make([]*domain.Participant, len(models))
+	for i, m := range models {
+		participants[i] = m.ToDomain()
+	}
+	return participants, nil
+}
+
+func (r *ParticipantRepository) DeleteParticipant(ctx context.Context, sessionID, userID string) error {
+	if err := r.db.Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Delete(&model.ParticipantModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete participant: %w", err)
+	}
+	return nil
+}
+
+var _ domain.ParticipantRepository = (*ParticipantRepository)(nil)
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+type PIIRepository struct {
+	db *gorm.DB
+}
+
+func NewPIIRepository(db *gorm.DB) *PIIRepository {
+	return &PIIRepository{db: db}
+}
+
+func (r *PIIRepository) SaveAudits(ctx context.Context, audits []*domain.PIIAudit) error {
+	if len(audits) == 0 {
+		return nil
+	}
+	models := make([]*model.PIIAuditModel, len(audits))
+	for i, a := range audits {
+		models[i] = model.ToPIIAuditModel(a)
+	}
+	if err := r.db.Create(&models).Error; err != nil {
+		return fmt.Errorf("failed to save pii audits: %w", err)
+	}
+	return nil
+}
+
+var _ domain.PIIRepository = (*PIIRepository)(nil)
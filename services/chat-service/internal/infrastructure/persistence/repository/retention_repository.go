@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// unpoliciedSQL 选择没有单独保留策略的行：工作空间中的行看空间策略，个人空间中的行看用户的个人策略
+const unpoliciedSQL = `NOT EXISTS (SELECT 1 FROM retention_policy_models p WHERE p.workspace_id = %[1]s.workspace_id
+	AND p.user_id = CASE WHEN %[1]s.workspace_id = '' THEN %[1]s.user_id ELSE '' END)`
+
+// refreshSessionStatsSQL 消息被彻底删除后重算会话的消息数，消息删光时清空摘要
+const refreshSessionStatsSQL = `UPDATE session_models s SET
+	message_count = (SELECT count(*) FROM message_models m
+		WHERE m.session_id = s.session_id AND m.deleted_at IS NULL),
+	preview = CASE WHEN EXISTS (SELECT 1 FROM message_models m
+		WHERE m.session_id = s.session_id AND m.deleted_at IS NULL) THEN s.preview ELSE '' END
+	WHERE s.session_id IN ?`
+
+// skipLocked 多个实例同时执行清理时各自取走不同的批次
+var skipLocked = clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}
+
+// deletedTable 是带软删除的表；bySession 的表没有工作空间字段，按所属会话确定范围
+type deletedTable struct {
+	model     interface{}
+	table     string
+	bySession bool
+}
+
+var deletedTables = map[domain.PurgeTarget]deletedTable{
+	domain.PurgeMessages:    {&model.MessageModel{}, "message_models", true},
+	domain.PurgeSessions:    {&model.SessionModel{}, "session_models", false},
+	domain.PurgeFolders:     {&model.FolderModel{}, "folder_models", false},
+	domain.PurgeMemories:    {&model.MemoryModel{}, "memory_models", false},
+	domain.PurgeDocuments:   {&model.DocumentModel{}, "document_models", false},
+	domain.PurgeCollections: {&model.CollectionModel{}, "collection_models", false},
+	domain.PurgeShares:      {&model.ShareModel{}, "share_models", true},
+}
+
+type RetentionRepository struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+func (r *RetentionRepository) GetRetentionPolicy(ctx context.Context, workspaceID, userID string) (*domain.RetentionPolicy, error) {
+	var m model.RetentionPolicyModel
+	if err := r.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find retention policy: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *RetentionRepository) SaveRetentionPolicy(ctx context.Context, policy *domain.RetentionPolicy) error {
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"message_days", "updated_by", "updated_at"}),
+	}).Create(model.ToRetentionPolicyModel(policy)).Error; err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+	return nil
+}
+
+func (r *RetentionRepository) ListRetentionPolicies(ctx context.Context) ([]*domain.RetentionPolicy, error) {
+	var models []*model.RetentionPolicyModel
+	if err := r.db.Order("id asc").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	policies := make([]*domain.RetentionPolicy, len(models))
+	for i, m := range models {
+		policies[i] = m.ToDomain()
+	}
+	return policies, nil
+}
+
+func (r *RetentionRepository) CountExpiredMessages(ctx context.Context, scope domain.RetentionScope, cutoff time.Time) (int64, error) {
+	var count int64
+	if err := r.expiredMessages(r.db, scope, cutoff).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count expired messages: %w", err)
+	}
+	return count, nil
+}
+
+func (r *RetentionRepository) PurgeExpiredMessages(ctx context.Context, scope domain.RetentionScope, cutoff time.Time, limit int) ([]*domain.Message, error) {
+	var batch []*model.MessageModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.expiredMessages(tx, scope, cutoff).
+			Select("id", "message_id", "session_id", "user_id", "created_at").
+			Order("id asc").Limit(limit).Clauses(skipLocked).
+			Find(&batch).Error; err != nil {
+			return fmt.Errorf("failed to find expired messages: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(batch))
+		messageIDs := make([]string, len(batch))
+		var sessionIDs []string
+		seen := make(map[string]bool)
+		for i, m := range batch {
+			ids[i], messageIDs[i] = m.ID, m.MessageID
+			if !seen[m.SessionID] {
+				seen[m.SessionID] = true
+				sessionIDs = append(sessionIDs, m.SessionID)
+			}
+		}
+		if err := tx.Where("message_id IN ?", messageIDs).Delete(&model.MessageEmbeddingModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete message embeddings: %w", err)
+		}
+		if err := tx.Where("message_id IN ?", messageIDs).Delete(&model.ShareMessageModel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete share messages: %w", err)
+		}
+		if err := tx.Unscoped().Delete(&model.MessageModel{}, ids).Error; err != nil {
+			return fmt.Errorf("failed to delete expired messages: %w", err)
+		}
+		if err := tx.Exec(refreshSessionStatsSQL, sessionIDs).Error; err != nil {
+			return fmt.Errorf("failed to refresh session stats: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*domain.Message, len(batch))
+	for i, m := range batch {
+		messages[i] = m.ToDomain()
+	}
+	return messages, nil
+}
+
+func (r *RetentionRepository) CountDeleted(ctx context.Context, scope *domain.RetentionScope, cutoff time.Time) ([]domain.PurgeCount, error) {
+	counts := make([]domain.PurgeCount, 0, len(domain.SoftDeletedTargets))
+	for _, target := range domain.SoftDeletedTargets {
+		t := deletedTables[target]
+		q := r.db.Unscoped().Model(t.model).Where(t.table+".deleted_at < ?", cutoff)
+		if scope != nil {
+			if t.bySession {
+				q = q.Where(t.table+".session_id IN (?)", scopeSessions(r.db, *scope))
+			} else {
+				q = scopeWhere(q, t.table, *scope)
+			}
+		}
+		var count int64
+		if err := q.Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count deleted %s: %w", target, err)
+		}
+		counts = append(counts, domain.PurgeCount{Target: target, Count: count})
+	}
+	return counts, nil
+}
+
+func (r *RetentionRepository) PurgeDeleted(ctx context.Context, target domain.PurgeTarget, cutoff time.Time, limit int) (int64, error) {
+	t, ok := deletedTables[target]
+	if !ok {
+		return 0, fmt.Errorf("unknown purge target %q", target)
+	}
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(t.model).Where("deleted_at < ?", cutoff).
+			Order("id asc").Limit(limit).Clauses(skipLocked).
+			Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to find deleted %s: %w", target, err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		// 附属数据没有软删除，随主记录一起删除
+		switch target {
+		case domain.PurgeMessages:
+			if err := tx.Where("message_id IN (?)",
+				tx.Unscoped().Model(&model.MessageModel{}).Select("message_id").Where("id IN ?", ids)).
+				Delete(&model.MessageEmbeddingModel{}).Error; err != nil {
+				return fmt.Errorf("failed to delete message embeddings: %w", err)
+			}
+		case domain.PurgeSessions:
+			// 审计记录不含原值，但记录了会话中出现过哪类敏感信息，随会话一起删除
+			if err := tx.Where("session_id IN (?)",
+				tx.Unscoped().Model(&model.SessionModel{}).Select("session_id").Where("id IN ?", ids)).
+				Delete(&model.PIIAuditModel{}).Error; err != nil {
+				return fmt.Errorf("failed to delete pii audits: %w", err)
+			}
+		case domain.PurgeShares:
+			if err := tx.Where("share_id IN (?)",
+				tx.Unscoped().Model(&model.ShareModel{}).Select("share_id").Where("id IN ?", ids)).
+				Delete(&model.ShareMessageModel{}).Error; err != nil {
+				return fmt.Errorf("failed to delete share messages: %w", err)
+			}
+		}
+		res := tx.Unscoped().Delete(t.model, ids)
+		if res.Error != nil {
+			return fmt.Errorf("failed to purge deleted %s: %w", target, res.Error)
+		}
+		purged = res.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+func (r *RetentionRepository) SaveRetentionAudit(ctx context.Context, audit *domain.RetentionAudit) error {
+	if err := r.db.Create(model.ToRetentionAuditModel(audit)).Error; err != nil {
+		return fmt.Errorf("failed to save retention audit: %w", err)
+	}
+	return nil
+}
+
+// expiredMessages 选择范围内早于 cutoff 的消息，已软删除的消息同样计入
+func (r *RetentionRepository) expiredMessages(db *gorm.DB, scope domain.RetentionScope, cutoff time.Time) *gorm.DB {
+	return db.Unscoped().Model(&model.MessageModel{}).
+		Where("created_at < ? AND session_id IN (?)", cutoff, scopeSessions(db, scope))
+}
+
+// scopeSessions 是范围内全部会话（含已软删除的）的 session_id 子查询
+func scopeSessions(db *gorm.DB, scope domain.RetentionScope) *gorm.DB {
+	return scopeWhere(db.Unscoped().Model(&model.SessionModel{}).Select("session_id"), "session_models", scope)
+}
+
+// scopeWhere 按工作空间与用户过滤带 workspace_id / user_id 字段的表
+func scopeWhere(q *gorm.DB, table string, scope domain.RetentionScope) *gorm.DB {
+	switch {
+	case scope.Default:
+		return q.Where(fmt.Sprintf(unpoliciedSQL, table))
+	case scope.WorkspaceID != "":
+		return q.Where(table+".workspace_id = ?", scope.WorkspaceID)
+	default:
+		return q.Where(table+".workspace_id = '' AND "+table+".user_id = ?", scope.UserID)
+	}
+}
+
+var _ domain.RetentionRepository = (*RetentionRepository)(nil)
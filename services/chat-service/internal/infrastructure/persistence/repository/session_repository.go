@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"free-chat/services/chat-service/internal/domain"
 	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -37,21 +39,194 @@ func (r *SessionRepository) FindByID(ctx context.Context, sessionID string) (*do
 	return sessionModel.ToDomain(), nil
 }
 
-func (r *SessionRepository) FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.Session, error) {
+// ExistsImported 返回用户在工作空间中是否已有来源为 importedFrom 的会话
+func (r *SessionRepository) ExistsImported(ctx context.Context, userID, workspaceID, importedFrom string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.SessionModel{}).
+		Where("user_id = ? AND workspace_id = ? AND imported_from = ?", userID, workspaceID, importedFrom).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check imported session: %w", err)
+	}
+	return count > 0, nil
+}
+
+// FindByUserID 按 query 过滤并返回一页会话，并附带用户的会话总数。置顶会话总在最前；
+// 最近活动与创建时间按 (pinned, 时间, session_id) 倒序，标题按置顶倒序、(title, session_id) 正序。
+func (r *SessionRepository) FindByUserID(ctx context.Context, q domain.SessionQuery) (*domain.SessionPage, error) {
+	query := r.filtered(q)
+	switch q.Sort {
+	case domain.SessionSortTitle:
+		// 库中只有标题密文，无法按标题排序与翻页
+		if model.Encrypted() {
+			return nil, domain.ErrTitleSortUnavailable
+		}
+		if q.Cursor != "" {
+			value, sessionID, err := model.DecodeTextCursor(q.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				return nil, domain.ErrInvalidCursor
+			}
+			// 排序值为置顶标记（"1"/"0"）加标题
+			pinned, title := value[0] == '1', value[1:]
+			query = query.Where("((pinned = ? AND (title, session_id) > (?, ?)) OR (? AND NOT pinned))", pinned, title, sessionID, pinned)
+		}
+		query = query.Order("pinned desc, title asc, session_id asc")
+	default:
+		column := "last_message_at"
+		if q.Sort == domain.SessionSortCreated {
+			column = "created_at"
+		}
+		if q.Cursor != "" {
+			score, sessionID, err := model.DecodeScoreCursor(q.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			pinned, at := model.SplitSessionScore(score)
+			query = query.Where("(pinned, "+column+", session_id) < (?, ?, ?)", pinned, at, sessionID)
+		}
+		query = query.Order("pinned desc, " + column + " desc, session_id desc")
+	}
+
 	var models []*model.SessionModel
-	if err := r.db.Where("user_id = ?", userID).
-		Order("created_at desc").
-		Limit(limit).
-		Offset(offset).
-		Find(&models).Error; err != nil {
+	if err := query.Limit(q.Limit + 1).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to find sessions: %w", err)
 	}
+	total, err := r.CountByUserID(ctx, q)
+	if err != nil {
+		return nil, err
+	}
 
-	sessions := make([]*domain.Session, len(models))
+	page := &domain.SessionPage{Total: total}
+	if len(models) > q.Limit {
+		models = models[:q.Limit]
+		page.NextCursor = sessionCursor(models[len(models)-1], q.Sort)
+	}
+	page.Sessions = make([]*domain.Session, len(models))
 	for i, m := range models {
-		sessions[i] = m.ToDomain()
+		page.Sessions[i] = m.ToDomain()
+	}
+	return page, nil
+}
+
+func sessionCursor(m *model.SessionModel, sort domain.SessionSort) string {
+	switch sort {
+	case domain.SessionSortTitle:
+		flag := "0"
+		if m.Pinned {
+			flag = "1"
+		}
+		return model.EncodeTextCursor(flag+m.Title, m.SessionID)
+	case domain.SessionSortCreated:
+		return model.EncodeScoreCursor(model.SessionScore(m.Pinned, m.CreatedAt), m.SessionID)
+	}
+	return model.EncodeScoreCursor(model.SessionScore(m.Pinned, m.LastMessageAt), m.SessionID)
+}
+
+// CountByUserID 返回满足 query 过滤条件的会话总数
+func (r *SessionRepository) CountByUserID(ctx context.Context, q domain.SessionQuery) (int, error) {
+	var total int64
+	if err := r.filtered(q).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return int(total), nil
+}
+
+// filtered 返回按 query 的工作空间、用户、归档状态、文件夹与标签过滤的会话查询；
+// Joined 时改为用户作为协作者加入的会话，文件夹、标签与归档属于所有者，不参与过滤
+func (r *SessionRepository) filtered(q domain.SessionQuery) *gorm.DB {
+	query := r.db.Model(&model.SessionModel{}).Where("workspace_id = ?", q.WorkspaceID)
+	if q.Joined {
+		joined := r.db.Model(&model.ParticipantModel{}).Select("session_id").Where("user_id = ?", q.UserID)
+		return query.Where("session_id IN (?)", joined)
+	}
+	query = query.Where("user_id = ? AND archived = ?", q.UserID, q.Archived)
+	if q.FolderID != "" {
+		query = query.Where("folder_id = ?", q.FolderID)
+	}
+	if q.Tag != "" {
+		tag, _ := json.Marshal([]string{q.Tag})
+		query = query.Where("tags @> ?::jsonb", string(tag))
+	}
+	return query
+}
+
+// UpdateOrganization 保存会话的标题、置顶、归档、文件夹与标签，会话不存在时返回 domain.ErrSessionNotFound
+func (r *SessionRepository) UpdateOrganization(ctx context.Context, s *domain.Session) error {
+	tags := s.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session tags: %w", err)
+	}
+	// 按列更新不经过加密序列化器，标题需要自行加密
+	title, err := model.Seal(ctx, domain.KeyScope(s.WorkspaceID, s.UserID), s.Title)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt session title: %w", err)
+	}
+	result := r.db.Model(&model.SessionModel{}).
+		Where("session_id = ?", s.ID).
+		UpdateColumns(map[string]interface{}{
+			"title":      title,
+			"pinned":     s.Pinned,
+			"archived":   s.Archived,
+			"folder_id":  s.FolderID,
+			"tags":       gorm.Expr("?::jsonb", string(tagsJSON)),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+// forkBatchSize 分叉时每批插入的消息数
+const forkBatchSize = 200
+
+// SaveFork 在一个事务中创建分叉会话并写入复制的消息，消息保留原有的 token 数与创建时间
+func (r *SessionRepository) SaveFork(ctx context.Context, s *domain.Session, messages []*domain.Message) error {
+	session := model.ToSessionModel(s)
+	models := make([]*model.MessageModel, len(messages))
+	for i, m := range messages {
+		models[i] = model.ToMessageModel(m)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("failed to create fork session: %w", err)
+		}
+		if len(models) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(models, forkBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to copy fork messages: %w", err)
+		}
+		return nil
+	})
+}
+
+// ClearFolder 把文件夹中的会话移出该文件夹，返回受影响的会话 ID
+func (r *SessionRepository) ClearFolder(ctx context.Context, userID, folderID string) ([]string, error) {
+	var sessionIDs []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SessionModel{}).
+			Where("user_id = ? AND folder_id = ?", userID, folderID).
+			Pluck("session_id", &sessionIDs).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.SessionModel{}).
+			Where("user_id = ? AND folder_id = ?", userID, folderID).
+			UpdateColumns(map[string]interface{}{"folder_id": "", "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear session folder: %w", err)
 	}
-	return sessions, nil
+	return sessionIDs, nil
 }
 
 func (r *SessionRepository) DeleteByID(ctx context.Context, sessionID string) error {
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+	"free-chat/services/chat-service/internal/infrastructure/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+type ShareRepository struct {
+	db *gorm.DB
+}
+
+func NewShareRepository(db *gorm.DB) *ShareRepository {
+	return &ShareRepository{db: db}
+}
+
+func (r *ShareRepository) SaveShare(ctx context.Context, s *domain.Share, messages []*domain.Message) error {
+	share := model.ToShareModel(s)
+	models := make([]*model.ShareMessageModel, len(messages))
+	for i, m := range messages {
+		models[i] = model.ToShareMessageModel(s, i, m)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(share).Error; err != nil {
+			return fmt.Errorf("failed to create share: %w", err)
+		}
+		if len(models) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(models, saveBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to save share snapshot: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *ShareRepository) GetShare(ctx context.Context, shareID string) (*domain.Share, error) {
+	return r.findOne("share_id = ?", shareID)
+}
+
+func (r *ShareRepository) GetShareByToken(ctx context.Context, tokenHash string) (*domain.Share, error) {
+	return r.findOne("token_hash = ?", tokenHash)
+}
+
+func (r *ShareRepository) findOne(query string, arg any) (*domain.Share, error) {
+	var m model.ShareModel
+	if err := r.db.Where(query, arg).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find share: %w", err)
+	}
+	return m.ToDomain(), nil
+}
+
+func (r *ShareRepository) ListShares(ctx context.Context, sessionID string) ([]*domain.Share, error) {
+	var models []*model.ShareModel
+	if err := r.db.Where("session_id = ?", sessionID).
+		Order("created_at desc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+	shares := make([]*domain.Share, len(models))
+	for i, m := range models {
+		shares[i] = m.ToDomain()
+	}
+	return shares, nil
+}
+
+func (r *ShareRepository) GetShareMessages(ctx context.Context, shareID string) ([]*domain.Message, error) {
+	var models []*model.ShareMessageModel
+	if err := r.db.Where("share_id = ?", shareID).
+		Order("position asc").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find share messages: %w", err)
+	}
+	messages := make([]*domain.Message, len(models))
+	for i, m := range models {
+		messages[i] = m.ToDomain()
+	}
+	return messages, nil
+}
+
+func (r *ShareRepository) RevokeShare(ctx context.Context, shareID string, at time.Time) error {
+	if err := r.db.Model(&model.ShareModel{}).
+		Where("share_id = ? AND revoked_at IS NULL", shareID).
+		Update("revoked_at", at).Error; err != nil {
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package pii
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// Detector finds one kind of sensitive data. Custom detectors can be passed
+// to NewScanner alongside DefaultDetectors.
+type Detector interface {
+	Kind() domain.PIIKind
+	Find(text string) []domain.PIIMatch
+}
+
+// regexDetector 用正则找出候选，再用 valid（校验位、日期等）排除误报
+type regexDetector struct {
+	kind  domain.PIIKind
+	re    *regexp.Regexp
+	valid func(value string) bool
+	// standalone 为真时候选前后不能紧接字母或数字，避免从更长的数字串中截取
+	standalone bool
+}
+
+// NewRegexDetector 创建基于正则的检测器，valid 可以为 nil
+func NewRegexDetector(kind domain.PIIKind, pattern string, valid func(value string) bool) Detector {
+	return &regexDetector{kind: kind, re: regexp.MustCompile(pattern), valid: valid, standalone: true}
+}
+
+func (d *regexDetector) Kind() domain.PIIKind {
+	return d.kind
+}
+
+func (d *regexDetector) Find(text string) []domain.PIIMatch {
+	var matches []domain.PIIMatch
+	for _, loc := range d.re.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if d.standalone && (start > 0 && isAlnum(text[start-1]) || end < len(text) && isAlnum(text[end])) {
+			continue
+		}
+		value := text[start:end]
+		if d.valid != nil && !d.valid(value) {
+			continue
+		}
+		matches = append(matches, domain.PIIMatch{Kind: d.kind, Start: start, End: end, Value: value})
+	}
+	return matches
+}
+
+// DefaultDetectors 返回内置检测器。顺序即优先级：同一位置的候选取更长的，长度相同时取靠前的检测器
+func DefaultDetectors() []Detector {
+	email := &regexDetector{
+		kind: domain.PIIEmail,
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	}
+	return []Detector{
+		NewRegexDetector(domain.PIIAPIKey, `(?:sk-(?:proj-)?[A-Za-z0-9_-]{20,}|sk_live_[A-Za-z0-9]{24,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abpr]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`, nil),
+		email,
+		NewRegexDetector(domain.PIIIDCard, `[1-9]\d{16}[\dXx]`, validIDCard),
+		NewRegexDetector(domain.PIICreditCard, `[2-6]\d{3}(?:[ -]?\d{4}){2}[ -]?\d{1,7}`, validCard),
+		// 中国大陆手机号（可带 +86 / 0086，可按 3-4-4 分隔）与带国家码的国际号码
+		NewRegexDetector(domain.PIIPhone, `(?:(?:\+|00)86[ -]?)?1[3-9]\d(?:[ -]?\d{4}){2}|\+[1-9]\d{0,2}(?:[ -]?\d{2,5}){2,5}`, validPhone),
+	}
+}
+
+// idCardWeights 是 GB 11643 校验位的加权因子
+var idCardWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// validIDCard 检查出生日期与 GB 11643 校验位
+func validIDCard(value string) bool {
+	if _, err := time.Parse("20060102", value[6:14]); err != nil {
+		return false
+	}
+	sum := 0
+	for i, w := range idCardWeights {
+		sum += int(value[i]-'0') * w
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(value[17:])[0]
+}
+
+// validCard 检查 13 到 19 位数字的 Luhn 校验
+func validCard(value string) bool {
+	digits := onlyDigits(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validPhone 国际号码按 E.164 最多 15 位，至少 8 位
+func validPhone(value string) bool {
+	n := len(onlyDigits(value))
+	return n >= 8 && n <= 15
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+func isAlnum(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
@@ -0,0 +1,57 @@
+package pii
+
+import (
+	"sort"
+
+	"free-chat/services/chat-service/internal/domain"
+)
+
+// Scanner runs every detector and resolves overlapping matches.
+type Scanner struct {
+	detectors []Detector
+}
+
+// NewScanner 不传检测器时使用 DefaultDetectors
+func NewScanner(detectors ...Detector) *Scanner {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return &Scanner{detectors: detectors}
+}
+
+// Scan 返回按位置排序、互不重叠的匹配：起点相同时取更长的，完全相同时取靠前的检测器
+func (s *Scanner) Scan(text string) []domain.PIIMatch {
+	type candidate struct {
+		domain.PIIMatch
+		priority int
+	}
+	var candidates []candidate
+	for i, d := range s.detectors {
+		for _, m := range d.Find(text) {
+			candidates = append(candidates, candidate{m, i})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.End != b.End {
+			return a.End > b.End
+		}
+		return a.priority < b.priority
+	})
+
+	var matches []domain.PIIMatch
+	end := 0
+	for _, c := range candidates {
+		if c.Start < end {
+			continue
+		}
+		matches = append(matches, c.PIIMatch)
+		end = c.End
+	}
+	return matches
+}
+
+var _ domain.PIIScanner = (*Scanner)(nil)
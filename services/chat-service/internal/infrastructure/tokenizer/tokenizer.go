@@ -55,6 +55,34 @@ func (t *Tokenizer) Count(text string) int {
 	return len(tokens)
 }
 
+// Encode 将文本编码为 token 序列；没有可用编码时退化为按 rune 切分
+func (t *Tokenizer) Encode(text string) []int {
+	if t.encoding != "" {
+		if tke, err := t.getEncoding(); err == nil {
+			return tke.Encode(text, nil, nil)
+		}
+	}
+	tokens := make([]int, 0, len(text))
+	for _, r := range text {
+		tokens = append(tokens, int(r))
+	}
+	return tokens
+}
+
+// Decode 是 Encode 的逆操作
+func (t *Tokenizer) Decode(tokens []int) string {
+	if t.encoding != "" {
+		if tke, err := t.getEncoding(); err == nil {
+			return tke.Decode(tokens)
+		}
+	}
+	runes := make([]rune, len(tokens))
+	for i, tok := range tokens {
+		runes[i] = rune(tok)
+	}
+	return string(runes)
+}
+
 func (t *Tokenizer) getEncoding() (*tiktoken.Tiktoken, error) {
 	if cached, ok := encodingCache.Load(t.encoding); ok {
 		return cached.(*tiktoken.Tiktoken), nil
@@ -66,3 +66,21 @@ func TestNewTokenizerWithQwenModel(t *testing.T) {
 		t.Errorf("expected positive token count, got %d", count)
 	}
 }
+
+func TestTokenizerEncodeDecodeRoundTrip(t *testing.T) {
+	for _, model := range []string{"gpt-4", "fake-unknown-model-v42"} {
+		tk, err := NewTokenizer(model)
+		if err != nil {
+			t.Fatalf("NewTokenizer failed: %v", err)
+		}
+
+		text := "Hello, 世界！"
+		tokens := tk.Encode(text)
+		if len(tokens) == 0 {
+			t.Fatalf("[%s] expected tokens for non-empty text", model)
+		}
+		if got := tk.Decode(tokens); got != text {
+			t.Errorf("[%s] round trip mismatch: got %q, want %q", model, got, text)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// accountArchiveType 账户数据导出包的类型
+const accountArchiveType = "application/zip"
+
+// errAccountUnavailable 数据库或服务发现不可用时账户导出与删除关闭
+var errAccountUnavailable = status.Error(codes.Unavailable, "account export and erasure are unavailable")
+
+func (h *ChatHandler) StartAccountExport(ctx context.Context, req *chatpb.StartAccountExportRequest) (*chatpb.StartAccountExportResponse, error) {
+	if h.accounts == nil {
+		return nil, errAccountUnavailable
+	}
+	export, err := h.accounts.StartExport(ctx, req.UserId)
+	if err != nil {
+		return nil, accountStatus(err, "start account export failed")
+	}
+	return &chatpb.StartAccountExportResponse{Export: accountExportToPB(export)}, nil
+}
+
+func (h *ChatHandler) GetAccountExport(ctx context.Context, req *chatpb.GetAccountExportRequest) (*chatpb.GetAccountExportResponse, error) {
+	if h.accounts == nil {
+		return nil, errAccountUnavailable
+	}
+	export, err := h.accounts.GetExport(ctx, req.UserId, req.ExportId)
+	if err != nil {
+		return nil, accountStatus(err, "get account export failed")
+	}
+	return &chatpb.GetAccountExportResponse{Export: accountExportToPB(export)}, nil
+}
+
+func (h *ChatHandler) DownloadAccountExport(req *chatpb.DownloadAccountExportRequest, stream chatpb.ChatService_DownloadAccountExportServer) error {
+	if h.accounts == nil {
+		return errAccountUnavailable
+	}
+	export, archive, err := h.accounts.DownloadExport(stream.Context(), req.UserId, req.ExportId)
+	if err != nil {
+		return accountStatus(err, "download account export failed")
+	}
+	defer archive.Close()
+	w := &chunkSender{
+		stream:      stream,
+		filename:    "account-" + export.ID + ".zip",
+		contentType: accountArchiveType,
+	}
+	if _, err := io.Copy(w, archive); err != nil {
+		return accountStatus(err, "download account export failed")
+	}
+	return nil
+}
+
+func (h *ChatHandler) EraseAccount(ctx context.Context, req *chatpb.EraseAccountRequest) (*chatpb.EraseAccountResponse, error) {
+	if h.accounts == nil {
+		return nil, errAccountUnavailable
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	erasure, err := h.accounts.EraseAccount(ctx, req.UserId)
+	if err != nil {
+		return nil, accountStatus(err, "erase account failed")
+	}
+	return &chatpb.EraseAccountResponse{Erasure: accountErasureToPB(erasure)}, nil
+}
+
+func (h *ChatHandler) GetAccountErasure(ctx context.Context, req *chatpb.GetAccountErasureRequest) (*chatpb.GetAccountErasureResponse, error) {
+	if h.accounts == nil {
+		return nil, errAccountUnavailable
+	}
+	erasure, err := h.accounts.GetErasure(ctx, req.UserId)
+	if err != nil {
+		return nil, accountStatus(err, "get account erasure failed")
+	}
+	return &chatpb.GetAccountErasureResponse{Erasure: accountErasureToPB(erasure)}, nil
+}
+
+func accountExportToPB(e *domain.AccountExport) *chatpb.AccountExport {
+	return &chatpb.AccountExport{
+		ExportId:    e.ID,
+		Status:      string(e.Status),
+		Size:        e.Size,
+		Error:       e.Error,
+		CreatedAt:   e.CreatedAt.Unix(),
+		CompletedAt: unixOrZero(e.CompletedAt),
+		ExpiresAt:   unixOrZero(e.ExpiresAt),
+	}
+}
+
+func accountErasureToPB(e *domain.AccountErasure) *chatpb.AccountErasure {
+	done := make([]string, len(e.Done))
+	for i, step := range e.Done {
+		done[i] = string(step)
+	}
+	// 按 ErasureTargets 的固定顺序输出
+	purged := make([]*chatpb.PurgeCount, 0, len(domain.ErasureTargets))
+	for _, target := range domain.ErasureTargets {
+		purged = append(purged, &chatpb.PurgeCount{Target: string(target), Count: e.Purged[target]})
+	}
+	return &chatpb.AccountErasure{
+		ErasureId:   e.ID,
+		Status:      string(e.Status()),
+		Done:        done,
+		Purged:      purged,
+		LastError:   e.LastError,
+		RequestedAt: e.RequestedAt.Unix(),
+		DrainUntil:  e.DrainUntil.Unix(),
+		CompletedAt: unixOrZero(e.CompletedAt),
+	}
+}
+
+func accountStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrExportNotFound), errors.Is(err, domain.ErrErasureNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrExportNotReady), errors.Is(err, domain.ErrAccountErased):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
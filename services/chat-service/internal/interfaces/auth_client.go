@@ -0,0 +1,123 @@
+package interfaces
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	authpb "free-chat/pkg/proto/auth"
+	"free-chat/pkg/registry"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// AuthClient calls auth-service discovered through Consul.
+// Implements domain.AccountDirectory for account export and erasure.
+type AuthClient struct {
+	mgr         *registry.ServiceManager
+	authService string
+	mu          sync.RWMutex
+	conns       map[string]*grpc.ClientConn
+}
+
+func NewAuthClient(mgr *registry.ServiceManager, authService string) *AuthClient {
+	return &AuthClient{
+		mgr:         mgr,
+		authService: authService,
+		conns:       make(map[string]*grpc.ClientConn),
+	}
+}
+
+func (c *AuthClient) getConn(target string) (*grpc.ClientConn, error) {
+	c.mu.RLock()
+	conn, ok := c.conns[target]
+	c.mu.RUnlock()
+	if ok && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn, ok = c.conns[target]; ok && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	c.conns[target] = conn
+	return conn, nil
+}
+
+func (c *AuthClient) client() (authpb.AuthServiceClient, error) {
+	instances, err := c.mgr.DiscoverService(c.authService)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", c.authService, err)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no auth service instances found for %s", c.authService)
+	}
+	conn, err := c.getConn(instances[rand.Intn(len(instances))].GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("connect auth service: %w", err)
+	}
+	return authpb.NewAuthServiceClient(conn), nil
+}
+
+// GetProfile returns the user's profile and workspace memberships.
+func (c *AuthClient) GetProfile(ctx context.Context, userID string) (*domain.AccountProfile, error) {
+	client, err := c.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetUserProfile(ctx, &authpb.GetUserProfileRequest{UserId: userID})
+	if err != nil {
+		return nil, fmt.Errorf("GetUserProfile RPC: %w", err)
+	}
+	p := resp.GetProfile()
+	profile := &domain.AccountProfile{
+		UserID:     p.GetUserId(),
+		Username:   p.GetUsername(),
+		Email:      p.GetEmail(),
+		CreatedAt:  time.Unix(p.GetCreatedAt(), 0),
+		Workspaces: make([]domain.AccountWorkspace, len(p.GetWorkspaces())),
+	}
+	for i, w := range p.GetWorkspaces() {
+		profile.Workspaces[i] = domain.AccountWorkspace{
+			WorkspaceID: w.GetWorkspaceId(),
+			Name:        w.GetName(),
+			Role:        w.GetRole(),
+			CreatedAt:   time.Unix(w.GetCreatedAt(), 0),
+		}
+	}
+	return profile, nil
+}
+
+// DeleteUser removes the user and its workspace memberships; deleting an
+// already deleted user succeeds.
+func (c *AuthClient) DeleteUser(ctx context.Context, userID string) error {
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	if _, err := client.DeleteUser(ctx, &authpb.DeleteUserRequest{UserId: userID}); err != nil {
+		return fmt.Errorf("DeleteUser RPC: %w", err)
+	}
+	return nil
+}
+
+func (c *AuthClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+	c.conns = make(map[string]*grpc.ClientConn)
+}
+
+var _ domain.AccountDirectory = (*AuthClient)(nil)
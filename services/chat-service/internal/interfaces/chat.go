@@ -3,6 +3,7 @@ package interfaces
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"strings"
 	"time"
@@ -16,16 +17,43 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// memoryTopK 每轮注入上下文的长期记忆条数
+const memoryTopK = 5
+
 type ChatHandler struct {
 	chatpb.UnimplementedChatServiceServer
 	app        *application.ChatService
+	memory     *application.MemoryService
+	documents  *application.DocumentService
+	sessions   *application.SessionService
+	exports    *application.ExportService
+	imports    *application.ImportService
+	shares     *application.ShareService
+	collab     *application.CollaborationService
+	retention  *application.RetentionService
+	accounts   *application.AccountService
+	encryption *application.EncryptionService
+	pii        *application.PIIService
+	moderation *application.ModerationService
 	llm        *LLMClient
 	ctxBuilder ctxbld.ContextBuilder
 }
 
-func NewChatHandler(app *application.ChatService, llm *LLMClient, ctxBuilder ctxbld.ContextBuilder) *ChatHandler {
+func NewChatHandler(app *application.ChatService, memory *application.MemoryService, documents *application.DocumentService, sessions *application.SessionService, exports *application.ExportService, imports *application.ImportService, shares *application.ShareService, collab *application.CollaborationService, retention *application.RetentionService, accounts *application.AccountService, encryption *application.EncryptionService, pii *application.PIIService, moderation *application.ModerationService, llm *LLMClient, ctxBuilder ctxbld.ContextBuilder) *ChatHandler {
 	return &ChatHandler{
 		app:        app,
+		memory:     memory,
+		documents:  documents,
+		sessions:   sessions,
+		exports:    exports,
+		imports:    imports,
+		shares:     shares,
+		collab:     collab,
+		retention:  retention,
+		accounts:   accounts,
+		encryption: encryption,
+		pii:        pii,
+		moderation: moderation,
 		llm:        llm,
 		ctxBuilder: ctxBuilder,
 	}
@@ -34,13 +62,6 @@ func NewChatHandler(app *application.ChatService, llm *LLMClient, ctxBuilder ctx
 func (h *ChatHandler) StreamChat(req *chatpb.ChatRequest, stream chatpb.ChatService_StreamChatServer) error {
 	ctx := stream.Context()
 
-	// 1. Ensure Session
-	sessionID, err := h.app.EnsureSession(ctx, req.UserId, req.SessionId, req.Message)
-	if err != nil {
-		return status.Errorf(codes.Internal, "ensure session failed: %v", err)
-	}
-
-	// 2. Save User Message
 	// 检查 message 是否包含 topic_id（从 API Gateway 传递）
 	userMessage := req.Message
 	var topicID int
@@ -56,18 +77,117 @@ func (h *ChatHandler) StreamChat(req *chatpb.ChatRequest, stream chatpb.ChatServ
 	}
 	_ = topicID // 后续用于过滤话题上下文
 
-	if err := h.app.SaveMessage(ctx, sessionID, req.UserId, domain.RoleUser, userMessage); err != nil {
+	// 1. Ensure Session
+	// 已有会话先确认可以发言再检测敏感信息；新会话在检测之后创建，标题取自检测后的文本
+	var session *domain.Session
+	var err error
+	if req.SessionId != "" {
+		if session, err = h.app.EnsureSession(ctx, req.UserId, req.SessionId, req.Message, req.Ephemeral); err != nil {
+			return sessionStatus(err, "ensure session failed")
+		}
+	}
+	// 敏感信息在保存与推理之前处理，之后的存储、记忆、检索与提示词都只使用处理后的文本
+	var screened *application.ScreenedInput
+	title := req.Message
+	if h.pii != nil {
+		if screened, err = h.pii.Screen(ctx, req.UserId, session, userMessage); err != nil {
+			return piiStatus(err)
+		}
+		userMessage = screened.Text
+		title = screened.Text
+	}
+	// 输入审核在保存与选择模型之前，被拒绝的输入不保存，新会话也不会创建
+	var inputFlag *application.ModerationResult
+	if h.moderation != nil {
+		if inputFlag = h.moderation.CheckInput(ctx, userMessage); inputFlag != nil && inputFlag.Blocked() {
+			if screened != nil {
+				h.pii.Audit(ctx, req.UserId, session, screened)
+			}
+			var sessionID string
+			if session != nil {
+				sessionID = session.ID
+			} else if req.Ephemeral {
+				// 请求创建的是无痕会话，复核记录同样不保存输入内容
+				inputFlag.Excerpt = ""
+			}
+			flagID := h.moderation.Flag(ctx, req.UserId, session, "", inputFlag)
+			return h.sendModeration(stream, sessionID, flagID, inputFlag)
+		}
+	}
+	if session == nil {
+		if session, err = h.app.EnsureSession(ctx, req.UserId, "", title, req.Ephemeral); err != nil {
+			return sessionStatus(err, "ensure session failed")
+		}
+	}
+	sessionID := session.ID
+	var restorer *application.PIIRestorer
+	if screened != nil {
+		if err := h.pii.Record(ctx, req.UserId, session, screened); err != nil {
+			log.Printf("[WARN] save pii tokens failed: %v", err)
+		}
+		restorer = screened.Restorer()
+	}
+
+	// 2. Save User Message
+	userMsg, err := h.app.SaveMessage(ctx, session, req.UserId, req.UserName, domain.RoleUser, userMessage, "")
+	if err != nil {
 		log.Printf("[WARN] save user message failed: %v", err)
-		return status.Errorf(codes.Internal, "save message failed: %v", err)
+		// 无痕会话已过期时为 NotFound
+		return sessionStatus(err, "save message failed")
+	}
+	if inputFlag != nil {
+		h.moderation.Flag(ctx, req.UserId, session, userMsg.ID, inputFlag)
+	}
+	// 多人会话：发言推送给其他参与者，上下文中标注发言人
+	collaborative := h.isCollaborative(ctx, sessionID)
+	if collaborative {
+		h.publishMessage(ctx, userMsg)
 	}
 
 	// 3. Build context with token management
 	var contextJSON string
-	history, histErr := h.app.GetHistory(ctx, sessionID, 10, 0)
-	if histErr != nil {
+	var history []*domain.Message
+	if page, histErr := h.app.GetHistory(ctx, req.UserId, sessionID, 10, ""); histErr != nil {
 		log.Printf("[WARN] get history failed: %v", histErr)
+	} else {
+		history = page.Messages
+	}
+	pinned, pinErr := h.app.GetPinnedMessages(ctx, sessionID)
+	if pinErr != nil {
+		log.Printf("[WARN] get pinned messages failed: %v", pinErr)
+	}
+	// 跨会话长期记忆作为置顶内容放在前缀中
+	if seg := h.memorySegment(ctx, req.UserId, userMessage); seg != nil {
+		pinned = append([]*domain.Message{seg}, pinned...)
+	}
+	// 检索到的文档片段同样置顶，回答结束后据此生成引用
+	sources, err := h.retrieveDocuments(ctx, req.UserId, sessionID, req.CollectionIds, userMessage)
+	if err != nil {
+		return err
+	}
+	if seg := ctxbld.DocumentSegment(sources); seg != nil {
+		pinned = append(pinned, seg)
+	}
+	history = mergePinned(pinned, history)
+	// 长期召回：最近窗口之外、与本轮输入相关的旧消息
+	recalled, recallErr := h.app.RecallMessages(ctx, sessionID, userMessage, history)
+	if recallErr != nil {
+		log.Printf("[WARN] recall messages failed: %v", recallErr)
+	}
+	history = append(history, recalled...)
+	prompt := userMessage
+	if collaborative {
+		history, prompt = ctxbld.LabelSpeakers(history, userMsg)
+	}
+	builtCtx, err := h.ctxBuilder.Build(ctx, history, prompt, 32768)
+	if errors.Is(err, ctxbld.ErrPinnedExceedsBudget) {
+		// 置顶内容无法装入上下文时不能静默丢弃，直接告知客户端
+		return stream.Send(&chatpb.ChatResponse{
+			SessionId:  sessionID,
+			Error:      err.Error(),
+			IsFinished: true,
+		})
 	}
-	builtCtx, err := h.ctxBuilder.Build(ctx, history, userMessage, 32768)
 	if err != nil {
 		log.Printf("[WARN] context build failed, falling back to plain message: %v", err)
 		contextJSON = ""
@@ -126,50 +246,118 @@ func (h *ChatHandler) StreamChat(req *chatpb.ChatRequest, stream chatpb.ChatServ
 		inferenceReq.Request = contextJSON
 	}
 
-	tokenChan, err := h.llm.GetGeneratedToken(ctx, inferenceReq)
+	// 回答被审核截断时取消推理
+	inferCtx, cancelInfer := context.WithCancel(ctx)
+	defer cancelInfer()
+	tokenChan, err := h.llm.GetGeneratedToken(inferCtx, inferenceReq)
 	if err != nil {
 		return status.Errorf(codes.Internal, "call llm failed: %v", err)
 	}
 
 	// 4. Stream Response & Aggregate
+	var monitor *application.OutputMonitor
+	if h.moderation != nil {
+		monitor = h.moderation.NewOutputMonitor()
+	}
 	var fullResponse string
 	for token := range tokenChan {
 		if token.Error != "" {
 			return status.Errorf(codes.Internal, "llm stream error: %v", token.Error)
 		}
+		// 分片先经过审核再发送，命中 block 的分片不会发出；被截断的回答不保存
+		if cutoff := monitor.Write(ctx, token.Content); cutoff != nil {
+			cancelInfer()
+			for range tokenChan {
+			}
+			flagID := h.moderation.Flag(ctx, req.UserId, session, "", cutoff)
+			return h.sendModeration(stream, sessionID, flagID, cutoff)
+		}
 
 		// Send to gRPC stream
+		// 占位符只对发起请求的用户还原，保存与推送给其他参与者的回答保留占位符
+		content := restorer.Write(token.Content)
+		if token.IsLast {
+			content += restorer.Flush()
+		}
+		// 有文档来源时最后一帧留给引用，保证 is_finished 只出现一次
 		if err := stream.Send(&chatpb.ChatResponse{
 			SessionId:       sessionID,
-			Content:         token.Content,
+			Content:         content,
 			GeneratedTokens: token.Count,
-			IsFinished:      token.IsLast,
+			IsFinished:      token.IsLast && len(sources) == 0,
 		}); err != nil {
 			return err
 		}
+		if collaborative && token.Content != "" {
+			if err := h.collab.PublishDelta(ctx, sessionID, req.UserId, userMsg.ID, token.Content); err != nil {
+				log.Printf("[WARN] publish delta failed: %v", err)
+			}
+		}
 
 		fullResponse += token.Content
 	}
+	if len(sources) > 0 {
+		if err := stream.Send(&chatpb.ChatResponse{
+			SessionId:  sessionID,
+			Citations:  citationsToPB(ctxbld.Cite(fullResponse, sources)),
+			IsFinished: true,
+		}); err != nil {
+			return err
+		}
+	}
 
 	// 5. Save Assistant Message
+	// 命中 flag 规则的回答照常保存，保存后进入复核队列
+	outputFlag := monitor.Finish(ctx)
 	if fullResponse != "" {
 		// Use a detached context for async save to ensure it completes even if stream ends
 		saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		if err := h.app.SaveMessage(saveCtx, sessionID, req.UserId, domain.RoleAssistant, fullResponse); err != nil {
+		assistantMsg, err := h.app.SaveMessage(saveCtx, session, req.UserId, "", domain.RoleAssistant, fullResponse, req.ModelName)
+		if err != nil {
 			log.Printf("[ERROR] save assistant message failed: %v", err)
+		} else {
+			if outputFlag != nil {
+				h.moderation.Flag(saveCtx, req.UserId, session, assistantMsg.ID, outputFlag)
+			}
+			if collaborative {
+				h.publishMessage(saveCtx, assistantMsg)
+			}
+			// 无痕会话的内容不进入长期记忆与检索索引
+			if !session.Ephemeral {
+				h.extractMemories(domain.TenantFromContext(ctx), req.UserId, sessionID, []*domain.Message{userMsg, assistantMsg})
+				h.indexMessages(userMsg, assistantMsg)
+			}
 		}
 	}
 
 	return nil
 }
 
+// isCollaborative 查询失败时按单人会话处理，不影响对话本身
+func (h *ChatHandler) isCollaborative(ctx context.Context, sessionID string) bool {
+	if h.collab == nil {
+		return false
+	}
+	ok, err := h.collab.IsCollaborative(ctx, sessionID)
+	if err != nil {
+		log.Printf("[WARN] check participants failed: %v", err)
+	}
+	return ok
+}
+
+func (h *ChatHandler) publishMessage(ctx context.Context, msg *domain.Message) {
+	if err := h.collab.PublishMessage(ctx, msg); err != nil {
+		log.Printf("[WARN] publish message failed: %v", err)
+	}
+}
+
 func (h *ChatHandler) CreateSession(ctx context.Context, req *chatpb.CreateSessionRequest) (*chatpb.CreateSessionResponse, error) {
 	title := req.Title
 	if title == "" {
 		title = "New Chat"
 	}
-	session, err := h.app.CreateSession(ctx, req.UserId, title)
+	session, err := h.app.CreateSession(ctx, req.UserId, title, req.Ephemeral)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "create session failed: %v", err)
 	}
@@ -182,29 +370,47 @@ func (h *ChatHandler) CreateSession(ctx context.Context, req *chatpb.CreateSessi
 }
 
 func (h *ChatHandler) GetChatHistory(ctx context.Context, req *chatpb.HistoryRequest) (*chatpb.HistoryResponse, error) {
-	messages, err := h.app.GetHistory(ctx, req.SessionId, int(req.Limit), int(req.Offset))
+	page, err := h.app.GetHistory(ctx, req.UserId, req.SessionId, int(req.Limit), req.Cursor)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "get history failed: %v", err)
+		return nil, pageStatus("get history failed", err)
 	}
 
 	var pbMessages []*chatpb.ChatMessage
-	for _, msg := range messages {
-		pbMessages = append(pbMessages, &chatpb.ChatMessage{
-			Role:      msg.Role.String(),
-			Content:   msg.Content,
-			Timestamp: msg.CreatedAt.Unix(),
-		})
+	for _, msg := range page.Messages {
+		pbMessages = append(pbMessages, messageToPB(msg))
 	}
 
 	return &chatpb.HistoryResponse{
-		Messages: pbMessages,
-		Total:    int32(len(pbMessages)),
+		Messages:   pbMessages,
+		Total:      int32(page.Total),
+		NextCursor: page.NextCursor,
 	}, nil
 }
 
+func messageToPB(msg *domain.Message) *chatpb.ChatMessage {
+	return &chatpb.ChatMessage{
+		SessionId:  msg.SessionID,
+		Role:       msg.Role.String(),
+		Content:    msg.Content,
+		Timestamp:  msg.CreatedAt.Unix(),
+		MessageId:  msg.ID,
+		Pinned:     msg.Pinned,
+		Model:      msg.Model,
+		UserId:     msg.UserID,
+		AuthorName: msg.AuthorName,
+	}
+}
+
 func (h *ChatHandler) DeleteSession(ctx context.Context, req *chatpb.DeleteSessionRequest) (*chatpb.DeleteSessionResponse, error) {
-	if err := h.app.DeleteSession(ctx, req.SessionId, req.UserId); err != nil {
-		return nil, status.Errorf(codes.Internal, "delete session failed: %v", err)
+	session, err := h.app.DeleteSession(ctx, req.SessionId, req.UserId)
+	if err != nil {
+		return nil, sessionStatus(err, "delete session failed")
+	}
+	if h.documents != nil {
+		// 附件属于会话所有者，管理员删除时也按所有者及会话所在的工作空间清理
+		if err := h.documents.DeleteSessionDocuments(ctx, session); err != nil {
+			log.Printf("[WARN] delete session documents failed: %v", err)
+		}
 	}
 
 	return &chatpb.DeleteSessionResponse{
@@ -214,23 +420,85 @@ func (h *ChatHandler) DeleteSession(ctx context.Context, req *chatpb.DeleteSessi
 }
 
 func (h *ChatHandler) GetSessions(ctx context.Context, req *chatpb.GetSessionsRequest) (*chatpb.GetSessionsResponse, error) {
-	// Default limit/offset if not provided
-
-	sessions, err := h.app.GetSessions(ctx, req.UserId, int(req.Limit), int(req.Offset))
+	sort, err := domain.ParseSessionSort(req.Sort)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "get sessions failed: %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "get sessions failed: %v", err)
+	}
+	// limit 非正时由应用层使用默认值
+	page, err := h.app.GetSessions(ctx, domain.SessionQuery{
+		UserID:   req.UserId,
+		Sort:     sort,
+		FolderID: req.FolderId,
+		Tag:      req.Tag,
+		Archived: req.Archived,
+		Joined:   req.Joined,
+		Cursor:   req.Cursor,
+		Limit:    int(req.Limit),
+	})
+	if err != nil {
+		return nil, pageStatus("get sessions failed", err)
 	}
 
-	var pbSessions []*chatpb.Session
-	for _, s := range sessions {
-		pbSessions = append(pbSessions, &chatpb.Session{
-			SessionId: s.ID,
-			Title:     s.Title,
-		})
+	pbSessions := make([]*chatpb.Session, len(page.Sessions))
+	for i, s := range page.Sessions {
+		pbSessions[i] = sessionToPB(s)
 	}
 
 	return &chatpb.GetSessionsResponse{
-		Sessions: pbSessions,
-		Total:    int32(len(pbSessions)),
+		Sessions:   pbSessions,
+		Total:      int32(page.Total),
+		NextCursor: page.NextCursor,
 	}, nil
 }
+
+// piiStatus 被拒绝的输入按参数错误返回，客户端可以据此提示用户删除敏感信息后重试
+func piiStatus(err error) error {
+	if errors.Is(err, domain.ErrPIIBlocked) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Errorf(codes.Internal, "screen message failed: %v", err)
+}
+
+// pageStatus 把分页查询的错误映射为 gRPC 状态码
+func pageStatus(msg string, err error) error {
+	if errors.Is(err, domain.ErrInvalidCursor) || errors.Is(err, domain.ErrTitleSortUnavailable) {
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	}
+	// 其余错误与其他会话操作一致
+	return sessionStatus(err, msg)
+}
+
+func (h *ChatHandler) PinMessage(ctx context.Context, req *chatpb.PinMessageRequest) (*chatpb.PinMessageResponse, error) {
+	if err := h.app.PinMessage(ctx, req.UserId, req.SessionId, req.MessageId, req.Pinned); err != nil {
+		return nil, sessionStatus(err, "pin message failed")
+	}
+
+	message := "Message pinned successfully"
+	if !req.Pinned {
+		message = "Message unpinned successfully"
+	}
+	return &chatpb.PinMessageResponse{
+		Success: true,
+		Message: message,
+	}, nil
+}
+
+// mergePinned 将置顶消息并入历史，去掉历史中已包含的同一条消息
+func mergePinned(pinned, history []*domain.Message) []*domain.Message {
+	if len(pinned) == 0 {
+		return history
+	}
+	seen := make(map[string]struct{}, len(pinned))
+	merged := make([]*domain.Message, 0, len(pinned)+len(history))
+	for _, msg := range pinned {
+		seen[msg.ID] = struct{}{}
+		merged = append(merged, msg)
+	}
+	for _, msg := range history {
+		if _, ok := seen[msg.ID]; ok {
+			continue
+		}
+		merged = append(merged, msg)
+	}
+	return merged
+}
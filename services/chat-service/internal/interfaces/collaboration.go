@@ -0,0 +1,116 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sessionEventReady 是订阅建立后发送的第一个事件，不经过事件总线
+const sessionEventReady = "ready"
+
+// errCollaborationUnavailable 数据库不可用时多人会话功能关闭
+var errCollaborationUnavailable = status.Error(codes.Unavailable, "collaboration is unavailable")
+
+func participantToPB(p *domain.Participant) *chatpb.Participant {
+	return &chatpb.Participant{
+		UserId:    p.UserID,
+		Role:      string(p.Role),
+		AddedBy:   p.AddedBy,
+		CreatedAt: p.CreatedAt.Unix(),
+	}
+}
+
+func (h *ChatHandler) ListParticipants(ctx context.Context, req *chatpb.ListParticipantsRequest) (*chatpb.ListParticipantsResponse, error) {
+	if h.collab == nil {
+		return nil, errCollaborationUnavailable
+	}
+	participants, err := h.collab.ListParticipants(ctx, req.UserId, req.SessionId)
+	if err != nil {
+		return nil, participantStatus(err, "list participants failed")
+	}
+	pbParticipants := make([]*chatpb.Participant, len(participants))
+	for i, p := range participants {
+		pbParticipants[i] = participantToPB(p)
+	}
+	return &chatpb.ListParticipantsResponse{Participants: pbParticipants}, nil
+}
+
+func (h *ChatHandler) SetParticipant(ctx context.Context, req *chatpb.SetParticipantRequest) (*chatpb.SetParticipantResponse, error) {
+	if h.collab == nil {
+		return nil, errCollaborationUnavailable
+	}
+	p, err := h.collab.SetParticipant(ctx, req.UserId, req.SessionId, req.ParticipantId, req.Role)
+	if err != nil {
+		return nil, participantStatus(err, "set participant failed")
+	}
+	return &chatpb.SetParticipantResponse{Participant: participantToPB(p)}, nil
+}
+
+func (h *ChatHandler) RemoveParticipant(ctx context.Context, req *chatpb.RemoveParticipantRequest) (*chatpb.RemoveParticipantResponse, error) {
+	if h.collab == nil {
+		return nil, errCollaborationUnavailable
+	}
+	if err := h.collab.RemoveParticipant(ctx, req.UserId, req.SessionId, req.ParticipantId); err != nil {
+		return nil, participantStatus(err, "remove participant failed")
+	}
+	return &chatpb.RemoveParticipantResponse{
+		Success: true,
+		Message: "Participant removed successfully",
+	}, nil
+}
+
+// SubscribeSession 推送会话的实时事件，直到客户端断开或用户被移出会话
+func (h *ChatHandler) SubscribeSession(req *chatpb.SubscribeSessionRequest, stream chatpb.ChatService_SubscribeSessionServer) error {
+	if h.collab == nil {
+		return errCollaborationUnavailable
+	}
+	events, err := h.collab.Subscribe(stream.Context(), req.UserId, req.SessionId)
+	if err != nil {
+		return participantStatus(err, "subscribe session failed")
+	}
+	// 先告知订阅已建立，客户端据此区分授权错误与长时间没有事件
+	if err := stream.Send(&chatpb.SessionEvent{Type: sessionEventReady, SessionId: req.SessionId, Timestamp: time.Now().Unix()}); err != nil {
+		return err
+	}
+	for event := range events {
+		pb := &chatpb.SessionEvent{
+			Type:      string(event.Type),
+			SessionId: event.SessionID,
+			UserId:    event.UserID,
+			Delta:     event.Delta,
+			ReplyTo:   event.ReplyTo,
+			Timestamp: event.CreatedAt.Unix(),
+		}
+		if event.Message != nil {
+			pb.Message = messageToPB(event.Message)
+		}
+		if err := stream.Send(pb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func participantStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrParticipantNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrEphemeralSession):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidParticipant):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrEventsUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
@@ -0,0 +1,178 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+	ctxbld "free-chat/services/chat-service/internal/infrastructure/context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errDocumentsUnavailable 数据库不可用时文档功能关闭
+var errDocumentsUnavailable = status.Error(codes.Unavailable, "documents are unavailable")
+
+// citationSnippetRunes 引用中附带的切片摘要长度
+const citationSnippetRunes = 160
+
+// retrieveDocuments 检索会话附件与指定知识库中的相关切片。
+// 知识库不存在或无权访问时直接报错，检索本身失败则降级为不带文档的对话。
+func (h *ChatHandler) retrieveDocuments(ctx context.Context, userID, sessionID string, collectionIDs []string, query string) ([]*domain.DocumentChunk, error) {
+	if h.documents == nil {
+		if len(collectionIDs) > 0 {
+			return nil, errDocumentsUnavailable
+		}
+		return nil, nil
+	}
+	chunks, err := h.documents.RetrieveForChat(ctx, userID, sessionID, collectionIDs, query)
+	if errors.Is(err, domain.ErrCollectionNotFound) || errors.Is(err, domain.ErrPermissionDenied) {
+		return nil, documentStatus(err, "retrieve documents failed")
+	}
+	if err != nil {
+		log.Printf("[WARN] retrieve documents failed: %v", err)
+		return nil, nil
+	}
+	return chunks, nil
+}
+
+func citationsToPB(citations []ctxbld.Citation) []*chatpb.Citation {
+	pbCitations := make([]*chatpb.Citation, len(citations))
+	for i, c := range citations {
+		snippet := []rune(c.Chunk.Content)
+		if len(snippet) > citationSnippetRunes {
+			snippet = append(snippet[:citationSnippetRunes], '…')
+		}
+		pbCitations[i] = &chatpb.Citation{
+			Start:      int32(c.Start),
+			End:        int32(c.End),
+			Source:     int32(c.Source),
+			DocumentId: c.Chunk.DocumentID,
+			ChunkId:    c.Chunk.ID,
+			Title:      c.Chunk.Title,
+			Snippet:    string(snippet),
+		}
+	}
+	return pbCitations
+}
+
+func (h *ChatHandler) UploadDocument(ctx context.Context, req *chatpb.UploadDocumentRequest) (*chatpb.UploadDocumentResponse, error) {
+	if h.documents == nil {
+		return nil, errDocumentsUnavailable
+	}
+	doc, err := h.documents.UploadDocument(ctx, req.UserId, req.SessionId, req.CollectionId, req.Title, req.Format, req.Content)
+	if err != nil {
+		return nil, documentStatus(err, "upload document failed")
+	}
+	return &chatpb.UploadDocumentResponse{
+		Success:    true,
+		Message:    "Document uploaded successfully",
+		DocumentId: doc.ID,
+		ChunkCount: int32(doc.ChunkCount),
+	}, nil
+}
+
+func (h *ChatHandler) ListDocuments(ctx context.Context, req *chatpb.ListDocumentsRequest) (*chatpb.ListDocumentsResponse, error) {
+	if h.documents == nil {
+		return nil, errDocumentsUnavailable
+	}
+	docs, err := h.documents.ListDocuments(ctx, req.UserId, req.SessionId, req.CollectionId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list documents failed: %v", err)
+	}
+
+	pbDocs := make([]*chatpb.Document, len(docs))
+	for i, d := range docs {
+		pbDocs[i] = &chatpb.Document{
+			DocumentId:   d.ID,
+			Title:        d.Title,
+			Format:       string(d.Format),
+			SessionId:    d.SessionID,
+			CollectionId: d.CollectionID,
+			ChunkCount:   int32(d.ChunkCount),
+			CreatedAt:    d.CreatedAt.Unix(),
+		}
+	}
+	return &chatpb.ListDocumentsResponse{Documents: pbDocs}, nil
+}
+
+func (h *ChatHandler) DeleteDocument(ctx context.Context, req *chatpb.DeleteDocumentRequest) (*chatpb.DeleteDocumentResponse, error) {
+	if h.documents == nil {
+		return nil, errDocumentsUnavailable
+	}
+	if err := h.documents.DeleteDocument(ctx, req.UserId, req.DocumentId); err != nil {
+		return nil, documentStatus(err, "delete document failed")
+	}
+	return &chatpb.DeleteDocumentResponse{
+		Success: true,
+		Message: "Document deleted successfully",
+	}, nil
+}
+
+func (h *ChatHandler) CreateCollection(ctx context.Context, req *chatpb.CreateCollectionRequest) (*chatpb.CreateCollectionResponse, error) {
+	if h.documents == nil {
+		return nil, errDocumentsUnavailable
+	}
+	collection, err := h.documents.CreateCollection(ctx, req.UserId, req.Name)
+	if err != nil {
+		return nil, documentStatus(err, "create collection failed")
+	}
+	return &chatpb.CreateCollectionResponse{
+		Success:      true,
+		Message:      "Collection created successfully",
+		CollectionId: collection.ID,
+	}, nil
+}
+
+func (h *ChatHandler) ListCollections(ctx context.Context, req *chatpb.ListCollectionsRequest) (*chatpb.ListCollectionsResponse, error) {
+	if h.documents == nil {
+		return nil, errDocumentsUnavailable
+	}
+	collections, err := h.documents.ListCollections(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list collections failed: %v", err)
+	}
+
+	pbCollections := make([]*chatpb.Collection, len(collections))
+	for i, c := range collections {
+		pbCollections[i] = &chatpb.Collection{
+			CollectionId: c.ID,
+			Name:         c.Name,
+			CreatedAt:    c.CreatedAt.Unix(),
+		}
+	}
+	return &chatpb.ListCollectionsResponse{Collections: pbCollections}, nil
+}
+
+func (h *ChatHandler) DeleteCollection(ctx context.Context, req *chatpb.DeleteCollectionRequest) (*chatpb.DeleteCollectionResponse, error) {
+	if h.documents == nil {
+		return nil, errDocumentsUnavailable
+	}
+	if err := h.documents.DeleteCollection(ctx, req.UserId, req.CollectionId); err != nil {
+		return nil, documentStatus(err, "delete collection failed")
+	}
+	return &chatpb.DeleteCollectionResponse{
+		Success: true,
+		Message: "Collection deleted successfully",
+	}, nil
+}
+
+func documentStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrDocumentNotFound),
+		errors.Is(err, domain.ErrCollectionNotFound),
+		errors.Is(err, domain.ErrSessionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrEphemeralSession):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidDocument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
@@ -0,0 +1,103 @@
+package interfaces
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	embeddingpb "free-chat/pkg/proto/embedding"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// embedBatchSize 单次 Embed RPC 最多携带的文本数
+const embedBatchSize = 64
+
+// EmbeddingClient calls a remote EmbeddingService via gRPC.
+// Implements domain.Embedder for a real embedding model.
+type EmbeddingClient struct {
+	mu     sync.RWMutex
+	conn   *grpc.ClientConn
+	target string
+	model  string
+}
+
+// NewEmbeddingClient creates a client for the embedding service. model may be
+// empty to use the server default.
+func NewEmbeddingClient(target, model string) *EmbeddingClient {
+	return &EmbeddingClient{target: target, model: model}
+}
+
+func (c *EmbeddingClient) getConn() (*grpc.ClientConn, error) {
+	c.mu.RLock()
+	if c.conn != nil && c.conn.GetState() != connectivity.Shutdown {
+		conn := c.conn
+		c.mu.RUnlock()
+		return conn, nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && c.conn.GetState() != connectivity.Shutdown {
+		return c.conn, nil
+	}
+
+	conn, err := grpc.NewClient(c.target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithIdleTimeout(30*time.Minute),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                20 * time.Second,
+			Timeout:             10 * time.Second,
+			PermitWithoutStream: true,
+		}))
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// Embed sends texts in batches and returns one vector per text.
+func (c *EmbeddingClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	conn, err := c.getConn()
+	if err != nil {
+		return nil, fmt.Errorf("connect embedding service: %w", err)
+	}
+	client := embeddingpb.NewEmbeddingServiceClient(conn)
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		batch := texts[start:min(start+embedBatchSize, len(texts))]
+		resp, err := client.Embed(ctx, &embeddingpb.EmbedRequest{Texts: batch, Model: c.model})
+		if err != nil {
+			return nil, fmt.Errorf("Embed RPC: %w", err)
+		}
+		if len(resp.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("Embed RPC returned %d vectors for %d texts", len(resp.Embeddings), len(batch))
+		}
+		for _, e := range resp.Embeddings {
+			vectors = append(vectors, e.Values)
+		}
+	}
+	return vectors, nil
+}
+
+func (c *EmbeddingClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+var _ domain.Embedder = (*EmbeddingClient)(nil)
@@ -0,0 +1,91 @@
+package interfaces
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	embeddingpb "free-chat/pkg/proto/embedding"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// mockEmbeddingService embeds each text as [len(text), batch size].
+type mockEmbeddingService struct {
+	embeddingpb.UnimplementedEmbeddingServiceServer
+	calls int
+}
+
+func (m *mockEmbeddingService) Embed(
+	ctx context.Context,
+	req *embeddingpb.EmbedRequest,
+) (*embeddingpb.EmbedResponse, error) {
+	m.calls++
+	resp := &embeddingpb.EmbedResponse{Dimension: 2}
+	for _, text := range req.Texts {
+		resp.Embeddings = append(resp.Embeddings, &embeddingpb.Embedding{
+			Values: []float32{float32(len(text)), float32(len(req.Texts))},
+		})
+	}
+	return resp, nil
+}
+
+func dialEmbeddingBufconn(t *testing.T, svc *mockEmbeddingService) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	embeddingpb.RegisterEmbeddingServiceServer(server, svc)
+	go server.Serve(lis)
+	t.Cleanup(func() { server.Stop() })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestEmbeddingClientBatchesInOrder(t *testing.T) {
+	svc := &mockEmbeddingService{}
+	client := &EmbeddingClient{conn: dialEmbeddingBufconn(t, svc)}
+
+	texts := make([]string, embedBatchSize+3)
+	for i := range texts {
+		texts[i] = string(make([]byte, i))
+	}
+	vectors, err := client.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if svc.calls != 2 {
+		t.Errorf("expected 2 batched calls, got %d", svc.calls)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("expected %d vectors, got %d", len(texts), len(vectors))
+	}
+	for i, v := range vectors {
+		if int(v[0]) != i {
+			t.Fatalf("vector %d out of order: %v", i, v)
+		}
+	}
+	if vectors[len(texts)-1][1] != 3 {
+		t.Errorf("last batch should hold the remaining 3 texts, got %v", vectors[len(texts)-1])
+	}
+}
+
+func TestEmbeddingClientEmptyInput(t *testing.T) {
+	svc := &mockEmbeddingService{}
+	client := &EmbeddingClient{conn: dialEmbeddingBufconn(t, svc)}
+
+	vectors, err := client.Embed(context.Background(), nil)
+	if err != nil || vectors != nil || svc.calls != 0 {
+		t.Errorf("empty input should not call the service: %v %v %d", vectors, err, svc.calls)
+	}
+}
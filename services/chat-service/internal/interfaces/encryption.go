@@ -0,0 +1,59 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errEncryptionUnavailable 未配置主密钥时不启用静态加密
+var errEncryptionUnavailable = status.Error(codes.Unavailable, "encryption at rest is not enabled")
+
+func (h *ChatHandler) ListDataKeys(ctx context.Context, req *chatpb.ListDataKeysRequest) (*chatpb.ListDataKeysResponse, error) {
+	if h.encryption == nil {
+		return nil, errEncryptionUnavailable
+	}
+	keys, err := h.encryption.Keys(ctx, req.UserId)
+	if err != nil {
+		return nil, encryptionStatus(err, "list data keys failed")
+	}
+	resp := &chatpb.ListDataKeysResponse{Keys: make([]*chatpb.DataKey, len(keys))}
+	for i, k := range keys {
+		resp.Keys[i] = dataKeyToPB(k)
+	}
+	return resp, nil
+}
+
+func (h *ChatHandler) RotateDataKey(ctx context.Context, req *chatpb.RotateDataKeyRequest) (*chatpb.RotateDataKeyResponse, error) {
+	if h.encryption == nil {
+		return nil, errEncryptionUnavailable
+	}
+	key, err := h.encryption.Rotate(ctx, req.UserId)
+	if err != nil {
+		return nil, encryptionStatus(err, "rotate data key failed")
+	}
+	return &chatpb.RotateDataKeyResponse{Key: dataKeyToPB(key)}, nil
+}
+
+func dataKeyToPB(k *domain.DataKey) *chatpb.DataKey {
+	return &chatpb.DataKey{
+		Scope:     k.Scope,
+		Version:   int32(k.Version),
+		Current:   k.Current(),
+		RetiredAt: unixOrZero(k.RetiredAt),
+		Destroyed: k.Destroyed,
+		CreatedAt: k.CreatedAt.Unix(),
+	}
+}
+
+func encryptionStatus(err error, msg string) error {
+	if errors.Is(err, domain.ErrPermissionDenied) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
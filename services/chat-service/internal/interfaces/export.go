@@ -0,0 +1,96 @@
+package interfaces
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exportChunkSize 每个 ExportChunk 携带的最大字节数，远低于 gRPC 默认的 4MB 消息上限
+const exportChunkSize = 32 << 10
+
+// errExportUnavailable 数据库不可用时导出关闭
+var errExportUnavailable = status.Error(codes.Unavailable, "export is unavailable")
+
+// chunkSender 把写入的数据切成不超过 exportChunkSize 的块发送，每块附带当前文件名
+type chunkSender struct {
+	stream      chatpb.ChatService_ExportSessionServer
+	filename    string
+	contentType string
+}
+
+func (c *chunkSender) Write(p []byte) (int, error) {
+	for sent := 0; sent < len(p); {
+		end := min(sent+exportChunkSize, len(p))
+		if err := c.stream.Send(&chatpb.ExportChunk{
+			Filename:    c.filename,
+			ContentType: c.contentType,
+			Data:        p[sent:end],
+		}); err != nil {
+			return sent, err
+		}
+		sent = end
+	}
+	return len(p), nil
+}
+
+func (h *ChatHandler) ExportSession(req *chatpb.ExportSessionRequest, stream chatpb.ChatService_ExportSessionServer) error {
+	if h.exports == nil {
+		return errExportUnavailable
+	}
+	format, ok := domain.ParseExportFormat(req.Format)
+	if !ok {
+		return status.Error(codes.InvalidArgument, domain.ErrInvalidExportFormat.Error())
+	}
+
+	// 每个会话一个缓冲写入器，开始下一个文件前把上一个文件的剩余数据发完
+	var current *bufio.Writer
+	target := func(session *domain.Session) (io.Writer, error) {
+		if current != nil {
+			if err := current.Flush(); err != nil {
+				return nil, err
+			}
+		}
+		current = bufio.NewWriterSize(&chunkSender{
+			stream:      stream,
+			filename:    domain.ExportFilename(session, format),
+			contentType: format.ContentType(),
+		}, exportChunkSize)
+		return current, nil
+	}
+
+	var err error
+	if req.SessionId != "" {
+		err = h.exports.ExportSession(stream.Context(), req.UserId, req.SessionId, format, target)
+	} else {
+		err = h.exports.ExportSessions(stream.Context(), req.UserId, format, target)
+	}
+	if err == nil && current != nil {
+		err = current.Flush()
+	}
+	if err != nil {
+		return exportStatus(err)
+	}
+	return nil
+}
+
+func exportStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrEphemeralSession):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidExportFormat):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "export failed: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package interfaces
+
+import (
+	"errors"
+	"io"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errImportUnavailable 数据库不可用时导入关闭
+var errImportUnavailable = status.Error(codes.Unavailable, "import is unavailable")
+
+// importReader 把客户端流式上传的 ImportChunk 还原为连续的字节流
+type importReader struct {
+	stream chatpb.ChatService_ImportConversationsServer
+	buf    []byte
+}
+
+func (r *importReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk.Data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (h *ChatHandler) ImportConversations(stream chatpb.ChatService_ImportConversationsServer) error {
+	if h.imports == nil {
+		return errImportUnavailable
+	}
+	// 第一块携带导入参数
+	head, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "empty import")
+	}
+	if err != nil {
+		return err
+	}
+	if head.UserId == "" {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	format, ok := domain.ParseImportFormat(head.Format)
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported import format %q", head.Format)
+	}
+
+	report, err := h.imports.Import(stream.Context(), head.UserId, format, &importReader{stream: stream, buf: head.Data}, head.DryRun)
+	if err != nil {
+		return importStatus(err)
+	}
+
+	resp := &chatpb.ImportReport{
+		DryRun:          report.DryRun,
+		Sessions:        int32(report.Sessions),
+		Messages:        int32(report.Messages),
+		Tokens:          int32(report.Tokens),
+		SkippedMessages: int32(report.SkippedMessages),
+		Duplicates:      int32(report.Duplicates),
+	}
+	for _, r := range report.Results {
+		resp.Results = append(resp.Results, &chatpb.ImportResult{
+			SourceId:     r.SourceID,
+			SessionId:    r.SessionID,
+			Title:        r.Title,
+			MessageCount: int32(r.MessageCount),
+			TokenCount:   int32(r.TokenCount),
+			Status:       r.Status,
+		})
+	}
+	return stream.SendAndClose(resp)
+}
+
+func importStatus(err error) error {
+	if errors.Is(err, domain.ErrInvalidImport) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "import failed: %v", err)
+}
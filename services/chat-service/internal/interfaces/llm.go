@@ -146,3 +146,10 @@ func (c *LLMClient) GetGeneratedToken(ctx context.Context, req *domain.Inference
 
 	return outCh, nil
 }
+
+// StreamInference implements domain.InferenceService.
+func (c *LLMClient) StreamInference(ctx context.Context, req *domain.InferenceRequest) (<-chan *domain.GeneratedToken, error) {
+	return c.GetGeneratedToken(ctx, req)
+}
+
+var _ domain.InferenceService = (*LLMClient)(nil)
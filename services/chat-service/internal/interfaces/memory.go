@@ -0,0 +1,136 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+	ctxbld "free-chat/services/chat-service/internal/infrastructure/context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errMemoryUnavailable 数据库不可用时记忆功能关闭
+var errMemoryUnavailable = status.Error(codes.Unavailable, "memory is unavailable")
+
+// memoryExtractTimeout 异步抽取记忆的超时时间（一次完整的模型调用）
+const memoryExtractTimeout = 2 * time.Minute
+
+// memorySegment 取出与本轮输入相关的长期记忆，渲染为置顶的 system 消息
+func (h *ChatHandler) memorySegment(ctx context.Context, userID, userMessage string) *domain.Message {
+	if h.memory == nil {
+		return nil
+	}
+	memories, err := h.memory.ActiveMemories(ctx, userID)
+	if err != nil {
+		log.Printf("[WARN] load memories failed: %v", err)
+		return nil
+	}
+	return ctxbld.MemorySegment(ctxbld.SelectMemories(memories, userMessage, memoryTopK))
+}
+
+// extractMemories 在回复完成后异步抽取记忆，不阻塞对话；记忆保存在请求所在的工作空间
+func (h *ChatHandler) extractMemories(tenant domain.Tenant, userID, sessionID string, exchange []*domain.Message) {
+	if h.memory == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(domain.WithTenant(context.Background(), tenant), memoryExtractTimeout)
+		defer cancel()
+		saved, err := h.memory.ExtractMemories(ctx, userID, sessionID, exchange)
+		if err != nil {
+			log.Printf("[WARN] extract memories failed: %v", err)
+			return
+		}
+		if len(saved) > 0 {
+			log.Printf("[INFO] saved %d memories for user %s", len(saved), userID)
+		}
+	}()
+}
+
+func (h *ChatHandler) ListMemories(ctx context.Context, req *chatpb.ListMemoriesRequest) (*chatpb.ListMemoriesResponse, error) {
+	if h.memory == nil {
+		return nil, errMemoryUnavailable
+	}
+	memories, enabled, err := h.memory.ListMemories(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list memories failed: %v", err)
+	}
+
+	pbMemories := make([]*chatpb.Memory, len(memories))
+	for i, m := range memories {
+		pbMemories[i] = &chatpb.Memory{
+			MemoryId:         m.ID,
+			Content:          m.Content,
+			Category:         string(m.Category),
+			SourceSessionId:  m.SourceSessionID,
+			SourceMessageIds: m.SourceMessageIDs,
+			CreatedAt:        m.CreatedAt.Unix(),
+			UpdatedAt:        m.UpdatedAt.Unix(),
+		}
+	}
+	return &chatpb.ListMemoriesResponse{
+		Memories: pbMemories,
+		Enabled:  enabled,
+	}, nil
+}
+
+func (h *ChatHandler) UpdateMemory(ctx context.Context, req *chatpb.UpdateMemoryRequest) (*chatpb.UpdateMemoryResponse, error) {
+	if h.memory == nil {
+		return nil, errMemoryUnavailable
+	}
+	if err := h.memory.UpdateMemory(ctx, req.UserId, req.MemoryId, req.Content); err != nil {
+		return nil, memoryStatus(err, "update memory failed")
+	}
+	return &chatpb.UpdateMemoryResponse{
+		Success: true,
+		Message: "Memory updated successfully",
+	}, nil
+}
+
+func (h *ChatHandler) DeleteMemory(ctx context.Context, req *chatpb.DeleteMemoryRequest) (*chatpb.DeleteMemoryResponse, error) {
+	if h.memory == nil {
+		return nil, errMemoryUnavailable
+	}
+	if err := h.memory.DeleteMemory(ctx, req.UserId, req.MemoryId); err != nil {
+		return nil, memoryStatus(err, "delete memory failed")
+	}
+	return &chatpb.DeleteMemoryResponse{
+		Success: true,
+		Message: "Memory deleted successfully",
+	}, nil
+}
+
+func (h *ChatHandler) SetMemoryEnabled(ctx context.Context, req *chatpb.SetMemoryEnabledRequest) (*chatpb.SetMemoryEnabledResponse, error) {
+	if h.memory == nil {
+		return nil, errMemoryUnavailable
+	}
+	if err := h.memory.SetMemoryEnabled(ctx, req.UserId, req.Enabled); err != nil {
+		return nil, status.Errorf(codes.Internal, "set memory enabled failed: %v", err)
+	}
+	msg := "Memory disabled"
+	if req.Enabled {
+		msg = "Memory enabled"
+	}
+	return &chatpb.SetMemoryEnabledResponse{
+		Success: true,
+		Message: msg,
+	}, nil
+}
+
+func memoryStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrMemoryNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrInvalidMemory):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
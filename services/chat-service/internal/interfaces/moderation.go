@@ -0,0 +1,93 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/application"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errModerationUnavailable 未启用审核时没有复核队列
+var errModerationUnavailable = status.Error(codes.Unavailable, "moderation is not enabled")
+
+func (h *ChatHandler) ListModerationFlags(ctx context.Context, req *chatpb.ListModerationFlagsRequest) (*chatpb.ListModerationFlagsResponse, error) {
+	if h.moderation == nil {
+		return nil, errModerationUnavailable
+	}
+	page, err := h.moderation.ListFlags(ctx, req.UserId, domain.FlagStatus(req.Status), int(req.Limit), req.Cursor)
+	if err != nil {
+		return nil, moderationStatus(err, "list moderation flags failed")
+	}
+	resp := &chatpb.ListModerationFlagsResponse{
+		Flags:      make([]*chatpb.ModerationFlag, len(page.Flags)),
+		NextCursor: page.NextCursor,
+	}
+	for i, f := range page.Flags {
+		resp.Flags[i] = moderationFlagToPB(f)
+	}
+	return resp, nil
+}
+
+func (h *ChatHandler) ReviewModerationFlag(ctx context.Context, req *chatpb.ReviewModerationFlagRequest) (*chatpb.ReviewModerationFlagResponse, error) {
+	if h.moderation == nil {
+		return nil, errModerationUnavailable
+	}
+	flag, err := h.moderation.ReviewFlag(ctx, req.UserId, req.FlagId, domain.FlagStatus(req.Status))
+	if err != nil {
+		return nil, moderationStatus(err, "review moderation flag failed")
+	}
+	return &chatpb.ReviewModerationFlagResponse{Flag: moderationFlagToPB(flag)}, nil
+}
+
+// sendModeration 用最后一帧告知客户端输入被拒绝或回答被截断
+func (h *ChatHandler) sendModeration(stream chatpb.ChatService_StreamChatServer, sessionID, flagID string, res *application.ModerationResult) error {
+	rule, category := res.Event()
+	return stream.Send(&chatpb.ChatResponse{
+		SessionId:  sessionID,
+		IsFinished: true,
+		Moderation: &chatpb.ModerationEvent{
+			Stage:    string(res.Stage),
+			Category: category,
+			Rule:     rule,
+			FlagId:   flagID,
+		},
+	})
+}
+
+func moderationFlagToPB(f *domain.ModerationFlag) *chatpb.ModerationFlag {
+	return &chatpb.ModerationFlag{
+		FlagId:     f.ID,
+		UserId:     f.UserID,
+		SessionId:  f.SessionID,
+		MessageId:  f.MessageID,
+		Stage:      string(f.Stage),
+		Rule:       f.Rule,
+		Category:   f.Category,
+		Action:     string(f.Action),
+		Score:      f.Score,
+		Excerpt:    f.Excerpt,
+		Status:     string(f.Status),
+		ReviewedBy: f.ReviewedBy,
+		ReviewedAt: unixOrZero(f.ReviewedAt),
+		CreatedAt:  f.CreatedAt.Unix(),
+	}
+}
+
+func moderationStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrFlagNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrInvalidReview), errors.Is(err, domain.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrReviewQueueUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
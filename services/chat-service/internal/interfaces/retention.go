@@ -0,0 +1,79 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errRetentionUnavailable 数据库不可用时没有保留策略可管理
+var errRetentionUnavailable = status.Error(codes.Unavailable, "retention is unavailable")
+
+func (h *ChatHandler) GetRetentionPolicy(ctx context.Context, req *chatpb.GetRetentionPolicyRequest) (*chatpb.GetRetentionPolicyResponse, error) {
+	if h.retention == nil {
+		return nil, errRetentionUnavailable
+	}
+	policy, err := h.retention.GetPolicy(ctx, req.UserId)
+	if err != nil {
+		return nil, retentionStatus(err, "get retention policy failed")
+	}
+	return &chatpb.GetRetentionPolicyResponse{Policy: retentionPolicyToPB(policy)}, nil
+}
+
+func (h *ChatHandler) SetRetentionPolicy(ctx context.Context, req *chatpb.SetRetentionPolicyRequest) (*chatpb.SetRetentionPolicyResponse, error) {
+	if h.retention == nil {
+		return nil, errRetentionUnavailable
+	}
+	policy, err := h.retention.SetPolicy(ctx, req.UserId, int(req.MessageDays))
+	if err != nil {
+		return nil, retentionStatus(err, "set retention policy failed")
+	}
+	return &chatpb.SetRetentionPolicyResponse{Policy: retentionPolicyToPB(policy)}, nil
+}
+
+func (h *ChatHandler) GetRetentionReport(ctx context.Context, req *chatpb.GetRetentionReportRequest) (*chatpb.GetRetentionReportResponse, error) {
+	if h.retention == nil {
+		return nil, errRetentionUnavailable
+	}
+	report, err := h.retention.Report(ctx, req.UserId)
+	if err != nil {
+		return nil, retentionStatus(err, "get retention report failed")
+	}
+	deleted := make([]*chatpb.PurgeCount, len(report.Deleted))
+	for i, c := range report.Deleted {
+		deleted[i] = &chatpb.PurgeCount{Target: string(c.Target), Count: c.Count}
+	}
+	return &chatpb.GetRetentionReportResponse{
+		Policy:          retentionPolicyToPB(report.Policy),
+		MessageCutoff:   unixOrZero(report.MessageCutoff),
+		ExpiredMessages: report.ExpiredMessages,
+		DeletedCutoff:   unixOrZero(report.DeletedCutoff),
+		Deleted:         deleted,
+	}, nil
+}
+
+func retentionPolicyToPB(p *domain.RetentionPolicy) *chatpb.RetentionPolicy {
+	return &chatpb.RetentionPolicy{
+		WorkspaceId: p.WorkspaceID,
+		MessageDays: int32(p.MessageDays),
+		IsDefault:   p.Default,
+		UpdatedBy:   p.UpdatedBy,
+		UpdatedAt:   unixOrZero(p.UpdatedAt),
+	}
+}
+
+func retentionStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrInvalidRetention):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
@@ -0,0 +1,106 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+	ctxbld "free-chat/services/chat-service/internal/infrastructure/context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// indexTimeout 异步建立消息向量索引的超时时间
+	indexTimeout = 30 * time.Second
+	// snippetRunes 全文检索结果片段的长度
+	snippetRunes = 160
+)
+
+// indexMessages 在回复完成后异步为本轮消息建立向量索引，不阻塞对话
+func (h *ChatHandler) indexMessages(messages ...*domain.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+		defer cancel()
+		if err := h.app.IndexMessages(ctx, messages); err != nil {
+			log.Printf("[WARN] index messages failed: %v", err)
+		}
+	}()
+}
+
+func (h *ChatHandler) SearchConversations(ctx context.Context, req *chatpb.SearchConversationsRequest) (*chatpb.SearchConversationsResponse, error) {
+	matches, err := h.app.SearchConversations(ctx, req.UserId, req.Query, int(req.K))
+	if errors.Is(err, domain.ErrSearchUnavailable) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "search conversations failed: %v", err)
+	}
+
+	results := make([]*chatpb.ConversationMatch, len(matches))
+	for i, m := range matches {
+		messages := make([]*chatpb.MessageMatch, len(m.Messages))
+		for j, mm := range m.Messages {
+			messages[j] = &chatpb.MessageMatch{
+				MessageId: mm.Message.ID,
+				Role:      mm.Message.Role.String(),
+				Content:   mm.Message.Content,
+				Score:     float32(mm.Score),
+				Timestamp: mm.Message.CreatedAt.Unix(),
+			}
+		}
+		results[i] = &chatpb.ConversationMatch{
+			SessionId: m.Session.ID,
+			Title:     m.Session.Title,
+			Score:     float32(m.Score),
+			Messages:  messages,
+		}
+	}
+	return &chatpb.SearchConversationsResponse{Results: results}, nil
+}
+
+func (h *ChatHandler) SearchMessages(ctx context.Context, req *chatpb.SearchMessagesRequest) (*chatpb.SearchMessagesResponse, error) {
+	query := domain.MessageSearchQuery{
+		UserID:    req.UserId,
+		Query:     req.Query,
+		SessionID: req.SessionId,
+		Role:      domain.Role(req.Role),
+		Cursor:    req.Cursor,
+		Limit:     int(req.Limit),
+	}
+	if req.From > 0 {
+		query.From = time.Unix(req.From, 0)
+	}
+	if req.To > 0 {
+		query.To = time.Unix(req.To, 0)
+	}
+
+	page, err := h.app.SearchMessages(ctx, query)
+	switch {
+	case errors.Is(err, domain.ErrInvalidSearch), errors.Is(err, domain.ErrInvalidCursor):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrSearchUnavailable):
+		return nil, status.Error(codes.Unavailable, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "search messages failed: %v", err)
+	}
+
+	results := make([]*chatpb.MessageSearchResult, len(page.Messages))
+	for i, m := range page.Messages {
+		results[i] = &chatpb.MessageSearchResult{
+			MessageId: m.ID,
+			SessionId: m.SessionID,
+			Role:      m.Role.String(),
+			Snippet:   ctxbld.HighlightSnippet(m.Content, req.Query, snippetRunes),
+			Timestamp: m.CreatedAt.Unix(),
+		}
+	}
+	return &chatpb.SearchMessagesResponse{
+		Results:    results,
+		NextCursor: page.NextCursor,
+	}, nil
+}
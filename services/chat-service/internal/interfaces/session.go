@@ -0,0 +1,143 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errSessionsUnavailable 数据库不可用时会话整理功能关闭
+var errSessionsUnavailable = status.Error(codes.Unavailable, "session organization is unavailable")
+
+func sessionToPB(s *domain.Session) *chatpb.Session {
+	return &chatpb.Session{
+		SessionId:     s.ID,
+		Title:         s.Title,
+		CreatedAt:     s.CreatedAt.Unix(),
+		UpdatedAt:     s.UpdatedAt.Unix(),
+		LastMessageAt: s.LastMessageAt.Unix(),
+		MessageCount:  int32(s.MessageCount),
+		Preview:       s.Preview,
+		Pinned:        s.Pinned,
+		Archived:      s.Archived,
+		FolderId:      s.FolderID,
+		Tags:          s.Tags,
+		ForkedFrom:    s.ForkedFrom,
+		ForkMessageId: s.ForkMessageID,
+	}
+}
+
+func folderToPB(f *domain.Folder) *chatpb.Folder {
+	return &chatpb.Folder{
+		FolderId:  f.ID,
+		Name:      f.Name,
+		CreatedAt: f.CreatedAt.Unix(),
+	}
+}
+
+func (h *ChatHandler) UpdateSession(ctx context.Context, req *chatpb.UpdateSessionRequest) (*chatpb.UpdateSessionResponse, error) {
+	if h.sessions == nil {
+		return nil, errSessionsUnavailable
+	}
+	update := domain.SessionUpdate{
+		Title:    req.Title,
+		Pinned:   req.Pinned,
+		Archived: req.Archived,
+		FolderID: req.FolderId,
+	}
+	if req.Tags != nil {
+		tags := req.Tags.Tags
+		update.Tags = &tags
+	}
+	session, err := h.sessions.UpdateSession(ctx, req.UserId, req.SessionId, update)
+	if err != nil {
+		return nil, sessionStatus(err, "update session failed")
+	}
+	return &chatpb.UpdateSessionResponse{Session: sessionToPB(session)}, nil
+}
+
+func (h *ChatHandler) ForkSession(ctx context.Context, req *chatpb.ForkSessionRequest) (*chatpb.ForkSessionResponse, error) {
+	if h.sessions == nil {
+		return nil, errSessionsUnavailable
+	}
+	session, err := h.sessions.ForkSession(ctx, req.UserId, req.SessionId, req.MessageId)
+	if err != nil {
+		return nil, sessionStatus(err, "fork session failed")
+	}
+	return &chatpb.ForkSessionResponse{Session: sessionToPB(session)}, nil
+}
+
+func (h *ChatHandler) CreateFolder(ctx context.Context, req *chatpb.CreateFolderRequest) (*chatpb.CreateFolderResponse, error) {
+	if h.sessions == nil {
+		return nil, errSessionsUnavailable
+	}
+	folder, err := h.sessions.CreateFolder(ctx, req.UserId, req.Name)
+	if err != nil {
+		return nil, sessionStatus(err, "create folder failed")
+	}
+	return &chatpb.CreateFolderResponse{Folder: folderToPB(folder)}, nil
+}
+
+func (h *ChatHandler) ListFolders(ctx context.Context, req *chatpb.ListFoldersRequest) (*chatpb.ListFoldersResponse, error) {
+	if h.sessions == nil {
+		return nil, errSessionsUnavailable
+	}
+	folders, err := h.sessions.ListFolders(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list folders failed: %v", err)
+	}
+	pbFolders := make([]*chatpb.Folder, len(folders))
+	for i, f := range folders {
+		pbFolders[i] = folderToPB(f)
+	}
+	return &chatpb.ListFoldersResponse{Folders: pbFolders}, nil
+}
+
+func (h *ChatHandler) UpdateFolder(ctx context.Context, req *chatpb.UpdateFolderRequest) (*chatpb.UpdateFolderResponse, error) {
+	if h.sessions == nil {
+		return nil, errSessionsUnavailable
+	}
+	if err := h.sessions.RenameFolder(ctx, req.UserId, req.FolderId, req.Name); err != nil {
+		return nil, sessionStatus(err, "update folder failed")
+	}
+	return &chatpb.UpdateFolderResponse{
+		Success: true,
+		Message: "Folder updated successfully",
+	}, nil
+}
+
+func (h *ChatHandler) DeleteFolder(ctx context.Context, req *chatpb.DeleteFolderRequest) (*chatpb.DeleteFolderResponse, error) {
+	if h.sessions == nil {
+		return nil, errSessionsUnavailable
+	}
+	if err := h.sessions.DeleteFolder(ctx, req.UserId, req.FolderId); err != nil {
+		return nil, sessionStatus(err, "delete folder failed")
+	}
+	return &chatpb.DeleteFolderResponse{
+		Success: true,
+		Message: "Folder deleted successfully",
+	}, nil
+}
+
+func sessionStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrFolderNotFound),
+		errors.Is(err, domain.ErrMessageNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrEphemeralSession):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidSession), errors.Is(err, domain.ErrInvalidFolder):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrForkNotReady):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
@@ -0,0 +1,120 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	chatpb "free-chat/pkg/proto/chat"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errSharesUnavailable 数据库不可用时分享功能关闭
+var errSharesUnavailable = status.Error(codes.Unavailable, "sharing is unavailable")
+
+func shareToPB(s *domain.Share) *chatpb.Share {
+	return &chatpb.Share{
+		ShareId:       s.ID,
+		SessionId:     s.SessionID,
+		Title:         s.Title,
+		IncludeFuture: s.IncludeFuture,
+		MessageCount:  int32(s.MessageCount),
+		ExpiresAt:     unixOrZero(s.ExpiresAt),
+		RevokedAt:     unixOrZero(s.RevokedAt),
+		CreatedAt:     s.CreatedAt.Unix(),
+	}
+}
+
+// unixOrZero 把零值时间转换为 0，而不是公元 1 年的 Unix 秒
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func (h *ChatHandler) CreateShare(ctx context.Context, req *chatpb.CreateShareRequest) (*chatpb.CreateShareResponse, error) {
+	if h.shares == nil {
+		return nil, errSharesUnavailable
+	}
+	var expiresAt time.Time
+	if req.ExpiresAt > 0 {
+		expiresAt = time.Unix(req.ExpiresAt, 0)
+	}
+	share, token, err := h.shares.CreateShare(ctx, req.UserId, req.SessionId, expiresAt, req.IncludeFuture)
+	if err != nil {
+		return nil, shareStatus(err, "create share failed")
+	}
+	return &chatpb.CreateShareResponse{Share: shareToPB(share), Token: token}, nil
+}
+
+func (h *ChatHandler) ListShares(ctx context.Context, req *chatpb.ListSharesRequest) (*chatpb.ListSharesResponse, error) {
+	if h.shares == nil {
+		return nil, errSharesUnavailable
+	}
+	shares, err := h.shares.ListShares(ctx, req.UserId, req.SessionId)
+	if err != nil {
+		return nil, shareStatus(err, "list shares failed")
+	}
+	pbShares := make([]*chatpb.Share, len(shares))
+	for i, s := range shares {
+		pbShares[i] = shareToPB(s)
+	}
+	return &chatpb.ListSharesResponse{Shares: pbShares}, nil
+}
+
+func (h *ChatHandler) RevokeShare(ctx context.Context, req *chatpb.RevokeShareRequest) (*chatpb.RevokeShareResponse, error) {
+	if h.shares == nil {
+		return nil, errSharesUnavailable
+	}
+	if err := h.shares.RevokeShare(ctx, req.UserId, req.ShareId); err != nil {
+		return nil, shareStatus(err, "revoke share failed")
+	}
+	return &chatpb.RevokeShareResponse{
+		Success: true,
+		Message: "Share revoked successfully",
+	}, nil
+}
+
+func (h *ChatHandler) GetSharedSession(ctx context.Context, req *chatpb.GetSharedSessionRequest) (*chatpb.GetSharedSessionResponse, error) {
+	if h.shares == nil {
+		return nil, errSharesUnavailable
+	}
+	shared, err := h.shares.ViewShare(ctx, req.Token)
+	if err != nil {
+		return nil, shareStatus(err, "get shared session failed")
+	}
+	messages := make([]*chatpb.SharedMessage, len(shared.Messages))
+	for i, m := range shared.Messages {
+		messages[i] = &chatpb.SharedMessage{
+			Role:      m.Role.String(),
+			Content:   m.Content,
+			Model:     m.Model,
+			Timestamp: m.CreatedAt.Unix(),
+		}
+	}
+	return &chatpb.GetSharedSessionResponse{
+		Title:     shared.Share.Title,
+		SharedAt:  shared.Share.CreatedAt.Unix(),
+		Messages:  messages,
+		Truncated: shared.Truncated,
+	}, nil
+}
+
+func shareStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrShareNotFound), errors.Is(err, domain.ErrSessionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrEphemeralSession):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidShare):
+		return status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
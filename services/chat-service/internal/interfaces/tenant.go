@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"context"
+
+	"free-chat/pkg/tenant"
+	"free-chat/services/chat-service/internal/domain"
+
+	"google.golang.org/grpc"
+)
+
+// TenantUnaryInterceptor 把网关随请求传来的工作空间放入 context，供应用层按租户过滤
+func TenantUnaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withTenant(ctx), req)
+}
+
+// TenantStreamInterceptor 是 TenantUnaryInterceptor 的流式版本
+func TenantStreamInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &tenantStream{ServerStream: ss, ctx: withTenant(ss.Context())})
+}
+
+func withTenant(ctx context.Context) context.Context {
+	ws := tenant.FromIncomingContext(ctx)
+	return domain.WithTenant(ctx, domain.Tenant{WorkspaceID: ws.ID, Role: domain.WorkspaceRole(ws.Role)})
+}
+
+// tenantStream 替换流的 context
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}